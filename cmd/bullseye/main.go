@@ -1,18 +1,46 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/embeddingbits/file_viewer/internal/ui"
+	"github.com/embeddingbits/file_viewer/pkg/browser"
 )
 
 func main() {
-	model := ui.NewAppModel()
+	selectName := flag.String("select", "", "name of the entry to select in the starting directory")
+	cwdFile := flag.String("cwd-file", "", "write the directory open on exit to this file, for a shell wrapper to cd into")
+	profile := flag.String("profile", "", `write CPU and heap profiles to the state directory ("file"), or serve pprof on the given address`)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [--select name] [--cwd-file file] [--profile file|addr] [path]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	stopProfiling := func() {}
+	if *profile != "" {
+		stop, err := startProfiling(*profile)
+		if err != nil {
+			fmt.Printf("Error: %v", err)
+			os.Exit(1)
+		}
+		stopProfiling = stop
+	}
+
+	model := browser.New(browser.Options{Dir: flag.Arg(0), Select: *selectName, Standalone: true})
 	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	if *cwdFile != "" && err == nil {
+		if writeErr := os.WriteFile(*cwdFile, []byte(model.Dir()+"\n"), 0644); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", writeErr)
+		}
+	}
+	model.Close()
+	stopProfiling()
+	if err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+)
+
+// startProfiling enables profiling for the run. With mode "file" a CPU
+// profile is recorded and a heap profile written next to it under the state
+// directory when the returned function is called; any other mode is taken as
+// the address to serve the net/http/pprof endpoints on.
+func startProfiling(mode string) (func(), error) {
+	if mode != "file" {
+		// Listening up front reports a bad or busy address before the
+		// interface takes over the terminal
+		listener, err := net.Listen("tcp", mode)
+		if err != nil {
+			return nil, err
+		}
+		server := &http.Server{}
+		go server.Serve(listener)
+		return func() { server.Close() }, nil
+	}
+
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(stateDir, "profiles")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	prefix := filepath.Join(dir, time.Now().Format("20060102-150405"))
+
+	cpuFile, err := os.Create(prefix + "-cpu.pprof")
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(cpuFile); err != nil {
+		cpuFile.Close()
+		return nil, err
+	}
+
+	return func() {
+		pprof.StopCPUProfile()
+		cpuFile.Close()
+
+		heapPath := prefix + "-heap.pprof"
+		heapFile, err := os.Create(heapPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing heap profile: %v\n", err)
+			return
+		}
+		defer heapFile.Close()
+		runtime.GC() // Up-to-date allocation statistics
+		if err := pprof.WriteHeapProfile(heapFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing heap profile: %v\n", err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Profiles written to %s and %s\n", cpuFile.Name(), heapPath)
+	}, nil
+}
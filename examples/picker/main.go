@@ -0,0 +1,55 @@
+// Command picker embeds the bullseye browser as a file picker: it asks for
+// files, prints the chosen paths and exits.
+//
+//	go run ./examples/picker [dir]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/pkg/browser"
+)
+
+// picker shows a title line above the browser
+type picker struct {
+	browser *browser.Browser
+	chosen  []string
+}
+
+func (p *picker) Init() tea.Cmd {
+	return p.browser.Init()
+}
+
+func (p *picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		return p, p.browser.SetSize(msg.Width, msg.Height-1)
+	case browser.ChosenMsg:
+		p.chosen = msg.Paths
+		return p, tea.Quit
+	case browser.QuitMsg:
+		return p, tea.Quit
+	}
+	_, cmd := p.browser.Update(msg)
+	return p, cmd
+}
+
+func (p *picker) View() string {
+	return "Pick files: enter chooses, space marks several, q cancels\n" + p.browser.View()
+}
+
+func main() {
+	flag.Parse()
+	p := &picker{browser: browser.New(browser.Options{Dir: flag.Arg(0), Chooser: true})}
+	_, err := tea.NewProgram(p, tea.WithAltScreen()).Run()
+	p.browser.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(strings.Join(p.chosen, "\n"))
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// detectBackground reports whether the terminal background is light and how
+// that was found out: COLORFGBG when the terminal exports it, else an OSC 11
+// query. Terminals that answer neither are assumed to be dark.
+func detectBackground() (light bool, source string) {
+	if fgbg := os.Getenv("COLORFGBG"); strings.Contains(fgbg, ";") {
+		fields := strings.Split(fgbg, ";")
+		if bg, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+			return isLight(termenv.ANSIColor(bg)), "COLORFGBG"
+		}
+	}
+	// termenv only queries a foreground terminal, outside screen and tmux,
+	// and returns black or no color when it gets no answer
+	switch bg := termenv.NewOutput(os.Stdout).BackgroundColor().(type) {
+	case termenv.NoColor:
+	case termenv.ANSIColor:
+		if bg != 0 {
+			return isLight(bg), "OSC 11"
+		}
+	default:
+		return isLight(bg), "OSC 11"
+	}
+	return false, "assumed"
+}
+
+// isLight reports whether c is closer to white than to black
+func isLight(c termenv.Color) bool {
+	_, _, l := termenv.ConvertToRGB(c).Hsl()
+	return l >= 0.5
+}
+
+// applyLightPalette replaces the default colors, chosen for dark
+// terminals, with ones readable on a light background
+func applyLightPalette(c *Config) {
+	c.BorderColor = "245"        // Gray
+	c.StatusBarBgColor = "252"   // Light gray
+	c.StatusBarFgColor = "235"   // Dark gray
+	c.DirColor = "25"            // Dark blue
+	c.SelectedItemColor = "130"  // Dark orange
+	c.DefaultFgColor = "236"     // Very dark gray
+	c.PreviewBgColor = "255"     // Near white
+	c.HiddenFileColor = "243"    // Gray
+	c.ExecutableColor = "28"     // Dark green
+	c.SymlinkColor = "30"        // Dark cyan
+	c.BrokenLinkColor = "160"    // Red
+	c.PreviewBorderColor = "248" // Light gray
+	c.HoverBgColor = "253"       // Pale gray
+	c.SpecialFileColor = "166"   // Orange
+	c.SetuidColor = "160"        // Red
+	c.SetgidColor = "136"        // Dark yellow
+	c.StickyColor = "31"         // Steel blue
+	c.DiffAddedColor = "28"      // Dark green
+	c.DiffRemovedColor = "124"   // Dark red
+	c.DiffHunkColor = "30"       // Dark cyan
+	c.MarkedColor = "127"        // Dark magenta
+	c.GitModifiedColor = "166"   // Orange
+	c.GitStagedColor = "28"      // Dark green
+	c.GitUntrackedColor = "124"  // Dark red
+	c.GitIgnoredColor = "248"    // Light gray
+}
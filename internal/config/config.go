@@ -3,24 +3,103 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
 // Config represents the application configuration
 type Config struct {
-	BorderColor        string `toml:"border_color"`
-	StatusBarBgColor   string `toml:"status_bar_bg_color"`
-	StatusBarFgColor   string `toml:"status_bar_fg_color"`
-	DirColor           string `toml:"dir_color"`
-	SelectedItemColor  string `toml:"selected_item_color"`
-	DefaultFgColor     string `toml:"default_fg_color"`
-	PreviewBgColor     string `toml:"preview_bg_color"`
-	HiddenFileColor    string `toml:"hidden_file_color"`
-	ExecutableColor    string `toml:"executable_color"`
-	SymlinkColor       string `toml:"symlink_color"`
-	PreviewBorderColor string `toml:"preview_border_color"`
-	HoverBgColor       string `toml:"hover_bg_color"`
+	BorderColor        string              `toml:"border_color"`
+	StatusBarBgColor   string              `toml:"status_bar_bg_color"`
+	StatusBarFgColor   string              `toml:"status_bar_fg_color"`
+	DirColor           string              `toml:"dir_color"`
+	SelectedItemColor  string              `toml:"selected_item_color"`
+	DefaultFgColor     string              `toml:"default_fg_color"`
+	PreviewBgColor     string              `toml:"preview_bg_color"`
+	HiddenFileColor    string              `toml:"hidden_file_color"`
+	ExecutableColor    string              `toml:"executable_color"`
+	SymlinkColor       string              `toml:"symlink_color"`
+	BrokenLinkColor    string              `toml:"broken_link_color"`
+	PreviewBorderColor string              `toml:"preview_border_color"`
+	HoverBgColor       string              `toml:"hover_bg_color"`
+	SpecialFileColor   string              `toml:"special_file_color"`
+	SetuidColor        string              `toml:"setuid_color"`
+	SetgidColor        string              `toml:"setgid_color"`
+	StickyColor        string              `toml:"sticky_color"`
+	DiffAddedColor     string              `toml:"diff_added_color"`
+	DiffRemovedColor   string              `toml:"diff_removed_color"`
+	DiffHunkColor      string              `toml:"diff_hunk_color"`
+	MarkedColor        string              `toml:"marked_color"`
+	GitModifiedColor   string              `toml:"git_modified_color"`
+	GitStagedColor     string              `toml:"git_staged_color"`
+	GitUntrackedColor  string              `toml:"git_untracked_color"`
+	GitIgnoredColor    string              `toml:"git_ignored_color"`
+	HiddenPosition     string              `toml:"hidden_position"` // "mixed", "first", "last"
+	PreserveTimes      bool                `toml:"preserve_times"`
+	CopyXattrs         bool                `toml:"copy_xattrs"`
+	EditorLineTemplate string              `toml:"editor_line_template"` // e.g. "+%l %f", empty picks one for $EDITOR
+	OpenerCommand      string              `toml:"opener_command"`       // Opens files with O, the path is appended; empty uses xdg-open, open or start
+	PreviewMaxSize     string              `toml:"preview_max_size"`     // Larger files only get a header preview
+	GrepMaxSize        string              `toml:"grep_max_size"`        // ctrl+f skips larger files
+	SearchIgnore       []string            `toml:"search_ignore"`        // Names the deep and content searches skip
+	OpenWarnSize       string              `toml:"open_warn_size"`       // Confirm before opening larger files
+	SearchScope        string              `toml:"search_scope"`         // "session" keeps the query across directories, "directory" clears it
+	SearchMode         string              `toml:"search_mode"`          // "fuzzy" or "substring" matching of the search query
+	MaskEnvSecrets     bool                `toml:"mask_env_secrets"`
+	EnvSecretPattern   string              `toml:"env_secret_pattern"` // Regexp, matched case-insensitively against .env key names
+	ProjectBadges      bool                `toml:"project_badges"`
+	GitStatus          bool                `toml:"git_status"`          // Mark modified, staged, untracked and ignored entries in repositories
+	MarkdownStyle      string              `toml:"markdown_style"`      // glamour style of markdown previews, "auto" follows the background
+	FilterParent       bool                `toml:"filter_parent"`       // The search filter also narrows the parent pane
+	ShowHiddenParent   string              `toml:"show_hidden_parent"`  // "follow" the hidden files toggle, or always "show" or "hide" them
+	ShowHiddenPreview  string              `toml:"show_hidden_preview"` // The same for directory previews
+	ScrollStep         any                 `toml:"scroll_step"`         // ctrl+u/d and mouse wheel: "half", "full" or a line count
+	Columns            int                 `toml:"columns"`             // Panes including current and preview, extra ones show further ancestors
+	SortFollowSymlinks bool                `toml:"sort_follow_symlinks"`
+	SortSizeOnDisk     bool                `toml:"sort_size_on_disk"` // Sort by allocated instead of apparent size
+	SortNatural        bool                `toml:"sort_natural"`      // Name sort compares numbers by value, file2 before file10
+	SizeIndicator      string              `toml:"size_indicator"`    // "off", "bar" or "color"
+	UeberzugSocket     string              `toml:"ueberzug_socket"`   // ueberzugpp socket for image overlays, empty uses $UB_SOCKET
+	Color              bool                `toml:"color"`             // false draws without colors or attributes, as do NO_COLOR and TERM=dumb
+	PageStep           any                 `toml:"page_step"`         // PgUp/PgDn: "half", "full" or a line count
+	KeyAcceleration    bool                `toml:"key_acceleration"`  // Held up/down keys move further the longer they repeat
+	Unicode            bool                `toml:"unicode"`           // false draws icons, borders and bars from ASCII, the default on non-UTF-8 locales
+	TrackChanges       bool                `toml:"track_changes"`     // Mark entries new or modified since the last visit, storing a snapshot per directory
+	GitLogPreview      bool                `toml:"git_log_preview"`   // Directory previews list the last commits touching the directory
+	VideoPreviews      bool                `toml:"video_previews"`    // Video previews show a frame and metadata from ffmpeg and ffprobe
+	PhysicalPaths      bool                `toml:"physical_paths"`    // Open files and report the exit directory with symlinks resolved
+	BatchJobs          int                 `toml:"batch_jobs"`        // Files the batch command runs on at once
+	BatchStopOnFailure bool                `toml:"batch_stop_on_failure"`
+	ColorImages        bool                `toml:"image_preview_colored"` // ASCII image previews in the image's colors, c toggles it
+	ImageProtocol      string              `toml:"image_protocol"`        // "auto", "kitty", "sixel" or "ascii"
+	PreviewMaxBytes    int64               `toml:"-"`
+	GrepMaxBytes       int64               `toml:"-"`
+	Keybindings        map[string]any      `toml:"keybindings"` // Action name to a key or a list of keys
+	Filters            map[string]string   `toml:"filters"`     // Filter preset name to a glob such as "*.{jpg,png}"
+	Openers            map[string]any      `toml:"openers"`     // Extension or file name to the command o opens it with
+	Keys               map[string][]string `toml:"-"`           // Keys bound to each action, the defaults with Keybindings applied
+	KeyProblems        []string            `toml:"-"`           // Why Keybindings entries were ignored
+	OpenRules          map[string]OpenRule `toml:"-"`           // Openers that parsed, see OpenRuleFor
+	OpenerProblems     []string            `toml:"-"`           // Why Openers entries were ignored
+	Background         string              `toml:"-"`           // Terminal background the default colors suit and how it was detected, e.g. "light (OSC 11)"
+	Locale             string              `toml:"-"`           // Locale variable that turned Unicode off by default, e.g. "LANG=C", empty on UTF-8
+	OpenWarnBytes      int64               `toml:"-"`
+	Scroll             Step                `toml:"-"`
+	Page               Step                `toml:"-"`
+
+	// ProjectMarkers are checked before the built-in go.mod, package.json,
+	// Cargo.toml and pyproject.toml markers
+	ProjectMarkers []ProjectMarker `toml:"project_markers"`
+
+	EnvSecretRegexp *regexp.Regexp `toml:"-"`
+}
+
+// ProjectMarker maps a well-known file to the badge shown after directories
+// that contain it
+type ProjectMarker struct {
+	File  string `toml:"file"`
+	Badge string `toml:"badge"`
 }
 
 // LoadConfig loads configuration from file or returns default configuration
@@ -36,14 +115,79 @@ func LoadConfig() Config {
 		HiddenFileColor:    "244", // Dark gray
 		ExecutableColor:    "46",  // Green
 		SymlinkColor:       "14",  // Cyan
+		BrokenLinkColor:    "9",   // Red
 		PreviewBorderColor: "240", // Gray
 		HoverBgColor:       "0",   // Black
+		SpecialFileColor:   "214", // Orange
+		SetuidColor:        "196", // Red
+		SetgidColor:        "226", // Bright yellow
+		StickyColor:        "75",  // Light blue
+		DiffAddedColor:     "40",  // Green
+		DiffRemovedColor:   "160", // Red
+		DiffHunkColor:      "37",  // Teal
+		MarkedColor:        "13",  // Magenta
+		GitModifiedColor:   "214", // Orange
+		GitStagedColor:     "40",  // Green
+		GitUntrackedColor:  "160", // Red
+		GitIgnoredColor:    "240", // Gray
+		HiddenPosition:     "mixed",
+		PreserveTimes:      true,
+		CopyXattrs:         false,
+		PreviewMaxSize:     "5M",
+		GrepMaxSize:        "10M",
+		OpenWarnSize:       "100M",
+		SearchScope:        "session",
+		SearchMode:         "fuzzy",
+		ShowHiddenParent:   "follow",
+		ShowHiddenPreview:  "follow",
+		MaskEnvSecrets:     true,
+		ProjectBadges:      true,
+		GitStatus:          true,
+		MarkdownStyle:      "auto",
+		ScrollStep:         "half",
+		PageStep:           "full",
+		Columns:            3,
+		BatchJobs:          1,
+		SearchIgnore:       []string{".git", "node_modules"},
+		SortFollowSymlinks: true,
+		SortNatural:        true,
+		VideoPreviews:      true,
+		ColorImages:        false,
+		ImageProtocol:      "auto",
+		SizeIndicator:      "off",
+		Color:              !colorDisabledByEnv(),
+		EnvSecretPattern:   "PASSWORD|SECRET|TOKEN|KEY",
 	}
+	// Explicit colors from the file are applied over whichever palette fits
+	// the terminal. Its query is skipped when colors are off anyway.
+	defaultConfig.Background = "dark (colors off)"
+	if defaultConfig.Color {
+		light, source := detectBackground()
+		defaultConfig.Background = "dark (" + source + ")"
+		if light {
+			applyLightPalette(&defaultConfig)
+			defaultConfig.Background = "light (" + source + ")"
+		}
+	}
+	if locale, utf8 := localeCharset(); utf8 {
+		defaultConfig.Unicode = true
+	} else {
+		defaultConfig.Locale = locale
+	}
+	defaultConfig.Keys, _ = resolveKeybindings(nil)
+	defaultConfig.PreviewMaxBytes, _ = ParseSize(defaultConfig.PreviewMaxSize)
+	defaultConfig.OpenWarnBytes, _ = ParseSize(defaultConfig.OpenWarnSize)
+	defaultConfig.GrepMaxBytes, _ = ParseSize(defaultConfig.GrepMaxSize)
+	defaultConfig.Scroll, _ = ParseStep(defaultConfig.ScrollStep)
+	defaultConfig.Page, _ = ParseStep(defaultConfig.PageStep)
+	defaultConfig.EnvSecretRegexp = regexp.MustCompile("(?i)" + defaultConfig.EnvSecretPattern)
 
-	homeDir, _ := os.UserHomeDir()
-	configPath := filepath.Join(homeDir, ".config", "bullseye", "config.toml")
-
-	data, err := os.ReadFile(configPath)
+	// Without a resolvable config dir only the local config is considered
+	var data []byte
+	err := os.ErrNotExist
+	if configDir, dirErr := ConfigDir(); dirErr == nil {
+		data, err = os.ReadFile(filepath.Join(configDir, "config.toml"))
+	}
 	if err != nil {
 		// Try local config
 		data, err = os.ReadFile("config.toml")
@@ -52,7 +196,8 @@ func LoadConfig() Config {
 		}
 	}
 
-	var config Config
+	// Start from the defaults so keys missing from the file keep their default
+	config := defaultConfig
 	if err := toml.Unmarshal(data, &config); err != nil {
 		return defaultConfig
 	}
@@ -88,12 +233,138 @@ func LoadConfig() Config {
 	if config.SymlinkColor == "" {
 		config.SymlinkColor = defaultConfig.SymlinkColor
 	}
+	if config.BrokenLinkColor == "" {
+		config.BrokenLinkColor = defaultConfig.BrokenLinkColor
+	}
 	if config.PreviewBorderColor == "" {
 		config.PreviewBorderColor = defaultConfig.PreviewBorderColor
 	}
 	if config.HoverBgColor == "" {
 		config.HoverBgColor = defaultConfig.HoverBgColor
 	}
+	if config.SpecialFileColor == "" {
+		config.SpecialFileColor = defaultConfig.SpecialFileColor
+	}
+	if config.SetuidColor == "" {
+		config.SetuidColor = defaultConfig.SetuidColor
+	}
+	if config.SetgidColor == "" {
+		config.SetgidColor = defaultConfig.SetgidColor
+	}
+	if config.StickyColor == "" {
+		config.StickyColor = defaultConfig.StickyColor
+	}
+	if config.DiffAddedColor == "" {
+		config.DiffAddedColor = defaultConfig.DiffAddedColor
+	}
+	if config.DiffRemovedColor == "" {
+		config.DiffRemovedColor = defaultConfig.DiffRemovedColor
+	}
+	if config.DiffHunkColor == "" {
+		config.DiffHunkColor = defaultConfig.DiffHunkColor
+	}
+	if config.MarkedColor == "" {
+		config.MarkedColor = defaultConfig.MarkedColor
+	}
+	if config.GitModifiedColor == "" {
+		config.GitModifiedColor = defaultConfig.GitModifiedColor
+	}
+	if config.GitStagedColor == "" {
+		config.GitStagedColor = defaultConfig.GitStagedColor
+	}
+	if config.GitUntrackedColor == "" {
+		config.GitUntrackedColor = defaultConfig.GitUntrackedColor
+	}
+	if config.GitIgnoredColor == "" {
+		config.GitIgnoredColor = defaultConfig.GitIgnoredColor
+	}
+	if size, err := ParseSize(config.PreviewMaxSize); err == nil {
+		config.PreviewMaxBytes = size
+	} else {
+		config.PreviewMaxBytes = defaultConfig.PreviewMaxBytes
+	}
+	if size, err := ParseSize(config.GrepMaxSize); err == nil {
+		config.GrepMaxBytes = size
+	} else {
+		config.GrepMaxBytes = defaultConfig.GrepMaxBytes
+	}
+	if size, err := ParseSize(config.OpenWarnSize); err == nil {
+		config.OpenWarnBytes = size
+	} else {
+		config.OpenWarnBytes = defaultConfig.OpenWarnBytes
+	}
+	if step, err := ParseStep(config.ScrollStep); err == nil {
+		config.Scroll = step
+	} else {
+		config.Scroll = defaultConfig.Scroll
+	}
+	if step, err := ParseStep(config.PageStep); err == nil {
+		config.Page = step
+	} else {
+		config.Page = defaultConfig.Page
+	}
+	// An invalid pattern keeps the default rather than revealing everything
+	if re, err := regexp.Compile("(?i)" + config.EnvSecretPattern); err == nil && config.EnvSecretPattern != "" {
+		config.EnvSecretRegexp = re
+	} else {
+		config.EnvSecretRegexp = defaultConfig.EnvSecretRegexp
+	}
+	if config.BatchJobs < 1 {
+		config.BatchJobs = defaultConfig.BatchJobs
+	}
+	// The current and preview panes are always shown
+	if config.Columns < 2 {
+		config.Columns = defaultConfig.Columns
+	}
+	switch config.SearchScope {
+	case "session", "directory":
+	default:
+		config.SearchScope = defaultConfig.SearchScope
+	}
+	switch config.SearchMode {
+	case "fuzzy", "substring":
+	default:
+		config.SearchMode = defaultConfig.SearchMode
+	}
+	switch config.ImageProtocol {
+	case "auto", "kitty", "sixel", "ascii":
+	default:
+		config.ImageProtocol = defaultConfig.ImageProtocol
+	}
+	switch config.MarkdownStyle {
+	case "auto", "dark", "light", "dracula", "tokyo-night", "pink", "ascii", "notty":
+	default:
+		config.MarkdownStyle = defaultConfig.MarkdownStyle
+	}
+	for _, setting := range []*string{&config.ShowHiddenParent, &config.ShowHiddenPreview} {
+		switch *setting {
+		case "follow", "show", "hide":
+		default:
+			*setting = "follow"
+		}
+	}
+	switch config.SizeIndicator {
+	case "off", "bar", "color":
+	default:
+		config.SizeIndicator = defaultConfig.SizeIndicator
+	}
+	switch config.HiddenPosition {
+	case "mixed", "first", "last":
+	default:
+		config.HiddenPosition = defaultConfig.HiddenPosition
+	}
+	config.Keys, config.KeyProblems = resolveKeybindings(config.Keybindings)
+	config.OpenRules, config.OpenerProblems = resolveOpeners(config.Openers)
+	// The environment wins over the file, see https://no-color.org
+	if colorDisabledByEnv() {
+		config.Color = false
+	}
 
 	return config
 }
+
+// colorDisabledByEnv reports whether NO_COLOR is set or the terminal is
+// declared dumb
+func colorDisabledByEnv() bool {
+	return os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+}
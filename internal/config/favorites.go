@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// favoritesPath returns the state file listing the starred paths
+func favoritesPath() (string, error) {
+	stateDir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "favorites"), nil
+}
+
+// LoadFavorites reads the starred paths, one absolute path per line. A
+// missing file means nothing was starred yet.
+func LoadFavorites() (map[string]bool, error) {
+	favorites := make(map[string]bool)
+	path, err := favoritesPath()
+	if err != nil {
+		return favorites, err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return favorites, nil
+	} else if err != nil {
+		return favorites, err
+	}
+	parseFavorites(content, favorites)
+	return favorites, nil
+}
+
+// parseFavorites adds the absolute paths listed in content to favorites
+func parseFavorites(content []byte, favorites map[string]bool) {
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); filepath.IsAbs(line) {
+			favorites[line] = true
+		}
+	}
+}
+
+// UpdateFavorites applies change to the starred paths as saved, rather than
+// as this instance last read them, so stars added by another running
+// instance are kept. It returns the favorites after the change.
+func UpdateFavorites(change func(favorites map[string]bool)) (map[string]bool, error) {
+	favorites := make(map[string]bool)
+	path, err := favoritesPath()
+	if err != nil {
+		return favorites, err
+	}
+	err = UpdateFile(path, 0644, func(current []byte) ([]byte, error) {
+		parseFavorites(current, favorites)
+		change(favorites)
+		var sb strings.Builder
+		for _, p := range SortedFavorites(favorites) {
+			sb.WriteString(p + "\n")
+		}
+		return []byte(sb.String()), nil
+	})
+	return favorites, err
+}
+
+// SortedFavorites lists the starred paths in lexical order, which groups
+// them by directory
+func SortedFavorites(favorites map[string]bool) []string {
+	paths := make([]string, 0, len(favorites))
+	for p := range favorites {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultKeybindings lists the keys bound to each action, by the action
+// names of the [keybindings] table
+var DefaultKeybindings = map[string][]string{
+	"up":                  {"up", "k"},
+	"down":                {"down", "j"},
+	"top":                 {"g", "home"},
+	"bottom":              {"G", "end"},
+	"scroll_up":           {"ctrl+u"},
+	"scroll_down":         {"ctrl+d"},
+	"page_up":             {"pgup"},
+	"page_down":           {"pgdown"},
+	"preview_scroll_up":   {"K"},
+	"preview_scroll_down": {"J"},
+
+	"quit":              {"ctrl+c", "q"},
+	"enter_dir":         {"right", "l"},
+	"parent_dir":        {"left", "h"},
+	"open_editor":       {"o"},
+	"open_default":      {"O"},
+	"activate":          {"enter"},
+	"home":              {"~"},
+	"force_preview":     {"P"},
+	"grid":              {"I"},
+	"quick_look":        {"i"},
+	"goto_line":         {":"},
+	"open_by":           {"L"},
+	"chmod":             {"="},
+	"rename":            {"a"},
+	"new_file":          {"N"},
+	"new_dir":           {"M"},
+	"trash":             {"d"},
+	"delete":            {"D"},
+	"favorite":          {"*"},
+	"favorites":         {"F"},
+	"debug":             {"ctrl+g"},
+	"goto_path":         {"C"},
+	"repeat":            {";"},
+	"clear":             {"esc"},
+	"search":            {"/"},
+	"toggle_hidden":     {"."},
+	"sort_size":         {"s"},
+	"sort_modified":     {"t"},
+	"sort_name":         {"n"},
+	"refresh":           {"r"},
+	"raw":               {"R"},
+	"reveal_secrets":    {"U"},
+	"yank":              {"y"},
+	"cut":               {"x"},
+	"paste":             {"p"},
+	"prev_sibling":      {"["},
+	"next_sibling":      {"]"},
+	"mark":              {" "},
+	"mark_all":          {"A"},
+	"invert_marks":      {"V"},
+	"new_from_template": {"T"},
+	"visual":            {"v"},
+	"filter_preset":     {"f"},
+	"clear_changes":     {"u"},
+	"history_back":      {"ctrl+o"},
+	"history_forward":   {"tab"}, // ctrl+i, which terminals send as tab
+	"physical_paths":    {"W"},
+	"batch":             {"!"},
+	"grep":              {"ctrl+f"},
+	"compare":           {"|"},
+	"palette":           {"ctrl+k"},
+	"fuzzy_search":      {}, // In search mode ctrl+t, otherwise only from the palette
+	"trash_view":        {"X"},
+	"image_color":       {"c"},
+	"macro_record":      {"ctrl+r"},
+	"macro_replay":      {"ctrl+p"},
+}
+
+// resolveKeybindings applies the [keybindings] table over the defaults. A
+// value is a key or a list of keys, an empty list unbinds the action.
+// Unknown actions are ignored, and an action whose keys are also bound to
+// another keeps its defaults. Each problem is described in the result.
+func resolveKeybindings(table map[string]any) (map[string][]string, []string) {
+	keys := make(map[string][]string, len(DefaultKeybindings))
+	for action, bound := range DefaultKeybindings {
+		keys[action] = bound
+	}
+
+	var problems []string
+	configured := make(map[string]bool)
+	for _, action := range sortedKeys(table) {
+		if _, ok := DefaultKeybindings[action]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown action %q", action))
+			continue
+		}
+		bound, ok := keyList(table[action])
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected a key or a list of keys", action))
+			continue
+		}
+		keys[action] = bound
+		configured[action] = true
+	}
+
+	// Revert configured actions until no key is bound twice, the defaults
+	// never collide among themselves
+resolve:
+	for {
+		owner := make(map[string]string)
+		for _, action := range sortedKeys(keys) {
+			for _, key := range keys[action] {
+				other, taken := owner[key]
+				if !taken || other == action {
+					owner[key] = action
+					continue
+				}
+				for _, a := range []string{other, action} {
+					if configured[a] {
+						keys[a] = DefaultKeybindings[a]
+						configured[a] = false
+						problems = append(problems, fmt.Sprintf("%s: %q is bound to both %s and %s, keeping the default", a, key, other, action))
+					}
+				}
+				continue resolve
+			}
+		}
+		return keys, problems
+	}
+}
+
+// keyList converts a [keybindings] value to the keys it binds
+func keyList(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []any:
+		keys := make([]string, 0, len(v))
+		for _, item := range v {
+			key, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			keys = append(keys, key)
+		}
+		return keys, true
+	}
+	return nil, false
+}
+
+// sortedKeys returns the keys of m in order, for deterministic results
+func sortedKeys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// localeCharset returns the locale variable that decides the character set,
+// as "NAME=value", and whether it selects UTF-8. LC_ALL overrides LC_CTYPE,
+// which overrides LANG. With none of them set the C locale applies.
+func localeCharset() (locale string, utf8 bool) {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			lower := strings.ToLower(value)
+			return name + "=" + value, strings.Contains(lower, "utf-8") || strings.Contains(lower, "utf8")
+		}
+	}
+	return "LANG unset", false
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+// lockFile is unavailable on platforms without flock, where the last
+// instance to write a file wins
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
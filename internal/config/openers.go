@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OpenRule is how o opens the files an [openers] entry matches
+type OpenRule struct {
+	Command []string // Program and arguments, "{file}" stands for the path
+	Detach  bool     // A GUI program, started without suspending the TUI
+}
+
+// resolveOpeners reads the [openers] table. A value is a command line, or a
+// table with a command and detach flag. Keys are extensions, with or
+// without the dot, or exact file names such as "Makefile". Each entry that
+// cannot be used is described in the result.
+func resolveOpeners(table map[string]any) (map[string]OpenRule, []string) {
+	rules := make(map[string]OpenRule, len(table))
+	var problems []string
+	for _, key := range sortedKeys(table) {
+		var rule OpenRule
+		var line string
+		switch v := table[key].(type) {
+		case string:
+			line = v
+		case map[string]any:
+			line, _ = v["command"].(string)
+			if detach, ok := v["detach"]; ok {
+				if rule.Detach, ok = detach.(bool); !ok {
+					problems = append(problems, fmt.Sprintf("%s: detach must be true or false", key))
+					continue
+				}
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s: expected a command or a table with one", key))
+			continue
+		}
+		args, err := SplitCommand(line)
+		if err == nil && len(args) == 0 {
+			err = errors.New("empty command")
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		rule.Command = args
+		rules[strings.TrimPrefix(key, ".")] = rule
+	}
+	return rules, problems
+}
+
+// OpenRuleFor returns the [openers] rule for the file called name: the one
+// for its exact name, else for its longest extension, so "tar.gz" is
+// preferred over "gz". Extensions ignore case.
+func (c Config) OpenRuleFor(name string) (OpenRule, bool) {
+	if rule, ok := c.OpenRules[name]; ok {
+		return rule, true
+	}
+	lower := strings.ToLower(name)
+	// A leading dot starts a hidden name, not an extension
+	for i := 1; i < len(lower); i++ {
+		if lower[i] != '.' {
+			continue
+		}
+		if rule, ok := c.OpenRules[lower[i+1:]]; ok {
+			return rule, true
+		}
+	}
+	return OpenRule{}, false
+}
+
+// SplitCommand splits a command line into arguments the way a shell would
+// for the common cases: single quotes keep everything literally, double
+// quotes let a backslash escape " and \, and outside quotes a backslash
+// escapes any character. No variables or globs are expanded.
+func SplitCommand(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
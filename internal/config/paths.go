@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ConfigDir returns the directory holding the user configuration,
+// honoring XDG_CONFIG_HOME before falling back to ~/.config/bullseye
+func ConfigDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// StateDir returns the directory for persisted application state,
+// honoring XDG_STATE_HOME before falling back to ~/.local/state/bullseye
+func StateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// xdgDir returns bullseye's directory below an XDG base directory
+func xdgDir(env, homeRel string) (string, error) {
+	dir, err := XDGBaseDir(env, homeRel)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bullseye"), nil
+}
+
+// XDGBaseDir resolves the XDG base directory named by env, or homeRel below
+// the home directory when env is unset or not absolute, as the spec asks.
+// It fails only when neither the variable nor the home directory is
+// available.
+func XDGBaseDir(env, homeRel string) (string, error) {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, homeRel), nil
+}
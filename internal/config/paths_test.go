@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dataDir resolves XDG_DATA_HOME, the base directory of the trash
+func dataDir() (string, error) {
+	return XDGBaseDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// unsetHome clears HOME and the XDG directories for the test, as in
+// containers that run without them
+func unsetHome(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"HOME", "XDG_CONFIG_HOME", "XDG_STATE_HOME", "XDG_DATA_HOME"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestDirsFromHome(t *testing.T) {
+	unsetHome(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dirs := map[string]func() (string, error){
+		filepath.Join(home, ".config", "bullseye"):         ConfigDir,
+		filepath.Join(home, ".local", "state", "bullseye"): StateDir,
+		filepath.Join(home, ".local", "share"):             dataDir,
+	}
+	for want, resolve := range dirs {
+		if got, err := resolve(); err != nil || got != want {
+			t.Errorf("dir = %q, %v, want %q", got, err, want)
+		}
+	}
+}
+
+func TestDirsFromXDG(t *testing.T) {
+	unsetHome(t)
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(xdg, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(xdg, "state"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(xdg, "data"))
+
+	// The XDG variables do without HOME
+	if got, err := ConfigDir(); err != nil || got != filepath.Join(xdg, "config", "bullseye") {
+		t.Errorf("ConfigDir() = %q, %v", got, err)
+	}
+	if got, err := StateDir(); err != nil || got != filepath.Join(xdg, "state", "bullseye") {
+		t.Errorf("StateDir() = %q, %v", got, err)
+	}
+	if got, err := dataDir(); err != nil || got != filepath.Join(xdg, "data") {
+		t.Errorf("XDGBaseDir(XDG_DATA_HOME) = %q, %v", got, err)
+	}
+
+	// A relative XDG path is invalid and ignored, as the spec asks
+	t.Setenv("XDG_STATE_HOME", "state")
+	if got, err := StateDir(); err == nil {
+		t.Errorf("StateDir() with a relative XDG_STATE_HOME and no HOME = %q", got)
+	}
+	t.Setenv("XDG_DATA_HOME", "data")
+	if got, err := dataDir(); err == nil {
+		t.Errorf("XDGBaseDir(XDG_DATA_HOME) with a relative path and no HOME = %q", got)
+	}
+}
+
+func TestDirsWithoutHome(t *testing.T) {
+	unsetHome(t)
+	if got, err := ConfigDir(); err == nil {
+		t.Errorf("ConfigDir() without HOME = %q", got)
+	}
+	if got, err := StateDir(); err == nil {
+		t.Errorf("StateDir() without HOME = %q", got)
+	}
+	if got, err := dataDir(); err == nil {
+		t.Errorf("XDGBaseDir(XDG_DATA_HOME) without HOME = %q", got)
+	}
+
+	// State files report the missing directory rather than using a path
+	// relative to the working directory
+	t.Chdir(t.TempDir())
+	if _, err := LoadFavorites(); err == nil {
+		t.Error("LoadFavorites() without HOME succeeded")
+	}
+	if err := SaveVisit("/somewhere", Visit{}); err == nil {
+		t.Error("SaveVisit() without HOME succeeded")
+	}
+	if entries, _ := os.ReadDir("."); len(entries) > 0 {
+		t.Errorf("state written to the working directory: %v", entries)
+	}
+}
+
+func TestLoadConfigWithoutHome(t *testing.T) {
+	unsetHome(t)
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// The defaults, or the config of the working directory
+	if got := LoadConfig().BorderColor; got == "" || got == "#123456" {
+		t.Errorf("border color without a config = %q, want the default", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("border_color = \"#123456\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := LoadConfig().BorderColor; got != "#123456" {
+		t.Errorf("border color from ./config.toml = %q", got)
+	}
+}
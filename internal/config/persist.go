@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic replaces path with data so readers, and the file after a
+// crash, only ever hold the old or the new content: data goes to a temp file
+// in the same directory, is synced, then renamed over path
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	// The rename itself is durable once the directory is synced, which not
+	// every platform or filesystem supports
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// UpdateFile rewrites path with what update makes of its current content,
+// nil when missing, holding a lock so that other instances updating the
+// same file wait rather than overwrite each other's changes. Returning an
+// error from update leaves the file as it was.
+func UpdateFile(path string, perm os.FileMode, update func(current []byte) ([]byte, error)) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	data, err := update(current)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, perm)
+}
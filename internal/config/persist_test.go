@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// tempFiles lists the temp files WriteFileAtomic left in dir
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state", "file")
+	for _, content := range []string{"first\n", "second\n"} {
+		if err := WriteFileAtomic(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("content %q, want %q", data, content)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode %v, want 0600", info.Mode().Perm())
+	}
+	if stray := tempFiles(t, filepath.Dir(path)); len(stray) > 0 {
+		t.Errorf("temp files left: %v", stray)
+	}
+}
+
+func TestUpdateFileConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list")
+	const writers, rounds = 8, 10
+
+	// Each writer appends its lines to what it reads, a lost update drops
+	// another writer's line
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*rounds)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rounds {
+				errs <- UpdateFile(path, 0o644, func(current []byte) ([]byte, error) {
+					return fmt.Appendf(current, "%d-%d\n", w, r), nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != writers*rounds {
+		t.Fatalf("%d lines, want %d", len(lines), writers*rounds)
+	}
+	for w := range writers {
+		for r := range rounds {
+			if line := fmt.Sprintf("%d-%d", w, r); !slices.Contains(lines, line) {
+				t.Errorf("line %s was lost", line)
+			}
+		}
+	}
+	if stray := tempFiles(t, filepath.Dir(path)); len(stray) > 0 {
+		t.Errorf("temp files left: %v", stray)
+	}
+}
+
+func TestUpdateFileAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "favorites")
+	if err := WriteFileAtomic(path, []byte("/a\n/b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A process that died writing its temp file leaves it half written,
+	// with the file itself untouched
+	stray := filepath.Join(dir, ".favorites.12345.tmp")
+	if err := os.WriteFile(stray, []byte("/a\n/b\n/c"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "/a\n/b\n" {
+		t.Fatalf("content after the crash %q", data)
+	}
+
+	// The next update reads the last complete content, not the stray file
+	err = UpdateFile(path, 0o644, func(current []byte) ([]byte, error) {
+		if string(current) != "/a\n/b\n" {
+			t.Errorf("update read %q", current)
+		}
+		return append(current, "/d\n"...), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "/a\n/b\n/d\n" {
+		t.Errorf("content %q, want /a, /b and /d", data)
+	}
+	if got := tempFiles(t, dir); !slices.Equal(got, []string{stray}) {
+		t.Errorf("temp files %v, want only the stray one", got)
+	}
+}
+
+func TestUpdateFileFailures(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list")
+	if err := WriteFileAtomic(path, []byte("kept\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An update returning an error writes nothing
+	errUpdate := errors.New("update failed")
+	err := UpdateFile(path, 0o644, func(current []byte) ([]byte, error) {
+		return []byte("lost\n"), errUpdate
+	})
+	if !errors.Is(err, errUpdate) {
+		t.Errorf("error %v, want %v", err, errUpdate)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "kept\n" {
+		t.Errorf("content %q after a failed update", data)
+	}
+
+	// A write that cannot be renamed into place removes its temp file
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "entry"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(blocked, []byte("data"), 0o644); err == nil {
+		t.Error("writing over a directory succeeded")
+	}
+	if stray := tempFiles(t, dir); len(stray) > 0 {
+		t.Errorf("temp files left: %v", stray)
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseSize parses a byte size such as "512", "512K", "10M" or "1.5G".
+// Suffixes are binary multiples and may be followed by an optional "B".
+// Sizes that do not fit an int64 are rejected.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "B")
+	if str == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	multiplier := int64(1)
+	if i := strings.IndexByte("KMGTPE", str[len(str)-1]); i >= 0 {
+		multiplier = int64(1) << (10 * (i + 1))
+		str = strings.TrimSpace(str[:len(str)-1])
+	}
+
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil || !(value >= 0) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if value >= float64(math.MaxInt64)/float64(multiplier) {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"1K", 1 << 10},
+		{"512K", 512 << 10},
+		{"10M", 10 << 20},
+		{"1.5G", 3 << 29},
+		{"2T", 2 << 40},
+		{"1P", 1 << 50},
+		{"7E", 7 << 60},
+		{"10m", 10 << 20},
+		{"10mb", 10 << 20},
+		{"10Mb", 10 << 20},
+		{"  10M  ", 10 << 20},
+		{"10 M", 10 << 20},
+		{"10 MB", 10 << 20},
+		{"\t4k\n", 4 << 10},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"   ",
+		"B",
+		"K",
+		"-1",
+		"-1K",
+		"ten",
+		"10X",
+		"10KK",
+		"1,5M",
+		"NaN",
+		"Inf",
+		"8E",
+		"9999999999T",
+		"9223372036854775808",
+		"99999999999999999999",
+	} {
+		if got, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want an error", in, got)
+		}
+	}
+}
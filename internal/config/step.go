@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Step is a scroll distance, either a fraction of the visible rows or a
+// fixed number of lines
+type Step struct {
+	pages float64 // Fraction of the visible rows, used when lines is 0
+	lines int
+}
+
+// ParseStep parses "half", "full" or a positive line count. The value may
+// come from TOML as a string or an integer.
+func ParseStep(v any) (Step, error) {
+	str := strings.ToLower(strings.TrimSpace(fmt.Sprint(v)))
+	switch str {
+	case "half":
+		return Step{pages: 0.5}, nil
+	case "full":
+		return Step{pages: 1}, nil
+	}
+	lines, err := strconv.Atoi(str)
+	if err != nil || lines < 1 {
+		return Step{}, fmt.Errorf("invalid step %q, want \"half\", \"full\" or a line count", str)
+	}
+	return Step{lines: lines}, nil
+}
+
+// Lines returns the distance in lines for a view showing rows lines. It is
+// at least one so a step always moves.
+func (s Step) Lines(rows int) int {
+	if s.lines > 0 {
+		return s.lines
+	}
+	return max(1, int(float64(rows)*s.pages))
+}
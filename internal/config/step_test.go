@@ -0,0 +1,42 @@
+package config
+
+import "testing"
+
+func TestParseStep(t *testing.T) {
+	tests := []struct {
+		in   any
+		rows int
+		want int
+	}{
+		{"half", 24, 12},
+		{"half", 25, 12},
+		{"half", 7, 3},
+		{"half", 1, 1},
+		{"full", 25, 25},
+		{"full", 1, 1},
+		{" Full ", 10, 10},
+		{"HALF", 10, 5},
+		// A line count holds whatever the height, TOML integers included
+		{"3", 25, 3},
+		{int64(3), 25, 3},
+		{int64(40), 10, 40},
+	}
+	for _, tt := range tests {
+		step, err := ParseStep(tt.in)
+		if err != nil {
+			t.Errorf("ParseStep(%v) error: %v", tt.in, err)
+			continue
+		}
+		if got := step.Lines(tt.rows); got != tt.want {
+			t.Errorf("ParseStep(%v).Lines(%d) = %d, want %d", tt.in, tt.rows, got, tt.want)
+		}
+	}
+}
+
+func TestParseStepInvalid(t *testing.T) {
+	for _, in := range []any{"", "0", "-2", int64(0), 2.5, "quarter", "1/2", true} {
+		if step, err := ParseStep(in); err == nil {
+			t.Errorf("ParseStep(%v) = %+v, want an error", in, step)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxVisitEntries caps the entries a visit snapshot records. Larger
+// directories only record the time of the visit.
+const MaxVisitEntries = 5000
+
+// Visit is what a directory held when it was last left, for track_changes
+type Visit struct {
+	Time    time.Time            // When the directory was left
+	Entries map[string]time.Time // Entry name to its modification time, nil when over MaxVisitEntries
+}
+
+// visitPath returns the state file of dir's last visit, named after a hash
+// of the path so any path maps to a flat file name
+func visitPath(dir string) (string, error) {
+	stateDir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(stateDir, "visits", hex.EncodeToString(sum[:16])), nil
+}
+
+// LoadVisit reads the snapshot of dir's last visit. ok is false when dir was
+// not visited before, or only under another path hashing the same.
+func LoadVisit(dir string) (visit Visit, ok bool, err error) {
+	path, err := visitPath(dir)
+	if err != nil {
+		return Visit{}, false, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Visit{}, false, nil
+	} else if err != nil {
+		return Visit{}, false, err
+	}
+	defer file.Close()
+
+	// The path, the visit time, then "mtime<TAB>name" per entry unless the
+	// directory was too large. Times are Unix nanoseconds.
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() || scanner.Text() != dir || !scanner.Scan() {
+		return Visit{}, false, scanner.Err()
+	}
+	left, err := strconv.ParseInt(scanner.Text(), 10, 64)
+	if err != nil {
+		return Visit{}, false, fmt.Errorf("%s: bad visit time", path)
+	}
+	visit.Time = time.Unix(0, left)
+	if !scanner.Scan() || scanner.Text() != "entries" {
+		return visit, true, scanner.Err()
+	}
+	visit.Entries = make(map[string]time.Time)
+	for scanner.Scan() {
+		mtime, name, found := strings.Cut(scanner.Text(), "\t")
+		nanos, err := strconv.ParseInt(mtime, 10, 64)
+		if !found || err != nil {
+			continue
+		}
+		visit.Entries[name] = time.Unix(0, nanos)
+	}
+	return visit, true, scanner.Err()
+}
+
+// SaveVisit records the snapshot of dir taken when leaving it
+func SaveVisit(dir string, visit Visit) error {
+	path, err := visitPath(dir)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n%d\n", dir, visit.Time.UnixNano())
+	if visit.Entries != nil {
+		sb.WriteString("entries\n")
+		names := make([]string, 0, len(visit.Entries))
+		for name := range visit.Entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !strings.Contains(name, "\n") {
+				fmt.Fprintf(&sb, "%d\t%s\n", visit.Entries[name].UnixNano(), name)
+			}
+		}
+	}
+	return WriteFileAtomic(path, []byte(sb.String()), 0644)
+}
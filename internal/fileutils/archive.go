@@ -0,0 +1,360 @@
+package fileutils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// IsArchive reports whether the file name is an archive OpenArchive can browse
+func IsArchive(fileName string) bool {
+	name := strings.ToLower(fileName)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenArchive opens a zip or (optionally gzip compressed) tar archive as a
+// read-only fs.FS. The closer releases the archive once browsing is done.
+func OpenArchive(archivePath string) (fs.FS, io.Closer, error) {
+	name := strings.ToLower(archivePath)
+	if strings.HasSuffix(name, ".zip") {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return r, r, nil
+	}
+
+	gzipped := strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")
+	fsys, err := indexTar(archivePath, gzipped)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fsys, fsys, nil
+}
+
+// ReadFSDirWithInfo is ReadDirWithInfo for a directory of an fs.FS
+func ReadFSDirWithInfo(fsys fs.FS, dir string) ([]models.FileInfo, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]models.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, GetFileInfo(entry, dir))
+	}
+	return files, nil
+}
+
+// ReadFSHead reads at most limit bytes of name from fsys, like ReadHead
+func ReadFSHead(fsys fs.FS, name string, limit int64) ([]byte, bool, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(content)) > limit {
+		return content[:limit], true, nil
+	}
+	return content, false, nil
+}
+
+// ExtractFSFile copies name out of fsys into dstDir, refusing to overwrite
+// an existing file. It returns the path written.
+func ExtractFSFile(fsys fs.FS, name, dstDir string) (string, error) {
+	src, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", name)
+	}
+
+	dstPath := filepath.Join(dstDir, path.Base(name))
+	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dstPath)
+		return "", err
+	}
+	return dstPath, nil
+}
+
+// tarFS is a read-only fs.FS over a tar archive. Headers are indexed once,
+// file contents are read by rescanning the stream since compressed tars
+// cannot seek.
+type tarFS struct {
+	archivePath string
+	gzipped     bool
+	entries     map[string]*tarEntry
+}
+
+type tarEntry struct {
+	info     fs.FileInfo
+	index    int      // Position of the header in the archive, -1 for implied directories
+	children []string // Base names, for directories
+}
+
+// indexTar reads every header of the archive and builds the directory tree,
+// adding directories the archive only implies through its file paths
+func indexTar(archivePath string, gzipped bool) (*tarFS, error) {
+	t := &tarFS{
+		archivePath: archivePath,
+		gzipped:     gzipped,
+		entries:     map[string]*tarEntry{".": {info: impliedDir{name: "."}, index: -1}},
+	}
+
+	tr, closeArchive, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+		t.addParents(name)
+		if existing, ok := t.entries[name]; ok && existing.info.IsDir() && hdr.FileInfo().IsDir() {
+			existing.info, existing.index = renamedInfo{hdr.FileInfo(), path.Base(name)}, i
+			continue
+		}
+		t.entries[name] = &tarEntry{info: renamedInfo{hdr.FileInfo(), path.Base(name)}, index: i}
+	}
+
+	for _, entry := range t.entries {
+		sort.Strings(entry.children)
+	}
+	return t, nil
+}
+
+// addParents registers name with its parent, creating implied directories
+func (t *tarFS) addParents(name string) {
+	for {
+		parent := path.Dir(name)
+		dir, ok := t.entries[parent]
+		if !ok {
+			dir = &tarEntry{info: impliedDir{name: path.Base(parent)}, index: -1}
+			t.entries[parent] = dir
+		}
+		base := path.Base(name)
+		for _, child := range dir.children {
+			if child == base {
+				return
+			}
+		}
+		dir.children = append(dir.children, base)
+		if ok {
+			return
+		}
+		name = parent
+	}
+}
+
+// Close implements io.Closer, the archive is only held open while reading
+func (t *tarFS) Close() error { return nil }
+
+// open starts reading the archive from the beginning
+func (t *tarFS) open() (*tar.Reader, func(), error) {
+	file, err := os.Open(t.archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !t.gzipped {
+		return tar.NewReader(file), func() { file.Close() }, nil
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return tar.NewReader(gz), func() { gz.Close(); file.Close() }, nil
+}
+
+// Open implements fs.FS
+func (t *tarFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := t.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.info.IsDir() {
+		return &tarDir{fsys: t, name: name, entry: entry}, nil
+	}
+
+	tr, closeArchive, err := t.open()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	for i := 0; i <= entry.index; i++ {
+		if _, err := tr.Next(); err != nil {
+			closeArchive()
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	return &tarFile{Reader: tr, info: entry.info, close: closeArchive}, nil
+}
+
+// tarFile streams one entry's content
+type tarFile struct {
+	io.Reader
+	info  fs.FileInfo
+	close func()
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *tarFile) Close() error               { f.close(); return nil }
+
+// tarDir lists a directory of the archive
+type tarDir struct {
+	fsys   *tarFS
+	name   string
+	entry  *tarEntry
+	offset int
+}
+
+func (d *tarDir) Stat() (fs.FileInfo, error) { return d.entry.info, nil }
+func (d *tarDir) Close() error               { return nil }
+func (d *tarDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *tarDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entry.children[d.offset:]
+	if n > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && len(remaining) > n {
+		remaining = remaining[:n]
+	}
+	entries := make([]fs.DirEntry, 0, len(remaining))
+	for _, child := range remaining {
+		entries = append(entries, fs.FileInfoToDirEntry(d.fsys.entries[path.Join(d.name, child)].info))
+	}
+	d.offset += len(remaining)
+	return entries, nil
+}
+
+// impliedDir describes a directory the archive has no header for
+type impliedDir struct{ name string }
+
+func (i impliedDir) Name() string       { return i.name }
+func (i impliedDir) Size() int64        { return 0 }
+func (i impliedDir) Mode() fs.FileMode  { return fs.ModeDir | 0o755 }
+func (i impliedDir) ModTime() time.Time { return time.Time{} }
+func (i impliedDir) IsDir() bool        { return true }
+func (i impliedDir) Sys() any           { return nil }
+
+// renamedInfo reports the cleaned base name, tar headers may carry a
+// trailing slash or a "./" prefix
+type renamedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (r renamedInfo) Name() string { return r.name }
+
+// ArchiveEntry is one member of an archive as ListArchive reports it
+type ArchiveEntry struct {
+	Name       string // Path within the archive, directories end in "/"
+	Size       int64
+	Compressed int64 // Stored size, -1 when the format compresses the archive as a whole
+	ModTime    time.Time
+}
+
+// ListArchive returns the first limit members of a zip or (optionally gzip
+// compressed) tar archive in stored order, and how many it has in total.
+// Zip archives are listed from their central directory, tar archives have
+// every header read.
+func ListArchive(archivePath string, limit int) ([]ArchiveEntry, int, error) {
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer r.Close()
+		entries := make([]ArchiveEntry, 0, min(limit, len(r.File)))
+		for _, f := range r.File[:min(limit, len(r.File))] {
+			entries = append(entries, ArchiveEntry{
+				Name:       f.Name,
+				Size:       int64(f.UncompressedSize64),
+				Compressed: int64(f.CompressedSize64),
+				ModTime:    f.Modified,
+			})
+		}
+		return entries, len(r.File), nil
+	}
+
+	name := strings.ToLower(archivePath)
+	t := &tarFS{archivePath: archivePath, gzipped: strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")}
+	tr, closeArchive, err := t.open()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer closeArchive()
+	var entries []ArchiveEntry
+	total := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, total, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if total < limit {
+			name := hdr.Name
+			if hdr.Typeflag == tar.TypeDir && !strings.HasSuffix(name, "/") {
+				name += "/"
+			}
+			entries = append(entries, ArchiveEntry{Name: name, Size: hdr.Size, Compressed: -1, ModTime: hdr.ModTime})
+		}
+		total++
+	}
+}
@@ -0,0 +1,214 @@
+package fileutils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Unix mode bits, fs.FileMode keeps the special ones outside the permissions
+const (
+	modeSetuid = 04000
+	modeSetgid = 02000
+	modeSticky = 01000
+)
+
+// ModeChange is a chmod mode, either octal such as "755" or symbolic such as
+// "u+rwX,go-w"
+type ModeChange struct {
+	octal   bool
+	mode    uint32
+	clauses []modeClause
+}
+
+// modeClause is one "who op perms" step of a symbolic mode
+type modeClause struct {
+	who      uint32 // Bits the clause may touch
+	op       byte   // '+', '-' or '='
+	perms    uint32 // Requested bits, before masking with who
+	execIfX  bool   // X: execute only for directories and already executable files
+	copyFrom uint32 // Class copied with e.g. "g=u", 0 for none
+}
+
+// ParseModeChange parses a mode as accepted by chmod(1). In symbolic modes
+// "who" defaults to all classes, the umask is not consulted.
+func ParseModeChange(spec string) (ModeChange, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return ModeChange{}, fmt.Errorf("empty mode")
+	}
+	if strings.Trim(spec, "01234567") == "" {
+		if len(spec) > 4 {
+			return ModeChange{}, fmt.Errorf("invalid mode %q", spec)
+		}
+		mode, _ := strconv.ParseUint(spec, 8, 32)
+		return ModeChange{octal: true, mode: uint32(mode)}, nil
+	}
+
+	var change ModeChange
+	for _, clause := range strings.Split(spec, ",") {
+		var who uint32
+		i := 0
+	whoLoop:
+		for ; i < len(clause); i++ {
+			switch clause[i] {
+			case 'u':
+				who |= modeSetuid | 0700
+			case 'g':
+				who |= modeSetgid | 0070
+			case 'o':
+				who |= modeSticky | 0007
+			case 'a':
+				who |= modeSetuid | modeSetgid | modeSticky | 0777
+			default:
+				break whoLoop
+			}
+		}
+		if who == 0 {
+			who = modeSetuid | modeSetgid | modeSticky | 0777
+		}
+		if i == len(clause) {
+			return ModeChange{}, fmt.Errorf("invalid mode %q: missing +, - or =", spec)
+		}
+
+		for i < len(clause) {
+			c := modeClause{who: who, op: clause[i]}
+			if c.op != '+' && c.op != '-' && c.op != '=' {
+				return ModeChange{}, fmt.Errorf("invalid mode %q: unexpected %q", spec, clause[i])
+			}
+			i++
+			if i < len(clause) && strings.IndexByte("ugo", clause[i]) >= 0 {
+				c.copyFrom = map[byte]uint32{'u': 0700, 'g': 0070, 'o': 0007}[clause[i]]
+				i++
+			} else {
+			permLoop:
+				for ; i < len(clause); i++ {
+					switch clause[i] {
+					case 'r':
+						c.perms |= 0444
+					case 'w':
+						c.perms |= 0222
+					case 'x':
+						c.perms |= 0111
+					case 'X':
+						c.execIfX = true
+					case 's':
+						c.perms |= modeSetuid | modeSetgid
+					case 't':
+						c.perms |= modeSticky
+					default:
+						break permLoop
+					}
+				}
+			}
+			change.clauses = append(change.clauses, c)
+		}
+	}
+	return change, nil
+}
+
+// Apply returns the permission and special bits mode ends up with, for a
+// directory when mode has fs.ModeDir set
+func (c ModeChange) Apply(mode fs.FileMode) fs.FileMode {
+	if c.octal {
+		return toFileMode(c.mode)
+	}
+
+	bits := fromFileMode(mode)
+	for _, clause := range c.clauses {
+		value := clause.perms
+		if clause.execIfX && (mode.IsDir() || bits&0111 != 0) {
+			value |= 0111
+		}
+		if clause.copyFrom != 0 {
+			// Spread the copied class's rwx to all classes
+			v := bits & clause.copyFrom
+			for v > 7 {
+				v >>= 3
+			}
+			value = v | v<<3 | v<<6
+		}
+		value &= clause.who
+
+		switch clause.op {
+		case '+':
+			bits |= value
+		case '-':
+			bits &^= value
+		case '=':
+			bits = bits&^clause.who | value
+		}
+	}
+	return toFileMode(bits)
+}
+
+// fromFileMode converts the permission and special bits of mode to unix bits
+func fromFileMode(mode fs.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= modeSetuid
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= modeSetgid
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= modeSticky
+	}
+	return bits
+}
+
+// toFileMode converts unix mode bits to what os.Chmod takes
+func toFileMode(bits uint32) fs.FileMode {
+	mode := fs.FileMode(bits & 0777)
+	if bits&modeSetuid != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if bits&modeSetgid != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if bits&modeSticky != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// ChmodTree applies change to root and, for a directory, everything below
+// it. Symlinks are not followed, as with chmod -R. Directories are changed
+// before they are read, so a mode adding search permission opens them up
+// for the walk. progress, if set, is called with the number of entries
+// visited so far. It returns how many modes changed and the failures.
+func ChmodTree(root string, change ModeChange, progress func(visited int)) (int, []error) {
+	changed, visited := 0, 0
+	var failures []error
+	WalkTree(root, false, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failures = append(failures, err)
+			return nil
+		}
+		visited++
+		if progress != nil {
+			progress(visited)
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			failures = append(failures, err)
+			return nil
+		}
+		mode := change.Apply(info.Mode())
+		if mode == info.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky) {
+			return nil
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			failures = append(failures, err)
+			return nil
+		}
+		changed++
+		return nil
+	})
+	return changed, failures
+}
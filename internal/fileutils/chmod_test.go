@@ -0,0 +1,195 @@
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModeChangeApply(t *testing.T) {
+	tests := []struct {
+		spec string
+		mode fs.FileMode
+		want fs.FileMode
+	}{
+		{"755", 0o600, 0o755},
+		{"0644", fs.ModeDir | 0o777, 0o644},
+		{"4755", 0o644, fs.ModeSetuid | 0o755},
+		{"u+x", 0o644, 0o744},
+		{"+x", 0o644, 0o755},
+		{"go-w", 0o666, 0o644},
+		{"a-rwx", 0o777, 0},
+
+		// X executes directories and files with an execute bit already
+		{"a+X", 0o644, 0o644},
+		{"a+X", 0o744, 0o755},
+		{"a+X", 0o614, 0o715},
+		{"a+X", fs.ModeDir | 0o644, 0o755},
+		{"u+rwX", 0o044, 0o644},
+		{"u+rwX", fs.ModeDir | 0o044, 0o744},
+		{"go-X", fs.ModeDir | 0o755, 0o744},
+
+		// = sets the classes named to exactly the bits given
+		{"u=", 0o755, 0o055},
+		{"go=", 0o755, 0o700},
+		{"a=r", 0o755, 0o444},
+		{"=rw", 0o701, 0o666},
+		{"u=rx", fs.ModeSetuid | 0o755, 0o555},
+		{"g=u", 0o640, 0o660},
+		{"o=g", 0o750, 0o755},
+
+		// Clauses apply in order, later ones seeing the earlier results
+		{"u=rwx,go=rx", 0, 0o755},
+		{"a=,u+r", 0o777, 0o400},
+		{"u+rwX,go-w", 0o766, 0o744},
+		{"u+rwX,go-w", fs.ModeDir | 0o666, 0o744},
+		{"u+x,g=u", 0o600, 0o770},
+		{"u+w-x", 0o544, 0o644},
+		{"ug+rw,o-rwx", 0o007, 0o660},
+
+		// Special bits
+		{"u+s", 0o755, fs.ModeSetuid | 0o755},
+		{"g+s", 0o755, fs.ModeSetgid | 0o755},
+		{"+t", fs.ModeDir | 0o777, fs.ModeSticky | 0o777},
+		{"o-t", fs.ModeSticky | 0o777, 0o777},
+		{"a-s", fs.ModeSetuid | fs.ModeSetgid | 0o755, 0o755},
+	}
+	for _, tt := range tests {
+		change, err := ParseModeChange(tt.spec)
+		if err != nil {
+			t.Errorf("ParseModeChange(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := change.Apply(tt.mode); got != tt.want {
+			t.Errorf("%q applied to %v = %v, want %v", tt.spec, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseModeChangeInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"  ",
+		"u",
+		"ug",
+		"u+q",
+		"u*x",
+		"u+r,",
+		",u+r",
+		"u+r,,g+r",
+		"8",
+		"12345",
+		"75a",
+		"x+u",
+	} {
+		if _, err := ParseModeChange(spec); err == nil {
+			t.Errorf("ParseModeChange(%q) succeeded", spec)
+		}
+	}
+}
+
+// modeOf returns the permission and special bits of path
+func modeOf(t *testing.T, path string) fs.FileMode {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
+
+func TestChmodTree(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, 1, "tree/plain", "tree/script", "tree/sub/deep", "tree/sub/more/")
+	modes := map[string]fs.FileMode{
+		"tree/plain":    0o604,
+		"tree/script":   0o700,
+		"tree/sub/deep": 0o666,
+		"tree/sub/more": 0o600,
+		"tree/sub":      0o700,
+		"tree":          0o755,
+	}
+	for name, mode := range modes {
+		if err := os.Chmod(filepath.Join(root, name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A symlink is not followed, a dangling one is no failure
+	if err := os.Symlink("missing", filepath.Join(root, "tree", "sub", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "tree", "plain"), filepath.Join(root, "tree", "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	change, err := ParseModeChange("u+rwX,go-w,go+rX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	visits := 0
+	changed, failures := ChmodTree(filepath.Join(root, "tree"), change, func(visited int) { visits = visited })
+	if len(failures) > 0 {
+		t.Fatalf("failures: %v", failures)
+	}
+	want := map[string]fs.FileMode{
+		"tree":          0o755,
+		"tree/plain":    0o644,
+		"tree/script":   0o755,
+		"tree/sub":      0o755,
+		"tree/sub/deep": 0o644,
+		"tree/sub/more": 0o755,
+	}
+	for name, mode := range want {
+		if got := modeOf(t, filepath.Join(root, name)); got != mode {
+			t.Errorf("mode of %s = %v, want %v", name, got, mode)
+		}
+	}
+	// tree already had its mode
+	if changed != len(want)-1 {
+		t.Errorf("changed %d modes, want %d", changed, len(want)-1)
+	}
+	if visits != len(want)+2 {
+		t.Errorf("visited %d entries, want %d", visits, len(want)+2)
+	}
+}
+
+func TestChmodTreeOfAFile(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, 1, "file")
+	change, err := ParseModeChange("a+X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, failures := ChmodTree(filepath.Join(root, "file"), change, nil); changed != 0 || len(failures) > 0 {
+		t.Errorf("a+X on a plain file changed %d with failures %v", changed, failures)
+	}
+	if got := modeOf(t, filepath.Join(root, "file")); got != 0o644 {
+		t.Errorf("mode %v, want 0644", got)
+	}
+}
+
+func TestChmodTreeReportsFailures(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root reads unreadable directories")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, 1, "tree/locked/inside", "tree/open")
+	if err := os.Chmod(filepath.Join(root, "tree", "locked"), 0o000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(filepath.Join(root, "tree", "locked"), 0o755) })
+
+	// locked keeps no search permission, so the walk cannot read it
+	change, err := ParseModeChange("go-r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, failures := ChmodTree(filepath.Join(root, "tree"), change, nil)
+	if len(failures) != 1 {
+		t.Errorf("failures %v, want the unreadable directory", failures)
+	}
+	if got := modeOf(t, filepath.Join(root, "tree", "open")); got != 0o600 {
+		t.Errorf("mode of open = %v, want 0600", got)
+	}
+}
@@ -0,0 +1,116 @@
+package fileutils
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// CompareDirs classifies the entries of directories a and b by name: those
+// only in one of them, and those in both that differ by type, size or
+// modification time. Files alike but for their modification times are
+// CompareUnsure until SameContent settles them. Directories in both are
+// alike, their contents are compared on entering them. Entries are sorted
+// by name.
+func CompareDirs(a, b string) ([]models.CompareEntry, error) {
+	infosA, err := lstatEntries(a)
+	if err != nil {
+		return nil, err
+	}
+	infosB, err := lstatEntries(b)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]models.CompareEntry, 0, len(infosA)+len(infosB))
+	for name, infoA := range infosA {
+		infoB, ok := infosB[name]
+		if !ok {
+			entries = append(entries, models.CompareEntry{Name: name, Kind: models.CompareOnlyA, A: infoA})
+			continue
+		}
+		entries = append(entries, models.CompareEntry{Name: name, Kind: classifyPair(infoA, infoB), A: infoA, B: infoB})
+	}
+	for name, infoB := range infosB {
+		if _, ok := infosA[name]; !ok {
+			entries = append(entries, models.CompareEntry{Name: name, Kind: models.CompareOnlyB, B: infoB})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// lstatEntries lists dir by name, each entry lstat'ed. Entries gone before
+// they were stat'ed are left out.
+func lstatEntries(dir string) (map[string]fs.FileInfo, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make(map[string]fs.FileInfo, len(dirEntries))
+	for _, entry := range dirEntries {
+		if info, err := entry.Info(); err == nil {
+			infos[entry.Name()] = info
+		}
+	}
+	return infos, nil
+}
+
+// classifyPair compares the entries of one name on both sides
+func classifyPair(a, b fs.FileInfo) models.CompareKind {
+	switch {
+	case a.Mode().Type() != b.Mode().Type():
+		return models.CompareDiffers
+	case a.IsDir():
+		return models.CompareSame
+	case !a.Mode().IsRegular():
+		// Symlinks and special files are alike when their type is, a
+		// symlink's size is the length of its target
+		if a.Size() != b.Size() {
+			return models.CompareDiffers
+		}
+		return models.CompareSame
+	case a.Size() != b.Size():
+		return models.CompareDiffers
+	case !a.ModTime().Equal(b.ModTime()):
+		return models.CompareUnsure
+	}
+	return models.CompareSame
+}
+
+// SameContent reports whether the files at a and b hold the same bytes,
+// reading both only as far as the first difference
+func SameContent(a, b string) (bool, error) {
+	fileA, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fileA.Close()
+	fileB, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fileB.Close()
+
+	bufA, bufB := make([]byte, 64*1024), make([]byte, 64*1024)
+	for {
+		nA, errA := io.ReadFull(fileA, bufA)
+		nB, errB := io.ReadFull(fileB, bufB)
+		// A failed read is an error rather than a difference
+		endA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		endB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		switch {
+		case errA != nil && !endA:
+			return false, errA
+		case errB != nil && !endB:
+			return false, errB
+		case !bytes.Equal(bufA[:nA], bufB[:nB]):
+			return false, nil
+		case endA || endB:
+			return endA == endB, nil
+		}
+	}
+}
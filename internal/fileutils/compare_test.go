@@ -0,0 +1,179 @@
+package fileutils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// compareFixture writes the files of each side with their contents and
+// modification times, names ending in "/" as directories and "@target" as
+// symlinks
+func compareFixture(t *testing.T, files map[string]string, modTime time.Time) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		switch {
+		case name[len(name)-1] == '/':
+			if err := os.Mkdir(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		case len(content) > 0 && content[0] == '@':
+			if err := os.Symlink(content[1:], path); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCompareDirs(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := compareFixture(t, map[string]string{
+		"same.txt":    "alike",
+		"touched.txt": "alike",
+		"size.txt":    "short",
+		"only-a.txt":  "a",
+		"only-a-dir/": "",
+		"both-dir/":   "",
+		"file-or-dir": "file",
+		"link":        "@abc",
+		"relinked":    "@abc",
+		"longer-link": "@abc",
+		"empty":       "",
+		"Case.txt":    "c",
+	}, t0)
+	b := compareFixture(t, map[string]string{
+		"same.txt":     "alike",
+		"size.txt":     "longer",
+		"only-b.txt":   "b",
+		"both-dir/":    "",
+		"file-or-dir/": "",
+		"link":         "@abc",
+		"relinked":     "@xyz",
+		"longer-link":  "@abcd",
+		"empty":        "",
+		"case.txt":     "c",
+	}, t0)
+	if err := os.WriteFile(filepath.Join(b, "touched.txt"), []byte("alike"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(b, "touched.txt"), t0.Add(time.Hour), t0.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := CompareDirs(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name string
+		kind models.CompareKind
+	}{
+		// Names are compared as they are, in byte order
+		{"Case.txt", models.CompareOnlyA},
+		{"both-dir", models.CompareSame},
+		{"case.txt", models.CompareOnlyB},
+		{"empty", models.CompareSame},
+		{"file-or-dir", models.CompareDiffers},
+		{"link", models.CompareSame},
+		{"longer-link", models.CompareDiffers},
+		{"only-a-dir", models.CompareOnlyA},
+		{"only-a.txt", models.CompareOnlyA},
+		{"only-b.txt", models.CompareOnlyB},
+		// Symlinks are only compared by the length of their targets
+		{"relinked", models.CompareSame},
+		{"same.txt", models.CompareSame},
+		{"size.txt", models.CompareDiffers},
+		{"touched.txt", models.CompareUnsure},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("%d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Name != w.name || e.Kind != w.kind {
+			t.Errorf("entry %d = %s kind %d, want %s kind %d", i, e.Name, e.Kind, w.name, w.kind)
+		}
+		// Each side's info is there exactly where the entry is
+		if (e.A != nil) != (e.Kind != models.CompareOnlyB) || (e.B != nil) != (e.Kind != models.CompareOnlyA) {
+			t.Errorf("%s: infos %v and %v for kind %d", e.Name, e.A, e.B, e.Kind)
+		}
+	}
+
+	if _, err := CompareDirs(a, filepath.Join(b, "missing")); err == nil {
+		t.Error("comparing with a missing directory succeeded")
+	}
+	if entries, err := CompareDirs(a, a); err != nil || len(entries) != 12 {
+		t.Errorf("comparing a directory with itself: %d entries, %v", len(entries), err)
+	} else {
+		for _, e := range entries {
+			if e.Kind != models.CompareSame {
+				t.Errorf("%s differs from itself: kind %d", e.Name, e.Kind)
+			}
+		}
+	}
+}
+
+func TestSameContent(t *testing.T) {
+	dir := t.TempDir()
+	big := bytes.Repeat([]byte("0123456789abcdef"), 10*1024) // Past one read buffer
+	changed := bytes.Clone(big)
+	changed[len(changed)-1] = 'X'
+	files := map[string][]byte{
+		"a":       []byte("same bytes"),
+		"b":       []byte("same bytes"),
+		"c":       []byte("same bytez"),
+		"prefix":  []byte("same"),
+		"empty1":  nil,
+		"empty2":  nil,
+		"big1":    big,
+		"big2":    bytes.Clone(big),
+		"changed": changed,
+		"longer":  append(bytes.Clone(big), '!'),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a", "b", true},
+		{"a", "c", false},
+		{"a", "prefix", false},
+		{"prefix", "a", false},
+		{"empty1", "empty2", true},
+		{"empty1", "a", false},
+		{"big1", "big2", true},
+		{"big1", "changed", false},
+		{"big1", "longer", false},
+	}
+	for _, tt := range tests {
+		got, err := SameContent(filepath.Join(dir, tt.a), filepath.Join(dir, tt.b))
+		if err != nil || got != tt.want {
+			t.Errorf("SameContent(%s, %s) = %v, %v, want %v", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	if _, err := SameContent(filepath.Join(dir, "a"), filepath.Join(dir, "missing")); err == nil {
+		t.Error("SameContent with a missing file succeeded")
+	}
+	if _, err := SameContent(filepath.Join(dir, "a"), dir); err == nil {
+		t.Error("SameContent with a directory succeeded")
+	}
+}
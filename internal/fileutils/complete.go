@@ -0,0 +1,85 @@
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// CompletePath returns the completions of a path typed into a prompt. The
+// text up to the last "/" names the directory to list, resolved like
+// ExpandPath, and the rest is the prefix entries must start with. Hidden
+// entries are only offered for a prefix starting with "." or when
+// showHidden is set. Each completion keeps the typed directory part, escapes
+// spaces as "\ " and ends in "/" for directories, so it can replace the
+// input as is. A lone "~" completes to "~/".
+func CompletePath(input, baseDir string, showHidden bool, readDir func(dir string) ([]models.FileInfo, error)) []string {
+	if input == "~" {
+		return []string{"~/"}
+	}
+	dirPart, prefix := "", input
+	if i := strings.LastIndex(input, "/"); i >= 0 {
+		dirPart, prefix = input[:i+1], input[i+1:]
+	}
+	prefix = UnescapePath(prefix)
+
+	dir := baseDir
+	if dirPart != "" {
+		dir = ExpandPath(UnescapePath(dirPart), baseDir)
+	}
+	files, err := readDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var completions []string
+	for _, file := range files {
+		name := file.Entry.Name()
+		if !strings.HasPrefix(name, prefix) || (file.IsHidden && !showHidden && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		completion := dirPart + EscapePath(name)
+		if file.Entry.IsDir() || isSymlinkToDir(file, filepath.Join(dir, name)) {
+			completion += "/"
+		}
+		completions = append(completions, completion)
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+func isSymlinkToDir(file models.FileInfo, path string) bool {
+	if file.Mode&fs.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// EscapePath escapes the spaces of a name as "\ " for a prompt
+func EscapePath(s string) string {
+	return strings.ReplaceAll(s, " ", `\ `)
+}
+
+// UnescapePath undoes EscapePath, typed unescaped spaces are kept as well
+func UnescapePath(s string) string {
+	return strings.ReplaceAll(s, `\ `, " ")
+}
+
+// ExpandPath resolves a typed path: "~" stands for the home directory and
+// relative paths are taken from baseDir
+func ExpandPath(path, baseDir string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,77 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompletePath(t *testing.T) {
+	dir, home := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	writeFiles(t, dir, 1, "docs/a.md", "docs/.hidden", "downloads/", "notes.txt", "README", "my files/inner.txt", ".config/", ".bashrc")
+	writeFiles(t, home, 1, "projects/", "photos/", ".profile")
+	if err := os.Symlink("docs", filepath.Join(dir, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input      string
+		showHidden bool
+		want       []string
+	}{
+		// A unique match, and several to cycle through in order
+		{"no", false, []string{"notes.txt"}},
+		{"do", false, []string{"docs/", "downloads/"}},
+		{"", false, []string{"README", "docs/", "downloads/", "linkdir/", `my\ files/`, "notes.txt"}},
+		{"xyz", false, nil},
+
+		// Hidden entries for a leading "." or with show_hidden
+		{".", false, []string{".bashrc", ".config/"}},
+		{".c", false, []string{".config/"}},
+		{"", true, []string{".bashrc", ".config/", "README", "docs/", "downloads/", "linkdir/", `my\ files/`, "notes.txt"}},
+		{"docs/", false, []string{"docs/a.md"}},
+		{"docs/.", false, []string{"docs/.hidden"}},
+		{"docs/", true, []string{"docs/.hidden", "docs/a.md"}},
+
+		// Prefixes ending in "/" list the directory, keeping what was typed
+		{"linkdir/", false, []string{"linkdir/a.md"}},
+		{dir + "/do", false, []string{dir + "/docs/", dir + "/downloads/"}},
+		{"./no", false, []string{"./notes.txt"}},
+		{"docs/../no", false, []string{"docs/../notes.txt"}},
+		{"notes.txt/", false, nil},
+		{"missing/", false, nil},
+
+		// Spaces, escaped or not, come back escaped
+		{"my", false, []string{`my\ files/`}},
+		{`my\ f`, false, []string{`my\ files/`}},
+		{"my f", false, []string{`my\ files/`}},
+		{`my\ files/`, false, []string{`my\ files/inner.txt`}},
+
+		// ~ is the home directory
+		{"~", false, []string{"~/"}},
+		{"~/", false, []string{"~/photos/", "~/projects/"}},
+		{"~/pr", false, []string{"~/projects/"}},
+		{"~/.", false, []string{"~/.profile"}},
+	}
+	for _, tt := range tests {
+		got := CompletePath(tt.input, dir, tt.showHidden, ReadDirWithInfo)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("CompletePath(%q, hidden %v) = %q, want %q", tt.input, tt.showHidden, got, tt.want)
+		}
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	for _, name := range []string{"plain", "two words", "  lead and trail  ", `back\slash`} {
+		escaped := EscapePath(name)
+		if strings.Count(escaped, " ") != strings.Count(escaped, `\ `) {
+			t.Errorf("EscapePath(%q) = %q left a bare space", name, escaped)
+		}
+		if got := UnescapePath(escaped); got != name {
+			t.Errorf("UnescapePath(EscapePath(%q)) = %q", name, got)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package fileutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CopyOptions controls which metadata CopyPath carries over to the destination
+type CopyOptions struct {
+	PreserveTimes bool // Keep the source modification times
+	CopyXattrs    bool // Copy extended attributes where the platform supports them
+
+	// Progress, if set, is called with the byte count of each chunk written
+	Progress func(n int64)
+	// Context, if set, stops the copy with its error once it is done
+	Context context.Context
+}
+
+// cancelled returns the error of a done Context, nil while the copy may go on
+func (opts CopyOptions) cancelled() error {
+	if opts.Context == nil {
+		return nil
+	}
+	return opts.Context.Err()
+}
+
+// progressWriter reports the bytes passing through to Progress, and stops
+// writing once the copy is cancelled
+type progressWriter struct {
+	w    io.Writer
+	opts CopyOptions
+}
+
+func (p progressWriter) Write(b []byte) (int, error) {
+	if err := p.opts.cancelled(); err != nil {
+		return 0, err
+	}
+	n, err := p.w.Write(b)
+	if p.opts.Progress != nil {
+		p.opts.Progress(int64(n))
+	}
+	return n, err
+}
+
+// CopyPath copies src to dst, recursing into directories. Mode bits are always
+// preserved and symlinks are recreated as symlinks instead of being followed.
+func CopyPath(src, dst string, opts CopyOptions) error {
+	if err := opts.cancelled(); err != nil {
+		return err
+	}
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return copySymlink(src, dst)
+	case info.IsDir():
+		if IsSameOrAncestor(src, dst) {
+			return fmt.Errorf("cannot copy %s into itself", src)
+		}
+		return copyDir(src, dst, info, opts)
+	case info.Mode().IsRegular():
+		return copyFile(src, dst, info, opts)
+	default:
+		return fmt.Errorf("cannot copy %s: unsupported file type %s", src, info.Mode().Type())
+	}
+}
+
+// copyFile streams a regular file so large files are never held in memory
+func copyFile(src, dst string, info os.FileInfo, opts CopyOptions) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	var w io.Writer = out
+	if opts.Progress != nil || opts.Context != nil {
+		w = progressWriter{w: out, opts: opts}
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return copyMetadata(src, dst, info, opts)
+}
+
+// UniqueName returns name, or when dir already has an entry by that name the
+// first free one with a numeric suffix before the extension, e.g. "notes_2.txt"
+func UniqueName(dir, name string) string {
+	stem, ext := SplitExt(name)
+	candidate := name
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d%s", stem, i, ext)
+	}
+}
+
+// compoundExts are extensions spanning two dots, kept together by SplitExt
+var compoundExts = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst"}
+
+// SplitExt splits name into its stem and extension, "report.final.pdf" into
+// "report.final" and ".pdf". A dotfile such as ".bashrc" is all stem, and
+// compressed tarballs keep their ".tar.gz" whole.
+func SplitExt(name string) (stem, ext string) {
+	lower := strings.ToLower(name)
+	for _, compound := range compoundExts {
+		if strings.HasSuffix(lower, compound) && len(name) > len(compound) {
+			return name[:len(name)-len(compound)], name[len(name)-len(compound):]
+		}
+	}
+	ext = filepath.Ext(name)
+	if ext == name || ext == "." {
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// copyDir populates dst before applying the source mode, so read-only
+// directories can still be filled
+func copyDir(src, dst string, info os.FileInfo, opts CopyOptions) error {
+	if err := os.Mkdir(dst, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := CopyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), opts); err != nil {
+			return err
+		}
+	}
+
+	return copyMetadata(src, dst, info, opts)
+}
+
+// copySymlink recreates the link itself rather than its target
+func copySymlink(src, dst string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(target, dst)
+}
+
+// copyMetadata applies mode bits, extended attributes and times from src to dst
+func copyMetadata(src, dst string, info os.FileInfo, opts CopyOptions) error {
+	mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if err := os.Chmod(dst, mode); err != nil {
+		return err
+	}
+
+	if opts.CopyXattrs {
+		if err := copyXattrs(src, dst); err != nil {
+			return err
+		}
+	}
+
+	if opts.PreserveTimes {
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package fileutils
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// copyFixture creates a tree below dir with unusual modes, a read-only
+// directory, a symlink and old times, returning the relative paths in it
+func copyFixture(t *testing.T, dir string) []string {
+	t.Helper()
+	writeFiles(t, dir, 10, "tree/private", "tree/script", "tree/locked/inside", "tree/shared/")
+	modes := []struct {
+		name string
+		mode fs.FileMode
+	}{
+		{"tree/private", 0o600},
+		{"tree/script", 0o751},
+		{"tree/locked/inside", 0o444},
+		{"tree/shared", fs.ModeSetgid | fs.ModeSticky | 0o775},
+		{"tree/locked", 0o555},
+	}
+	for _, m := range modes {
+		if err := os.Chmod(filepath.Join(dir, m.name), m.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("private", filepath.Join(dir, "tree", "link")); err != nil {
+		t.Fatal(err)
+	}
+	// The directories last, as writing into them moved their times
+	for i, name := range []string{"tree/private", "tree/script", "tree/locked/inside", "tree/shared", "tree/locked", "tree"} {
+		old := time.Date(2020, 1, 1+i, 12, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Read-only directories are not removable otherwise
+	t.Cleanup(func() {
+		os.Chmod(filepath.Join(dir, "tree", "locked"), 0o755)
+	})
+	return []string{"tree", "tree/private", "tree/script", "tree/locked", "tree/locked/inside", "tree/shared", "tree/link"}
+}
+
+func TestCopyPathRoundTrip(t *testing.T) {
+	for _, preserveTimes := range []bool{true, false} {
+		src, dst := t.TempDir(), t.TempDir()
+		names := copyFixture(t, src)
+		start := time.Now().Add(-time.Minute)
+		if err := CopyPath(filepath.Join(src, "tree"), filepath.Join(dst, "tree"), CopyOptions{PreserveTimes: preserveTimes}); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			os.Chmod(filepath.Join(dst, "tree", "locked"), 0o755)
+		})
+
+		for _, name := range names {
+			want, err := os.Lstat(filepath.Join(src, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.Lstat(filepath.Join(dst, name))
+			if err != nil {
+				t.Errorf("%s was not copied: %v", name, err)
+				continue
+			}
+			if got.Mode() != want.Mode() {
+				t.Errorf("mode of %s = %v, want %v", name, got.Mode(), want.Mode())
+			}
+			if want.Mode()&fs.ModeSymlink != 0 {
+				if target, _ := os.Readlink(filepath.Join(dst, name)); target != "private" {
+					t.Errorf("%s points at %q, want private", name, target)
+				}
+				continue
+			}
+			switch {
+			case preserveTimes && !got.ModTime().Equal(want.ModTime()):
+				t.Errorf("time of %s = %v, want %v", name, got.ModTime(), want.ModTime())
+			case !preserveTimes && got.ModTime().Before(start):
+				t.Errorf("time of %s = %v without preserve_times, want the copy's", name, got.ModTime())
+			}
+		}
+		data, err := os.ReadFile(filepath.Join(dst, "tree", "locked", "inside"))
+		if err != nil || len(data) != 10 {
+			t.Errorf("content of the read-only copy = %q, %v", data, err)
+		}
+	}
+}
+
+func TestCopyPathIntoItself(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, 1, "tree/file")
+	if err := CopyPath(filepath.Join(dir, "tree"), filepath.Join(dir, "tree", "copy"), CopyOptions{}); err == nil {
+		t.Error("copying a directory into itself succeeded")
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "tree", "copy")); !os.IsNotExist(err) {
+		t.Errorf("the failed copy left tree/copy: %v", err)
+	}
+}
+
+func TestSplitExt(t *testing.T) {
+	tests := []struct{ name, stem, ext string }{
+		{"report-final.pdf", "report-final", ".pdf"},
+		{"report.final.v2.pdf", "report.final.v2", ".pdf"},
+		{"noext", "noext", ""},
+		{".bashrc", ".bashrc", ""},
+		{".env.local", ".env", ".local"},
+		{"..double", ".", ".double"},
+		{"trailing.", "trailing.", ""},
+		{"backup.tar.gz", "backup", ".tar.gz"},
+		{"BACKUP.TAR.XZ", "BACKUP", ".TAR.XZ"},
+		{".tar.gz", ".tar", ".gz"},
+		{"notes.tar", "notes", ".tar"},
+		{"résumé.pdf", "résumé", ".pdf"},
+	}
+	for _, tt := range tests {
+		if stem, ext := SplitExt(tt.name); stem != tt.stem || ext != tt.ext {
+			t.Errorf("SplitExt(%q) = %q, %q, want %q, %q", tt.name, stem, ext, tt.stem, tt.ext)
+		}
+	}
+}
+
+func TestUniqueName(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, 1, "notes.txt", "notes_1.txt", ".bashrc", "backup.tar.gz", "plain")
+	tests := map[string]string{
+		"free.txt":      "free.txt",
+		"notes.txt":     "notes_2.txt",
+		".bashrc":       ".bashrc_1",
+		"backup.tar.gz": "backup_1.tar.gz",
+		"plain":         "plain_1",
+	}
+	for name, want := range tests {
+		if got := UniqueName(dir, name); got != want {
+			t.Errorf("UniqueName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCopyPathCancelled(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, 1<<20, "tree/big", "tree/later")
+
+	// Cancelled after the first chunk, the copy stops within the file
+	ctx, cancel := context.WithCancel(context.Background())
+	var written int64
+	opts := CopyOptions{Context: ctx, Progress: func(n int64) {
+		written += n
+		cancel()
+	}}
+	err := CopyPath(filepath.Join(src, "tree"), filepath.Join(dst, "tree"), opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CopyPath = %v, want context.Canceled", err)
+	}
+	if written >= 1<<20 {
+		t.Errorf("wrote %d bytes after the cancellation", written)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "tree", "later")); !os.IsNotExist(err) {
+		t.Errorf("the entry after the cancellation was copied: %v", err)
+	}
+
+	// Already cancelled, nothing is created
+	if err := CopyPath(filepath.Join(src, "tree"), filepath.Join(dst, "again"), opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("CopyPath with a done context = %v, want context.Canceled", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "again")); !os.IsNotExist(err) {
+		t.Errorf("the cancelled copy created again: %v", err)
+	}
+}
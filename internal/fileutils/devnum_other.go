@@ -0,0 +1,15 @@
+//go:build !unix
+
+package fileutils
+
+import "io/fs"
+
+// deviceNumbers is unavailable on platforms without unix device numbers
+func deviceNumbers(info fs.FileInfo) (uint32, uint32, bool) {
+	return 0, 0, false
+}
+
+// fileID is unavailable on platforms without inode numbers
+func fileID(info fs.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
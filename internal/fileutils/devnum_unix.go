@@ -0,0 +1,29 @@
+//go:build unix
+
+package fileutils
+
+import (
+	"io/fs"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// deviceNumbers extracts the major and minor numbers of a device file
+func deviceNumbers(info fs.FileInfo) (uint32, uint32, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	rdev := uint64(st.Rdev)
+	return unix.Major(rdev), unix.Minor(rdev), true
+}
+
+// fileID returns the device and inode numbers identifying info's file
+func fileID(info fs.FileInfo) (dev, ino uint64, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(st.Dev), st.Ino, true
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package fileutils
+
+import "io/fs"
+
+// DiskSize falls back to the apparent size where allocated blocks are not
+// reported
+func DiskSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
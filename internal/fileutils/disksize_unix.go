@@ -0,0 +1,18 @@
+//go:build unix
+
+package fileutils
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// DiskSize returns the bytes allocated to a file, from st_blocks, which is
+// smaller than its size for sparse or compressed files
+func DiskSize(info fs.FileInfo) int64 {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+	return int64(st.Blocks) * 512 // st_blocks is in 512-byte units everywhere
+}
@@ -1,14 +1,21 @@
 package fileutils
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/embeddingbits/file_viewer/pkg/models"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
@@ -58,58 +65,200 @@ func GetFileInfo(entry fs.DirEntry, dirPath string) models.FileInfo {
 
 	if fileInfo, err := entry.Info(); err == nil {
 		info.Size = fileInfo.Size()
+		info.DiskSize = DiskSize(fileInfo)
 		info.ModTime = fileInfo.ModTime()
+		info.Mode = fileInfo.Mode()
+		info.SortSize = info.Size
+		info.SortModTime = info.ModTime
+	} else {
+		info.Mode = entry.Type()
 	}
 
 	return info
 }
 
-// ReadDirWithInfo reads a directory and returns FileInfo for each entry
+// SortByDiskSize makes files sort by their allocated size
+func SortByDiskSize(files []models.FileInfo) {
+	for i := range files {
+		files[i].SortSize = files[i].DiskSize
+	}
+}
+
+// DiskSizeDiffers reports whether the allocated size is far enough from the
+// apparent one to mention, over a MiB and a tenth apart. Rounding up to
+// whole blocks alone never is.
+func DiskSizeDiffers(size, diskSize int64) bool {
+	diff := size - diskSize
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff > 1<<20 && diff*10 > max(size, diskSize)
+}
+
+// ReadDirWithInfo reads a directory and returns FileInfo for each entry.
+// Entries on virtual filesystems are not stat'ed, /proc alone has thousands
+// whose sizes mean nothing.
 func ReadDirWithInfo(dirPath string) ([]models.FileInfo, error) {
+	return ReadDirWithInfoContext(context.Background(), dirPath)
+}
+
+// ReadDirWithInfoContext is ReadDirWithInfo giving up with ctx's error once
+// ctx is done, checked between batches of stat calls
+func ReadDirWithInfoContext(ctx context.Context, dirPath string) ([]models.FileInfo, error) {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		return nil, err
 	}
 
+	virtual := IsVirtualFS(dirPath)
 	files := make([]models.FileInfo, 0, len(entries))
-	for _, entry := range entries {
-		files = append(files, GetFileInfo(entry, dirPath))
+	for i, entry := range entries {
+		if i%256 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if virtual {
+			files = append(files, models.FileInfo{
+				Entry:    entry,
+				Mode:     entry.Type(),
+				IsHidden: strings.HasPrefix(entry.Name(), "."),
+				Virtual:  true,
+			})
+			continue
+		}
+		file := GetFileInfo(entry, dirPath)
+		if entry.Type()&fs.ModeSymlink != 0 {
+			resolveLink(&file, filepath.Join(dirPath, entry.Name()))
+		}
+		files = append(files, file)
 	}
 
 	return files, nil
 }
 
-// SortFiles sorts files based on the specified criteria
-func SortFiles(files []models.FileInfo, sortBy string, reverseSort bool) {
+// SortFiles sorts files based on the specified criteria. hiddenPosition
+// ("mixed", "first" or "last") groups hidden entries within the directory
+// and file groups, independent of reverseSort. With natural, names sort
+// with the numbers in them compared by value, see NaturalLess.
+func SortFiles(files []models.FileInfo, sortBy string, reverseSort bool, hiddenPosition string, natural bool) {
 	sort.Slice(files, func(i, j int) bool {
 		// Directories first
-		if files[i].Entry.IsDir() != files[j].Entry.IsDir() {
-			return files[i].Entry.IsDir()
+		if files[i].IsDir() != files[j].IsDir() {
+			return files[i].IsDir()
 		}
 
-		var result bool
+		// Hidden entries grouped before or after the rest
+		if files[i].IsHidden != files[j].IsHidden {
+			switch hiddenPosition {
+			case "first":
+				return files[i].IsHidden
+			case "last":
+				return files[j].IsHidden
+			}
+		}
+
+		// Reversing swaps the operands, so entries that compare equal stay
+		// equal. Sizes and times that tie fall back to the names.
+		a, b := files[i], files[j]
+		if reverseSort {
+			a, b = b, a
+		}
 		switch sortBy {
 		case "size":
-			result = files[i].Size < files[j].Size
+			if a.SortSize != b.SortSize {
+				return a.SortSize < b.SortSize
+			}
 		case "modified":
-			result = files[i].ModTime.Before(files[j].ModTime)
-		default: // name
-			result = strings.ToLower(files[i].Entry.Name()) < strings.ToLower(files[j].Entry.Name())
+			if !a.SortModTime.Equal(b.SortModTime) {
+				return a.SortModTime.Before(b.SortModTime)
+			}
 		}
-
-		if reverseSort {
-			return !result
+		if natural {
+			return NaturalLess(a.Entry.Name(), b.Entry.Name())
 		}
-		return result
+		return strings.ToLower(a.Entry.Name()) < strings.ToLower(b.Entry.Name())
 	})
 }
 
+// FoldName maps a name to the form names are compared in when searching:
+// NFC-normalized, so composed and decomposed accents match, and case-folded
+// beyond ASCII, so "STRASSE" finds "straße"
+func FoldName(name string) string {
+	ascii := true
+	for i := 0; i < len(name); i++ {
+		if name[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return strings.ToLower(name)
+	}
+	return cases.Fold().String(norm.NFC.String(name))
+}
+
+// foldedRune is a rune of a folded name with the runes of the original it
+// came from, [start, end), a composed character and its marks together
+type foldedRune struct {
+	r          rune
+	start, end int
+}
+
+// foldRunes folds name one character at a time as FoldName does, keeping
+// where in name each folded rune came from. A character folding to several
+// runes, such as "ß" to "ss", gives each the same origin.
+func foldRunes(name []rune) []foldedRune {
+	folded := make([]foldedRune, 0, len(name))
+	for start := 0; start < len(name); {
+		end := start + 1
+		for end < len(name) && unicode.Is(unicode.Mn, name[end]) {
+			end++
+		}
+		if r := name[start]; end == start+1 && r < utf8.RuneSelf {
+			folded = append(folded, foldedRune{r: unicode.ToLower(r), start: start, end: end})
+			start = end
+			continue
+		}
+		for _, r := range FoldName(string(name[start:end])) {
+			folded = append(folded, foldedRune{r: r, start: start, end: end})
+		}
+		start = end
+	}
+	return folded
+}
+
+// MatchPositions returns the rune indexes of name holding the first match
+// of query as FilterFiles finds it, for highlighting, nil without one
+func MatchPositions(name, query string) []int {
+	folded, want := foldRunes([]rune(name)), []rune(FoldName(query))
+	if len(want) == 0 {
+		return nil
+	}
+	for start := 0; start+len(want) <= len(folded); start++ {
+		matched := true
+		for i, r := range want {
+			if folded[start+i].r != r {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			var positions []int
+			for i := folded[start].start; i < folded[start+len(want)-1].end; i++ {
+				positions = append(positions, i)
+			}
+			return positions
+		}
+	}
+	return nil
+}
+
 // FilterFiles filters files based on hidden status and search query
 func FilterFiles(files []models.FileInfo, showHidden bool, searchQuery string) []models.FileInfo {
 	if showHidden && searchQuery == "" {
 		return files
 	}
 
+	query := FoldName(searchQuery)
 	filtered := make([]models.FileInfo, 0, len(files))
 	for _, file := range files {
 		// Filter hidden files
@@ -119,7 +268,7 @@ func FilterFiles(files []models.FileInfo, showHidden bool, searchQuery string) [
 
 		// Filter by search query
 		if searchQuery != "" {
-			if !strings.Contains(strings.ToLower(file.Entry.Name()), strings.ToLower(searchQuery)) {
+			if !strings.Contains(FoldName(file.Entry.Name()), query) {
 				continue
 			}
 		}
@@ -194,3 +343,196 @@ func IsTextFileByExtension(fileName string) bool {
 
 	return false
 }
+
+// IsSameOrAncestor reports whether ancestor is path itself or one of its
+// parent directories, as written or once the symlinked directories leading
+// to either are resolved. The final elements are kept, a symlink is not
+// inside the directory it points to.
+func IsSameOrAncestor(ancestor, path string) bool {
+	return isSameOrAncestor(ancestor, path) || isSameOrAncestor(resolveParent(ancestor), resolveParent(path))
+}
+
+// resolveParent resolves the symlinks of path's directory, keeping the
+// final element. Parts that do not exist yet are kept as written.
+func resolveParent(path string) string {
+	path = filepath.Clean(path)
+	dir, base := filepath.Split(path)
+	if base == "" {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(filepath.Clean(dir)); err == nil {
+		return filepath.Join(resolved, base)
+	}
+	if parent := filepath.Clean(dir); parent != path && parent != "." {
+		return filepath.Join(resolveParent(parent), base)
+	}
+	return path
+}
+
+// isSameOrAncestor compares the paths as written
+func isSameOrAncestor(ancestor, path string) bool {
+	ancestor = filepath.Clean(ancestor)
+	path = filepath.Clean(path)
+	if ancestor == path {
+		return true
+	}
+	rel, err := filepath.Rel(ancestor, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// NearestExistingDir walks up from dir and returns the first directory that still exists
+func NearestExistingDir(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// FindRenamed looks for the directory info described, once at dir, among
+// the entries of dir's parent, for following a directory another program
+// renamed. ok is false when it is not there, moved further or removed.
+func FindRenamed(dir string, info fs.FileInfo) (string, bool) {
+	if info == nil {
+		return "", false
+	}
+	parent := filepath.Dir(dir)
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(parent, entry.Name())
+		if other, err := os.Stat(path); err == nil && os.SameFile(info, other) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// ReadHeadWithin is ReadHead giving up after timeout, for files such as
+// /proc/kmsg whose reads block until there is something new
+func ReadHeadWithin(path string, limit int64, timeout time.Duration) ([]byte, bool, error) {
+	type result struct {
+		content   []byte
+		truncated bool
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		content, truncated, err := ReadHead(path, limit)
+		done <- result{content, truncated, err}
+	}()
+	select {
+	case r := <-done:
+		return r.content, r.truncated, r.err
+	case <-time.After(timeout):
+		return nil, false, fmt.Errorf("reading %s timed out after %s", filepath.Base(path), timeout)
+	}
+}
+
+// ReadHead reads at most limit bytes from the start of a file, reporting
+// whether the file continues beyond what was read
+func ReadHead(path string, limit int64) ([]byte, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(content)) > limit {
+		return content[:limit], true, nil
+	}
+	return content, false, nil
+}
+
+// IsSpecialFile reports whether mode describes a device, socket or named pipe,
+// files whose reads may block or never end
+func IsSpecialFile(mode fs.FileMode) bool {
+	return mode&(fs.ModeDevice|fs.ModeCharDevice|fs.ModeSocket|fs.ModeNamedPipe) != 0
+}
+
+// DescribeSpecialFile returns a short description such as
+// "character device (1, 3)" for a special file
+func DescribeSpecialFile(info fs.FileInfo) string {
+	mode := info.Mode()
+	switch {
+	case mode&fs.ModeSocket != 0:
+		return "unix socket"
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe (FIFO)"
+	case mode&fs.ModeDevice != 0:
+		kind := "block device"
+		if mode&fs.ModeCharDevice != 0 {
+			kind = "character device"
+		}
+		if major, minor, ok := deviceNumbers(info); ok {
+			return fmt.Sprintf("%s (%d, %d)", kind, major, minor)
+		}
+		return kind
+	default:
+		return "special file"
+	}
+}
+
+// FormatPermissions renders mode the way ls -l does, e.g. "-rwsr-xr-x",
+// with s/S, s/S and t/T replacing the execute bits for setuid, setgid and
+// sticky (lowercase when the underlying execute bit is also set)
+func FormatPermissions(mode fs.FileMode) string {
+	buf := []byte("----------")
+
+	switch {
+	case mode&fs.ModeDir != 0:
+		buf[0] = 'd'
+	case mode&fs.ModeSymlink != 0:
+		buf[0] = 'l'
+	case mode&fs.ModeCharDevice != 0:
+		buf[0] = 'c'
+	case mode&fs.ModeDevice != 0:
+		buf[0] = 'b'
+	case mode&fs.ModeSocket != 0:
+		buf[0] = 's'
+	case mode&fs.ModeNamedPipe != 0:
+		buf[0] = 'p'
+	}
+
+	const rwx = "rwx"
+	perm := mode.Perm()
+	for i := 0; i < 9; i++ {
+		if perm&(1<<uint(8-i)) != 0 {
+			buf[i+1] = rwx[i%3]
+		}
+	}
+
+	special := func(pos int, set bool, ch byte) {
+		if !set {
+			return
+		}
+		if buf[pos] == 'x' {
+			buf[pos] = ch
+		} else {
+			buf[pos] = ch - ('a' - 'A')
+		}
+	}
+	special(3, mode&fs.ModeSetuid != 0, 's')
+	special(6, mode&fs.ModeSetgid != 0, 's')
+	special(9, mode&fs.ModeSticky != 0, 't')
+
+	return string(buf)
+}
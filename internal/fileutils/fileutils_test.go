@@ -0,0 +1,249 @@
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// names lists the names of files in order
+func names(files []models.FileInfo) []string {
+	list := make([]string, len(files))
+	for i, file := range files {
+		list[i] = file.Entry.Name()
+	}
+	return list
+}
+
+// sortFixture lists a directory of visible and hidden directories and files,
+// b the largest file and .b the largest hidden one, all equally old but c
+func sortFixture(t *testing.T) []models.FileInfo {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, 1, "d/", ".d/", "a", ".a", "c", ".c")
+	writeFiles(t, dir, 100, "b", ".b")
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"a", ".a", "b", ".b", ".c"} {
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return listDir(t, dir)
+}
+
+func TestSortFilesHiddenPosition(t *testing.T) {
+	tests := []struct {
+		sortBy   string
+		reverse  bool
+		position string
+		want     []string
+	}{
+		{"name", false, "mixed", []string{".d", "d", ".a", ".b", ".c", "a", "b", "c"}},
+		{"name", true, "mixed", []string{"d", ".d", "c", "b", "a", ".c", ".b", ".a"}},
+		{"name", false, "first", []string{".d", "d", ".a", ".b", ".c", "a", "b", "c"}},
+		{"name", true, "first", []string{".d", "d", ".c", ".b", ".a", "c", "b", "a"}},
+		{"name", false, "last", []string{"d", ".d", "a", "b", "c", ".a", ".b", ".c"}},
+		{"name", true, "last", []string{"d", ".d", "c", "b", "a", ".c", ".b", ".a"}},
+
+		// Ties of size and time are in name order, reversed with the rest
+		{"size", false, "last", []string{"d", ".d", "a", "c", "b", ".a", ".c", ".b"}},
+		{"size", true, "last", []string{"d", ".d", "b", "c", "a", ".b", ".c", ".a"}},
+		{"size", true, "mixed", []string{"d", ".d", "b", ".b", "c", "a", ".c", ".a"}},
+		{"size", true, "first", []string{".d", "d", ".b", ".c", ".a", "b", "c", "a"}},
+		{"modified", false, "last", []string{"d", ".d", "a", "b", "c", ".a", ".b", ".c"}},
+		{"modified", true, "last", []string{"d", ".d", "c", "b", "a", ".c", ".b", ".a"}},
+	}
+	for _, tt := range tests {
+		files := sortFixture(t)
+		SortFiles(files, tt.sortBy, tt.reverse, tt.position, false)
+		if got := names(files); !slices.Equal(got, tt.want) {
+			t.Errorf("%s reverse %v hidden %s: %v, want %v", tt.sortBy, tt.reverse, tt.position, got, tt.want)
+		}
+	}
+}
+
+// Both spellings of é: composed, and e with a combining acute accent
+const (
+	eComposed   = "\u00e9"
+	eDecomposed = "e\u0301"
+)
+
+func TestFoldName(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"caf" + eComposed, "caf" + eDecomposed},
+		{"CAF" + eDecomposed, "caf" + eComposed},
+		{"straße", "STRASSE"},
+		{"Straße", "strasse"},
+		{"ПРИВЕТ", "привет"},
+		{"日本語", "日本語"},
+		{"README.md", "readme.MD"},
+	}
+	for _, tt := range tests {
+		if FoldName(tt.a) != FoldName(tt.b) {
+			t.Errorf("FoldName(%q) = %q, FoldName(%q) = %q, want them equal", tt.a, FoldName(tt.a), tt.b, FoldName(tt.b))
+		}
+	}
+	if FoldName("資料") == FoldName("資料2") {
+		t.Error("distinct CJK names fold the same")
+	}
+}
+
+// unicodeFixture lists a directory of names in several scripts and forms
+func unicodeFixture(t *testing.T) []models.FileInfo {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, 1, "caf"+eComposed+".txt", "r"+eDecomposed+"sum"+eDecomposed+".pdf", "Straße.md", "日本語のメモ.txt", "資料.csv", "Привет.txt", "plain.txt")
+	return listDir(t, dir)
+}
+
+func TestFilterFilesUnicode(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Either form of é finds both
+		{eComposed, []string{"caf" + eComposed + ".txt", "r" + eDecomposed + "sum" + eDecomposed + ".pdf"}},
+		{eDecomposed, []string{"caf" + eComposed + ".txt", "r" + eDecomposed + "sum" + eDecomposed + ".pdf"}},
+		{"CAF" + eDecomposed, []string{"caf" + eComposed + ".txt"}},
+		{"r" + eComposed + "sum" + eComposed, []string{"r" + eDecomposed + "sum" + eDecomposed + ".pdf"}},
+		{"strasse", []string{"Straße.md"}},
+		{"STRASSE", []string{"Straße.md"}},
+		{"ß", []string{"Straße.md"}},
+		{"日本", []string{"日本語のメモ.txt"}},
+		{"メモ", []string{"日本語のメモ.txt"}},
+		{"資料", []string{"資料.csv"}},
+		{"привет", []string{"Привет.txt"}},
+		{"日本資料", nil},
+	}
+	for _, tt := range tests {
+		got := names(FilterFiles(unicodeFixture(t), false, tt.query))
+		slices.Sort(got)
+		slices.Sort(tt.want)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("FilterFiles(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyMatchUnicode(t *testing.T) {
+	tests := []struct {
+		name, query string
+		positions   []int
+	}{
+		{"caf" + eDecomposed + ".txt", "cf" + eComposed, []int{0, 2, 3, 4}},
+		{"caf" + eComposed + ".txt", "CF" + eDecomposed, []int{0, 2, 3}},
+		{"Straße.md", "strasse", []int{0, 1, 2, 3, 4, 5}},
+		{"Straße.md", "sse", []int{0, 4, 5}},
+		{"日本語のメモ.txt", "日メ", []int{0, 4}},
+		{"Привет.txt", "пв", []int{0, 3}},
+	}
+	for _, tt := range tests {
+		_, positions, ok := FuzzyMatch(tt.name, tt.query)
+		if !ok || !slices.Equal(positions, tt.positions) {
+			t.Errorf("FuzzyMatch(%q, %q) = %v, %v, want %v", tt.name, tt.query, positions, ok, tt.positions)
+		}
+	}
+	for _, miss := range [][2]string{{"Straße.md", "ssss"}, {"日本語", "語日"}, {"cafe.txt", "caf" + eComposed}} {
+		if _, _, ok := FuzzyMatch(miss[0], miss[1]); ok {
+			t.Errorf("FuzzyMatch(%q, %q) matched", miss[0], miss[1])
+		}
+	}
+}
+
+func TestMatchPositions(t *testing.T) {
+	tests := []struct {
+		name, query string
+		want        []int
+	}{
+		{"notes.txt", "TES", []int{2, 3, 4}},
+		{"caf" + eDecomposed + ".txt", eComposed + ".", []int{3, 4, 5}},
+		{"caf" + eComposed + ".txt", "F" + eDecomposed, []int{2, 3}},
+		{"Straße.md", "SSE", []int{4, 5}},
+		{"日本語のメモ.txt", "メモ", []int{4, 5}},
+		{"notes.txt", "xyz", nil},
+		{"notes.txt", "", nil},
+	}
+	for _, tt := range tests {
+		if got := MatchPositions(tt.name, tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("MatchPositions(%q, %q) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFormatPermissions(t *testing.T) {
+	tests := []struct {
+		mode fs.FileMode
+		want string
+	}{
+		{0o755, "-rwxr-xr-x"},
+		{0o644, "-rw-r--r--"},
+		{0, "----------"},
+		{fs.ModeDir | 0o755, "drwxr-xr-x"},
+		{fs.ModeSymlink | 0o777, "lrwxrwxrwx"},
+		{fs.ModeDevice | fs.ModeCharDevice | 0o620, "crw--w----"},
+		{fs.ModeDevice | 0o660, "brw-rw----"},
+		{fs.ModeSocket | 0o755, "srwxr-xr-x"},
+		{fs.ModeNamedPipe | 0o644, "prw-r--r--"},
+
+		// Each special bit over its execute bit, lowercase when that is set
+		{fs.ModeSetuid | 0o755, "-rwsr-xr-x"},
+		{fs.ModeSetuid | 0o644, "-rwSr--r--"},
+		{fs.ModeSetgid | 0o755, "-rwxr-sr-x"},
+		{fs.ModeSetgid | 0o745, "-rwxr-Sr-x"},
+		{fs.ModeDir | fs.ModeSticky | 0o777, "drwxrwxrwt"},
+		{fs.ModeDir | fs.ModeSticky | 0o776, "drwxrwxrwT"},
+
+		// Combinations
+		{fs.ModeSetuid | fs.ModeSetgid | 0o755, "-rwsr-sr-x"},
+		{fs.ModeSetuid | fs.ModeSetgid | 0o644, "-rwSr-Sr--"},
+		{fs.ModeSetuid | fs.ModeSticky | 0o751, "-rwsr-x--t"},
+		{fs.ModeDir | fs.ModeSetgid | fs.ModeSticky | 0o770, "drwxrws--T"},
+		{fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky | 0o777, "-rwsrwsrwt"},
+		{fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky, "---S--S--T"},
+	}
+	for _, tt := range tests {
+		if got := FormatPermissions(tt.mode); got != tt.want {
+			t.Errorf("FormatPermissions(%v) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestFindRenamed(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, 1, "old/", "decoy/", "file")
+	old := filepath.Join(root, "old")
+	info, err := os.Stat(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Found where it is, then under its new name
+	if dir, ok := FindRenamed(old, info); dir != old || !ok {
+		t.Errorf("FindRenamed in place = %q, %v", dir, ok)
+	}
+	if err := os.Rename(old, filepath.Join(root, "new")); err != nil {
+		t.Fatal(err)
+	}
+	if dir, ok := FindRenamed(old, info); dir != filepath.Join(root, "new") || !ok {
+		t.Errorf("FindRenamed = %q, %v", dir, ok)
+	}
+
+	// Not once moved into another directory or removed
+	if err := os.Rename(filepath.Join(root, "new"), filepath.Join(root, "decoy", "new")); err != nil {
+		t.Fatal(err)
+	}
+	if dir, ok := FindRenamed(old, info); ok {
+		t.Errorf("FindRenamed after a move = %q", dir)
+	}
+	if dir, ok := FindRenamed(filepath.Join(root, "gone", "old"), info); ok {
+		t.Errorf("FindRenamed below a missing parent = %q", dir)
+	}
+	if dir, ok := FindRenamed(old, nil); ok {
+		t.Errorf("FindRenamed without info = %q", dir)
+	}
+}
@@ -0,0 +1,106 @@
+package fileutils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// Fuzzy match scores: each matched character earns fuzzyMatchScore, more
+// when it follows the previous match or starts a word, and characters
+// skipped before the first match cost a little so prefixes rank first
+const (
+	fuzzyMatchScore       = 1
+	fuzzyConsecutiveBonus = 5
+	fuzzyBoundaryBonus    = 8
+	fuzzyLeadingPenalty   = 1
+)
+
+// FuzzyMatch reports whether the characters of query appear in name in
+// order, ignoring case, so "fbar" matches "foo_bar.go". Characters compare
+// folded as FoldName folds them, composed or not. The score ranks better
+// matches higher: runs of consecutive characters and characters starting
+// words, after a separator or at a lower-to-upper case change. positions
+// holds the rune indexes in name of each matched character.
+func FuzzyMatch(name, query string) (score int, positions []int, ok bool) {
+	if query == "" {
+		return 0, nil, true
+	}
+	nameRunes, queryRunes := []rune(name), []rune(FoldName(query))
+	positions = make([]int, 0, len(queryRunes))
+	q, last := 0, -1
+	for k, folded := range foldRunes(nameRunes) {
+		if q == len(queryRunes) {
+			break
+		}
+		if folded.r != queryRunes[q] {
+			continue
+		}
+		score += fuzzyMatchScore
+		if last >= 0 && last == k-1 {
+			score += fuzzyConsecutiveBonus
+		}
+		// The rest of a character folding to several runes adds no position
+		if n := len(positions); n == 0 || positions[n-1] < folded.start {
+			if wordStart(nameRunes, folded.start) {
+				score += fuzzyBoundaryBonus
+			}
+			if n == 0 {
+				score -= fuzzyLeadingPenalty * folded.start
+			}
+			for i := folded.start; i < folded.end; i++ {
+				positions = append(positions, i)
+			}
+		}
+		last = k
+		q++
+	}
+	if q < len(queryRunes) {
+		return 0, nil, false
+	}
+	return score, positions, true
+}
+
+// wordStart reports whether the rune at i starts a word of name
+func wordStart(name []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, r := name[i-1], name[i]
+	if strings.ContainsRune("_-. ", prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(r)
+}
+
+// FilterFilesFuzzy is FilterFiles matching the query with FuzzyMatch
+// rather than as a substring
+func FilterFilesFuzzy(files []models.FileInfo, showHidden bool, searchQuery string) []models.FileInfo {
+	if searchQuery == "" {
+		return FilterFiles(files, showHidden, "")
+	}
+	filtered := make([]models.FileInfo, 0, len(files))
+	for _, file := range files {
+		if !showHidden && file.IsHidden {
+			continue
+		}
+		if _, _, ok := FuzzyMatch(file.Entry.Name(), searchQuery); ok {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
+// SortByScore orders files by how well their names fuzzy match query, the
+// best first, keeping the order of those scoring the same
+func SortByScore(files []models.FileInfo, query string) {
+	scores := make(map[string]int, len(files))
+	for _, file := range files {
+		scores[file.Entry.Name()], _, _ = FuzzyMatch(file.Entry.Name(), query)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return scores[files[i].Entry.Name()] > scores[files[j].Entry.Name()]
+	})
+}
@@ -0,0 +1,76 @@
+package fileutils
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// MatchGlob reports whether name matches pattern, a filepath.Match pattern
+// that may also list alternatives in braces such as "*.{jpg,png}". Case is
+// ignored, as in the search filter.
+func MatchGlob(pattern, name string) (bool, error) {
+	name = strings.ToLower(name)
+	for _, alternative := range expandBraces(strings.ToLower(pattern)) {
+		matched, err := filepath.Match(alternative, name)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// expandBraces returns the patterns "a{b,c}d" stands for, "abd" and "acd".
+// Braces nest, a brace without its partner is kept literally.
+func expandBraces(pattern string) []string {
+	open := strings.IndexByte(pattern, '{')
+	if open < 0 {
+		return []string{pattern}
+	}
+	depth, start := 0, open+1
+	var alternatives []string
+	for i := open; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				alternatives = append(alternatives, pattern[start:i])
+				start = i + 1
+			}
+		case '}':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			alternatives = append(alternatives, pattern[start:i])
+			var expanded []string
+			for _, rest := range expandBraces(pattern[i+1:]) {
+				for _, alternative := range alternatives {
+					for _, middle := range expandBraces(alternative) {
+						expanded = append(expanded, pattern[:open]+middle+rest)
+					}
+				}
+			}
+			return expanded
+		}
+	}
+	// Unbalanced, the brace is an ordinary character
+	return []string{pattern}
+}
+
+// FilterGlob keeps the files whose names match pattern, see MatchGlob.
+// Directories are kept so the filtered listing can still be navigated.
+func FilterGlob(files []models.FileInfo, pattern string) ([]models.FileInfo, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return files, err
+	}
+	filtered := make([]models.FileInfo, 0, len(files))
+	for _, file := range files {
+		if matched, _ := MatchGlob(pattern, file.Entry.Name()); matched || file.IsDir() {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,142 @@
+package fileutils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// grepLineLimit caps the length of a line GrepTree searches, longer lines
+// are searched up to it
+const grepLineLimit = 64 * 1024
+
+// SearchOptions narrow the entries a tree search visits
+type SearchOptions struct {
+	Hidden bool     // Visit hidden entries
+	Ignore []string // Names skipped wherever they are, such as ".git"
+}
+
+// walkSearch walks the tree at root for a search, without following
+// symlinks. Unreadable parts and the entries opts leave out are passed
+// over, and the walk ends with ctx's error once it is done.
+func walkSearch(ctx context.Context, root string, opts SearchOptions, fn func(path string, d fs.DirEntry) error) error {
+	ignore := make(map[string]bool, len(opts.Ignore))
+	for _, name := range opts.Ignore {
+		ignore[name] = true
+	}
+	return WalkTree(root, false, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		if name := d.Name(); ignore[name] || !opts.Hidden && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// FindTree calls found with each entry below root whose name contains
+// query, compared as the search filter does, in walk order. The walk stops
+// early once ctx is done, or found returns false. It returns the number of
+// entries visited.
+func FindTree(ctx context.Context, root, query string, opts SearchOptions, found func(models.GrepMatch) bool) (int, error) {
+	folded := FoldName(query)
+	visited := 0
+	err := walkSearch(ctx, root, opts, func(path string, d fs.DirEntry) error {
+		visited++
+		if strings.Contains(FoldName(d.Name()), folded) && !found(models.GrepMatch{Path: path}) {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return visited, err
+}
+
+// GrepTree searches the files below root for lines containing query, calling
+// found with each match in walk order. A query without capitals ignores
+// case. Binary files, per IsLikelyTextFile, files over maxSize and the
+// entries opts leave out are skipped. The walk stops early once ctx is
+// done, or found returns false. It returns the number of files searched.
+func GrepTree(ctx context.Context, root, query string, maxSize int64, opts SearchOptions, found func(models.GrepMatch) bool) (int, error) {
+	foldCase := strings.ToLower(query) == query
+	needle := []byte(query)
+	if foldCase {
+		needle = bytes.ToLower(needle)
+	}
+	searched := 0
+	err := walkSearch(ctx, root, opts, func(path string, d fs.DirEntry) error {
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || maxSize > 0 && info.Size() > maxSize {
+			return nil
+		}
+		searched++
+		if !grepFile(path, needle, foldCase, found) {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return searched, err
+}
+
+// grepFile calls found with each line of path containing needle, reporting
+// whether to go on with the walk
+func grepFile(path string, needle []byte, foldCase bool, found func(models.GrepMatch) bool) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return true
+	}
+	if !IsLikelyTextFile(head[:n]) {
+		return true
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return true
+	}
+
+	reader := bufio.NewReaderSize(file, grepLineLimit)
+	for number := 1; ; number++ {
+		line, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return true
+		}
+		text := line
+		if foldCase {
+			text = bytes.ToLower(line)
+		}
+		if bytes.Contains(text, needle) {
+			if !found(models.GrepMatch{Path: path, Line: number, Text: string(bytes.TrimSpace(line))}) {
+				return false
+			}
+		}
+		// The rest of an overlong line is skipped
+		for isPrefix && err == nil {
+			_, isPrefix, err = reader.ReadLine()
+		}
+	}
+}
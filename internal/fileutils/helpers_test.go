@@ -0,0 +1,41 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// writeFiles creates the files below dir, each holding size bytes, a name
+// ending in "/" as a directory
+func writeFiles(t *testing.T, dir string, size int, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listDir reads dir as the listing does
+func listDir(t *testing.T, dir string) []models.FileInfo {
+	t.Helper()
+	files, err := ReadDirWithInfo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
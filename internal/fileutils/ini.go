@@ -0,0 +1,76 @@
+package fileutils
+
+import "strings"
+
+// INISection is a named section of an INI-style file, keeping keys in file
+// order since formats like systemd units allow repeated keys
+type INISection struct {
+	Name string
+	Keys []INIKey
+}
+
+// INIKey is a single key=value line
+type INIKey struct {
+	Key   string
+	Value string
+}
+
+// Get returns the first value of key in the section
+func (s INISection) Get(key string) (string, bool) {
+	for _, k := range s.Keys {
+		if k.Key == key {
+			return k.Value, true
+		}
+	}
+	return "", false
+}
+
+// ParseINI parses the desktop-entry/systemd flavor of INI: "[Section]"
+// headers, "key=value" lines, "#" and ";" comments, and trailing backslash
+// line continuations. Lines before the first header go in an unnamed section.
+func ParseINI(content string) []INISection {
+	sections := []INISection{{}}
+	current := &sections[0]
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(strings.TrimSuffix(lines[i], "\r"))
+
+		// Join continuation lines
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSpace(strings.TrimSuffix(line, "\\")) + " " + strings.TrimSpace(lines[i])
+		}
+		// A continuation on the last line has nothing to join
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			sections = append(sections, INISection{Name: strings.TrimSpace(line[1 : len(line)-1])})
+			current = &sections[len(sections)-1]
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			current.Keys = append(current.Keys, INIKey{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+		}
+	}
+
+	if len(sections[0].Keys) == 0 {
+		sections = sections[1:]
+	}
+	return sections
+}
+
+// FindINISection returns the first section with the given name
+func FindINISection(sections []INISection, name string) (INISection, bool) {
+	for _, s := range sections {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return INISection{}, false
+}
@@ -0,0 +1,70 @@
+package fileutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseINI(t *testing.T) {
+	content := "; preamble comment\n" +
+		"top=level\n" +
+		"[Unit]\r\n" +
+		"Description = A service \r\n" +
+		"# comment=ignored\n" +
+		"\n" +
+		"not a key\n" +
+		"[ Service ]\n" +
+		"ExecStart=/usr/bin/app \\\n" +
+		"    --flag \\\n" +
+		"    --other\n" +
+		"Environment=A=1 B=2\n" +
+		"Environment=C=3\n" +
+		"Empty=\n" +
+		"[Desktop Entry]\n" +
+		"Name=App\n" +
+		"Name[de]=Anwendung\n" +
+		"Exec=app %U ; not a comment\n"
+	want := []INISection{
+		{Keys: []INIKey{{"top", "level"}}},
+		{Name: "Unit", Keys: []INIKey{{"Description", "A service"}}},
+		{Name: "Service", Keys: []INIKey{
+			{"ExecStart", "/usr/bin/app --flag --other"},
+			{"Environment", "A=1 B=2"},
+			{"Environment", "C=3"},
+			{"Empty", ""},
+		}},
+		{Name: "Desktop Entry", Keys: []INIKey{{"Name", "App"}, {"Name[de]", "Anwendung"}, {"Exec", "app %U ; not a comment"}}},
+	}
+	if got := ParseINI(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseINI =\n%q\nwant\n%q", got, want)
+	}
+
+	// Without keys before the first header there is no unnamed section
+	if got := ParseINI("# only\n[A]\nk=v"); len(got) != 1 || got[0].Name != "A" {
+		t.Errorf("ParseINI without a preamble = %q", got)
+	}
+	if got := ParseINI(""); len(got) != 0 {
+		t.Errorf("ParseINI(\"\") = %q", got)
+	}
+	// A continuation on the last line ends there
+	if got := ParseINI("[A]\nk=v \\"); !reflect.DeepEqual(got, []INISection{{Name: "A", Keys: []INIKey{{"k", "v"}}}}) {
+		t.Errorf("ParseINI with a final continuation = %q", got)
+	}
+}
+
+func TestFindINISection(t *testing.T) {
+	sections := ParseINI("[Unit]\nDescription=first\n[Install]\nWantedBy=multi-user.target\n[Unit]\nDescription=second\n")
+	unit, ok := FindINISection(sections, "Unit")
+	if !ok {
+		t.Fatal("Unit not found")
+	}
+	if value, _ := unit.Get("Description"); value != "first" {
+		t.Errorf("Description = %q, want the first section's", value)
+	}
+	if _, ok := unit.Get("WantedBy"); ok {
+		t.Error("Get found a key of another section")
+	}
+	if _, ok := FindINISection(sections, "unit"); ok {
+		t.Error("section names matched ignoring case")
+	}
+}
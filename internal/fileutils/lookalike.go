@@ -0,0 +1,109 @@
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// entryFS is what the collision checks ask of the filesystem, since only it
+// knows whether two names lead to the same entry
+type entryFS interface {
+	Lstat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	SameFile(a, b fs.FileInfo) bool
+}
+
+// osEntryFS is the host's filesystem
+type osEntryFS struct{}
+
+func (osEntryFS) Lstat(name string) (fs.FileInfo, error)     { return os.Lstat(name) }
+func (osEntryFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osEntryFS) SameFile(a, b fs.FileInfo) bool             { return os.SameFile(a, b) }
+
+// entries is the filesystem the collision checks consult, tests stand in a
+// case-insensitive one
+var entries entryFS = osEntryFS{}
+
+// Lookalikes returns the names of files equal to another's after FoldName.
+// Such entries look the same in a listing, and on case-insensitive or
+// normalizing filesystems, as macOS uses, only one of them is reachable by
+// either name.
+func Lookalikes(files []models.FileInfo) map[string]bool {
+	first := make(map[string]string, len(files))
+	var lookalikes map[string]bool
+	for _, file := range files {
+		name := file.Entry.Name()
+		folded := FoldName(name)
+		other, seen := first[folded]
+		if !seen {
+			first[folded] = name
+			continue
+		}
+		if lookalikes == nil {
+			lookalikes = make(map[string]bool)
+		}
+		lookalikes[other] = true
+		lookalikes[name] = true
+	}
+	return lookalikes
+}
+
+// SameEntry reports whether a and b lead to the same directory entry, as
+// "Notes.txt" and "notes.txt" do on a case-insensitive filesystem. The
+// filesystem decides by resolving both; hard links to one file under names
+// that differ beyond case and normalization are distinct entries.
+func SameEntry(a, b string) bool {
+	if FoldName(filepath.Clean(a)) != FoldName(filepath.Clean(b)) {
+		return false
+	}
+	infoA, err := entries.Lstat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := entries.Lstat(b)
+	return err == nil && entries.SameFile(infoA, infoB)
+}
+
+// Collides reports whether renaming or copying src to dst would meet another
+// entry at dst. A case-only rename on a case-insensitive filesystem finds
+// src itself there, which is no collision.
+func Collides(src, dst string) bool {
+	_, err := entries.Lstat(dst)
+	return err == nil && !SameEntry(src, dst)
+}
+
+// ExistingName returns the name of the entry path resolves to, as its
+// directory lists it. That differs from the base of path when the filesystem
+// ignores case or normalization. ok is false when nothing exists at path.
+func ExistingName(path string) (name string, ok bool) {
+	info, err := entries.Lstat(path)
+	if err != nil {
+		return "", false
+	}
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	listing, err := entries.ReadDir(dir)
+	if err != nil {
+		return base, true
+	}
+	folded := FoldName(base)
+	for _, entry := range listing {
+		if entry.Name() == base {
+			return base, true
+		}
+	}
+	for _, entry := range listing {
+		if FoldName(entry.Name()) != folded {
+			continue
+		}
+		if other, err := entries.Lstat(filepath.Join(dir, entry.Name())); err == nil && entries.SameFile(info, other) {
+			return entry.Name(), true
+		}
+	}
+	return base, true
+}
@@ -0,0 +1,160 @@
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// foldingFS stands in for a case-insensitive, normalizing filesystem such
+// as macOS uses: a name finds the stored entry it equals after FoldName
+type foldingFS map[string]map[string]string // Directory, stored name, inode
+
+// foldedInfo is an entry of a foldingFS under its stored name
+type foldedInfo struct{ name, inode string }
+
+func (i foldedInfo) Name() string       { return i.name }
+func (i foldedInfo) Size() int64        { return 0 }
+func (i foldedInfo) Mode() fs.FileMode  { return 0o644 }
+func (i foldedInfo) ModTime() time.Time { return time.Time{} }
+func (i foldedInfo) IsDir() bool        { return false }
+func (i foldedInfo) Sys() any           { return nil }
+
+func (f foldingFS) Lstat(name string) (fs.FileInfo, error) {
+	dir, base := filepath.Split(name)
+	for stored, inode := range f[filepath.Clean(dir)] {
+		if FoldName(stored) == FoldName(base) {
+			return foldedInfo{stored, inode}, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "lstat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (f foldingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var listing []fs.DirEntry
+	for stored, inode := range f[filepath.Clean(name)] {
+		listing = append(listing, fs.FileInfoToDirEntry(foldedInfo{stored, inode}))
+	}
+	return listing, nil
+}
+
+func (f foldingFS) SameFile(a, b fs.FileInfo) bool {
+	return a.(foldedInfo).inode == b.(foldedInfo).inode
+}
+
+// useEntries makes the collision checks consult fsys for the test
+func useEntries(t *testing.T, fsys entryFS) {
+	t.Helper()
+	previous := entries
+	entries = fsys
+	t.Cleanup(func() { entries = previous })
+}
+
+func TestLookalikes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Notes.txt":                 {},
+		"notes.txt":                 {},
+		"caf" + eComposed + ".md":   {},
+		"caf" + eDecomposed + ".md": {},
+		"STRASSE":                   {},
+		"straße":                    {},
+		"other":                     {},
+		"others":                    {},
+	}
+	files, err := ReadFSDirWithInfo(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Lookalikes(files)
+	for name := range fsys {
+		if want := name != "other" && name != "others"; got[name] != want {
+			t.Errorf("%q marked %v, want %v", name, got[name], want)
+		}
+	}
+	if got := Lookalikes(files[:1]); got != nil {
+		t.Errorf("a single entry has lookalikes %v", got)
+	}
+}
+
+func TestCollisionsOnFoldingFS(t *testing.T) {
+	useEntries(t, foldingFS{"/d": {
+		"Notes.txt":         "1",
+		"caf" + eDecomposed: "2",
+		"a.txt":             "3",
+		"hardlink":          "3",
+	}})
+	tests := []struct {
+		src, dst string
+		same     bool
+		collides bool
+	}{
+		// A case-only rename finds the entry itself
+		{"/d/Notes.txt", "/d/notes.txt", true, false},
+		{"/d/Notes.txt", "/d/NOTES.TXT", true, false},
+		{"/d/caf" + eDecomposed, "/d/CAF" + eComposed, true, false},
+		// Another entry answers to the new name in any case or form
+		{"/d/a.txt", "/d/NOTES.txt", false, true},
+		{"/d/a.txt", "/d/caf" + eComposed, false, true},
+		{"/d/a.txt", "/d/new.txt", false, false},
+		// Hard links to one file are distinct entries
+		{"/d/a.txt", "/d/hardlink", false, true},
+		// A missing source is never the entry at the target
+		{"/d/missing", "/d/Missing", false, false},
+	}
+	for _, tt := range tests {
+		if got := SameEntry(tt.src, tt.dst); got != tt.same {
+			t.Errorf("SameEntry(%q, %q) = %v, want %v", tt.src, tt.dst, got, tt.same)
+		}
+		if got := Collides(tt.src, tt.dst); got != tt.collides {
+			t.Errorf("Collides(%q, %q) = %v, want %v", tt.src, tt.dst, got, tt.collides)
+		}
+	}
+
+	// The name the directory lists, not the one asked for
+	for path, want := range map[string]string{
+		"/d/notes.TXT":         "Notes.txt",
+		"/d/Notes.txt":         "Notes.txt",
+		"/d/caf" + eComposed:   "caf" + eDecomposed,
+		"/d/A.TXT":             "a.txt",
+		"/d/hardlink":          "hardlink",
+		"/d/missing":           "",
+		"/elsewhere/Notes.txt": "",
+	} {
+		name, ok := ExistingName(path)
+		if name != want || ok != (want != "") {
+			t.Errorf("ExistingName(%q) = %q, %v, want %q", path, name, ok, want)
+		}
+	}
+}
+
+func TestCollisionsOnHostFS(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, 1, "Notes.txt")
+	if _, err := os.Lstat(filepath.Join(dir, "notes.txt")); err == nil {
+		t.Skip("the filesystem ignores case")
+	}
+	notes, lower := filepath.Join(dir, "Notes.txt"), filepath.Join(dir, "notes.txt")
+
+	// On a case-sensitive filesystem a case-only rename is free
+	if SameEntry(notes, lower) || Collides(notes, lower) {
+		t.Error("Notes.txt and a missing notes.txt collide")
+	}
+	if name, ok := ExistingName(notes); name != "Notes.txt" || !ok {
+		t.Errorf("ExistingName = %q, %v", name, ok)
+	}
+	if _, ok := ExistingName(lower); ok {
+		t.Error("ExistingName found a missing entry")
+	}
+
+	// and a lookalike is an entry of its own
+	writeFiles(t, dir, 1, "notes.txt")
+	if SameEntry(notes, lower) || !Collides(notes, lower) {
+		t.Error("Notes.txt and notes.txt do not collide")
+	}
+	if !SameEntry(notes, filepath.Join(dir, ".", "Notes.txt")) {
+		t.Error("an entry is not the same as itself")
+	}
+}
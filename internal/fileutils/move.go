@@ -0,0 +1,27 @@
+package fileutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// MovePath moves src to dst with a rename, or across filesystems by copying
+// and then deleting src. Moving a directory into itself is refused.
+func MovePath(src, dst string, opts CopyOptions) error {
+	if IsSameOrAncestor(src, dst) {
+		return fmt.Errorf("cannot move %s into itself", src)
+	}
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := CopyPath(src, dst, opts); err != nil {
+		// Leave src alone and drop the partial copy
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
@@ -0,0 +1,68 @@
+package fileutils
+
+import (
+	"cmp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NaturalLess orders names the way people count, ignoring case: runs of
+// digits compare by their value, so "file2" sorts before "file10" and
+// "v1.9" before "v1.10". Equal values with more leading zeros come later,
+// and names equal but for case fall back to byte order.
+func NaturalLess(a, b string) bool {
+	if c := naturalCompare(a, b); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+// naturalCompare compares a and b as NaturalLess does without the final
+// tie-break: -1, 0 or 1, with 0 for names alike but for case
+func naturalCompare(a, b string) int {
+	// Leading zeros only decide between otherwise equal names
+	zeros := 0
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			runA, restA := digitRun(a)
+			runB, restB := digitRun(b)
+			valueA, valueB := strings.TrimLeft(runA, "0"), strings.TrimLeft(runB, "0")
+			switch {
+			case len(valueA) != len(valueB):
+				return cmp.Compare(len(valueA), len(valueB))
+			case valueA != valueB:
+				return strings.Compare(valueA, valueB)
+			}
+			if zeros == 0 {
+				zeros = cmp.Compare(len(runA), len(runB))
+			}
+			a, b = restA, restB
+			continue
+		}
+		ra, sizeA := utf8.DecodeRuneInString(a)
+		rb, sizeB := utf8.DecodeRuneInString(b)
+		if la, lb := unicode.ToLower(ra), unicode.ToLower(rb); la != lb {
+			return cmp.Compare(la, lb)
+		}
+		a, b = a[sizeA:], b[sizeB:]
+	}
+	if a != "" || b != "" {
+		return cmp.Compare(len(a), len(b))
+	}
+	return zeros
+}
+
+// isDigit matches the ASCII digits, the ones compared by value
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// digitRun splits the leading digits off s
+func digitRun(s string) (run, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
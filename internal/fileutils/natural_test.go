@@ -0,0 +1,76 @@
+package fileutils
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	// Each list is in order, every name before all the later ones
+	orders := [][]string{
+		{"file1.txt", "file2.txt", "file10.txt", "file20.txt", "file100.txt"},
+		{"v1.2", "v1.9", "v1.10", "v1.10.1", "v2"},
+		// Leading zeros compare by value, more of them later on a tie
+		{"img1", "img01", "img001", "img2", "img010", "img11"},
+		{"0", "00", "1", "9", "10"},
+		// Case is ignored but for byte order on a tie
+		{"Apple", "apple", "Banana", "banana2", "BANANA10", "cherry"},
+		{"README", "Readme", "readme"},
+		// Longer numbers than int64 holds
+		{"x99999999999999999999", "x100000000000000000000"},
+		// Unicode letters compare folded, and non-ASCII digits as letters
+		{"Äpfel1", "äpfel2", "äpfel10"},
+		{"Ωmega", "ωmega3", "ωmega12"},
+		{"文件2", "文件10", "文件٣"},
+		{"a", "a0", "a1", "ab"},
+	}
+	for _, order := range orders {
+		for i := range order {
+			if NaturalLess(order[i], order[i]) {
+				t.Errorf("NaturalLess(%q, %q) = true", order[i], order[i])
+			}
+			for j := i + 1; j < len(order); j++ {
+				if !NaturalLess(order[i], order[j]) || NaturalLess(order[j], order[i]) {
+					t.Errorf("%q and %q are out of order", order[i], order[j])
+				}
+			}
+		}
+		shuffled := slices.Clone(order)
+		rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		slices.SortFunc(shuffled, func(a, b string) int {
+			if NaturalLess(a, b) {
+				return -1
+			}
+			if NaturalLess(b, a) {
+				return 1
+			}
+			return 0
+		})
+		if !slices.Equal(shuffled, order) {
+			t.Errorf("sorted %q, want %q", shuffled, order)
+		}
+	}
+}
+
+func TestSortFilesNatural(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, 1, "file10", "file2", "File1", "file01")
+	tests := []struct {
+		natural bool
+		want    []string
+	}{
+		{true, []string{"File1", "file01", "file2", "file10"}},
+		// sort_natural = false, names compared a character at a time
+		{false, []string{"file01", "File1", "file10", "file2"}},
+	}
+	for _, tt := range tests {
+		files := listDir(t, dir)
+		SortFiles(files, "name", false, "mixed", tt.natural)
+		if got := names(files); !slices.Equal(got, tt.want) {
+			t.Errorf("natural %v: %q, want %q", tt.natural, got, tt.want)
+		}
+	}
+}
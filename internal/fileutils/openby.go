@@ -0,0 +1,16 @@
+package fileutils
+
+// OpenHandle is a process holding a file open
+type OpenHandle struct {
+	PID     int
+	Command string
+	FD      string // Descriptor number, or "cwd" for a working directory
+	Mode    string // "r", "w" or "rw", empty for a working directory
+}
+
+// OpenByResult lists the processes found holding a file open
+type OpenByResult struct {
+	Handles    []OpenHandle
+	Unreadable int  // Processes whose descriptors could not be read
+	TimedOut   bool // The scan stopped at its deadline, other holders may exist
+}
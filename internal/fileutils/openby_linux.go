@@ -0,0 +1,106 @@
+//go:build linux
+
+package fileutils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenBy scans /proc for the processes that have path open, or have it as
+// their working directory. Processes that cannot be inspected are counted
+// rather than failing the scan, which gives up once timeout has passed.
+func OpenBy(path string, timeout time.Duration) (OpenByResult, error) {
+	var result OpenByResult
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return result, err
+	}
+	if target, err = filepath.Abs(target); err != nil {
+		return result, err
+	}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return result, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil {
+			continue
+		}
+		if time.Now().After(deadline) {
+			result.TimedOut = true
+			break
+		}
+		procDir := filepath.Join("/proc", proc.Name())
+		command := readComm(procDir)
+
+		if cwd, err := os.Readlink(filepath.Join(procDir, "cwd")); err == nil && cwd == target {
+			result.Handles = append(result.Handles, OpenHandle{PID: pid, Command: command, FD: "cwd"})
+		}
+		fds, err := os.ReadDir(filepath.Join(procDir, "fd"))
+		if err != nil {
+			// Exited meanwhile or owned by another user
+			if !os.IsNotExist(err) {
+				result.Unreadable++
+			}
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(procDir, "fd", fd.Name())); err == nil && link == target {
+				result.Handles = append(result.Handles, OpenHandle{
+					PID:     pid,
+					Command: command,
+					FD:      fd.Name(),
+					Mode:    fdMode(filepath.Join(procDir, "fdinfo", fd.Name())),
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// readComm returns the command name of a process, "?" if unreadable
+func readComm(procDir string) string {
+	data, err := os.ReadFile(filepath.Join(procDir, "comm"))
+	if err != nil {
+		return "?"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// fdMode reads the access mode of a descriptor from the flags in its fdinfo
+func fdMode(fdinfoPath string) string {
+	file, err := os.Open(fdinfoPath)
+	if err != nil {
+		return "?"
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "flags:")
+		if !ok {
+			continue
+		}
+		flags, err := strconv.ParseUint(strings.TrimSpace(value), 8, 64)
+		if err != nil {
+			break
+		}
+		switch flags & uint64(os.O_RDONLY|os.O_WRONLY|os.O_RDWR) {
+		case uint64(os.O_WRONLY):
+			return "w"
+		case uint64(os.O_RDWR):
+			return "rw"
+		default:
+			return "r"
+		}
+	}
+	return "?"
+}
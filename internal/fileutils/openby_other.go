@@ -0,0 +1,14 @@
+//go:build !linux
+
+package fileutils
+
+import (
+	"errors"
+	"time"
+)
+
+// OpenBy needs /proc, listing the processes holding a file open is only
+// supported on Linux
+func OpenBy(path string, timeout time.Duration) (OpenByResult, error) {
+	return OpenByResult{}, errors.New("finding processes with a file open is only supported on Linux")
+}
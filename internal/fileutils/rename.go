@@ -0,0 +1,47 @@
+package fileutils
+
+import "strings"
+
+// RenameLike applies the edit that turned oldName into newName to name, for
+// repeating a rename on another entry. The edit is the text between the
+// parts both names share at their start and end: replaced text is replaced
+// in name where it occurs, inserted text goes at the same end of name, or
+// before its extension when it was inserted before the old one. It reports
+// false when the edit does not apply to name or leaves it unchanged.
+func RenameLike(oldName, newName, name string) (string, bool) {
+	oldRunes, newRunes := []rune(oldName), []rune(newName)
+	prefix := 0
+	for prefix < len(oldRunes) && prefix < len(newRunes) && oldRunes[prefix] == newRunes[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldRunes)-prefix && suffix < len(newRunes)-prefix &&
+		oldRunes[len(oldRunes)-1-suffix] == newRunes[len(newRunes)-1-suffix] {
+		suffix++
+	}
+	from := string(oldRunes[prefix : len(oldRunes)-suffix])
+	to := string(newRunes[prefix : len(newRunes)-suffix])
+
+	var renamed string
+	switch {
+	case from != "":
+		if !strings.Contains(name, from) {
+			return name, false
+		}
+		renamed = strings.Replace(name, from, to, 1)
+	case prefix == 0:
+		renamed = to + name
+	case suffix == 0:
+		renamed = name + to
+	default:
+		// Inserted in the middle, where only an extension gives a place
+		// that means the same in another name
+		_, oldExt := SplitExt(oldName)
+		if oldExt == "" || string(oldRunes[len(oldRunes)-suffix:]) != oldExt {
+			return name, false
+		}
+		stem, ext := SplitExt(name)
+		renamed = stem + to + ext
+	}
+	return renamed, renamed != name
+}
@@ -0,0 +1,35 @@
+package fileutils
+
+import "testing"
+
+func TestRenameLike(t *testing.T) {
+	tests := []struct {
+		oldName, newName, name string
+		want                   string
+		ok                     bool
+	}{
+		// Inserted before the extension
+		{"report.pdf", "report-final.pdf", "summary.txt", "summary-final.txt", true},
+		{"report.pdf", "report-final.pdf", "archive.tar.gz", "archive-final.tar.gz", true},
+		{"report.pdf", "report-final.pdf", "Makefile", "Makefile-final", true},
+		// Inserted at either end
+		{"a.txt", "old-a.txt", "b.log", "old-b.log", true},
+		{"a.txt", "a.txt.bak", "b.log", "b.log.bak", true},
+		// Replaced where it occurs
+		{"IMG_001.jpg", "holiday_001.jpg", "IMG_002.jpg", "holiday_002.jpg", true},
+		{"draft-v1.md", "draft-v2.md", "notes-v1.md", "notes-v2.md", true},
+		{"draft-v1.md", "draft.md", "notes-v1.md", "notes.md", true},
+		{"IMG_001.jpg", "holiday_001.jpg", "DSC_002.jpg", "DSC_002.jpg", false},
+		{"café.txt", "cafe.txt", "né.txt", "ne.txt", true},
+		// Inserted in the middle, away from the extension
+		{"abcd", "abXcd", "wxyz", "wxyz", false},
+		// Replaced text the name does not have
+		{"a-1", "b-1", "b-2", "b-2", false},
+	}
+	for _, tt := range tests {
+		got, ok := RenameLike(tt.oldName, tt.newName, tt.name)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RenameLike(%q, %q, %q) = %q, %v, want %q, %v", tt.oldName, tt.newName, tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
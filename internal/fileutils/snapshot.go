@@ -0,0 +1,70 @@
+package fileutils
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// DirSnapshot records the entries of a directory and their modification
+// times, to notice what an external command changed
+type DirSnapshot map[string]time.Time
+
+// SnapshotDir lists dirPath into a DirSnapshot
+func SnapshotDir(dirPath string) (DirSnapshot, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(DirSnapshot, len(entries))
+	for _, entry := range entries {
+		var modTime time.Time
+		if info, err := entry.Info(); err == nil {
+			modTime = info.ModTime()
+		}
+		snapshot[entry.Name()] = modTime
+	}
+	return snapshot, nil
+}
+
+// DirChanges counts the differences between two snapshots of a directory
+type DirChanges struct {
+	Added, Modified, Removed int
+}
+
+// DiffSnapshots compares a directory before and after a change
+func DiffSnapshots(before, after DirSnapshot) DirChanges {
+	var changes DirChanges
+	for name, modTime := range after {
+		previous, ok := before[name]
+		switch {
+		case !ok:
+			changes.Added++
+		case !previous.Equal(modTime):
+			changes.Modified++
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changes.Removed++
+		}
+	}
+	return changes
+}
+
+// String formats the changes like "+2 files, 1 modified, 1 removed", or ""
+// when nothing changed
+func (c DirChanges) String() string {
+	var parts []string
+	if c.Added > 0 {
+		parts = append(parts, "+"+plural(c.Added, "file", "files"))
+	}
+	if c.Modified > 0 {
+		parts = append(parts, plural(c.Modified, "modified", "modified"))
+	}
+	if c.Removed > 0 {
+		parts = append(parts, plural(c.Removed, "removed", "removed"))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,78 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Second)
+	tests := []struct {
+		name          string
+		before, after DirSnapshot
+		want          DirChanges
+		summary       string
+	}{
+		{"unchanged", DirSnapshot{"a": t0, "b": t1}, DirSnapshot{"a": t0, "b": t1}, DirChanges{}, ""},
+		{"both empty", DirSnapshot{}, nil, DirChanges{}, ""},
+		{"added", DirSnapshot{"a": t0}, DirSnapshot{"a": t0, "b": t0, "c": t1}, DirChanges{Added: 2}, "+2 files"},
+		{"added one", nil, DirSnapshot{"a": t0}, DirChanges{Added: 1}, "+1 file"},
+		{"modified", DirSnapshot{"a": t0, "b": t0}, DirSnapshot{"a": t1, "b": t0}, DirChanges{Modified: 1}, "1 modified"},
+		{"removed", DirSnapshot{"a": t0, "b": t0}, DirSnapshot{}, DirChanges{Removed: 2}, "2 removed"},
+		{
+			"all at once",
+			DirSnapshot{"keep": t0, "edit": t0, "gone": t0},
+			DirSnapshot{"keep": t0, "edit": t1, "new1": t1, "new2": t1},
+			DirChanges{Added: 2, Modified: 1, Removed: 1},
+			"+2 files, 1 modified, 1 removed",
+		},
+		// A rename is a removal and an addition
+		{"renamed", DirSnapshot{"old": t0}, DirSnapshot{"new": t0}, DirChanges{Added: 1, Removed: 1}, "+1 file, 1 removed"},
+		// The same instant in another location is no change
+		{"same instant", DirSnapshot{"a": t0}, DirSnapshot{"a": t0.In(time.FixedZone("X", 3600))}, DirChanges{}, ""},
+		// An entry whose time could not be read is compared as the zero time
+		{"unreadable time", DirSnapshot{"a": {}}, DirSnapshot{"a": t0}, DirChanges{Modified: 1}, "1 modified"},
+	}
+	for _, tt := range tests {
+		got := DiffSnapshots(tt.before, tt.after)
+		if got != tt.want || got.String() != tt.summary {
+			t.Errorf("%s: %+v %q, want %+v %q", tt.name, got, got.String(), tt.want, tt.summary)
+		}
+	}
+}
+
+func TestSnapshotDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, 1, "a.txt", "b.txt", "sub/")
+	before, err := SnapshotDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before) != 3 {
+		t.Fatalf("snapshot %v, want 3 entries", before)
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "a.txt"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, 1, "c.txt", "sub/inner.txt")
+	after, err := SnapshotDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the listing itself counts, the new file in sub touched sub
+	if got := DiffSnapshots(before, after).String(); got != "+1 file, 2 modified, 1 removed" {
+		t.Errorf("changes %q", got)
+	}
+
+	if _, err := SnapshotDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("snapshot of a missing directory succeeded")
+	}
+}
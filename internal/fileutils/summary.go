@@ -0,0 +1,83 @@
+package fileutils
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// SelectionSummary describes what an operation on a set of entries affects
+type SelectionSummary struct {
+	Dirs        int
+	Files       int
+	Bytes       int64
+	Approximate bool // A directory walk was cut short by the entry limit
+}
+
+// SummarizeSelection counts the given entries of dirPath and totals their
+// size, taking file sizes from the listing and estimating directory sizes
+// with a walk of at most walkLimit entries. Symlinked directories are not
+// followed.
+func SummarizeSelection(dirPath string, files []models.FileInfo, walkLimit int) SelectionSummary {
+	var summary SelectionSummary
+	remaining := walkLimit
+
+	for _, file := range files {
+		if !file.Entry.IsDir() {
+			summary.Files++
+			summary.Bytes += file.Size
+			continue
+		}
+
+		summary.Dirs++
+		root := filepath.Join(dirPath, file.Entry.Name())
+		WalkTree(root, false, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || path == root {
+				return nil
+			}
+			if remaining <= 0 {
+				summary.Approximate = true
+				return filepath.SkipAll
+			}
+			remaining--
+			if d.Type().IsRegular() {
+				if info, err := d.Info(); err == nil {
+					summary.Bytes += info.Size()
+				}
+			}
+			return nil
+		})
+	}
+
+	return summary
+}
+
+// String formats the summary as e.g. "14 items (3 dirs, 11 files, 268.0 MB)"
+func (s SelectionSummary) String() string {
+	var parts []string
+	if s.Dirs > 0 {
+		parts = append(parts, plural(s.Dirs, "dir", "dirs"))
+	}
+	if s.Files > 0 {
+		parts = append(parts, plural(s.Files, "file", "files"))
+	}
+
+	size := FormatSize(s.Bytes)
+	if s.Approximate {
+		size = "~" + size
+	}
+	parts = append(parts, size)
+
+	return fmt.Sprintf("%s (%s)", plural(s.Dirs+s.Files, "item", "items"), strings.Join(parts, ", "))
+}
+
+// plural formats a count with the matching singular or plural noun
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
@@ -0,0 +1,49 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummarizeSelection(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, 1000, "a", "b", "src/x", "src/y", "src/deep/z", "empty/")
+	if err := os.Symlink(filepath.Join(dir, "src"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := SummarizeSelection(dir, listDir(t, dir), 100)
+	want := SelectionSummary{Dirs: 2, Files: 3, Bytes: 5000 + int64(len(filepath.Join(dir, "src")))}
+	if summary != want {
+		t.Errorf("SummarizeSelection = %+v, want %+v", summary, want)
+	}
+}
+
+func TestSummarizeSelectionLimit(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, 10, "big/1", "big/2", "big/3", "big/4", "big/5")
+
+	summary := SummarizeSelection(dir, listDir(t, dir), 2)
+	if !summary.Approximate || summary.Dirs != 1 || summary.Bytes != 20 {
+		t.Errorf("SummarizeSelection with a limit of 2 = %+v, want 1 dir, 20 bytes, approximate", summary)
+	}
+}
+
+func TestSelectionSummaryString(t *testing.T) {
+	tests := []struct {
+		summary SelectionSummary
+		want    string
+	}{
+		{SelectionSummary{Files: 1, Bytes: 12}, "1 item (1 file, 12 B)"},
+		{SelectionSummary{Dirs: 1, Bytes: 4096}, "1 item (1 dir, 4.0 KB)"},
+		{SelectionSummary{Dirs: 3, Files: 11, Bytes: 268 << 20}, "14 items (3 dirs, 11 files, 268.0 MB)"},
+		{SelectionSummary{Dirs: 2, Files: 1, Bytes: 3 << 30, Approximate: true}, "3 items (2 dirs, 1 file, ~3.0 GB)"},
+		{SelectionSummary{}, "0 items (0 B)"},
+	}
+	for _, tt := range tests {
+		if got := tt.summary.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.summary, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package fileutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// resolveLink fills in the target of the symlink at path, and whether the
+// target is missing or a directory
+func resolveLink(file *models.FileInfo, path string) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return
+	}
+	file.LinkTarget = target
+	info, err := os.Stat(path)
+	file.LinkBroken = err != nil
+	file.LinkDir = err == nil && info.IsDir()
+}
+
+// MaxLinkDepth caps the symlinks a directory path may pass through before
+// following another link enters its resolved path instead
+const MaxLinkDepth = 8
+
+// FollowDirLink returns the directory to enter for the symlink to a
+// directory at linkPath: the link's own path, or its resolved path when the
+// link points back at a directory holding it or the path already passes
+// MaxLinkDepth links, so following links can never nest forever
+func FollowDirLink(linkPath string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(linkPath)
+	if err != nil {
+		return "", err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(linkPath))
+	if err != nil {
+		return "", err
+	}
+	if IsSameOrAncestor(resolved, parent) || linkDepth(linkPath) > MaxLinkDepth {
+		return resolved, nil
+	}
+	return linkPath, nil
+}
+
+// linkDepth counts the symlinks among path and the directories leading to it
+func linkDepth(path string) int {
+	depth := 0
+	for {
+		if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			depth++
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return depth
+		}
+		path = parent
+	}
+}
+
+// FollowSymlinks sets the sort size and modification time of the symlinks
+// in files to those of their targets. Targets are stat'ed once per link path
+// and remembered in cache, a broken link is cached as nil and sorts as an
+// empty file with a zero time. With onDisk the target's allocated size is
+// used. It returns how many targets came from cache and how many were
+// stat'ed.
+func FollowSymlinks(dirPath string, files []models.FileInfo, cache map[string]fs.FileInfo, onDisk bool) (hits, misses int) {
+	for i := range files {
+		if files[i].Mode&fs.ModeSymlink == 0 || files[i].Virtual {
+			continue
+		}
+		linkPath := filepath.Join(dirPath, files[i].Entry.Name())
+		target, ok := cache[linkPath]
+		if ok {
+			hits++
+		} else {
+			target, _ = os.Stat(linkPath)
+			cache[linkPath] = target
+			misses++
+		}
+		if target == nil {
+			files[i].SortSize = 0
+			files[i].SortModTime = time.Time{}
+			continue
+		}
+		files[i].SortSize = target.Size()
+		if onDisk {
+			files[i].SortSize = DiskSize(target)
+		}
+		files[i].SortModTime = target.ModTime()
+	}
+	return hits, misses
+}
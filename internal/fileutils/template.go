@@ -0,0 +1,32 @@
+package fileutils
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// templatePlaceholder matches a {{key}} placeholder, spaces inside the
+// braces allowed
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_]+)\s*\}\}`)
+
+// ExpandTemplate replaces the {{key}} placeholders of a text template with
+// their value in vars. An unknown key is an error rather than being left in
+// place, so a typo never ends up in the created file.
+func ExpandTemplate(content []byte, vars map[string]string) ([]byte, error) {
+	var unknown string
+	expanded := templatePlaceholder.ReplaceAllFunc(content, func(match []byte) []byte {
+		key := string(templatePlaceholder.FindSubmatch(match)[1])
+		value, ok := vars[key]
+		if !ok {
+			if unknown == "" {
+				unknown = key
+			}
+			return match
+		}
+		return []byte(value)
+	})
+	if unknown != "" {
+		return nil, fmt.Errorf("unknown placeholder {{%s}}", unknown)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,322 @@
+package fileutils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// Trashing follows the FreeDesktop.org trash specification: an entry moves
+// into the files directory of a trash can, and an info file of the same
+// name in its info directory records where it came from and when
+
+// trashInfoSuffix ends the name of every info file
+const trashInfoSuffix = ".trashinfo"
+
+// trashDateLayout is the local time format of an info file's DeletionDate
+const trashDateLayout = "2006-01-02T15:04:05"
+
+// HomeTrash returns the trash can in the user's home, honoring
+// XDG_DATA_HOME before falling back to ~/.local/share/Trash
+func HomeTrash() (string, error) {
+	dir, err := config.XDGBaseDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "Trash"), nil
+}
+
+// TrashPath moves path into a trash can: the home trash when path is on its
+// filesystem, else the trash of path's mount, .Trash/$uid when the
+// administrator set one up or .Trash-$uid. When neither can be used the
+// entry is copied to the home trash and then deleted.
+func TrashPath(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	can, top, err := trashFor(path)
+	if err != nil {
+		return err
+	}
+	if IsSameOrAncestor(can, path) {
+		return fmt.Errorf("%s is already in the trash", path)
+	}
+	if err := ensureTrash(can); err != nil {
+		return err
+	}
+	// Info files of a mount's trash hold paths relative to the mount
+	original := path
+	if top != "" {
+		if rel, err := filepath.Rel(top, path); err == nil {
+			original = rel
+		}
+	}
+	name, infoPath, err := reserveTrashName(can, filepath.Base(path), original, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := MovePath(path, filepath.Join(can, "files", name), CopyOptions{PreserveTimes: true}); err != nil {
+		os.Remove(infoPath)
+		return err
+	}
+	return nil
+}
+
+// trashFor picks the trash can for path, and the mount directory paths in
+// its info files are relative to, "" when they are absolute
+func trashFor(path string) (can, top string, err error) {
+	home, err := HomeTrash()
+	if err != nil {
+		return "", "", err
+	}
+	// The parent decides, path itself may be a symlink to elsewhere
+	dir := filepath.Dir(path)
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", "", err
+	}
+	homeInfo, err := os.Stat(NearestExistingDir(home))
+	if err != nil || sameDevice(info, homeInfo) {
+		return home, "", nil
+	}
+	top = mountTop(dir, info)
+	if can, ok := mountTrash(top); ok {
+		return can, top, nil
+	}
+	return home, "", nil
+}
+
+// sameDevice reports whether a and b are on one filesystem, assuming they
+// are where the platform cannot tell
+func sameDevice(a, b fs.FileInfo) bool {
+	devA, _, okA := fileID(a)
+	devB, _, okB := fileID(b)
+	return !okA || !okB || devA == devB
+}
+
+// mountTop walks up from dir, whose info is given, to the topmost directory
+// still on its filesystem
+func mountTop(dir string, info fs.FileInfo) string {
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		parentInfo, err := os.Stat(parent)
+		if err != nil || !sameDevice(info, parentInfo) {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// mountTrash returns the user's trash can on the mount at top, preferring
+// a shared .Trash directory, which must be a real sticky directory, over
+// the user's own .Trash-$uid
+func mountTrash(top string) (string, bool) {
+	uid := os.Getuid()
+	if uid < 0 {
+		return "", false
+	}
+	shared := filepath.Join(top, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		can := filepath.Join(shared, strconv.Itoa(uid))
+		if ensureTrash(can) == nil {
+			return can, true
+		}
+	}
+	can := filepath.Join(top, ".Trash-"+strconv.Itoa(uid))
+	if ensureTrash(can) == nil {
+		return can, true
+	}
+	return "", false
+}
+
+// ensureTrash creates the files and info directories of the trash can,
+// refusing a can that is a symlink
+func ensureTrash(can string) error {
+	if info, err := os.Lstat(can); err == nil && !info.IsDir() {
+		return fmt.Errorf("trash %s is not a directory", can)
+	}
+	for _, sub := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(can, sub), 0700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserveTrashName claims a name in can for an entry called base by
+// creating its info file exclusively, numbering the name when it is taken,
+// e.g. "notes.2.txt". It returns the name and the info file's path.
+func reserveTrashName(can, base, original string, deleted time.Time) (string, string, error) {
+	stem, ext := SplitExt(base)
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", (&url.URL{Path: original}).EscapedPath(), deleted.Format(trashDateLayout))
+	name := base
+	for i := 2; ; i++ {
+		infoPath := filepath.Join(can, "info", name+trashInfoSuffix)
+		if _, err := os.Lstat(filepath.Join(can, "files", name)); os.IsNotExist(err) {
+			file, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err == nil {
+				_, err = file.WriteString(content)
+				if closeErr := file.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					os.Remove(infoPath)
+					return "", "", err
+				}
+				return name, infoPath, nil
+			}
+			if !errors.Is(err, fs.ErrExist) {
+				return "", "", err
+			}
+		}
+		name = fmt.Sprintf("%s.%d%s", stem, i, ext)
+	}
+}
+
+// ListTrash returns the entries of the home trash and of the user's trash
+// cans on other mounts. Info files without their entry, or that cannot be
+// parsed, are left out.
+func ListTrash() ([]models.TrashEntry, error) {
+	home, err := HomeTrash()
+	if err != nil {
+		return nil, err
+	}
+	entries := listTrashCan(home, "")
+	uid := strconv.Itoa(os.Getuid())
+	// A filesystem can be mounted more than once at the same point
+	seen := map[string]bool{home: true}
+	for _, top := range mountPoints() {
+		for _, can := range []string{filepath.Join(top, ".Trash", uid), filepath.Join(top, ".Trash-"+uid)} {
+			if !seen[can] {
+				seen[can] = true
+				entries = append(entries, listTrashCan(can, top)...)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// listTrashCan reads the info files of one trash can, resolving relative
+// paths against top
+func listTrashCan(can, top string) []models.TrashEntry {
+	infos, err := os.ReadDir(filepath.Join(can, "info"))
+	if err != nil {
+		return nil
+	}
+	var entries []models.TrashEntry
+	for _, info := range infos {
+		name, ok := strings.CutSuffix(info.Name(), trashInfoSuffix)
+		if !ok {
+			continue
+		}
+		stat, err := os.Lstat(filepath.Join(can, "files", name))
+		if err != nil {
+			continue
+		}
+		path, deleted, err := readTrashInfo(filepath.Join(can, "info", info.Name()))
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(top, path)
+		}
+		entries = append(entries, models.TrashEntry{Name: name, Can: can, Path: path, Deleted: deleted, Info: stat})
+	}
+	return entries
+}
+
+// readTrashInfo parses the original path and deletion time of an info file,
+// the time is zero when missing
+func readTrashInfo(infoPath string) (string, time.Time, error) {
+	file, err := os.Open(infoPath)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer file.Close()
+
+	var path string
+	var deleted time.Time
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if path, err = url.PathUnescape(value); err != nil {
+				return "", time.Time{}, err
+			}
+		case "DeletionDate":
+			deleted, _ = time.ParseInLocation(trashDateLayout, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", time.Time{}, err
+	}
+	if path == "" {
+		return "", time.Time{}, fmt.Errorf("%s has no Path", infoPath)
+	}
+	return path, deleted, nil
+}
+
+// mountPoints lists the mounted filesystems from /proc/self/mounts, none
+// where it is unavailable
+func mountPoints() []string {
+	content, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return nil
+	}
+	var points []string
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		// Spaces and the like in mount points are octal escapes
+		if point, err := strconv.Unquote(`"` + fields[1] + `"`); err == nil {
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// RestoreTrash moves a trashed entry back to its original path, creating
+// the directories leading to it. An entry now at that path is left alone.
+func RestoreTrash(entry models.TrashEntry) error {
+	if _, err := os.Lstat(entry.Path); err == nil {
+		return fmt.Errorf("%s already exists", entry.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+		return err
+	}
+	if err := MovePath(filepath.Join(entry.Can, "files", entry.Name), entry.Path, CopyOptions{PreserveTimes: true}); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(entry.Can, "info", entry.Name+trashInfoSuffix))
+}
+
+// PurgeTrash deletes a trashed entry for good, along with its info file
+func PurgeTrash(entry models.TrashEntry) error {
+	if err := os.RemoveAll(filepath.Join(entry.Can, "files", entry.Name)); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(entry.Can, "info", entry.Name+trashInfoSuffix))
+}
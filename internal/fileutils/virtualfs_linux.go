@@ -0,0 +1,30 @@
+//go:build linux
+
+package fileutils
+
+import "golang.org/x/sys/unix"
+
+// virtualFSMagic lists the kernel filesystems whose files report size 0 or
+// no meaningful size, and whose directories can hold many entries
+var virtualFSMagic = map[uint32]bool{
+	unix.PROC_SUPER_MAGIC:    true,
+	unix.SYSFS_MAGIC:         true,
+	unix.DEBUGFS_MAGIC:       true,
+	unix.TRACEFS_MAGIC:       true,
+	unix.SECURITYFS_MAGIC:    true,
+	unix.CGROUP_SUPER_MAGIC:  true,
+	unix.CGROUP2_SUPER_MAGIC: true,
+	unix.BPF_FS_MAGIC:        true,
+	unix.EFIVARFS_MAGIC:      true,
+	unix.PSTOREFS_MAGIC:      true,
+}
+
+// IsVirtualFS reports whether dir is on a kernel virtual filesystem such as
+// /proc or /sys, going by the filesystem type statfs reports
+func IsVirtualFS(dir string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return false
+	}
+	return virtualFSMagic[uint32(st.Type)]
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package fileutils
+
+// IsVirtualFS reports no virtual filesystems outside Linux
+func IsVirtualFS(dir string) bool {
+	return false
+}
@@ -0,0 +1,101 @@
+package fileutils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WalkTree walks the tree at root like filepath.WalkDir, which every
+// recursive operation uses instead: entries are lstat'ed and symlinks,
+// including a symlinked root, are handed to fn as leaves, so a link to /
+// inside the tree is never descended into. With follow, symlinks to
+// directories are walked into as well and fn sees them with their target's
+// type, each directory at most once by its device and inode so symlink loops
+// end. fn may return fs.SkipDir and fs.SkipAll as with WalkDir.
+func WalkTree(root string, follow bool, fn fs.WalkDirFunc) error {
+	if !follow {
+		return filepath.WalkDir(root, fn)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		w := treeWalker{fn: fn, seen: make(map[fileKey]bool)}
+		err = w.walk(root, fs.FileInfoToDirEntry(info))
+	}
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// treeWalker follows symlinks for WalkTree, remembering the directories
+// already walked
+type treeWalker struct {
+	fn   fs.WalkDirFunc
+	seen map[fileKey]bool
+}
+
+// fileKey identifies a directory by device and inode, or by its resolved
+// path on platforms without them
+type fileKey struct {
+	dev, ino uint64
+	path     string
+}
+
+// visit records dir as walked, reporting false when it was before
+func (w *treeWalker) visit(path string, dir fs.DirEntry) bool {
+	key := fileKey{path: path}
+	if info, err := dir.Info(); err == nil {
+		if dev, ino, ok := fileID(info); ok {
+			key = fileKey{dev: dev, ino: ino}
+		} else if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			key.path = resolved
+		}
+	}
+	if w.seen[key] {
+		return false
+	}
+	w.seen[key] = true
+	return true
+}
+
+// walk calls fn on path and, for a directory not walked before, everything
+// below it
+func (w *treeWalker) walk(path string, d fs.DirEntry) error {
+	if err := w.fn(path, d, nil); err != nil || !d.IsDir() {
+		if errors.Is(err, fs.SkipDir) && d.IsDir() {
+			return nil
+		}
+		return err
+	}
+	if !w.visit(path, d) {
+		return nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := w.fn(path, d, err); err != nil && !errors.Is(err, fs.SkipDir) {
+			return err
+		}
+		return nil
+	}
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// A dangling link stays a leaf
+			if info, err := os.Stat(child); err == nil {
+				entry = fs.FileInfoToDirEntry(info)
+			}
+		}
+		if err := w.walk(child, entry); err != nil {
+			// From a file, SkipDir skips the rest of its directory
+			if errors.Is(err, fs.SkipDir) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
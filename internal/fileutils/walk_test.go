@@ -0,0 +1,173 @@
+package fileutils
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// loopFixture creates a tree below dir with symlinks looping on each other
+// (a to b to a), back at the tree itself and out of it, returning the tree
+func loopFixture(t *testing.T, dir string) string {
+	t.Helper()
+	writeFiles(t, dir, 10, "tree/file", "tree/sub/inner", "outside/secret")
+	tree := filepath.Join(dir, "tree")
+	links := map[string]string{
+		"tree/a":        "b",
+		"tree/b":        "a",
+		"tree/self":     ".",
+		"tree/sub/back": "..",
+		"tree/out":      filepath.Join(dir, "outside"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tree
+}
+
+// finishes runs fn, failing the test if it has not returned in time
+func finishes(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("%s did not finish", what)
+	}
+}
+
+// walked lists the paths WalkTree visits below tree, relative to it and
+// suffixed "/" as directories or "@" as symlinks
+func walked(t *testing.T, tree string, follow bool) []string {
+	t.Helper()
+	var list []string
+	finishes(t, "WalkTree", func() {
+		WalkTree(tree, follow, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || path == tree {
+				return nil
+			}
+			rel, _ := filepath.Rel(tree, path)
+			switch {
+			case d.Type()&fs.ModeSymlink != 0:
+				rel += "@"
+			case d.IsDir():
+				rel += "/"
+			}
+			list = append(list, filepath.ToSlash(rel))
+			return nil
+		})
+	})
+	slices.Sort(list)
+	return list
+}
+
+func TestWalkTreeLoops(t *testing.T) {
+	tree := loopFixture(t, t.TempDir())
+
+	// Every link is a leaf
+	want := []string{"a@", "b@", "file", "out@", "self@", "sub/", "sub/back@", "sub/inner"}
+	if got := walked(t, tree, false); !slices.Equal(got, want) {
+		t.Errorf("walk = %q, want %q", got, want)
+	}
+
+	// Following, the links to directories are walked once each and the
+	// looping links stay leaves
+	want = []string{"a@", "b@", "file", "out/", "out/secret", "self/", "sub/", "sub/back/", "sub/inner"}
+	if got := walked(t, tree, true); !slices.Equal(got, want) {
+		t.Errorf("walk following links = %q, want %q", got, want)
+	}
+}
+
+func TestLoopedLinksAreBroken(t *testing.T) {
+	tree := loopFixture(t, t.TempDir())
+	for _, file := range listDir(t, tree) {
+		name := file.Entry.Name()
+		wantBroken := name == "a" || name == "b"
+		wantDir := name == "self" || name == "out" || name == "sub"
+		if file.LinkBroken != wantBroken || file.IsDir() != wantDir {
+			t.Errorf("%s: broken %v, directory %v", name, file.LinkBroken, file.IsDir())
+		}
+	}
+	dirs, err := ReadDirWithInfo(filepath.Join(tree, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range dirs {
+		if file.Entry.Name() == "back" && (file.LinkBroken || !file.LinkDir) {
+			t.Errorf("sub/back: broken %v, directory %v", file.LinkBroken, file.LinkDir)
+		}
+	}
+}
+
+func TestRecursiveOperationsStayInTree(t *testing.T) {
+	dir := t.TempDir()
+	tree := loopFixture(t, dir)
+	secret := filepath.Join(dir, "outside", "secret")
+
+	change, err := ParseModeChange("go-r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	finishes(t, "ChmodTree", func() {
+		changed, failures := ChmodTree(tree, change, nil)
+		// The tree, sub and both files, none of the links
+		if changed != 4 || len(failures) > 0 {
+			t.Errorf("ChmodTree changed %d, failures %v", changed, failures)
+		}
+	})
+	if info, err := os.Stat(secret); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("ChmodTree changed the file outside the tree: %v, %v", info.Mode(), err)
+	}
+
+	var found []string
+	finishes(t, "FindTree", func() {
+		FindTree(context.Background(), tree, "e", SearchOptions{}, func(match models.GrepMatch) bool {
+			rel, _ := filepath.Rel(tree, match.Path)
+			found = append(found, filepath.ToSlash(rel))
+			return true
+		})
+	})
+	slices.Sort(found)
+	if want := []string{"file", "self", "sub/inner"}; !slices.Equal(found, want) {
+		t.Errorf("FindTree found %q, want %q", found, want)
+	}
+
+	finishes(t, "GrepTree", func() {
+		// Only reachable through the link out
+		os.WriteFile(secret, []byte("needle\n"), 0o600)
+		FindTree(context.Background(), tree, "secret", SearchOptions{}, func(match models.GrepMatch) bool {
+			t.Errorf("FindTree found %s outside the tree", match.Path)
+			return true
+		})
+		GrepTree(context.Background(), tree, "needle", 1<<20, SearchOptions{}, func(match models.GrepMatch) bool {
+			t.Errorf("GrepTree matched %s outside the tree", match.Path)
+			return true
+		})
+	})
+
+	finishes(t, "SummarizeSelection", func() {
+		var selected []models.FileInfo
+		for _, file := range listDir(t, dir) {
+			if file.Entry.Name() == "tree" {
+				selected = append(selected, file)
+			}
+		}
+		// The two files of 10 bytes in the tree, not the one outside
+		summary := SummarizeSelection(dir, selected, 1000)
+		if summary.Dirs != 1 || summary.Bytes != 20 || summary.Approximate {
+			t.Errorf("summary = %+v, want 1 directory of 20 bytes", summary)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package fileutils
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyXattrs copies every extended attribute readable on src to dst
+func copyXattrs(src, dst string) error {
+	size, err := unix.Listxattr(src, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil
+		}
+		return err
+	}
+	if size == 0 {
+		return nil
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(src, buf)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+
+		valueSize, err := unix.Getxattr(src, attr, nil)
+		if err != nil {
+			return err
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = unix.Getxattr(src, attr, value)
+		if err != nil {
+			return err
+		}
+
+		if err := unix.Setxattr(dst, attr, value[:valueSize], 0); err != nil && !errors.Is(err, unix.ENOTSUP) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package ui
+
+import "time"
+
+// Key repeat acceleration: once up or down has been repeating for
+// accelerationDelay, each repeat moves 2, then 4, then 8 rows
+const (
+	accelerationDelay = time.Second
+	accelerationStage = 500 * time.Millisecond // How long each step size lasts before doubling
+	accelerationPause = 200 * time.Millisecond // A longer gap between presses ends the repeat
+	accelerationMax   = 8
+)
+
+// keyRepeat tracks consecutive presses of one movement key
+type keyRepeat struct {
+	key   string
+	start time.Time // First press of the current run of repeats
+	last  time.Time
+}
+
+// moveDirection returns -1 for actionUp and 1 for actionDown, 0 for the rest
+func moveDirection(action keyAction) int {
+	switch action {
+	case actionUp:
+		return -1
+	case actionDown:
+		return 1
+	}
+	return 0
+}
+
+// acceleratedSteps records a press of key and returns how many rows it
+// moves, 1 unless key_acceleration is on and key has been held long enough.
+// Only up and down accelerate, ctrl+d and G stay the deliberate fast paths.
+func (m *AppModel) acceleratedSteps(key string, action keyAction) int {
+	if !m.config.KeyAcceleration || m.replaying || moveDirection(action) == 0 {
+		return 1
+	}
+	now := time.Now()
+	if key != m.held.key || now.Sub(m.held.last) > accelerationPause {
+		m.held = keyRepeat{key: key, start: now}
+	}
+	m.held.last = now
+
+	held := now.Sub(m.held.start)
+	if held < accelerationDelay {
+		return 1
+	}
+	steps := 2
+	for stage := (held - accelerationDelay) / accelerationStage; stage > 0 && steps < accelerationMax; stage-- {
+		steps *= 2
+	}
+	return steps
+}
@@ -0,0 +1,389 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// commands runs the normal-mode actions other than the movements, which
+// moveCursor handles
+var commands = map[keyAction]func(m *AppModel) tea.Cmd{
+	actionQuit:          (*AppModel).quit,
+	actionEnter:         (*AppModel).enterSelected,
+	actionParent:        (*AppModel).goParent,
+	actionOpen:          (*AppModel).openSelected,
+	actionOpenDefault:   (*AppModel).openSelectedWithDefault,
+	actionActivate:      (*AppModel).activateSelected,
+	actionHome:          (*AppModel).goHome,
+	actionForcePreview:  (*AppModel).forcePreview,
+	actionGrid:          (*AppModel).enterGrid,
+	actionQuickLook:     (*AppModel).quickLook,
+	actionGotoLine:      (*AppModel).promptGotoLine,
+	actionOpenBy:        (*AppModel).scanOpenBy,
+	actionChmod:         run((*AppModel).promptChmod),
+	actionRename:        run((*AppModel).promptRename),
+	actionNewFile:       func(m *AppModel) tea.Cmd { m.promptCreate(false); return nil },
+	actionNewDir:        func(m *AppModel) tea.Cmd { m.promptCreate(true); return nil },
+	actionTrash:         (*AppModel).trashSelected,
+	actionDelete:        run((*AppModel).confirmDelete),
+	actionFavorite:      run((*AppModel).toggleFavorite),
+	actionFavorites:     run((*AppModel).openFavorites),
+	actionDebug:         func(m *AppModel) tea.Cmd { m.DebugScreen = true; return nil },
+	actionGotoPath:      func(m *AppModel) tea.Cmd { m.pathPrompt("Go to: ", m.gotoPath); return nil },
+	actionRepeat:        (*AppModel).repeatLast,
+	actionClear:         (*AppModel).clearFilterAndCut,
+	actionSearch:        (*AppModel).startSearch,
+	actionToggleHidden:  (*AppModel).toggleHidden,
+	actionSortSize:      func(m *AppModel) tea.Cmd { m.sortBy("size"); return nil },
+	actionSortModified:  func(m *AppModel) tea.Cmd { m.sortBy("modified"); return nil },
+	actionSortName:      func(m *AppModel) tea.Cmd { m.sortBy("name"); return nil },
+	actionRefresh:       (*AppModel).refresh,
+	actionRaw:           (*AppModel).toggleRaw,
+	actionRevealSecrets: (*AppModel).toggleSecrets,
+	actionYank:          (*AppModel).yankOrExtract,
+	actionCut:           run((*AppModel).cut),
+	actionPaste:         (*AppModel).paste,
+	actionPrevSibling:   func(m *AppModel) tea.Cmd { m.moveToSibling(-1); return nil },
+	actionNextSibling:   func(m *AppModel) tea.Cmd { m.moveToSibling(1); return nil },
+	actionMark:          run((*AppModel).toggleMark),
+	actionMarkAll:       func(m *AppModel) tea.Cmd { m.markVisible(false); return nil },
+	actionInvertMarks:   func(m *AppModel) tea.Cmd { m.markVisible(true); return nil },
+	actionNewTemplate:   run((*AppModel).promptTemplate),
+	actionVisual:        run((*AppModel).startVisual),
+	actionFilterPreset:  run((*AppModel).choosePreset),
+	actionClearChanges:  run((*AppModel).clearChanges),
+	actionHistoryBack:   func(m *AppModel) tea.Cmd { m.historyJump(-1); return nil },
+	actionHistoryNext:   func(m *AppModel) tea.Cmd { m.historyJump(1); return nil },
+	actionPhysicalPaths: run((*AppModel).togglePhysicalPaths),
+	actionBatch:         run((*AppModel).promptBatch),
+	actionGrep:          run((*AppModel).promptGrep),
+	actionCompare:       run((*AppModel).promptCompare),
+	actionPalette:       run((*AppModel).openPalette),
+	actionFuzzySearch:   run((*AppModel).toggleFuzzy),
+	actionTrashView:     run((*AppModel).openTrash),
+	actionImageColor:    (*AppModel).toggleImageColor,
+	actionMacroRecord:   run((*AppModel).toggleMacroRecording),
+	actionMacroReplay:   run((*AppModel).queueReplay),
+}
+
+// run adapts an action without a command to the commands table
+func run(action func(*AppModel)) func(*AppModel) tea.Cmd {
+	return func(m *AppModel) tea.Cmd {
+		action(m)
+		return nil
+	}
+}
+
+// enterSelected navigates into the selected directory, following a symlink
+// to one, or browses the selected archive
+func (m *AppModel) enterSelected() tea.Cmd {
+	if len(m.Files) == 0 {
+		return nil
+	}
+	selectedFile := m.Files[m.Selected]
+	fullPath := filepath.Join(m.CurrentDir, selectedFile.Entry.Name())
+	if selectedFile.Entry.IsDir() {
+		m.navigateTo(fullPath, "")
+	} else if selectedFile.LinkDir && m.ArchiveFS == nil {
+		m.followDirLink(fullPath)
+	} else if m.ArchiveFS == nil && fileutils.IsArchive(selectedFile.Entry.Name()) {
+		m.enterArchive(fullPath)
+	}
+	return nil
+}
+
+// followDirLink enters the directory the symlink at linkPath points to,
+// through the link unless that would loop
+func (m *AppModel) followDirLink(linkPath string) {
+	dir, err := fileutils.FollowDirLink(linkPath)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error following the link: %v", err)
+		return
+	}
+	m.navigateTo(dir, "")
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		m.StatusMessage = "Followed the link to " + displayName(resolved)
+	}
+}
+
+// goParent navigates to the parent directory with the current one selected
+func (m *AppModel) goParent() tea.Cmd {
+	parent := filepath.Dir(m.CurrentDir)
+	if parent != m.CurrentDir {
+		m.navigateTo(parent, filepath.Base(m.CurrentDir))
+	}
+	return nil
+}
+
+// openSelected opens the selected file with its [openers] rule, else in the
+// editor, asking first when it is larger than open_warn_size
+func (m *AppModel) openSelected() tea.Cmd {
+	if len(m.Files) == 0 {
+		return nil
+	}
+	selectedFile := m.Files[m.Selected]
+	if m.Chooser {
+		return m.chooseSelected()
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only, press y to extract"
+		return nil
+	}
+	if selectedFile.IsDir() {
+		return nil
+	}
+	fullPath := filepath.Join(m.CurrentDir, selectedFile.Entry.Name())
+	if rule, ok := m.config.OpenRuleFor(selectedFile.Entry.Name()); ok {
+		cmd := ruleCommand(rule, m.usePath(fullPath))
+		if rule.Detach {
+			m.StatusMessage = fmt.Sprintf("Opening %s with %s", displayName(selectedFile.Entry.Name()), filepath.Base(cmd.Args[0]))
+			return runDetached(cmd)
+		}
+		return m.runExternal(cmd)
+	}
+	if m.config.OpenWarnBytes > 0 && selectedFile.Size > m.config.OpenWarnBytes {
+		prompt := fmt.Sprintf("Open %s (%s) in editor? (y/n)", displayName(selectedFile.Entry.Name()), fileutils.FormatSize(selectedFile.Size))
+		m.confirm(prompt, func() tea.Cmd {
+			return m.openInEditor(fullPath)
+		})
+		return nil
+	}
+	return m.openInEditor(fullPath)
+}
+
+// openSelectedWithDefault opens the selected entry in the application the
+// desktop associates with it, for files the editor is no good for
+func (m *AppModel) openSelectedWithDefault() tea.Cmd {
+	if len(m.Files) == 0 {
+		return nil
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only, press y to extract"
+		return nil
+	}
+	name := m.Files[m.Selected].Entry.Name()
+	cmd := openerCommand(m.config, m.usePath(filepath.Join(m.CurrentDir, name)))
+	m.StatusMessage = fmt.Sprintf("Opening %s with %s", displayName(name), filepath.Base(cmd.Args[0]))
+	return runDetached(cmd)
+}
+
+// activateSelected browses the selected archive, or opens the selected file
+func (m *AppModel) activateSelected() tea.Cmd {
+	if len(m.Files) > 0 && m.ArchiveFS == nil && fileutils.IsArchive(m.Files[m.Selected].Entry.Name()) {
+		m.enterArchive(filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name()))
+		return nil
+	}
+	return m.openSelected()
+}
+
+// goHome navigates to the home directory
+func (m *AppModel) goHome() tea.Cmd {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot determine home directory: %v", err)
+		return nil
+	}
+	m.navigateTo(homeDir, "")
+	return nil
+}
+
+// forcePreview previews the selected file despite the size limit
+func (m *AppModel) forcePreview() tea.Cmd {
+	if len(m.Files) > 0 && !m.Files[m.Selected].IsDir() {
+		m.ForcePreviewPath = filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name())
+		UpdatePreview(m.Model, m.config)
+	}
+	return nil
+}
+
+// enterGrid shows the current directory as a thumbnail grid, scrolled to
+// the selected entry
+func (m *AppModel) enterGrid() tea.Cmd {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Grid mode is not available inside archives"
+		return nil
+	}
+	m.GridMode = true
+	m.GridOffset = 0
+	if cols, rows := gridLayout(m.Model); m.Selected/cols >= rows {
+		m.GridOffset = m.Selected/cols - rows + 1
+	}
+	return nil
+}
+
+// quickLook maximizes the preview
+func (m *AppModel) quickLook() tea.Cmd {
+	if !m.GridMode {
+		m.toggleQuickLook()
+	}
+	return nil
+}
+
+// promptGotoLine asks for a line of the previewed file to jump to
+func (m *AppModel) promptGotoLine() tea.Cmd {
+	if len(m.Files) > 0 && !m.Files[m.Selected].IsDir() {
+		m.prompt("Go to line: ", m.gotoPreviewLine)
+	}
+	return nil
+}
+
+// repeatLast repeats the last repeatable operation
+func (m *AppModel) repeatLast() tea.Cmd {
+	if m.repeatAction == nil {
+		m.StatusMessage = "Nothing to repeat"
+		return nil
+	}
+	cmd := m.repeatAction()
+	if m.StatusMessage == "" {
+		m.StatusMessage = "Repeated " + m.repeatLabel
+	}
+	return cmd
+}
+
+// updateCancellable names the running operation esc cancels first, for the
+// help bar
+func (m *AppModel) updateCancellable() {
+	switch {
+	case m.batchCancel != nil:
+		m.Cancellable = "batch"
+	case m.transferCancel != nil:
+		m.Cancellable = m.transferVerb
+	default:
+		m.Cancellable = ""
+	}
+}
+
+// clearFilterAndCut cancels a running batch or the running copies and
+// moves, else clears an active search filter and filter preset, else the
+// marks and the cut buffer
+func (m *AppModel) clearFilterAndCut() tea.Cmd {
+	if m.batchCancel != nil {
+		m.batchCancel()
+		m.StatusMessage = "Cancelling the batch, running commands are killed"
+		return nil
+	}
+	if m.transferCancel != nil {
+		m.transferCancel()
+		m.StatusMessage = "Cancelling the " + m.transferVerb + ", partial copies are removed"
+		return nil
+	}
+	// Entries marked under the filter stay marked in the full listing, the
+	// next press clears the marks
+	if m.SearchQuery != "" || m.FilterPreset != "" {
+		m.SearchQuery = ""
+		m.FilterPreset = ""
+		m.loadCurrentDir()
+		return nil
+	}
+	m.clearCut()
+	m.clearMarks()
+	return nil
+}
+
+// startSearch opens the search filter input
+func (m *AppModel) startSearch() tea.Cmd {
+	m.InputMode = models.ModeSearch
+	m.SearchQuery = ""
+	return nil
+}
+
+// toggleHidden shows or hides hidden files
+func (m *AppModel) toggleHidden() tea.Cmd {
+	m.ShowHidden = !m.ShowHidden
+	m.loadCurrentDir()
+	return nil
+}
+
+// sortBy sorts by field, or reverses the order when already sorted by it,
+// keeping the cursor on the entry it was on
+func (m *AppModel) sortBy(field string) {
+	if m.SortBy == field {
+		m.ReverseSort = !m.ReverseSort
+	} else {
+		m.SortBy = field
+		m.ReverseSort = false
+	}
+	m.reloadKeepingCursor()
+}
+
+// refresh reloads the listing and drops cached directory state, keeping
+// the cursor on the same entry if it still exists
+func (m *AppModel) refresh() tea.Cmd {
+	clear(m.GitInfos)
+	clear(m.GitLogs)
+	clear(m.gitStatusTimes)
+	clear(m.ProjectBadges)
+	clear(m.LinkTargets)
+	m.reloadKeepingCursor()
+	return nil
+}
+
+// toggleRaw switches the selected file between its rendered and raw preview
+func (m *AppModel) toggleRaw() tea.Cmd {
+	if len(m.Files) == 0 || m.Files[m.Selected].IsDir() {
+		return nil
+	}
+	fullPath := filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name())
+	if m.RawPreviewPath == fullPath {
+		m.RawPreviewPath = ""
+	} else {
+		m.RawPreviewPath = fullPath
+	}
+	m.PreviewOffset = 0
+	UpdatePreview(m.Model, m.config)
+	return nil
+}
+
+// toggleImageColor switches image previews between color and monochrome and
+// redraws the selected image's
+func (m *AppModel) toggleImageColor() tea.Cmd {
+	if len(m.Files) == 0 || m.Files[m.Selected].IsDir() || !isImageFileByExtension(m.Files[m.Selected].Entry.Name()) {
+		return nil
+	}
+	if m.ImageProtocol != "" {
+		m.StatusMessage = "Images are drawn with " + m.ImageProtocol + " graphics, in their colors"
+		return nil
+	}
+	if !m.config.Color {
+		m.StatusMessage = "Colors are off, image previews stay monochrome"
+		return nil
+	}
+	m.ImagePreviewColored = !m.ImagePreviewColored
+	m.StatusMessage = "Monochrome image previews"
+	if m.ImagePreviewColored {
+		m.StatusMessage = "Colored image previews"
+	}
+	UpdatePreview(m.Model, m.config)
+	return nil
+}
+
+// toggleSecrets reveals or re-masks the secrets in an env file preview
+func (m *AppModel) toggleSecrets() tea.Cmd {
+	if len(m.Files) == 0 || !isEnvFile(m.Files[m.Selected].Entry.Name()) {
+		return nil
+	}
+	fullPath := filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name())
+	if m.RevealSecretsPath == fullPath {
+		m.RevealSecretsPath = ""
+	} else {
+		m.RevealSecretsPath = fullPath
+	}
+	UpdatePreview(m.Model, m.config)
+	return nil
+}
+
+// yankOrExtract yanks the selected entry, inside archives it extracts it
+// next to the archive instead
+func (m *AppModel) yankOrExtract() tea.Cmd {
+	if m.ArchiveFS != nil {
+		m.extractArchiveEntry()
+	} else {
+		m.yank()
+	}
+	return nil
+}
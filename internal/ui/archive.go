@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// archiveEntry maps a path at or below the open archive to its name within
+// the archive's fs.FS, reporting false for paths on the real filesystem
+func archiveEntry(m *models.Model, path string) (string, bool) {
+	if m.ArchiveFS == nil {
+		return "", false
+	}
+	if path == m.ArchivePath {
+		return ".", true
+	}
+	prefix := m.ArchivePath
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	rel, ok := strings.CutPrefix(path, prefix)
+	if !ok {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// readDir lists dir from the open archive or from the real filesystem,
+// where symlinks sort by their targets when configured to
+func readDir(m *models.Model, dir string) ([]models.FileInfo, error) {
+	if name, ok := archiveEntry(m, dir); ok {
+		return fileutils.ReadFSDirWithInfo(m.ArchiveFS, name)
+	}
+	files, counts, err := listDir(context.Background(), dir, m.SortDiskSize, m.SortFollowSymlinks, m.LinkTargets)
+	counts.record(&m.Stats)
+	return files, err
+}
+
+// renderArchiveEntryPreview previews a file inside the open archive from
+// memory: images up to the preview size limit are decoded, anything else
+// gets the text/hex preview of its first bytes.
+func renderArchiveEntryPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, name string) {
+	limit := int64(previewReadLimit)
+	isImage := isImageFileByExtension(selectedFile.Entry.Name())
+	if isImage && (m.PreviewMaxSize <= 0 || selectedFile.Size <= m.PreviewMaxSize) && selectedFile.Size > limit {
+		limit = selectedFile.Size
+	}
+
+	content, truncated, err := fileutils.ReadFSHead(m.ArchiveFS, name, limit)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading archive entry: %v", err))
+		return
+	}
+
+	if isEnvFile(selectedFile.Entry.Name()) {
+		renderEnvContent(m, cfg, selectedFile, filepath.Join(m.CurrentDir, selectedFile.Entry.Name()), content, truncated)
+		return
+	}
+
+	note := ""
+	if isImage && !truncated {
+		img, _, err := image.Decode(bytes.NewReader(content))
+		if err == nil {
+			width, height := previewContentSize(m)
+			setPreview(m, imageToASCII(img, width, height, m.ImagePreviewColored && cfg.Color))
+			return
+		}
+		note = fmt.Sprintf("Image decode failed: %v", err)
+	}
+	renderContentPreview(m, cfg, selectedFile, content, truncated, selectedFile.Mode, note)
+}
+
+// enterArchive opens the archive at path and shows its root in the current
+// pane. Leaving it through h or any other navigation closes it again.
+func (m *AppModel) enterArchive(path string) {
+	fsys, closer, err := fileutils.OpenArchive(path)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot open archive: %v", err)
+		return
+	}
+	m.rememberCursor()
+	m.ArchiveFS = fsys
+	m.ArchivePath = path
+	m.archiveCloser = closer
+	m.clearMarks()
+	m.CurrentDir = path
+	m.Selected = 0
+	m.ListOffset = 0
+	m.PreviewOffset = 0
+	m.GridOffset = 0
+	m.pendingSelect = m.DirCursors[path].Name
+	m.loadCurrentDir()
+}
+
+// closeArchive returns to the real filesystem
+func (m *AppModel) closeArchive() {
+	if m.archiveCloser != nil {
+		m.archiveCloser.Close()
+	}
+	m.ArchiveFS = nil
+	m.ArchivePath = ""
+	m.archiveCloser = nil
+}
+
+// extractArchiveEntry copies the selected archive file next to the archive
+func (m *AppModel) extractArchiveEntry() {
+	if len(m.Files) == 0 {
+		return
+	}
+	selectedFile := m.Files[m.Selected]
+	name, ok := archiveEntry(m.Model, filepath.Join(m.CurrentDir, selectedFile.Entry.Name()))
+	if !ok {
+		return
+	}
+	if m.RootFS {
+		m.StatusMessage = "Entries of the browsed filesystem are read-only"
+		return
+	}
+	if selectedFile.Entry.IsDir() {
+		m.StatusMessage = "Only single files can be extracted"
+		return
+	}
+	dest, err := fileutils.ExtractFSFile(m.ArchiveFS, name, filepath.Dir(m.ArchivePath))
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Extract failed: %v", err)
+		return
+	}
+	m.StatusMessage = fmt.Sprintf("Extracted %s", dest)
+}
+
+// archiveListLimit caps the members an archive preview lists
+const archiveListLimit = 300
+
+// isZipFile matches zip archives, listed from their central directory
+// without reading the members
+func isZipFile(fileName string) bool {
+	return strings.HasSuffix(strings.ToLower(fileName), ".zip")
+}
+
+// renderArchiveListPreview lists the members of an archive with their
+// sizes and dates. An archive that does not parse gets the hex preview.
+func renderArchiveListPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	entries, total, err := fileutils.ListArchive(fullPath, archiveListLimit)
+	if err != nil {
+		renderBinaryPreview(m, cfg, selectedFile, fullPath, fmt.Sprintf("Cannot list archive: %v", err))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	sb.WriteString(fmt.Sprintf("%d %s\n\n", total, plural(total, "entry", "entries")))
+	m.PreviewContentStart = strings.Count(sb.String(), "\n")
+	for _, entry := range entries {
+		compressed := ""
+		if entry.Compressed >= 0 && !strings.HasSuffix(entry.Name, "/") {
+			compressed = fileutils.FormatSize(entry.Compressed)
+		}
+		sb.WriteString(fmt.Sprintf("%9s %9s  %s  %s\n",
+			fileutils.FormatSize(entry.Size), compressed, entry.ModTime.Format("2006-01-02 15:04"), displayName(entry.Name)))
+	}
+	if more := total - len(entries); more > 0 {
+		dots := "…"
+		if !cfg.Unicode {
+			dots = "..."
+		}
+		sb.WriteString(fmt.Sprintf("%sand %d more", dots, more))
+	}
+	setPreview(m, strings.TrimSuffix(sb.String(), "\n"))
+}
@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// batchWaitDelay bounds the wait for a cancelled command's output to close
+const batchWaitDelay = time.Second
+
+// promptBatch asks for the command to run on each marked file, or the
+// selected one. "%f" in it stands for the path, without one the path is
+// appended.
+func (m *AppModel) promptBatch() {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Commands cannot run on archive entries, extract them first"
+		return
+	}
+	if m.batchCancel != nil {
+		m.StatusMessage = "A batch is already running, esc cancels it"
+		return
+	}
+	paths := m.targets()
+	if len(paths) == 0 {
+		return
+	}
+	noun := "files"
+	if len(paths) == 1 {
+		noun = "file"
+	}
+	m.prompt(fmt.Sprintf("Run on %d %s (%%f is the path): ", len(paths), noun), func(input string) tea.Cmd {
+		args, err := config.SplitCommand(input)
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Cannot run %s: %v", input, err)
+			return nil
+		}
+		if len(args) == 0 {
+			return nil
+		}
+		return m.startBatch(input, args, paths)
+	})
+	m.setPromptInput(m.BatchCommand)
+}
+
+// batchArgs substitutes path for each "%f" of the command's arguments, or
+// appends it when none has one
+func batchArgs(args []string, path string) []string {
+	substituted := make([]string, len(args))
+	found := false
+	for i, arg := range args {
+		if strings.Contains(arg, "%f") {
+			found = true
+		}
+		substituted[i] = strings.ReplaceAll(arg, "%f", path)
+	}
+	if !found {
+		substituted = append(substituted, path)
+	}
+	return substituted
+}
+
+// startBatch runs the command on each path in the background, batch_jobs at
+// a time, in the current directory and with bullseye's environment. Once
+// done the results fill the window, esc cancels the batch before that.
+func (m *AppModel) startBatch(line string, args, paths []string) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.batchCancel = cancel
+	m.updateCancellable()
+	m.BatchCommand = line
+	dir, jobs, stopOnFailure := m.CurrentDir, m.config.BatchJobs, m.config.BatchStopOnFailure
+	results := make([]models.BatchResult, len(paths))
+	for i, path := range paths {
+		name, err := filepath.Rel(dir, path)
+		if err != nil || strings.HasPrefix(name, "..") {
+			name = path
+		}
+		results[i] = models.BatchResult{Name: name, Status: "skipped"}
+	}
+
+	work := func(report taskReport) (string, string) {
+		var mu sync.Mutex
+		done, failed, stopped := 0, 0, false
+		// A failure with batch_stop_on_failure starts no more commands but
+		// lets the running ones finish, esc kills them
+		stop := make(chan struct{})
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for range min(jobs, len(paths)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					result := runBatchCommand(ctx, dir, batchArgs(args, paths[i]))
+					result.Name = results[i].Name
+					mu.Lock()
+					results[i] = result
+					done++
+					if result.Failed {
+						failed++
+						if stopOnFailure && !stopped {
+							stopped = true
+							close(stop)
+						}
+					}
+					report(fmt.Sprintf("batch: %d/%d done, %d failed", done, len(paths), failed))
+					mu.Unlock()
+				}
+			}()
+		}
+	dispatch:
+		for i := range paths {
+			select {
+			case next <- i:
+			case <-stop:
+				break dispatch
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(next)
+		wg.Wait()
+
+		summary := fmt.Sprintf("Batch %s: %d ok, %d failed", args[0], done-failed, failed)
+		if skipped := len(paths) - done; skipped > 0 {
+			switch {
+			case stopped:
+				summary += fmt.Sprintf(", stopped on the failure with %d left", skipped)
+			default:
+				summary += fmt.Sprintf(", cancelled with %d left", skipped)
+			}
+		}
+		return summary, ""
+	}
+	return m.startTaskThen(fmt.Sprintf("batch: 0/%d done", len(paths)), dir, work, func() {
+		cancel()
+		m.batchCancel = nil
+		m.updateCancellable()
+		m.BatchResults = results
+		m.BatchOffset = 0
+		m.BatchScreen = true
+	})
+}
+
+// runBatchCommand runs one command of a batch, its output collected for the
+// results screen
+func runBatchCommand(ctx context.Context, dir string, args []string) models.BatchResult {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &output, &output
+	// Once killed, children still holding the output open are not waited for
+	cmd.WaitDelay = batchWaitDelay
+	err := cmd.Run()
+
+	result := models.BatchResult{Status: "ok"}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	result.Output = lines[len(lines)-1]
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		result.Status, result.Failed = "cancelled", true
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		result.Status, result.Failed = fmt.Sprintf("exit %d", exitErr.ExitCode()), true
+	default:
+		result.Status, result.Failed = "error", true
+		if result.Output == "" {
+			result.Output = err.Error()
+		}
+	}
+	return result
+}
+
+// handleBatchKeys scrolls and closes the batch results, reporting whether
+// the key was consumed. Everything but quitting is swallowed while they are
+// shown.
+func (m *AppModel) handleBatchKeys(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "ctrl+c":
+		return false
+	case "esc", "q":
+		m.BatchScreen = false
+		return true
+	}
+	switch m.keys[msg.String()] {
+	case actionDown:
+		m.BatchOffset++
+	case actionUp:
+		m.BatchOffset = max(0, m.BatchOffset-1)
+	case actionTop:
+		m.BatchOffset = 0
+	case actionBottom:
+		m.BatchOffset = len(m.BatchResults)
+	}
+	return true
+}
+
+// renderBatchPane lists the outcome of the last batch command per file,
+// with the last line each command printed
+func renderBatchPane(m *models.Model, cfg config.Config, width, height int) string {
+	failed := 0
+	for _, result := range m.BatchResults {
+		if result.Failed {
+			failed++
+		}
+	}
+	lines := []string{
+		fmt.Sprintf(" Batch: %s  (%d files, %d failed)", m.BatchCommand, len(m.BatchResults), failed),
+		paneRule(cfg, width-2),
+	}
+	for i, line := range lines {
+		lines[i] = TruncateString(line, max(0, width-2))
+	}
+	failedStyle := newStyle(cfg).Foreground(lipgloss.Color(cfg.DiffRemovedColor))
+	rows := max(1, height-len(lines))
+	m.BatchOffset = min(m.BatchOffset, max(0, len(m.BatchResults)-rows))
+	for _, result := range m.BatchResults[m.BatchOffset:min(len(m.BatchResults), m.BatchOffset+rows)] {
+		line := fmt.Sprintf(" %-10s %s", result.Status, displayName(result.Name))
+		if result.Output != "" {
+			line += "  " + strings.Join(strings.Fields(result.Output), " ")
+		}
+		line = TruncateString(line, max(0, width-2))
+		if result.Failed {
+			line = failedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+func TestBatchArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"gzip"}, []string{"gzip", "/d/a b"}},
+		{[]string{"cp", "%f", "%f.bak"}, []string{"cp", "/d/a b", "/d/a b.bak"}},
+		{[]string{"sh", "-c", `echo "$0"`, "%f"}, []string{"sh", "-c", `echo "$0"`, "/d/a b"}},
+	}
+	for _, tt := range tests {
+		if got := batchArgs(tt.args, "/d/a b"); !slices.Equal(got, tt.want) {
+			t.Errorf("batchArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// markAll marks the named entries of the listing
+func markAll(t *testing.T, m *AppModel, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		selectName(t, m, name)
+		press(t, m, " ")
+	}
+}
+
+// batchStatuses lists the status of each result, by name
+func batchStatuses(m *AppModel) map[string]string {
+	statuses := make(map[string]string)
+	for _, result := range m.BatchResults {
+		statuses[result.Name] = result.Status
+	}
+	return statuses
+}
+
+func TestBatchQuotesPaths(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"two words.txt", `it's "quoted".txt`, "tab\there.txt", "-dash.txt"}
+	writeTree(t, dir, names...)
+	m := newTestModel(t, Options{Path: dir})
+	markAll(t, m, names...)
+
+	// Each path is one argument however it is spelled, the command runs
+	// without a shell
+	press(t, m, "!")
+	typeText(t, m, `cp -- %f "%f copy"`)
+	press(t, m, "enter")
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name+" copy"))
+		if err != nil {
+			t.Errorf("no copy of %q: %v", name, err)
+			continue
+		}
+		if string(data) != name+"\n" {
+			t.Errorf("copy of %q holds %q", name, data)
+		}
+	}
+	for name, status := range batchStatuses(m) {
+		if status != "ok" {
+			t.Errorf("%q: status %q", name, status)
+		}
+	}
+	if got, want := m.StatusMessage, "Batch cp: 4 ok, 0 failed"; got != want {
+		t.Errorf("status %q, want %q", got, want)
+	}
+}
+
+func TestBatchInheritsEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "sub/a", "sub/b")
+	m := newTestModel(t, Options{Path: filepath.Join(dir, "sub")})
+	t.Setenv("BATCH_TEST_VALUE", "inherited value")
+	markAll(t, m, "a", "b")
+
+	press(t, m, "!")
+	typeText(t, m, `sh -c 'printf "%s in %s" "$BATCH_TEST_VALUE" "$(pwd)"'`)
+	press(t, m, "enter")
+	if !m.BatchScreen || len(m.BatchResults) != 2 {
+		t.Fatalf("batch screen %v with %d results", m.BatchScreen, len(m.BatchResults))
+	}
+	want := "inherited value in " + filepath.Join(dir, "sub")
+	for _, result := range m.BatchResults {
+		if result.Status != "ok" || result.Output != want {
+			t.Errorf("%s: %s %q, want ok %q", result.Name, result.Status, result.Output, want)
+		}
+	}
+}
+
+func TestBatchStopsOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b", "c")
+	m := newConfiguredModel(t, Options{Path: dir}, "batch_jobs = 1\nbatch_stop_on_failure = true\n")
+	markAll(t, m, "a", "b", "c")
+
+	press(t, m, "!")
+	typeText(t, m, `sh -c 'echo failed on "$0"; exit 3'`)
+	press(t, m, "enter")
+	if got, want := batchStatuses(m), map[string]string{"a": "exit 3", "b": "skipped", "c": "skipped"}; !maps.Equal(got, want) {
+		t.Errorf("statuses %v, want %v", got, want)
+	}
+	if got, want := m.BatchResults[0].Output, "failed on "+filepath.Join(dir, "a"); got != want {
+		t.Errorf("output %q, want %q", got, want)
+	}
+	if got, want := m.StatusMessage, "Batch sh: 0 ok, 1 failed, stopped on the failure with 2 left"; got != want {
+		t.Errorf("status %q, want %q", got, want)
+	}
+}
+
+func TestBatchCancelled(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b", "c")
+	m := newConfiguredModel(t, Options{Path: dir}, "batch_jobs = 1\n")
+	markAll(t, m, "a", "b", "c")
+
+	press(t, m, "!")
+	typeText(t, m, `sh -c 'touch "$0.started"; sleep 30'`)
+	// The batch runs in the background, its command waits for it to end
+	_, cmd := m.Update(keyMsg(t, "enter"))
+	if m.Task == "" {
+		t.Fatal("no batch task running")
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	started := filepath.Join(dir, "a.started")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the first command did not start")
+		}
+	}
+	// esc cancels the batch rather than clearing the marks, and says so
+	if got := helpBar(m); !strings.HasPrefix(got, "esc:cancel batch") || strings.Contains(got, "clear marks") {
+		t.Errorf("help bar during the batch = %q, want the cancel hint first", got)
+	}
+	begin := time.Now()
+	press(t, m, "esc")
+	settle(t, m, func() tea.Msg { return <-done })
+	if elapsed := time.Since(begin); elapsed > 10*time.Second {
+		t.Errorf("cancelling took %v", elapsed)
+	}
+
+	if got, want := batchStatuses(m), map[string]string{"a": "cancelled", "b": "skipped", "c": "skipped"}; !maps.Equal(got, want) {
+		t.Errorf("statuses %v, want %v", got, want)
+	}
+	if !strings.HasSuffix(m.StatusMessage, "cancelled with 2 left") {
+		t.Errorf("status %q does not report the cancellation", m.StatusMessage)
+	}
+	for _, name := range []string{"b.started", "c.started"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s ran after the cancellation", strings.TrimSuffix(name, ".started"))
+		}
+	}
+	if m.batchCancel != nil || m.Task != "" || m.InputMode != models.ModeNormal {
+		t.Errorf("batch left cancel %v, task %q, mode %v", m.batchCancel != nil, m.Task, m.InputMode)
+	}
+	if m.Cancellable != "" {
+		t.Errorf("batch left %q cancellable", m.Cancellable)
+	}
+}
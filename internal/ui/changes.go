@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// changedMark returns the indicator after entries new or modified since the
+// last visit
+func changedMark(cfg config.Config) string {
+	if !cfg.Unicode {
+		return "~"
+	}
+	return "•"
+}
+
+// snapshotVisit records files as seen at t
+func snapshotVisit(files []models.FileInfo, t time.Time) config.Visit {
+	visit := config.Visit{Time: t}
+	if len(files) <= config.MaxVisitEntries {
+		visit.Entries = make(map[string]time.Time, len(files))
+		for _, file := range files {
+			visit.Entries[file.Entry.Name()] = file.ModTime
+		}
+	}
+	return visit
+}
+
+// changedSince returns the names of files that are new or modified since
+// visit. A visit too large to list its entries only tells modifications
+// after it, which misses entries moved in with their old times.
+func changedSince(visit config.Visit, files []models.FileInfo) map[string]bool {
+	changed := make(map[string]bool)
+	for _, file := range files {
+		name := file.Entry.Name()
+		if visit.Entries == nil {
+			if file.ModTime.After(visit.Time) {
+				changed[name] = true
+			}
+		} else if seen, ok := visit.Entries[name]; !ok || !seen.Equal(file.ModTime) {
+			changed[name] = true
+		}
+	}
+	return changed
+}
+
+// trackVisit compares the fresh listing of the current directory with the
+// last visit when track_changes is on. Entering another directory first
+// saves the one left, archives and virtual filesystems are not tracked.
+func (m *AppModel) trackVisit(files []models.FileInfo) {
+	m.Changed = nil
+	if !m.config.TrackChanges || m.ArchiveFS != nil || len(files) > 0 && files[0].Virtual {
+		return
+	}
+	if m.visitDir != m.CurrentDir {
+		m.leaveVisit()
+		visit, ok, err := config.LoadVisit(m.CurrentDir)
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Error loading last visit: %v", err)
+		}
+		m.visitDir, m.lastVisit, m.visited = m.CurrentDir, visit, ok
+	}
+	m.visitFiles = files
+	if m.visited {
+		m.Changed = changedSince(m.lastVisit, files)
+	}
+}
+
+// leaveVisit saves the snapshot of the tracked directory, as it was last
+// listed
+func (m *AppModel) leaveVisit() {
+	if m.visitDir == "" {
+		return
+	}
+	if err := config.SaveVisit(m.visitDir, snapshotVisit(m.visitFiles, time.Now())); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error saving visit: %v", err)
+	}
+	m.visitDir = ""
+}
+
+// clearChanges drops the change markers, counting the listing as seen
+func (m *AppModel) clearChanges() {
+	if m.visitDir == "" {
+		m.StatusMessage = "Change tracking is off, set track_changes = true to enable it"
+		return
+	}
+	m.lastVisit, m.visited = snapshotVisit(m.visitFiles, time.Now()), true
+	m.Changed = nil
+}
+
+// Close saves the state kept for the next run, call on exit
+func (m *AppModel) Close() {
+	m.leaveVisit()
+}
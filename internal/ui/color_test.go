@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+	"github.com/muesli/termenv"
+)
+
+// forceColors makes the default renderer emit true color, as a terminal
+// would, rather than nothing as it does writing to a test's output
+func forceColors(t *testing.T) {
+	t.Helper()
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+}
+
+// colorFixture writes a directory holding one of each kind of entry that is
+// drawn in its own color
+func colorFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeTree(t, dir, "src/", "main.go", "notes.md", ".hidden", "other/main.go")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\n// main runs\nfunc main() {\n\tprintln(\"hi\", 42)\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\necho hi\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A gradient, so the colored ASCII preview has colors to draw
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for x := range 32 {
+		for y := range 16 {
+			img.Set(x, y, color.RGBA{uint8(x * 8), uint8(y * 16), 200, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "photo.png"), buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range map[string]string{"link": "notes.md", "broken": "missing"} {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// colorScreens are the states the no-color renders are checked in, each
+// reached by selecting an entry and pressing keys
+var colorScreens = []struct {
+	name  string
+	entry string
+	keys  []string
+	text  string
+}{
+	{name: "listing"},
+	{name: "source preview", entry: "main.go"},
+	{name: "markdown preview", entry: "notes.md"},
+	{name: "image preview", entry: "photo.png"},
+	{name: "broken link", entry: "broken"},
+	{name: "marks", entry: "notes.md", keys: []string{" ", "j", " "}},
+	{name: "search", keys: []string{"/"}, text: "ma"},
+	{name: "prompt", keys: []string{"C"}, text: "sr"},
+	{name: "confirm", entry: "notes.md", keys: []string{"D"}},
+	{name: "grid", keys: []string{"I"}},
+	{name: "quick look", entry: "main.go", keys: []string{"i"}},
+	{name: "debug", keys: []string{"ctrl+g"}},
+	{name: "palette", keys: []string{"ctrl+k"}},
+	{name: "favorites", keys: []string{"F"}},
+	{name: "trash", keys: []string{"X"}},
+	{name: "compare", keys: []string{"|"}, text: "other\n"},
+	{name: "batch", entry: "notes.md", keys: []string{" ", "!"}, text: "true\n"},
+}
+
+// renderScreens renders each of colorScreens in a fresh model
+func renderScreens(t *testing.T, configTOML string, env map[string]string) map[string]string {
+	t.Helper()
+	views := make(map[string]string)
+	for _, screen := range colorScreens {
+		m := newEnvModel(t, Options{Path: colorFixture(t)}, "image_preview_colored = true\n"+configTOML, env)
+		if screen.entry != "" {
+			selectName(t, m, screen.entry)
+		}
+		press(t, m, screen.keys...)
+		for _, line := range strings.SplitAfter(screen.text, "\n") {
+			typeText(t, m, strings.TrimSuffix(line, "\n"))
+			if strings.HasSuffix(line, "\n") {
+				press(t, m, "enter")
+			}
+		}
+		views[screen.name] = m.View()
+	}
+	return views
+}
+
+func TestNoColorRendersNoEscapes(t *testing.T) {
+	forceColors(t)
+
+	// The check finds the escapes colors bring, on every screen
+	for name, view := range renderScreens(t, "", nil) {
+		if !strings.Contains(view, "\x1b[") {
+			t.Fatalf("the colored %s has no escape sequences:\n%s", name, view)
+		}
+	}
+
+	disabled := []struct {
+		name       string
+		configTOML string
+		env        map[string]string
+	}{
+		{"NO_COLOR", "", map[string]string{"NO_COLOR": "1"}},
+		{"TERM=dumb", "", map[string]string{"TERM": "dumb"}},
+		{"color = false", "color = false\n", nil},
+	}
+	for _, d := range disabled {
+		for name, view := range renderScreens(t, d.configTOML, d.env) {
+			if i := strings.Index(view, "\x1b"); i >= 0 {
+				t.Errorf("%s, %s: escape sequence at %q in:\n%s", d.name, name, view[i:min(len(view), i+12)], view)
+			}
+			if !strings.HasPrefix(view, "+") || strings.ContainsAny(view, "╭╮╰╯│─") {
+				t.Errorf("%s, %s: borders are not ASCII:\n%s", d.name, name, view)
+			}
+		}
+	}
+}
+
+func TestNoColorSnapshot(t *testing.T) {
+	forceColors(t)
+	fsys := fstest.MapFS{
+		"docs/guide.md": {Data: []byte("# Guide\n"), Mode: 0o644, ModTime: fixtureTime},
+		"main.go":       {Data: []byte("package main\n\nfunc main() {}\n"), Mode: 0o644, ModTime: fixtureTime},
+		"notes.txt":     {Data: []byte("first line\nsecond line\n"), Mode: 0o644, ModTime: fixtureTime},
+	}
+	m := newEnvModel(t, Options{FS: fsys}, "unicode = false\n", map[string]string{"NO_COLOR": "1"})
+	send(t, m, tea.WindowSizeMsg{Width: 72, Height: 9})
+	press(t, m, "/")
+	typeText(t, m, "n")
+
+	// The cursor and the search stand out without colors or attributes
+	want := strings.Join([]string{
+		"+------------------++------------------------++------------------------+",
+		"|                  || / (2 items)            ||- notes.txt             |",
+		"|                  ||----------------------  ||Size: 23 B              |",
+		"|                  ||> notes.txt             ||Modified: 2024-03-0...  |",
+		"|                  ||- main.go               ||                        |",
+		"|                  ||                        ||                        |",
+		"+------------------++------------------------++------------------------+",
+		" Fuzzy search: n (2 matches)                                            ",
+		" Type to search | Enter:confirm | Esc:cancel | Ctrl+T:fuzzy/substring   ",
+	}, "\n")
+	if got := m.View(); got != want {
+		t.Errorf("view =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPreviewBackground(t *testing.T) {
+	forceColors(t)
+	m := newConfiguredModel(t, Options{Path: t.TempDir()}, "preview_bg_color = \"#102030\"\n")
+	preview := &models.Model{
+		PreviewLines:     []string{"ab", "\x1b[31mred\x1b[m tail", "日本", "dir", "much too long line"},
+		PreviewHighlight: 3,
+	}
+
+	// Rows are padded to the pane width on the background, which is turned
+	// back on after every reset, also where the pane has no lines left
+	const border, bg, reset = "\x1b[38;5;240m", "\x1b[48;2;16;32;48m", "\x1b[0m"
+	side := border + "│" + reset
+	want := []string{
+		border + "╭──────────╮" + reset,
+		side + bg + "ab        " + reset + side,
+		side + bg + "\x1b[31mred\x1b[m" + bg + " tail  " + reset + side,
+		side + bg + "日本      " + reset + side,
+		side + bg + "\x1b[93;40mdir" + reset + bg + "       " + reset + side,
+		side + bg + "much ...  " + reset + side,
+		side + bg + "          " + reset + side,
+		side + bg + "          " + reset + side,
+		border + "╰──────────╯" + reset,
+	}
+	if got := strings.Split(renderPreviewPane(preview, m.config, 10, 7), "\n"); !slices.Equal(got, want) {
+		t.Errorf("preview pane =\n%q\nwant\n%q", got, want)
+	}
+
+	// Colored image lines end their colors themselves
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := range 4 {
+		for y := range 2 {
+			img.Set(x, y, color.RGBA{uint8(x * 60), uint8(y * 100), 0, 255})
+		}
+	}
+	if got, want := imageToASCII(img, 4, 2, true), "\x1b[38;5;16m.\x1b[0m\x1b[38;5;52m,\x1b[0m\x1b[38;5;88m:\x1b[0m\x1b[38;5;124m;\x1b[0m\x1b[0m\n"; got != want {
+		t.Errorf("colored image = %q, want %q", got, want)
+	}
+	if got, want := imageToASCII(img, 4, 2, false), ".,:;\n"; got != want {
+		t.Errorf("image = %q, want %q", got, want)
+	}
+
+	// Without colors there is no background to fill
+	lipgloss.SetColorProfile(termenv.Ascii)
+	if got := GetPreviewBgSequence(m.config); got != "" {
+		t.Errorf("background sequence without colors = %q", got)
+	}
+	if got := withBackground("ab", "", 10); got != "ab" {
+		t.Errorf("line without colors = %q", got)
+	}
+}
@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// promptCompare asks for the directory to compare the current one with
+func (m *AppModel) promptCompare() {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive directories cannot be compared"
+		return
+	}
+	m.pathPrompt("Compare with: ", func(input string) tea.Cmd {
+		if strings.TrimSpace(input) == "" {
+			return nil
+		}
+		other := fileutils.ExpandPath(fileutils.UnescapePath(input), m.CurrentDir)
+		if info, err := os.Stat(other); err != nil {
+			m.StatusMessage = fmt.Sprintf("Cannot compare with %s: %v", other, err)
+			return nil
+		} else if !info.IsDir() {
+			m.StatusMessage = other + " is not a directory"
+			return nil
+		}
+		if fileutils.SameEntry(m.CurrentDir, other) {
+			m.StatusMessage = "That is the current directory"
+			return nil
+		}
+		m.CompareA, m.CompareB, m.CompareDepth = m.CurrentDir, other, 0
+		m.loadCompare("")
+		return nil
+	})
+}
+
+// loadCompare compares CompareA with CompareB and shows the result, the
+// cursor on the named entry when there is one
+func (m *AppModel) loadCompare(selectName string) {
+	entries, err := fileutils.CompareDirs(m.CompareA, m.CompareB)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot compare: %v", err)
+		return
+	}
+	m.CompareEntries = entries
+	m.CompareSelected = 0
+	for i, entry := range entries {
+		if entry.Name == selectName {
+			m.CompareSelected = i
+		}
+		// Files read before and not modified since are settled already
+		if same, ok := m.compareSettled[compareKey(m.CompareA, m.CompareB, entry)]; ok && entry.Kind == models.CompareUnsure {
+			m.CompareEntries[i].Kind = models.CompareDiffers
+			if same {
+				m.CompareEntries[i].Kind = models.CompareSame
+			}
+		}
+	}
+	m.CompareView = true
+}
+
+// compareKey identifies the pair of files of an entry as they were when
+// compared by content
+func compareKey(a, b string, entry models.CompareEntry) string {
+	if entry.A == nil || entry.B == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s\x00%s\x00%d\x00%d\x00%d", filepath.Join(a, entry.Name), filepath.Join(b, entry.Name),
+		entry.A.Size(), entry.A.ModTime().UnixNano(), entry.B.ModTime().UnixNano())
+}
+
+// compareCounts summarizes a comparison for its title
+func compareCounts(entries []models.CompareEntry) string {
+	var onlyA, onlyB, differ int
+	for _, entry := range entries {
+		switch entry.Kind {
+		case models.CompareOnlyA:
+			onlyA++
+		case models.CompareOnlyB:
+			onlyB++
+		case models.CompareDiffers, models.CompareUnsure:
+			differ++
+		}
+	}
+	return fmt.Sprintf(" (%d only in A, %d only in B, %d differ)", onlyA, onlyB, differ)
+}
+
+// compareDetail describes how an entry compares
+func compareDetail(entry models.CompareEntry) string {
+	switch entry.Kind {
+	case models.CompareOnlyA:
+		return "only in A"
+	case models.CompareOnlyB:
+		return "only in B"
+	case models.CompareUnsure:
+		if entry.A.ModTime().After(entry.B.ModTime()) {
+			return "newer in A, same size"
+		}
+		return "newer in B, same size"
+	case models.CompareDiffers:
+		switch {
+		case entry.A.Mode().Type() != entry.B.Mode().Type():
+			return fmt.Sprintf("%s in A, %s in B", compareTypeName(entry.A), compareTypeName(entry.B))
+		case entry.A.Size() != entry.B.Size():
+			return fmt.Sprintf("%s in A, %s in B", fileutils.FormatSize(entry.A.Size()), fileutils.FormatSize(entry.B.Size()))
+		}
+		return "content differs"
+	}
+	if entry.A.IsDir() {
+		return "directory in both"
+	}
+	return "same"
+}
+
+// compareTypeName names the type of an entry for compareDetail
+func compareTypeName(info os.FileInfo) string {
+	switch {
+	case info.IsDir():
+		return "directory"
+	case info.Mode()&os.ModeSymlink != 0:
+		return "symlink"
+	case info.Mode().IsRegular():
+		return "file"
+	}
+	return "special file"
+}
+
+// handleCompareKeys moves through the comparison, enters the directories in
+// both and copies entries across, reporting whether the key was consumed
+func (m *AppModel) handleCompareKeys(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch m.keys[msg.String()] {
+	case actionUp:
+		m.CompareSelected = max(0, m.CompareSelected-1)
+		return true, nil
+	case actionDown:
+		m.CompareSelected = max(0, min(len(m.CompareEntries)-1, m.CompareSelected+1))
+		return true, nil
+	case actionTop:
+		m.CompareSelected = 0
+		return true, nil
+	case actionBottom:
+		m.CompareSelected = max(0, len(m.CompareEntries)-1)
+		return true, nil
+	}
+
+	var entry models.CompareEntry
+	if len(m.CompareEntries) > 0 {
+		entry = m.CompareEntries[m.CompareSelected]
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		return false, nil
+	case "enter", "l":
+		switch {
+		case entry.Name == "":
+		case entry.Kind == models.CompareSame && entry.A.IsDir():
+			m.CompareA = filepath.Join(m.CompareA, entry.Name)
+			m.CompareB = filepath.Join(m.CompareB, entry.Name)
+			m.CompareDepth++
+			m.loadCompare("")
+		case entry.A != nil:
+			m.CompareView = false
+			m.navigateTo(m.CompareA, entry.Name)
+		default:
+			m.CompareView = false
+			m.navigateTo(m.CompareB, entry.Name)
+		}
+	case "h", "backspace":
+		if m.CompareDepth == 0 {
+			m.StatusMessage = "At the compared directories, esc closes the comparison"
+			return true, nil
+		}
+		name := filepath.Base(m.CompareA)
+		m.CompareA, m.CompareB = filepath.Dir(m.CompareA), filepath.Dir(m.CompareB)
+		m.CompareDepth--
+		m.loadCompare(name)
+	case "r":
+		m.loadCompare(entry.Name)
+	case "c":
+		if entry.Kind != models.CompareOnlyA && entry.Kind != models.CompareOnlyB {
+			m.StatusMessage = "c copies entries missing on one side, > and < overwrite"
+			return true, nil
+		}
+		return true, m.copyAcross([]models.CompareEntry{entry})
+	case "C":
+		var missing []models.CompareEntry
+		for _, e := range m.CompareEntries {
+			if e.Kind == models.CompareOnlyA || e.Kind == models.CompareOnlyB {
+				missing = append(missing, e)
+			}
+		}
+		if len(missing) == 0 {
+			m.StatusMessage = "Nothing is missing on either side"
+			return true, nil
+		}
+		return true, m.copyAcross(missing)
+	case ">", "<":
+		if entry.Kind != models.CompareDiffers && entry.Kind != models.CompareUnsure {
+			m.StatusMessage = "> and < overwrite entries that differ, c copies missing ones"
+			return true, nil
+		}
+		src, dst := filepath.Join(m.CompareA, entry.Name), filepath.Join(m.CompareB, entry.Name)
+		if msg.String() == "<" {
+			src, dst = dst, src
+		}
+		// Replacing a directory the source lives in would delete the source
+		if fileutils.IsSameOrAncestor(dst, src) {
+			m.StatusMessage = fmt.Sprintf("Cannot overwrite %s, %s is inside it", dst, src)
+			return true, nil
+		}
+		m.confirm(fmt.Sprintf("Overwrite %s with %s? (y/n)", dst, src), func() tea.Cmd {
+			return m.startTransferThen([]transfer{{src: src, dst: dst, overwrite: true}}, false, m.refreshCompare(entry.Name))
+		})
+	case "H":
+		return true, m.compareContents()
+	case "esc", "q":
+		m.CompareView = false
+	}
+	return true, nil
+}
+
+// copyAcross copies each entry to the side it is missing on, refreshing
+// the comparison once done
+func (m *AppModel) copyAcross(entries []models.CompareEntry) tea.Cmd {
+	items := make([]transfer, 0, len(entries))
+	for _, entry := range entries {
+		src, dst := filepath.Join(m.CompareA, entry.Name), filepath.Join(m.CompareB, entry.Name)
+		if entry.Kind == models.CompareOnlyB {
+			src, dst = dst, src
+		}
+		items = append(items, transfer{src: src, dst: dst})
+	}
+	return m.startTransferThen(items, false, m.refreshCompare(m.CompareEntries[m.CompareSelected].Name))
+}
+
+// refreshCompare returns the hook comparing again after a copy, if the
+// comparison is still of the same directories
+func (m *AppModel) refreshCompare(selectName string) func() {
+	a, b := m.CompareA, m.CompareB
+	return func() {
+		if m.CompareView && m.CompareA == a && m.CompareB == b {
+			m.loadCompare(selectName)
+		}
+	}
+}
+
+// compareContents reads the files of the same size but modified at other
+// times in the background, settling whether they differ
+func (m *AppModel) compareContents() tea.Cmd {
+	var names []string
+	for _, entry := range m.CompareEntries {
+		if entry.Kind == models.CompareUnsure {
+			names = append(names, entry.Name)
+		}
+	}
+	if len(names) == 0 {
+		m.StatusMessage = "No files left to compare by content"
+		return nil
+	}
+	a, b := m.CompareA, m.CompareB
+	same := make(map[string]bool, len(names)) // Name to whether the content is the same, absent when unreadable
+	work := func(report taskReport) (string, string) {
+		differ, failed := 0, 0
+		for i, name := range names {
+			report(fmt.Sprintf("compare: %d/%d files", i, len(names)))
+			equal, err := fileutils.SameContent(filepath.Join(a, name), filepath.Join(b, name))
+			switch {
+			case err != nil:
+				failed++
+			case equal:
+				same[name] = true
+			default:
+				same[name] = false
+				differ++
+			}
+		}
+		result := fmt.Sprintf("Compared %d files by content: %d differ", len(names), differ)
+		if failed > 0 {
+			result += fmt.Sprintf(", %d unreadable", failed)
+		}
+		return result, ""
+	}
+	return m.startTaskThen(fmt.Sprintf("compare: 0/%d files", len(names)), "", work, func() {
+		if m.CompareA != a || m.CompareB != b {
+			return
+		}
+		for i, entry := range m.CompareEntries {
+			equal, read := same[entry.Name]
+			if entry.Kind != models.CompareUnsure || !read {
+				continue
+			}
+			m.compareSettled[compareKey(a, b, entry)] = equal
+			m.CompareEntries[i].Kind = models.CompareDiffers
+			if equal {
+				m.CompareEntries[i].Kind = models.CompareSame
+			}
+		}
+	})
+}
+
+// renderComparePane lists the names of both directories with how they
+// compare, scrolled to keep the selected one visible
+func renderComparePane(m *models.Model, cfg config.Config, width, height int) string {
+	var content strings.Builder
+	paneContentWidth := max(0, width-2)
+	content.WriteString(truncatePaneTitle("Comparison", compareCounts(m.CompareEntries), paneContentWidth) + "\n")
+	content.WriteString(TruncateString(" A: "+displayName(m.CompareA), paneContentWidth) + "\n")
+	content.WriteString(TruncateString(" B: "+displayName(m.CompareB), paneContentWidth) + "\n")
+	content.WriteString(paneRule(cfg, width-2) + "\n")
+
+	if len(m.CompareEntries) == 0 {
+		content.WriteString(" Both directories are empty")
+	}
+	nameWidth := 0
+	for _, entry := range m.CompareEntries {
+		nameWidth = max(nameWidth, lipgloss.Width(displayName(entry.Name)))
+	}
+	nameWidth = min(nameWidth, paneContentWidth/2)
+	rows := max(1, height-4)
+	start := max(0, m.CompareSelected-rows+1)
+	end := min(start+rows, len(m.CompareEntries))
+	same := newStyle(cfg).Foreground(lipgloss.Color(cfg.HiddenFileColor)).Faint(true)
+	onlyA := newStyle(cfg).Foreground(lipgloss.Color(cfg.DiffRemovedColor))
+	onlyB := newStyle(cfg).Foreground(lipgloss.Color(cfg.DiffAddedColor))
+	differs := newStyle(cfg).Foreground(lipgloss.Color(cfg.DiffHunkColor))
+	for i := start; i < end; i++ {
+		entry := m.CompareEntries[i]
+		prefix := " "
+		if i == m.CompareSelected && !cfg.Color {
+			prefix = ">"
+		}
+		name := TruncateString(displayName(entry.Name), nameWidth)
+		line := TruncateString(fmt.Sprintf("%s%s%s  %s", prefix, name, strings.Repeat(" ", nameWidth-lipgloss.Width(name)), compareDetail(entry)), paneContentWidth)
+		switch {
+		case i == m.CompareSelected:
+			line = GetPreviewHighlightStyle(cfg).Render(line)
+		case entry.Kind == models.CompareSame:
+			line = same.Render(line)
+		case entry.Kind == models.CompareOnlyA:
+			line = onlyA.Render(line)
+		case entry.Kind == models.CompareOnlyB:
+			line = onlyB.Render(line)
+		default:
+			line = differs.Render(line)
+		}
+		content.WriteString(line + "\n")
+	}
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(strings.TrimSuffix(content.String(), "\n"))
+}
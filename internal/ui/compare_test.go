@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// compareKinds maps each compared name to its kind
+func compareKinds(m *AppModel) map[string]models.CompareKind {
+	kinds := make(map[string]models.CompareKind)
+	for _, entry := range m.CompareEntries {
+		kinds[entry.Name] = entry.Kind
+	}
+	return kinds
+}
+
+func TestCompareClassifiesAndCopies(t *testing.T) {
+	root := t.TempDir()
+	left, right := filepath.Join(root, "left"), filepath.Join(root, "right")
+	writeTree(t, left, "same.txt", "only-left.txt", "dir/inner.txt", "size.txt")
+	writeTree(t, right, "same.txt", "only-right/x.txt", "dir/inner.txt", "size.txt")
+	for path, content := range map[string]string{
+		filepath.Join(right, "size.txt"):    "longer content\n",
+		filepath.Join(left, "touched.txt"):  "alike\n",
+		filepath.Join(right, "touched.txt"): "alike\n",
+		filepath.Join(left, "edited.txt"):   "before\n",
+		filepath.Join(right, "edited.txt"):  "after!\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// same.txt is alike on both sides, the others only differ by time
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for path, modTime := range map[string]time.Time{
+		filepath.Join(left, "same.txt"):     t0,
+		filepath.Join(right, "same.txt"):    t0,
+		filepath.Join(left, "touched.txt"):  t0,
+		filepath.Join(right, "touched.txt"): t0.Add(time.Hour),
+		filepath.Join(left, "edited.txt"):   t0,
+		filepath.Join(right, "edited.txt"):  t0.Add(time.Hour),
+	} {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newTestModel(t, Options{Path: left})
+	press(t, m, "|")
+	typeText(t, m, "../right")
+	press(t, m, "enter")
+	if !m.CompareView || m.CompareB != right {
+		t.Fatalf("compare view %v against %q", m.CompareView, m.CompareB)
+	}
+	want := map[string]models.CompareKind{
+		"dir":           models.CompareSame,
+		"edited.txt":    models.CompareUnsure,
+		"only-left.txt": models.CompareOnlyA,
+		"only-right":    models.CompareOnlyB,
+		"same.txt":      models.CompareSame,
+		"size.txt":      models.CompareDiffers,
+		"touched.txt":   models.CompareUnsure,
+	}
+	wantKinds := func(what string) {
+		t.Helper()
+		got := compareKinds(m)
+		if len(got) != len(want) {
+			t.Errorf("%s: kinds %v, want %v", what, got, want)
+		}
+		for name, kind := range want {
+			if got[name] != kind {
+				t.Errorf("%s: %s is kind %d, want %d", what, name, got[name], kind)
+			}
+		}
+	}
+	wantKinds("listed")
+
+	// The content settles the files alike but for their times
+	press(t, m, "H")
+	want["edited.txt"], want["touched.txt"] = models.CompareDiffers, models.CompareSame
+	wantKinds("compared by content")
+	if m.StatusMessage != "Compared 2 files by content: 1 differ" {
+		t.Errorf("status %q", m.StatusMessage)
+	}
+
+	// Copying the missing entries across leaves nothing missing
+	press(t, m, "C")
+	wantExists(t, filepath.Join(right, "only-left.txt"))
+	wantExists(t, filepath.Join(left, "only-right", "x.txt"))
+	want["only-left.txt"], want["only-right"] = models.CompareSame, models.CompareSame
+	wantKinds("copied across")
+
+	// Entering a directory in both compares its contents
+	m.CompareSelected = 0
+	press(t, m, "l")
+	if m.CompareA != filepath.Join(left, "dir") || m.CompareDepth != 1 {
+		t.Errorf("entered %q at depth %d", m.CompareA, m.CompareDepth)
+	}
+	if kinds := compareKinds(m); len(kinds) != 1 || kinds["inner.txt"] == models.CompareOnlyA || kinds["inner.txt"] == models.CompareOnlyB {
+		t.Errorf("kinds below dir %v", kinds)
+	}
+}
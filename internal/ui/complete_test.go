@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// wantPrompt checks the prompt input and the candidates being cycled through
+func wantPrompt(t *testing.T, m *AppModel, input string, candidates ...string) {
+	t.Helper()
+	if m.PromptInput != input || !slices.Equal(m.PromptCandidates, candidates) {
+		t.Errorf("prompt %q with candidates %q, want %q with %q", m.PromptInput, m.PromptCandidates, input, candidates)
+	}
+}
+
+func TestPathPromptCompletion(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "docs/guide.md", "downloads/", "notes.txt", ".hidden/", "my files/")
+	m := newTestModel(t, Options{Path: dir})
+
+	// A unique match is taken, the next tab completes inside it
+	press(t, m, "C")
+	typeText(t, m, "no")
+	press(t, m, "tab")
+	wantPrompt(t, m, "notes.txt")
+	press(t, m, "esc")
+
+	press(t, m, "C")
+	typeText(t, m, "doc")
+	press(t, m, "tab", "tab")
+	wantPrompt(t, m, "docs/guide.md")
+	press(t, m, "esc")
+
+	// Several are cycled through both ways, and listed above the prompt
+	press(t, m, "C")
+	typeText(t, m, "do")
+	press(t, m, "tab")
+	wantPrompt(t, m, "docs/", "docs/", "downloads/")
+	if view := m.View(); !strings.Contains(view, "docs/  downloads/") {
+		t.Errorf("candidates are not listed:\n%s", view)
+	}
+	press(t, m, "tab")
+	wantPrompt(t, m, "downloads/", "docs/", "downloads/")
+	press(t, m, "tab")
+	wantPrompt(t, m, "docs/", "docs/", "downloads/")
+	press(t, m, "shift+tab")
+	wantPrompt(t, m, "downloads/", "docs/", "downloads/")
+
+	// Typing ends the cycle, the next tab completes the new input
+	press(t, m, "backspace")
+	wantPrompt(t, m, "downloads")
+	press(t, m, "tab")
+	wantPrompt(t, m, "downloads/")
+	press(t, m, "esc")
+
+	// Hidden entries only for a leading "."
+	press(t, m, "C", "tab")
+	if slices.Contains(m.PromptCandidates, ".hidden/") {
+		t.Errorf("hidden entry offered: %q", m.PromptCandidates)
+	}
+	press(t, m, "esc", "C")
+	typeText(t, m, ".")
+	press(t, m, "tab")
+	wantPrompt(t, m, ".hidden/")
+	press(t, m, "esc")
+
+	// Names with spaces complete escaped and are entered as named
+	press(t, m, "C")
+	typeText(t, m, "my")
+	press(t, m, "tab")
+	wantPrompt(t, m, `my\ files/`)
+	press(t, m, "enter")
+	wantDir(t, m, filepath.Join(dir, "my files"))
+}
+
+func TestPathPromptCompletesHome(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestModel(t, Options{Path: dir})
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, home, "projects/app/")
+
+	press(t, m, "C")
+	typeText(t, m, "~")
+	press(t, m, "tab")
+	wantPrompt(t, m, "~/")
+	press(t, m, "tab")
+	wantPrompt(t, m, "~/projects/")
+	press(t, m, "tab")
+	wantPrompt(t, m, "~/projects/app/")
+	press(t, m, "enter")
+	wantDir(t, m, filepath.Join(home, "projects", "app"))
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// handleDebugKeys closes the debug screen, reporting whether the key was
+// consumed. Everything but quitting is swallowed while it is shown.
+func (m *AppModel) handleDebugKeys(msg tea.KeyMsg) bool {
+	if m.keys[msg.String()] == actionDebug {
+		m.DebugScreen = false
+		return true
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		return false
+	case "esc", "q":
+		m.DebugScreen = false
+	}
+	return true
+}
+
+// renderDebugPane lists the timings and cache statistics collected since
+// startup, to attach to reports about slow directories
+func renderDebugPane(m *models.Model, cfg config.Config, width, height int) string {
+	s := m.Stats
+	lines := []string{
+		" Performance",
+		paneRule(cfg, width-2),
+		fmt.Sprintf(" Last directory load  %8s  %d entries in %s", formatDuration(s.DirLoad), s.DirEntries, s.DirLoadPath),
+		fmt.Sprintf(" Last preview         %8s  %s", formatDuration(s.Preview), s.PreviewPath),
+		fmt.Sprintf(" Entries stat'ed      %8d", s.EntriesStated),
+		fmt.Sprintf(" Terminal background  %s", cfg.Background),
+		fmt.Sprintf(" Image previews       %s", imageProtocolLabel(m)),
+		"",
+		fmt.Sprintf(" %-20s %8s %8s %6s", "Cache", "hits", "misses", "rate"),
+	}
+	for _, c := range []struct {
+		name  string
+		stats models.CacheStats
+	}{
+		{"Thumbnails", s.Thumbnails},
+		{"Video previews", s.Videos},
+		{"Git status", s.Git},
+		{"Symlink targets", s.LinkTargets},
+	} {
+		rate := "-"
+		if total := c.stats.Hits + c.stats.Misses; total > 0 {
+			rate = fmt.Sprintf("%d%%", c.stats.Hits*100/total)
+		}
+		lines = append(lines, fmt.Sprintf(" %-20s %8d %8d %6s", c.name, c.stats.Hits, c.stats.Misses, rate))
+	}
+	lines = append(lines, "")
+	lines = append(lines, dependencyLines(m.MissingTools)...)
+
+	for i, line := range lines {
+		lines[i] = TruncateString(line, max(0, width-2))
+	}
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// formatDuration rounds d for display, "-" before anything was measured
+func formatDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "-"
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	default:
+		return d.Round(10 * time.Microsecond).String()
+	}
+}
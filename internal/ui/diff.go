@@ -0,0 +1,192 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// isDiffFileByExtension detects diff and patch files by name.
+func isDiffFileByExtension(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".diff", ".patch":
+		return true
+	}
+	return false
+}
+
+// looksLikeDiff reports whether head is the start of a unified diff or a
+// git format-patch mail.
+func looksLikeDiff(head []byte) bool {
+	if bytes.HasPrefix(head, []byte("diff --git ")) {
+		return true
+	}
+	if bytes.HasPrefix(head, []byte("--- ")) {
+		return bytes.Contains(head, []byte("\n+++ "))
+	}
+	if bytes.HasPrefix(head, []byte("From ")) {
+		return bytes.Contains(head, []byte("\ndiff --git "))
+	}
+	return false
+}
+
+// diffLineKind classifies a line of a unified diff.
+type diffLineKind int
+
+const (
+	diffContext diffLineKind = iota
+	diffFileHeader
+	diffHunkHeader
+	diffAdded
+	diffRemoved
+)
+
+// diffStats counts the files and lines changed by a diff.
+type diffStats struct {
+	files, added, removed int
+}
+
+// classifyDiff tags every line of a diff. Hunk bodies are tracked with the
+// line counts from their "@@" header, so a removed line starting with "--"
+// is not mistaken for a file header.
+func classifyDiff(lines []string) ([]diffLineKind, diffStats) {
+	kinds := make([]diffLineKind, len(lines))
+	var stats diffStats
+	sawGitHeader := false
+	oldLeft, newLeft := 0, 0
+
+	for i, line := range lines {
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				kinds[i] = diffAdded
+				stats.added++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				kinds[i] = diffRemoved
+				stats.removed++
+				oldLeft--
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			kinds[i] = diffFileHeader
+			stats.files++
+			sawGitHeader = true
+		case strings.HasPrefix(line, "--- "):
+			kinds[i] = diffFileHeader
+		case strings.HasPrefix(line, "+++ "):
+			kinds[i] = diffFileHeader
+			if !sawGitHeader {
+				stats.files++
+			}
+		case strings.HasPrefix(line, "@@"):
+			kinds[i] = diffHunkHeader
+			oldLeft, newLeft = parseHunkHeader(line)
+		}
+	}
+	return kinds, stats
+}
+
+// parseHunkHeader returns the old and new line counts from a hunk header
+// such as "@@ -12,7 +12,9 @@ func main()". An omitted count means one line.
+func parseHunkHeader(line string) (int, int) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	return hunkRangeCount(fields[1], "-"), hunkRangeCount(fields[2], "+")
+}
+
+func hunkRangeCount(field, prefix string) int {
+	field, ok := strings.CutPrefix(field, prefix)
+	if !ok {
+		return 0
+	}
+	_, count, found := strings.Cut(field, ",")
+	if !found {
+		return 1
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// String formats the stats like "3 files changed, +120 −45".
+func (s diffStats) String() string {
+	return fmt.Sprintf("%d %s changed, +%d −%d", s.files, plural(s.files, "file", "files"), s.added, s.removed)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// renderDiffPreview colorizes a diff or patch and summarises its changes
+// above the content. Lines map one to one onto the file so goto-line and
+// opening at the preview line keep working.
+func renderDiffPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	content, truncated, err := fileutils.ReadHead(fullPath, previewReadLimit)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	kinds, stats := classifyDiff(lines)
+
+	var sb strings.Builder
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	sb.WriteString(stats.String())
+	if truncated {
+		sb.WriteString(" (in the previewed part)")
+	}
+	sb.WriteString("\n\n")
+	m.PreviewContentStart = strings.Count(sb.String(), "\n")
+
+	added := newStyle(cfg).Foreground(lipgloss.Color(cfg.DiffAddedColor))
+	removed := newStyle(cfg).Foreground(lipgloss.Color(cfg.DiffRemovedColor))
+	hunk := newStyle(cfg).Foreground(lipgloss.Color(cfg.DiffHunkColor))
+	header := newStyle(cfg).Bold(true)
+
+	for i, line := range lines {
+		// Tabs would be expanded by the terminal past the pane's edge
+		line = strings.ReplaceAll(line, "\t", "    ")
+		switch kinds[i] {
+		case diffFileHeader:
+			line = header.Render(line)
+		case diffHunkHeader:
+			line = hunk.Render(line)
+		case diffAdded:
+			line = added.Render(line)
+		case diffRemoved:
+			line = removed.Render(line)
+		}
+		sb.WriteString(line)
+		if i < len(lines)-1 {
+			sb.WriteString("\n")
+		}
+	}
+	if truncated {
+		sb.WriteString("\n\n... (file truncated for preview)")
+	}
+	setPreview(m, sb.String())
+}
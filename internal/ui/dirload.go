@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// listCounts are the stat calls and symlink cache lookups of listing a
+// directory, added to the debug screen's stats once the listing is used
+type listCounts struct {
+	stated, linkHits, linkMisses int
+}
+
+// record adds the counts to stats
+func (c listCounts) record(stats *models.PerfStats) {
+	stats.EntriesStated += c.stated + c.linkMisses
+	stats.LinkTargets.Hits += c.linkHits
+	stats.LinkTargets.Misses += c.linkMisses
+}
+
+// listDir lists dir on the real filesystem with sort sizes taken from the
+// allocated size or symlink targets as asked. Filling linkTargets aside it
+// shares no state with the model, so given a map of its own it runs off the
+// UI goroutine.
+func listDir(ctx context.Context, dir string, diskSize, followSymlinks bool, linkTargets map[string]fs.FileInfo) ([]models.FileInfo, listCounts, error) {
+	var counts listCounts
+	files, err := fileutils.ReadDirWithInfoContext(ctx, dir)
+	if err != nil {
+		return nil, counts, err
+	}
+	if len(files) > 0 && !files[0].Virtual {
+		counts.stated = len(files)
+	}
+	if diskSize {
+		fileutils.SortByDiskSize(files)
+	}
+	if followSymlinks {
+		counts.linkHits, counts.linkMisses = fileutils.FollowSymlinks(dir, files, linkTargets, diskSize)
+	}
+	return files, counts, nil
+}
+
+// dirRead is the outcome of listing one directory in the background
+type dirRead struct {
+	files []models.FileInfo
+	err   error
+}
+
+// dirLoadedMsg delivers the current directory and its ancestors read by
+// startDirLoad. seq tells a load apart from those it superseded.
+type dirLoadedMsg struct {
+	seq         int
+	reads       map[string]dirRead
+	linkTargets map[string]fs.FileInfo // Symlink targets stat'ed on the way
+	counts      listCounts
+	elapsed     time.Duration
+}
+
+// startDirLoad reads the current directory, and the ancestors its columns
+// show, in the background. Until the listing arrives the pane shows a
+// placeholder and keys keep working; navigating elsewhere or reloading
+// synchronously cancels the load. Archives are in memory and macro replays
+// expect each key to see the previous one's listing, so they load at once.
+func (m *AppModel) startDirLoad() {
+	if m.ArchiveFS != nil || m.replaying {
+		m.loadCurrentDir()
+		return
+	}
+	m.cancelDirLoad()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.loadCancel = cancel
+	m.Loading = true
+	m.Files = nil
+	m.Ancestors = m.Ancestors[:0]
+	m.LargestFileSize = 0
+	UpdatePreview(m.Model, m.config)
+
+	dirs := []string{m.CurrentDir}
+	for child := m.CurrentDir; len(dirs) < m.Columns-1; {
+		dir := filepath.Dir(child)
+		if dir == child {
+			break
+		}
+		dirs = append(dirs, dir)
+		child = dir
+	}
+	seq, diskSize, followSymlinks := m.loadSeq, m.SortDiskSize, m.SortFollowSymlinks
+	m.dirLoad = func() tea.Msg {
+		start := time.Now()
+		msg := dirLoadedMsg{seq: seq, reads: make(map[string]dirRead), linkTargets: make(map[string]fs.FileInfo)}
+		for _, dir := range dirs {
+			files, counts, err := listDir(ctx, dir, diskSize, followSymlinks, msg.linkTargets)
+			if ctx.Err() != nil {
+				return nil
+			}
+			msg.reads[dir] = dirRead{files, err}
+			msg.counts.stated += counts.stated
+			msg.counts.linkHits += counts.linkHits
+			msg.counts.linkMisses += counts.linkMisses
+		}
+		msg.elapsed = time.Since(start)
+		return msg
+	}
+}
+
+// takeDirLoad hands the load startDirLoad prepared to the runtime, once
+func (m *AppModel) takeDirLoad() tea.Cmd {
+	cmd := m.dirLoad
+	m.dirLoad = nil
+	return cmd
+}
+
+// cancelDirLoad abandons the background load in flight, if any, so its
+// listing is dropped should it still arrive
+func (m *AppModel) cancelDirLoad() {
+	if m.loadCancel != nil {
+		m.loadCancel()
+		m.loadCancel = nil
+	}
+	m.loadSeq++
+	m.dirLoad = nil
+	m.Loading = false
+}
+
+// handleDirLoaded shows the listing of the load still current
+func (m *AppModel) handleDirLoaded(msg dirLoadedMsg) {
+	if msg.seq != m.loadSeq || !m.Loading {
+		return
+	}
+	for path, target := range msg.linkTargets {
+		m.LinkTargets[path] = target
+	}
+	msg.counts.record(&m.Stats)
+	m.preloaded = msg.reads
+	m.loadCurrentDir()
+	m.preloaded = nil
+	m.Stats.DirLoad = msg.elapsed
+}
+
+// readListing lists dir, taking the listing the finished background load
+// read when there is one
+func (m *AppModel) readListing(dir string) ([]models.FileInfo, error) {
+	if read, ok := m.preloaded[dir]; ok {
+		return read.files, read.err
+	}
+	return readDir(m.Model, dir)
+}
+
+// loadingText is the placeholder shown while a listing loads
+func loadingText(cfg config.Config) string {
+	if !cfg.Unicode {
+		return "Loading..."
+	}
+	return "Loading…"
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+)
+
+// editorLineTemplates holds the line-positioning arguments of well known editors
+var editorLineTemplates = map[string]string{
+	"nvim":  "+%l %f",
+	"vim":   "+%l %f",
+	"vi":    "+%l %f",
+	"nano":  "+%l %f",
+	"micro": "+%l %f",
+	"emacs": "+%l %f",
+	"hx":    "%f:%l",
+	"kak":   "+%l %f",
+	"code":  "-g %f:%l",
+	"subl":  "%f:%l",
+}
+
+// editorCommand builds the command opening path in $EDITOR, positioned at
+// line when it is non-zero and the editor's line syntax is known
+func editorCommand(cfg config.Config, path string, line int) *exec.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "nvim"
+	}
+	args := strings.Fields(editor)
+
+	template := cfg.EditorLineTemplate
+	if template == "" {
+		template = editorLineTemplates[filepath.Base(args[0])]
+	}
+
+	if line <= 0 || template == "" {
+		return exec.Command(args[0], append(args[1:], path)...)
+	}
+
+	for _, field := range strings.Fields(template) {
+		field = strings.ReplaceAll(field, "%l", strconv.Itoa(line))
+		args = append(args, strings.ReplaceAll(field, "%f", path))
+	}
+	return exec.Command(args[0], args[1:]...)
+}
+
+// ruleCommand builds the command of an [openers] rule for path, in place of
+// each {file} or appended when the rule has none
+func ruleCommand(rule config.OpenRule, path string) *exec.Cmd {
+	args := make([]string, 0, len(rule.Command)+1)
+	placed := false
+	for _, arg := range rule.Command {
+		if strings.Contains(arg, "{file}") {
+			arg = strings.ReplaceAll(arg, "{file}", path)
+			placed = true
+		}
+		args = append(args, arg)
+	}
+	if !placed {
+		args = append(args, path)
+	}
+	return exec.Command(args[0], args[1:]...)
+}
+
+// openerCommand builds the command handing path to the application the
+// desktop associates with it: opener_command when set, else the platform's
+// opener
+func openerCommand(cfg config.Config, path string) *exec.Cmd {
+	args := strings.Fields(cfg.OpenerCommand)
+	if len(args) == 0 {
+		switch runtime.GOOS {
+		case "darwin":
+			args = []string{"open"}
+		case "windows":
+			// start takes a quoted first argument as the window title
+			args = []string{"cmd", "/c", "start", ""}
+		default:
+			args = []string{"xdg-open"}
+		}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// openerDoneMsg reports that a command started by runDetached exited
+type openerDoneMsg struct {
+	name string
+	err  error
+}
+
+// runDetached runs cmd without the terminal so the TUI keeps running, for
+// openers and GUI programs. A failure, with the last line the command wrote,
+// shows in the status bar.
+func runDetached(cmd *exec.Cmd) tea.Cmd {
+	var stderr bytes.Buffer
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = nil, nil, &stderr
+	name := filepath.Base(cmd.Args[0])
+	return func() tea.Msg {
+		err := cmd.Run()
+		if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); err != nil && lines[len(lines)-1] != "" {
+			err = fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+		}
+		return openerDoneMsg{name: name, err: err}
+	}
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Options configure a model beyond the user's config, for the command line
+// and for programs embedding the browser through pkg/browser
+type Options struct {
+	Path       string // Directory or file to start at, the working directory when empty
+	Select     string // Entry to select in the starting directory
+	Chooser    bool   // Opening chooses the marked entries, else the selected file, sending ChosenMsg
+	Standalone bool   // The model is the whole program, quitting ends it rather than sending QuitMsg
+	FS         fs.FS  // Browsed read-only instead of the real filesystem, Path is then a slash-separated path in it
+}
+
+// ChosenMsg is sent when an entry is chosen in chooser mode. Paths are
+// absolute, or slash-separated paths within Options.FS when it is set.
+type ChosenMsg struct {
+	Paths []string
+}
+
+// QuitMsg is sent instead of ending the program when the quit key is
+// pressed in a model that is not standalone
+type QuitMsg struct{}
+
+// fsRoot is the path the root of Options.FS is listed under, which has no
+// ancestors to show
+const fsRoot = string(filepath.Separator)
+
+// resolveStart returns the directory to open for opts and the entry to
+// select in it
+func resolveStart(opts Options) (string, string, error) {
+	if opts.FS != nil {
+		name := path.Clean("/" + filepath.ToSlash(opts.Path))[1:]
+		if name == "" {
+			return fsRoot, opts.Select, nil
+		}
+		info, err := fs.Stat(opts.FS, name)
+		if err != nil {
+			return "", "", err
+		}
+		dir := filepath.Join(fsRoot, filepath.FromSlash(name))
+		if info.IsDir() {
+			return dir, opts.Select, nil
+		}
+		if opts.Select == "" {
+			opts.Select = filepath.Base(dir)
+		}
+		return filepath.Dir(dir), opts.Select, nil
+	}
+	if opts.Path == "" {
+		dir, err := os.Getwd()
+		return dir, opts.Select, err
+	}
+	return resolveStartPath(opts.Path, opts.Select)
+}
+
+// quit ends the program, or tells the embedding program to close the
+// browser
+func (m *AppModel) quit() tea.Cmd {
+	if m.standalone {
+		return tea.Quit
+	}
+	return func() tea.Msg { return QuitMsg{} }
+}
+
+// chooseSelected sends the marked entries, else the selected one, as a ChosenMsg.
+// A selected directory is entered instead, directories are chosen by
+// marking them.
+func (m *AppModel) chooseSelected() tea.Cmd {
+	if len(m.Marked) == 0 && len(m.Files) > 0 && m.Files[m.Selected].IsDir() {
+		return m.enterSelected()
+	}
+	if m.ArchiveFS != nil && !m.RootFS {
+		m.StatusMessage = "Archive entries cannot be chosen, press y to extract"
+		return nil
+	}
+	paths := m.targets()
+	if len(paths) == 0 {
+		return nil
+	}
+	if m.RootFS {
+		for i, p := range paths {
+			paths[i], _ = archiveEntry(m.Model, p)
+		}
+	}
+	m.clearMarks()
+	return func() tea.Msg { return ChosenMsg{Paths: paths} }
+}
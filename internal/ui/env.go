@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// secretMask replaces the value of a masked env entry
+const secretMask = "••••"
+
+// isEnvFile detects dotenv files such as .env, .env.local or prod.env.
+func isEnvFile(fileName string) bool {
+	name := strings.ToLower(fileName)
+	return name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env")
+}
+
+// maskEnvSecrets replaces the values of keys matching pattern, leaving key
+// names, comments and layout untouched. It returns the masked text and how
+// many values were hidden.
+func maskEnvSecrets(content string, pattern *regexp.Regexp) (string, int) {
+	lines := strings.Split(content, "\n")
+	masked := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:eq])
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+		value := line[eq+1:]
+		if !pattern.MatchString(key) || strings.TrimSpace(value) == "" {
+			continue
+		}
+		spacing := value[:len(value)-len(strings.TrimLeft(value, " \t"))]
+		lines[i] = line[:eq+1] + spacing + secretMask
+		masked++
+	}
+	return strings.Join(lines, "\n"), masked
+}
+
+// renderEnvPreview shows a dotenv file with secret values masked unless they
+// were revealed for this file. It also serves as the raw view, so toggling
+// raw mode never bypasses the masking.
+func renderEnvPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	content, truncated, err := fileutils.ReadHead(fullPath, previewReadLimit)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+	renderEnvContent(m, cfg, selectedFile, fullPath, content, truncated)
+}
+
+// renderEnvContent shows the content read from a dotenv file, on disk or in
+// an archive, masking it before it is stored as the preview
+func renderEnvContent(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string, content []byte, truncated bool) {
+	text := string(content)
+	note := ""
+	switch {
+	case m.RevealSecretsPath == fullPath:
+		note = "Secrets revealed, press U to mask them again"
+	case cfg.MaskEnvSecrets:
+		var masked int
+		text, masked = maskEnvSecrets(text, cfg.EnvSecretRegexp)
+		if masked > 0 {
+			note = fmt.Sprintf("%d %s masked, press U to reveal", masked, plural(masked, "value", "values"))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	if note != "" {
+		sb.WriteString(note + "\n")
+	}
+	sb.WriteString("\n")
+	m.PreviewContentStart = strings.Count(sb.String(), "\n")
+
+	if text == "" {
+		sb.WriteString("(empty file)")
+	} else {
+		sb.WriteString(text)
+	}
+	if truncated {
+		sb.WriteString("\n\n... (file truncated for preview)")
+	}
+	setPreview(m, sb.String())
+}
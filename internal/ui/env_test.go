@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const envFixture = "# Credentials\nexport DB_PASSWORD=hunter2\nAPI_KEY =  \"sk-live-1\"\nUSER=admin\nEMPTY_TOKEN=\nsecret_value=lower\n"
+
+func TestMaskEnvSecrets(t *testing.T) {
+	pattern := regexp.MustCompile("(?i)PASSWORD|SECRET|TOKEN|KEY")
+	got, masked := maskEnvSecrets(envFixture, pattern)
+	want := "# Credentials\nexport DB_PASSWORD=••••\nAPI_KEY =  ••••\nUSER=admin\nEMPTY_TOKEN=\nsecret_value=••••\n"
+	if got != want || masked != 3 {
+		t.Errorf("masked %d:\n%s\nwant 3:\n%s", masked, got, want)
+	}
+	if got, masked := maskEnvSecrets(envFixture, regexp.MustCompile("(?i)^USER$")); masked != 1 || !strings.Contains(got, "USER=••••") || !strings.Contains(got, "hunter2") {
+		t.Errorf("masked %d with a custom pattern:\n%s", masked, got)
+	}
+}
+
+// previewText is the stored preview
+func previewText(m *AppModel) string {
+	return strings.Join(m.PreviewLines, "\n")
+}
+
+// wantMasked checks that neither the stored preview nor the view holds the
+// secret values
+func wantMasked(t *testing.T, m *AppModel, what string) {
+	t.Helper()
+	for _, secret := range []string{"hunter2", "sk-live-1", "lower"} {
+		if strings.Contains(previewText(m), secret) || strings.Contains(m.View(), secret) {
+			t.Errorf("%s: %s is shown:\n%s", what, secret, previewText(m))
+		}
+	}
+	if !strings.Contains(previewText(m), "USER=admin") || !strings.Contains(previewText(m), "3 values masked") {
+		t.Errorf("%s: keys or note missing:\n%s", what, previewText(m))
+	}
+}
+
+func TestEnvSecretsRevealTransiently(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt", "notes.txt")
+	if err := os.WriteFile(filepath.Join(dir, "prod.env"), []byte(envFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, Options{Path: dir})
+	selectName(t, m, "prod.env")
+	wantMasked(t, m, "selected")
+
+	// Revealed for this file until toggled back
+	press(t, m, "U")
+	if !strings.Contains(previewText(m), "DB_PASSWORD=hunter2") || !strings.Contains(m.View(), "revealed") {
+		t.Errorf("not revealed:\n%s", m.View())
+	}
+	press(t, m, "U")
+	wantMasked(t, m, "toggled back")
+
+	// or until the selection moves, coming back masks again
+	press(t, m, "U", "k")
+	press(t, m, "j")
+	wantMasked(t, m, "selected again")
+	press(t, m, "U", "h", "l")
+	wantMasked(t, m, "entered again")
+	if m.RevealSecretsPath != "" {
+		t.Errorf("RevealSecretsPath = %q after moving away", m.RevealSecretsPath)
+	}
+
+	// Raw mode does not bypass the masking
+	press(t, m, "R")
+	wantMasked(t, m, "raw")
+	press(t, m, "U")
+	if !strings.Contains(previewText(m), "hunter2") {
+		t.Errorf("raw preview not revealed:\n%s", previewText(m))
+	}
+	press(t, m, "U")
+	wantMasked(t, m, "raw toggled back")
+
+	// U does nothing for other files
+	selectName(t, m, "notes.txt")
+	press(t, m, "U")
+	if m.RevealSecretsPath != "" {
+		t.Errorf("revealed %q", m.RevealSecretsPath)
+	}
+}
+
+func TestEnvSecretsConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newConfiguredModel(t, Options{Path: dir}, "mask_env_secrets = false\n")
+	press(t, m, ".")
+	selectName(t, m, ".env")
+	if !strings.Contains(previewText(m), "hunter2") || strings.Contains(previewText(m), "masked") {
+		t.Errorf("masked with mask_env_secrets = false:\n%s", previewText(m))
+	}
+
+	// An invalid pattern keeps the default one
+	m = newConfiguredModel(t, Options{Path: dir}, "env_secret_pattern = \"(\"\n")
+	press(t, m, ".")
+	selectName(t, m, ".env")
+	wantMasked(t, m, "invalid pattern")
+	m = newConfiguredModel(t, Options{Path: dir}, "env_secret_pattern = \"^user$\"\n")
+	press(t, m, ".")
+	selectName(t, m, ".env")
+	if !strings.Contains(previewText(m), "USER=••••") || !strings.Contains(previewText(m), "hunter2") {
+		t.Errorf("custom pattern not applied:\n%s", previewText(m))
+	}
+}
+
+func TestEnvSecretsMaskedInArchives(t *testing.T) {
+	fsys := fstest.MapFS{"app/.env.local": {Data: []byte(envFixture), Mode: 0o644}}
+	m := newTestModel(t, Options{FS: fsys, Path: "/app"})
+	press(t, m, ".")
+	selectName(t, m, ".env.local")
+	wantMasked(t, m, "archive entry")
+	press(t, m, "U")
+	if !strings.Contains(previewText(m), "hunter2") {
+		t.Errorf("archive entry not revealed:\n%s", previewText(m))
+	}
+}
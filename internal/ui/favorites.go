@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// favoriteMark returns the indicator after starred entries in the listing
+func favoriteMark(cfg config.Config) string {
+	if !cfg.Color || !cfg.Unicode {
+		return "*"
+	}
+	return "★"
+}
+
+// toggleFavorite stars or unstars the selected entry and saves the favorites
+func (m *AppModel) toggleFavorite() {
+	if len(m.Files) == 0 {
+		return
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Entries inside archives cannot be starred"
+		return
+	}
+	path := filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name())
+	starred := !m.Favorites[path]
+	if starred {
+		m.Favorites[path] = true
+		m.StatusMessage = "Starred " + displayName(filepath.Base(path))
+	} else {
+		delete(m.Favorites, path)
+		m.StatusMessage = "Unstarred " + displayName(filepath.Base(path))
+	}
+	m.saveFavorites(func(favorites map[string]bool) {
+		if starred {
+			favorites[path] = true
+		} else {
+			delete(favorites, path)
+		}
+	})
+}
+
+// saveFavorites applies change to the saved favorites too, picking up what
+// other instances starred meanwhile. On failure the change is kept for this
+// session only.
+func (m *AppModel) saveFavorites(change func(favorites map[string]bool)) {
+	favorites, err := config.UpdateFavorites(change)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error saving favorites: %v", err)
+		return
+	}
+	m.Favorites = favorites
+}
+
+// openFavorites shows the favorites view, checking which entries still exist
+func (m *AppModel) openFavorites() {
+	m.FavoriteList = config.SortedFavorites(m.Favorites)
+	m.StaleFavorites = make(map[string]bool)
+	for _, path := range m.FavoriteList {
+		if _, err := os.Lstat(path); err != nil {
+			m.StaleFavorites[path] = true
+		}
+	}
+	m.FavoriteSelected = 0
+	m.FavoritesView = true
+}
+
+// pruneFavorites forgets the favorites that no longer exist
+func (m *AppModel) pruneFavorites() {
+	if len(m.StaleFavorites) == 0 {
+		m.StatusMessage = "No missing favorites"
+		return
+	}
+	var kept []string
+	for _, path := range m.FavoriteList {
+		if m.StaleFavorites[path] {
+			delete(m.Favorites, path)
+		} else {
+			kept = append(kept, path)
+		}
+	}
+	m.StatusMessage = fmt.Sprintf("Pruned %d missing favorites", len(m.StaleFavorites))
+	stale := m.StaleFavorites
+	m.FavoriteList = kept
+	m.StaleFavorites = make(map[string]bool)
+	m.FavoriteSelected = min(m.FavoriteSelected, max(0, len(kept)-1))
+	m.saveFavorites(func(favorites map[string]bool) {
+		for path := range stale {
+			delete(favorites, path)
+		}
+	})
+}
+
+// handleFavoritesKeys moves through the favorites view and jumps to the
+// chosen entry, reporting whether the key was consumed
+func (m *AppModel) handleFavoritesKeys(msg tea.KeyMsg) bool {
+	switch m.keys[msg.String()] {
+	case actionFavorites:
+		m.FavoritesView = false
+		return true
+	case actionUp:
+		m.FavoriteSelected = max(0, m.FavoriteSelected-1)
+		return true
+	case actionDown:
+		m.FavoriteSelected = max(0, min(len(m.FavoriteList)-1, m.FavoriteSelected+1))
+		return true
+	case actionTop:
+		m.FavoriteSelected = 0
+		return true
+	case actionBottom:
+		m.FavoriteSelected = max(0, len(m.FavoriteList)-1)
+		return true
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return false
+	case "enter", "l":
+		if len(m.FavoriteList) == 0 {
+			return true
+		}
+		path := m.FavoriteList[m.FavoriteSelected]
+		if m.StaleFavorites[path] {
+			m.StatusMessage = displayName(path) + " no longer exists"
+			return true
+		}
+		m.FavoritesView = false
+		m.navigateTo(filepath.Dir(path), filepath.Base(path))
+	case "D": // Prune missing entries
+		m.pruneFavorites()
+	case "esc", "q":
+		m.FavoritesView = false
+	}
+	return true
+}
+
+// renderFavoritesPane lists the starred paths, those that went missing
+// dimmed, scrolled to keep the selected one visible
+func renderFavoritesPane(m *models.Model, cfg config.Config, width, height int) string {
+	var content strings.Builder
+	paneContentWidth := max(0, width-2)
+	content.WriteString(truncatePaneTitle("Favorites", fmt.Sprintf(" (%d)", len(m.FavoriteList)), paneContentWidth) + "\n")
+	content.WriteString(paneRule(cfg, width-2) + "\n")
+
+	if len(m.FavoriteList) == 0 {
+		content.WriteString(" No favorites, star entries with *")
+	}
+	rows := max(1, height-2)
+	start := max(0, m.FavoriteSelected-rows+1)
+	end := min(start+rows, len(m.FavoriteList))
+	stale := newStyle(cfg).Foreground(lipgloss.Color(cfg.HiddenFileColor)).Faint(true)
+	for i := start; i < end; i++ {
+		path := m.FavoriteList[i]
+		prefix := " "
+		if i == m.FavoriteSelected && !cfg.Color {
+			prefix = ">"
+		}
+		line := prefix + displayName(path)
+		if m.StaleFavorites[path] {
+			line += " (missing)"
+		}
+		line = TruncateString(line, paneContentWidth)
+		switch {
+		case i == m.FavoriteSelected:
+			line = GetPreviewHighlightStyle(cfg).Render(line)
+		case m.StaleFavorites[path]:
+			line = stale.Render(line)
+		}
+		content.WriteString(line + "\n")
+	}
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(strings.TrimSuffix(content.String(), "\n"))
+}
@@ -0,0 +1,673 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// deleteSummaryLimit caps the entries walked to describe a directory in the
+// delete confirmation
+const deleteSummaryLimit = 10000
+
+// confirmNames is how many of the entries a delete or trash confirmation
+// names before counting the rest
+const confirmNames = 3
+
+// confirmDelete asks before permanently deleting the marked entries, or the
+// selected one. Directories are removed with everything in them, so their prompt
+// says how much that is.
+func (m *AppModel) confirmDelete() {
+	if len(m.Files) == 0 && len(m.Marked) == 0 {
+		return
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only"
+		return
+	}
+	paths, files := existingTargets(m.targets())
+	if len(files) == 0 {
+		m.StatusMessage = "Nothing to delete, the marked entries are gone"
+		m.clearMarks()
+		return
+	}
+
+	prompt := currentDirWarning(m.CurrentDir, paths) + selectionPrompt("Permanently delete", m.CurrentDir, files)
+	m.confirm(prompt, func() tea.Cmd {
+		m.deletePaths(paths)
+		return nil
+	})
+}
+
+// existingTargets keeps the paths that still exist, along with their info
+func existingTargets(targets []string) ([]string, []models.FileInfo) {
+	var paths []string
+	var files []models.FileInfo
+	for _, path := range targets {
+		if info, err := os.Lstat(path); err == nil {
+			paths = append(paths, path)
+			files = append(files, models.FileInfo{Entry: fs.FileInfoToDirEntry(info), Size: info.Size()})
+		}
+	}
+	return paths, files
+}
+
+// selectionPrompt asks whether to verb the files of dir, saying how many
+// entries and bytes that is and naming the first few, e.g. "Trash 4 items
+// (1 dir, 3 files, 2.1 MB): a, b, c and 1 more? (y/n)"
+func selectionPrompt(verb, dir string, files []models.FileInfo) string {
+	summary := fileutils.SummarizeSelection(dir, files, deleteSummaryLimit)
+	return fmt.Sprintf("%s %s: %s? (y/n)", verb, summary, leadingNames(files))
+}
+
+// currentDirWarning warns, ahead of a confirmation, that paths include dir
+// or one of its ancestors. The listing then moves up to the nearest
+// directory left. "" when none of them is.
+func currentDirWarning(dir string, paths []string) string {
+	for _, path := range paths {
+		switch {
+		case !fileutils.IsSameOrAncestor(path, dir):
+		case sameFile(path, dir):
+			return "Warning: this is the directory you are in! "
+		default:
+			return fmt.Sprintf("Warning: %s holds the directory you are in! ", displayName(filepath.Base(path)))
+		}
+	}
+	return ""
+}
+
+// sameFile reports whether a and b are the same file, once symlinks are
+// followed
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// leadingNames names the first confirmNames files, counting the others
+func leadingNames(files []models.FileInfo) string {
+	shown := make([]string, 0, confirmNames)
+	for _, file := range files[:min(len(files), confirmNames)] {
+		shown = append(shown, displayName(file.Entry.Name()))
+	}
+	names := strings.Join(shown, ", ")
+	if rest := len(files) - len(shown); rest > 0 {
+		names += fmt.Sprintf(" and %d more", rest)
+	}
+	return names
+}
+
+// deletePaths removes paths, directories recursively, and reloads the
+// listing with the cursor left on the same index
+func (m *AppModel) deletePaths(paths []string) {
+	var failures []error
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	m.clearMarks()
+	// Reload either way, a failed RemoveAll may have deleted part of the tree
+	m.loadCurrentDir()
+	switch {
+	case len(failures) > 0 && len(paths) == 1:
+		m.StatusMessage = fmt.Sprintf("Error deleting: %v", failures[0])
+	case len(failures) > 0:
+		m.StatusMessage = fmt.Sprintf("Deleted %d of %d entries, error: %v", len(paths)-len(failures), len(paths), failures[0])
+	case len(paths) == 1:
+		m.StatusMessage = "Deleted " + displayName(filepath.Base(paths[0]))
+	default:
+		m.StatusMessage = fmt.Sprintf("Deleted %d entries", len(paths))
+	}
+}
+
+// promptRename asks for a new name for the selected entry, starting from
+// the current one, and keeps it selected after the rename. The repeat key
+// then makes the same edit to the name of the entry selected.
+func (m *AppModel) promptRename() {
+	if len(m.Files) == 0 {
+		return
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only"
+		return
+	}
+	file := m.Files[m.Selected]
+	oldName := file.Entry.Name()
+	m.prompt("Rename to: ", func(input string) tea.Cmd {
+		if m.renamePath(oldName, input) {
+			m.setRepeatAction("rename "+oldName+" to "+input, func() tea.Cmd {
+				m.renameLike(oldName, input)
+				return nil
+			})
+		}
+		return nil
+	})
+	m.setPromptInput(oldName)
+	// The stem is what usually changes, the cursor waits before the
+	// extension and ctrl+a selects the whole name
+	m.promptSelect = true
+	if !file.Entry.IsDir() {
+		stem, _ := fileutils.SplitExt(oldName)
+		m.PromptCursor = len([]rune(stem))
+	}
+}
+
+// renameLike renames the selected entry with the edit that turned oldName
+// into newName, for the repeat key
+func (m *AppModel) renameLike(oldName, newName string) {
+	if len(m.Files) == 0 || m.ArchiveFS != nil {
+		return
+	}
+	name := m.Files[m.Selected].Entry.Name()
+	renamed, ok := fileutils.RenameLike(oldName, newName, name)
+	if !ok {
+		m.StatusMessage = fmt.Sprintf("The rename of %s to %s does not apply to %s", displayName(oldName), displayName(newName), displayName(name))
+		return
+	}
+	m.renamePath(name, renamed)
+}
+
+// renamePath renames oldName in the current directory to newName, refusing
+// to replace an existing entry. It reports whether the entry was renamed.
+func (m *AppModel) renamePath(oldName, newName string) bool {
+	if newName == "" || newName == oldName {
+		return false
+	}
+	if newName == "." || newName == ".." || strings.ContainsRune(newName, '/') || strings.ContainsRune(newName, 0) {
+		m.StatusMessage = fmt.Sprintf("Invalid name: %q", newName)
+		return false
+	}
+	oldPath := filepath.Join(m.CurrentDir, oldName)
+	newPath := filepath.Join(m.CurrentDir, newName)
+	if fileutils.Collides(oldPath, newPath) {
+		m.StatusMessage = existsMessage(newPath, "already exists")
+		return false
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		m.StatusMessage = fmt.Sprintf("Error renaming: %v", err)
+		return false
+	}
+	m.loadCurrentDir()
+	// A normalizing filesystem may list the new name in another form
+	if name, ok := fileutils.ExistingName(newPath); ok {
+		newName = name
+	}
+	if i := m.indexByName(newName); i >= 0 {
+		m.selectIndex(i)
+	}
+	m.StatusMessage = fmt.Sprintf("Renamed %s to %s", displayName(oldName), displayName(newName))
+	if info, err := os.Lstat(newPath); err == nil && !info.IsDir() {
+		m.StatusMessage += extensionChange(oldName, newName)
+	}
+	return true
+}
+
+// extensionChange warns when a rename changes or drops the extension, which
+// decides how the file is opened, "" when it is kept
+func extensionChange(oldName, newName string) string {
+	_, oldExt := fileutils.SplitExt(oldName)
+	_, newExt := fileutils.SplitExt(newName)
+	switch {
+	case strings.EqualFold(oldExt, newExt):
+		return ""
+	case newExt == "":
+		return fmt.Sprintf(", note the extension %s was dropped", oldExt)
+	case oldExt == "":
+		return fmt.Sprintf(", note it now has the extension %s", newExt)
+	}
+	return fmt.Sprintf(", note the extension changed from %s to %s", oldExt, newExt)
+}
+
+// lookalikeMark returns the indicator after entries whose names only differ
+// from another's in case or Unicode normalization
+func lookalikeMark(cfg config.Config) string {
+	if !cfg.Unicode {
+		return "="
+	}
+	return "≈"
+}
+
+// existsMessage tells that the entry at path exists, as "notes already
+// exists", naming the entry it collides with when the filesystem matched it
+// despite case or normalization
+func existsMessage(path, exists string) string {
+	name := filepath.Base(path)
+	if existing, _ := fileutils.ExistingName(path); existing != "" && existing != name {
+		return fmt.Sprintf("%s %s as %s", displayName(name), exists, displayName(existing))
+	}
+	return displayName(name) + " " + exists
+}
+
+// promptCreate asks for the name of a new empty file, or directory with
+// isDir, in the current directory. Names may be nested paths such as
+// foo/bar/baz, intermediate directories are created.
+func (m *AppModel) promptCreate(isDir bool) {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only"
+		return
+	}
+	label := "New file: "
+	if isDir {
+		label = "New directory: "
+	}
+	m.prompt(label, func(input string) tea.Cmd {
+		m.createPath(input, isDir)
+		return nil
+	})
+}
+
+// createPath creates name below the current directory and selects the entry
+// of the current directory it ended up in
+func (m *AppModel) createPath(name string, isDir bool) {
+	name = strings.TrimSuffix(name, "/")
+	if name == "" {
+		return
+	}
+	if err := validateNewPath(name); err != nil {
+		m.StatusMessage = fmt.Sprintf("Invalid name %q: %v", name, err)
+		return
+	}
+	fullPath := filepath.Join(m.CurrentDir, name)
+	if _, err := os.Lstat(fullPath); err == nil {
+		m.StatusMessage = existsMessage(fullPath, "already exists")
+		return
+	}
+
+	var err error
+	if isDir {
+		err = os.MkdirAll(fullPath, 0o755)
+	} else if err = os.MkdirAll(filepath.Dir(fullPath), 0o755); err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644); err == nil {
+			err = f.Close()
+		}
+	}
+	m.loadCurrentDir()
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error creating %s: %v", displayName(name), err)
+		return
+	}
+	top, _, _ := strings.Cut(name, "/")
+	if i := m.indexByName(top); i >= 0 {
+		m.selectIndex(i)
+	}
+	m.StatusMessage = "Created " + displayName(name)
+}
+
+// validateNewPath rejects names that would leave the current directory or
+// cannot be file names
+func validateNewPath(name string) error {
+	if filepath.IsAbs(name) {
+		return fmt.Errorf("must be relative to the current directory")
+	}
+	for _, part := range strings.Split(name, "/") {
+		switch {
+		case part == "", part == ".", part == "..":
+			return fmt.Errorf("empty, . or .. component")
+		case strings.ContainsRune(part, 0):
+			return fmt.Errorf("contains a NUL byte")
+		}
+	}
+	return nil
+}
+
+// promptChmod asks for the new mode of the selected entry, octal or
+// symbolic. For a directory it then asks whether to recurse. The repeat key
+// then applies the same mode to the entry selected.
+func (m *AppModel) promptChmod() {
+	if len(m.Files) == 0 {
+		return
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only"
+		return
+	}
+	file := m.Files[m.Selected]
+	fullPath := filepath.Join(m.CurrentDir, file.Entry.Name())
+	label := fmt.Sprintf("Mode for %s (e.g. 644, u+rwX,go-w): ", displayName(file.Entry.Name()))
+	m.prompt(label, func(input string) tea.Cmd {
+		change, err := fileutils.ParseModeChange(input)
+		if err != nil {
+			m.StatusMessage = err.Error()
+			return nil
+		}
+		if !file.Entry.IsDir() {
+			m.setChmodRepeat(input, change, false)
+			m.chmodPath(fullPath, change)
+			return nil
+		}
+		prompt := fmt.Sprintf("Apply %s to everything in %s too? (y: recursive, n: directory only)", input, displayName(file.Entry.Name()))
+		m.choose(prompt, map[string]func() tea.Cmd{
+			"y": func() tea.Cmd {
+				m.setChmodRepeat(input, change, true)
+				return m.startChmodTree(fullPath, input, change)
+			},
+			"n": func() tea.Cmd {
+				m.setChmodRepeat(input, change, false)
+				m.chmodPath(fullPath, change)
+				return nil
+			},
+		})
+		return nil
+	})
+}
+
+// setChmodRepeat makes the repeat key apply change to the selected entry,
+// into directories with recursive
+func (m *AppModel) setChmodRepeat(spec string, change fileutils.ModeChange, recursive bool) {
+	label := "chmod " + spec
+	if recursive {
+		label = "chmod -R " + spec
+	}
+	m.setRepeatAction(label, func() tea.Cmd {
+		if len(m.Files) == 0 || m.ArchiveFS != nil {
+			return nil
+		}
+		file := m.Files[m.Selected]
+		fullPath := filepath.Join(m.CurrentDir, file.Entry.Name())
+		if recursive && file.Entry.IsDir() {
+			return m.startChmodTree(fullPath, spec, change)
+		}
+		m.chmodPath(fullPath, change)
+		return nil
+	})
+}
+
+// chmodPath changes the mode of a single entry, following a symlink to its
+// target as chmod(1) does
+func (m *AppModel) chmodPath(fullPath string, change fileutils.ModeChange) {
+	info, err := os.Stat(fullPath)
+	if err == nil {
+		err = os.Chmod(fullPath, change.Apply(info.Mode()))
+	}
+	m.reloadKeepingCursor()
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error changing mode: %v", err)
+		return
+	}
+	m.StatusMessage = fmt.Sprintf("Mode of %s is now %s", displayName(filepath.Base(fullPath)), fileutils.FormatPermissions(change.Apply(info.Mode())))
+}
+
+// startChmodTree runs a recursive chmod in the background, its progress
+// shown in the status bar as it walks
+func (m *AppModel) startChmodTree(root, spec string, change fileutils.ModeChange) tea.Cmd {
+	name := displayName(filepath.Base(root))
+	return m.startTask(fmt.Sprintf("chmod %s: starting", name), filepath.Dir(root), func(report taskReport) (string, string) {
+		changed, failures := fileutils.ChmodTree(root, change, func(visited int) {
+			if visited%200 == 0 {
+				report(fmt.Sprintf("chmod %s: %d entries", name, visited))
+			}
+		})
+		result := fmt.Sprintf("chmod %s %s: %d changed", spec, name, changed)
+		if len(failures) > 0 {
+			result += fmt.Sprintf(", %d failed (%v)", len(failures), failures[0])
+		}
+		return result, ""
+	})
+}
+
+// yank remembers the marked entries, or the selected one, for p to paste
+// copies of
+func (m *AppModel) yank() {
+	m.fillClipboard(false)
+}
+
+// cut remembers the marked entries, or the selected one, for p to move
+// into the directory then shown
+func (m *AppModel) cut() {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only, press y to extract"
+		return
+	}
+	m.fillClipboard(true)
+}
+
+// fillClipboard puts the targets in the clipboard, the marks are spent
+func (m *AppModel) fillClipboard(cut bool) {
+	paths := m.targets()
+	if len(paths) == 0 {
+		return
+	}
+	m.Clipboard = paths
+	m.ClipboardCut = cut
+	m.ClipboardIsDir = false
+	if len(paths) == 1 {
+		info, err := os.Lstat(paths[0])
+		m.ClipboardIsDir = err == nil && info.IsDir()
+	}
+	m.clearMarks()
+
+	what := displayName(filepath.Base(paths[0]))
+	if len(paths) > 1 {
+		what = fmt.Sprintf("%d entries", len(paths))
+	}
+	if cut {
+		m.StatusMessage = fmt.Sprintf("Cut %s, p moves here, esc clears", what)
+	} else {
+		m.StatusMessage = fmt.Sprintf("Yanked %s, p pastes a copy", what)
+	}
+}
+
+// cutIndicator describes the cut buffer for the status bar, "" when empty
+func cutIndicator(m *models.Model) string {
+	switch {
+	case !m.ClipboardCut:
+		return ""
+	case len(m.Clipboard) > 1:
+		return fmt.Sprintf("%d entries cut", len(m.Clipboard))
+	case m.ClipboardIsDir:
+		return "1 directory cut"
+	}
+	return "1 file cut"
+}
+
+// clearCut empties the cut buffer, a yanked copy stays
+func (m *AppModel) clearCut() {
+	if m.ClipboardCut {
+		m.Clipboard = nil
+		m.ClipboardCut = false
+	}
+}
+
+// transfer is one entry copied or moved by paste
+type transfer struct {
+	src, dst  string
+	overwrite bool // dst exists and is replaced
+}
+
+// paste copies or moves the clipboard entries into the current directory.
+// Taken names ask, once for all of them, whether to overwrite, skip or
+// paste under numbered names. Pasting a copy next to its original always
+// picks a numbered name.
+func (m *AppModel) paste() tea.Cmd {
+	if len(m.Clipboard) == 0 {
+		m.StatusMessage = "Nothing to paste, y yanks and x cuts the selected entry"
+		return nil
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archives are read-only"
+		return nil
+	}
+	move := m.ClipboardCut
+	var ready, conflicts, renamed []transfer
+	canOverwrite := true
+	for _, src := range m.Clipboard {
+		if _, err := os.Lstat(src); err != nil {
+			m.StatusMessage = fmt.Sprintf("Cannot paste: %v", err)
+			return nil
+		}
+		name := filepath.Base(src)
+		dst := filepath.Join(m.CurrentDir, name)
+		// Pasting where the source lives, under any case of the path on a
+		// case-insensitive filesystem
+		here := fileutils.SameEntry(src, dst)
+		if move && (here || fileutils.IsSameOrAncestor(src, dst)) {
+			m.StatusMessage = fmt.Sprintf("Cannot move %s into itself", displayName(name))
+			if here {
+				m.StatusMessage = displayName(name) + " is already here"
+			}
+			return nil
+		}
+		if _, err := os.Lstat(dst); os.IsNotExist(err) {
+			ready = append(ready, transfer{src: src, dst: dst})
+			continue
+		}
+		rename := transfer{src: src, dst: filepath.Join(m.CurrentDir, fileutils.UniqueName(m.CurrentDir, name))}
+		if here {
+			ready = append(ready, rename)
+			continue
+		}
+		conflicts = append(conflicts, transfer{src: src, dst: dst, overwrite: true})
+		renamed = append(renamed, rename)
+		// Overwriting a directory the source lives in would delete the source
+		if fileutils.IsSameOrAncestor(dst, src) {
+			canOverwrite = false
+		}
+	}
+	if len(conflicts) == 0 {
+		return m.startTransfer(ready, move)
+	}
+
+	choices := map[string]func() tea.Cmd{
+		"s": func() tea.Cmd {
+			if len(ready) == 0 {
+				m.StatusMessage = "Skipped " + skippedNames(conflicts)
+				return nil
+			}
+			return m.startTransfer(ready, move)
+		},
+		"r": func() tea.Cmd { return m.startTransfer(append(ready, renamed...), move) },
+	}
+	what := existsMessage(conflicts[0].dst, "exists")
+	rename := "(r)ename to " + displayName(filepath.Base(renamed[0].dst))
+	if len(conflicts) > 1 {
+		what = fmt.Sprintf("%d names exist", len(conflicts))
+		rename = "(r)ename with numbered names"
+	}
+	prompt := fmt.Sprintf("%s: (s)kip, %s", what, rename)
+	if canOverwrite {
+		choices["o"] = func() tea.Cmd { return m.startTransfer(append(ready, conflicts...), move) }
+		prompt = fmt.Sprintf("%s: (o)verwrite, (s)kip, %s", what, rename)
+	}
+	m.choose(prompt+"?", choices)
+	return nil
+}
+
+// skippedNames names the skipped entries for the status bar
+func skippedNames(skipped []transfer) string {
+	if len(skipped) == 1 {
+		return displayName(filepath.Base(skipped[0].dst))
+	}
+	return fmt.Sprintf("%d entries", len(skipped))
+}
+
+// startTransfer copies or moves the items in the background, one after the
+// other. Copies, including moves across filesystems, show the bytes copied
+// so far in the status bar.
+func (m *AppModel) startTransfer(items []transfer, move bool) tea.Cmd {
+	return m.startTransferThen(items, move, nil)
+}
+
+// startTransferThen is startTransfer calling then once the items are done,
+// see startTaskThen
+func (m *AppModel) startTransferThen(items []transfer, move bool, then func()) tea.Cmd {
+	verb, doing, done := "copy", "copying", "Copied"
+	if move {
+		verb, doing, done = "move", "moving", "Moved"
+		// The cut buffer is spent once the move starts
+		m.clearCut()
+	}
+	dir := filepath.Dir(items[0].dst)
+	first := filepath.Base(items[0].dst)
+	total := int64(-1) // Unknown for directories and several entries
+	if len(items) == 1 {
+		if info, err := os.Lstat(items[0].src); err == nil && info.Mode().IsRegular() {
+			total = info.Size()
+		}
+	}
+	if m.transferCancel == nil {
+		m.transferCtx, m.transferCancel = context.WithCancel(context.Background())
+	}
+	m.transfers++
+	m.transferVerb = verb
+	m.updateCancellable()
+	opts := fileutils.CopyOptions{PreserveTimes: m.config.PreserveTimes, CopyXattrs: m.config.CopyXattrs, Context: m.transferCtx}
+	label := displayName(first)
+	if len(items) > 1 {
+		label = fmt.Sprintf("%d entries", len(items))
+	}
+
+	return m.startTaskThen(fmt.Sprintf("%s %s: starting", verb, label), dir, func(report taskReport) (string, string) {
+		var copied int64
+		var lastReport time.Time
+		opts.Progress = func(n int64) {
+			copied += n
+			if time.Since(lastReport) < 100*time.Millisecond {
+				return
+			}
+			lastReport = time.Now()
+			if total > 0 {
+				report(fmt.Sprintf("%s %s: %s/%s %d%%", verb, label, fileutils.FormatSize(copied), fileutils.FormatSize(total), copied*100/total))
+			} else {
+				report(fmt.Sprintf("%s %s: %s", verb, label, fileutils.FormatSize(copied)))
+			}
+		}
+
+		transferPath := fileutils.CopyPath
+		if move {
+			transferPath = fileutils.MovePath
+		}
+		for i, item := range items {
+			name := displayName(filepath.Base(item.dst))
+			if item.overwrite {
+				if err := os.RemoveAll(item.dst); err != nil {
+					return failedTransfer(fmt.Sprintf("Error replacing %s: %v", name, err), i, len(items), done), first
+				}
+			}
+			if err := opts.Context.Err(); err != nil {
+				return failedTransfer(fmt.Sprintf("Cancelled %s %s", doing, name), i, len(items), done), first
+			}
+			if err := transferPath(item.src, item.dst, opts); err != nil {
+				if errors.Is(err, context.Canceled) {
+					if !move {
+						// A move drops its own partial copy
+						os.RemoveAll(item.dst)
+					}
+					return failedTransfer(fmt.Sprintf("Cancelled %s %s, the partial copy was removed", doing, name), i, len(items), done), first
+				}
+				return failedTransfer(fmt.Sprintf("Error %s %s: %v", doing, name, err), i, len(items), done), first
+			}
+		}
+		return fmt.Sprintf("%s %s to %s", done, label, displayName(dir)), first
+	}, func() {
+		m.transfers--
+		if m.transfers == 0 {
+			m.transferCancel()
+			m.transferCtx, m.transferCancel = nil, nil
+			m.updateCancellable()
+		}
+		if then != nil {
+			then()
+		}
+	})
+}
+
+// failedTransfer prefixes the error of a transfer of several entries with
+// how many got through before it
+func failedTransfer(message string, succeeded, total int, done string) string {
+	if total == 1 {
+		return message
+	}
+	return fmt.Sprintf("%s %d of %d entries. %s", done, succeeded, total, message)
+}
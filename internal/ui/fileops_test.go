@@ -0,0 +1,318 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+func TestDeletePromptSummarizesTheMarks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt", "b.txt", "c.txt", "d.txt", "src/main.go", "src/util.go")
+	m := newTestModel(t, Options{Path: dir})
+
+	press(t, m, "A", "D")
+	if m.InputMode != models.ModeConfirm {
+		t.Fatalf("D did not ask for a confirmation")
+	}
+	// Each file holds its name and a newline
+	want := "Permanently delete 5 items (1 dir, 4 files, 48 B): a.txt, b.txt, c.txt and 2 more? (y/n)"
+	if m.ConfirmPrompt != want {
+		t.Errorf("prompt = %q, want %q", m.ConfirmPrompt, want)
+	}
+	press(t, m, "y")
+	if got := listed(m); len(got) != 0 {
+		t.Errorf("entries left after deleting them all: %v", got)
+	}
+}
+
+func TestDeletePromptForOneEntry(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "notes.txt")
+	m := newTestModel(t, Options{Path: dir})
+
+	press(t, m, "D")
+	if want := "Permanently delete 1 item (1 file, 10 B): notes.txt? (y/n)"; m.ConfirmPrompt != want {
+		t.Errorf("prompt = %q, want %q", m.ConfirmPrompt, want)
+	}
+	// Anything but y cancels
+	press(t, m, "n")
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("n deleted the file: %v", err)
+	}
+}
+
+func TestTrashConfirmsSeveralEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.log", "b.log", "keep.txt")
+	m := newTestModel(t, Options{Path: dir})
+
+	press(t, m, "/")
+	typeText(t, m, ".log")
+	press(t, m, "enter", "A", "d")
+	if want := "Trash 2 items (2 files, 12 B): a.log, b.log? (y/n)"; m.ConfirmPrompt != want {
+		t.Fatalf("prompt = %q, want %q", m.ConfirmPrompt, want)
+	}
+	press(t, m, "y", "esc")
+	if got := strings.Join(listed(m), " "); got != "keep.txt" {
+		t.Errorf("entries after trashing the logs = %q, want keep.txt", got)
+	}
+	if len(m.Marked) != 0 {
+		t.Errorf("marks left after trashing: %v", m.Marked)
+	}
+}
+
+func TestTrashOneEntryAtOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b")
+	m := newTestModel(t, Options{Path: dir})
+
+	press(t, m, "d")
+	if m.InputMode != models.ModeNormal {
+		t.Errorf("trashing one entry asked %q", m.ConfirmPrompt)
+	}
+	if got := strings.Join(listed(m), " "); got != "b" {
+		t.Errorf("entries after trashing a = %q, want b", got)
+	}
+}
+
+func TestDeleteWarnsAboutTheCurrentDirectory(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string // Marked, relative to the root
+		warning string
+		after   string // CurrentDir once deleted, relative to the root
+	}{
+		{"current", "a/cur", "Warning: this is the directory you are in! ", "a"},
+		{"parent", "a", "Warning: a holds the directory you are in! ", "."},
+		{"sibling", "a/other", "", "a/cur"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTree(t, root, "a/cur/file", "a/other/file", "a/keep")
+			m := newTestModel(t, Options{Path: filepath.Join(root, "a", "cur")})
+			m.Marked = map[string]bool{filepath.Join(root, tt.target): true}
+
+			press(t, m, "D")
+			if !strings.HasPrefix(m.ConfirmPrompt, tt.warning+"Permanently delete 1 item (1 dir") {
+				t.Fatalf("prompt = %q, want it to start with %q", m.ConfirmPrompt, tt.warning)
+			}
+			press(t, m, "y")
+			if _, err := os.Stat(filepath.Join(root, tt.target)); !os.IsNotExist(err) {
+				t.Errorf("%s was not deleted: %v", tt.target, err)
+			}
+			if want := filepath.Join(root, tt.after); m.CurrentDir != want {
+				t.Errorf("CurrentDir = %q, want %q", m.CurrentDir, want)
+			}
+			if m.Err != nil {
+				t.Errorf("Err = %v after deleting", m.Err)
+			}
+		})
+	}
+}
+
+func TestDeleteWarnsThroughSymlinks(t *testing.T) {
+	// Through loop, a link back to the root, root/d/loop/d is root/d and
+	// holds the logical path of the directory shown
+	root := t.TempDir()
+	writeTree(t, root, "d/file")
+	if err := os.Symlink(root, filepath.Join(root, "d", "loop")); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, Options{Path: filepath.Join(root, "d", "loop")})
+
+	selectName(t, m, "d")
+	press(t, m, "D")
+	if want := "Warning: d holds the directory you are in! "; !strings.HasPrefix(m.ConfirmPrompt, want) {
+		t.Fatalf("prompt = %q, want it to start with %q", m.ConfirmPrompt, want)
+	}
+	press(t, m, "y")
+	if m.CurrentDir != root || m.Err != nil {
+		t.Errorf("CurrentDir = %q, Err = %v after deleting, want %q", m.CurrentDir, m.Err, root)
+	}
+}
+
+func TestTrashConfirmsTheCurrentDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "cur/file")
+	m := newTestModel(t, Options{Path: filepath.Join(root, "cur")})
+	m.Marked = map[string]bool{m.CurrentDir: true}
+
+	press(t, m, "d")
+	if want := "Warning: this is the directory you are in! Trash 1 item"; !strings.HasPrefix(m.ConfirmPrompt, want) {
+		t.Fatalf("prompt = %q, want it to start with %q", m.ConfirmPrompt, want)
+	}
+	press(t, m, "y")
+	if m.CurrentDir != root || m.Err != nil {
+		t.Errorf("CurrentDir = %q, Err = %v after trashing, want %q", m.CurrentDir, m.Err, root)
+	}
+}
+
+func TestLookalikeNames(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "Notes.txt", "notes.txt", "other.txt")
+	if _, err := os.Lstat(filepath.Join(dir, "OTHER.txt")); err == nil {
+		t.Skip("the filesystem ignores case")
+	}
+	m := newTestModel(t, Options{Path: dir})
+
+	// Both lookalikes are marked, the rest is not
+	pane := renderCurrentPane(m.Model, m.config, 50, m.getVisibleHeight())
+	for _, line := range strings.Split(ansi.Strip(pane), "\n") {
+		for _, name := range []string{"Notes.txt", "notes.txt", "other.txt"} {
+			if i := strings.Index(line, " "+name); i >= 0 && strings.Contains(line[i:], "≈") != (name != "other.txt") {
+				t.Errorf("%s marked wrongly: %q", name, line)
+			}
+		}
+	}
+	m = newConfiguredModel(t, Options{Path: dir}, "unicode = false\n")
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Notes.txt =") || strings.Contains(view, "other.txt =") {
+		t.Errorf("ASCII marks missing:\n%s", view)
+	}
+
+	// Renaming onto a lookalike collides with it, a case-only rename of
+	// another name does not
+	selectName(t, m, "Notes.txt")
+	press(t, m, "a", "ctrl+a")
+	typeText(t, m, "notes.txt")
+	press(t, m, "enter")
+	if m.StatusMessage != "notes.txt already exists" {
+		t.Errorf("status %q", m.StatusMessage)
+	}
+	wantExists(t, filepath.Join(dir, "Notes.txt"))
+	selectName(t, m, "other.txt")
+	press(t, m, "a", "ctrl+a")
+	typeText(t, m, "OTHER.txt")
+	press(t, m, "enter")
+	wantExists(t, filepath.Join(dir, "OTHER.txt"))
+	wantSelected(t, m, "OTHER.txt", -1)
+	if m.Lookalikes["OTHER.txt"] {
+		t.Error("the renamed entry is marked")
+	}
+
+	// Renaming one away clears the marks
+	selectName(t, m, "notes.txt")
+	press(t, m, "a", "ctrl+a")
+	typeText(t, m, "todo.txt")
+	press(t, m, "enter")
+	if len(m.Lookalikes) != 0 {
+		t.Errorf("lookalikes %v after the rename", m.Lookalikes)
+	}
+}
+
+func TestRenamePromptCursor(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"report-final.pdf", "report.final.v2.pdf", ".bashrc", ".env.local", "backup.tar.gz", "noext", "résumé.pdf", "v1.2/"}
+	writeTree(t, dir, names...)
+	m := newTestModel(t, Options{Path: dir})
+
+	// The cursor waits before the extension, at the end without one and
+	// for directories
+	cursors := map[string]int{
+		"report-final.pdf":    12,
+		"report.final.v2.pdf": 15,
+		".bashrc":             7,
+		".env.local":          4,
+		"backup.tar.gz":       6,
+		"noext":               5,
+		"résumé.pdf":          6,
+		"v1.2":                4,
+	}
+	press(t, m, ".")
+	for name, cursor := range cursors {
+		selectName(t, m, name)
+		press(t, m, "a")
+		if m.PromptInput != name || m.PromptCursor != cursor || m.PromptSelected {
+			t.Errorf("%s: prompt %q, cursor %d, selected %v, want the cursor at %d", name, m.PromptInput, m.PromptCursor, m.PromptSelected, cursor)
+		}
+		press(t, m, "esc")
+	}
+
+	tests := []struct {
+		name, keys, text, want, status string
+	}{
+		// Typing edits the stem, the extension is untouched
+		{"report-final.pdf", "", "-v2", "report-final-v2.pdf", "Renamed report-final.pdf to report-final-v2.pdf"},
+		{"report.final.v2.pdf", "backspace", "3", "report.final.v3.pdf", "Renamed report.final.v2.pdf to report.final.v3.pdf"},
+		{".bashrc", "", ".bak", ".bashrc.bak", "Renamed .bashrc to .bashrc.bak, note it now has the extension .bak"},
+		{".env.local", "", ".prod", ".env.prod.local", "Renamed .env.local to .env.prod.local"},
+		{"backup.tar.gz", "", "-old", "backup-old.tar.gz", "Renamed backup.tar.gz to backup-old.tar.gz"},
+		// ctrl+a selects the whole name, typing replaces it and may change the
+		// extension with a warning
+		{"résumé.pdf", "ctrl+a", "cv.txt", "cv.txt", "Renamed résumé.pdf to cv.txt, note the extension changed from .pdf to .txt"},
+		{"noext", "ctrl+a", "NoExt", "NoExt", "Renamed noext to NoExt"},
+		{"cv.txt", "ctrl+a", "cv", "cv", "Renamed cv.txt to cv, note the extension .txt was dropped"},
+		// Directories get no warning
+		{"v1.2", "", ".3", "v1.2.3", "Renamed v1.2 to v1.2.3"},
+	}
+	for _, tt := range tests {
+		selectName(t, m, tt.name)
+		press(t, m, "a")
+		if tt.keys != "" {
+			press(t, m, tt.keys)
+		}
+		typeText(t, m, tt.text)
+		press(t, m, "enter")
+		wantExists(t, filepath.Join(dir, tt.want))
+		if m.StatusMessage != tt.status {
+			t.Errorf("%s: status %q, want %q", tt.name, m.StatusMessage, tt.status)
+		}
+	}
+
+	// A selection is dropped by moving, and backspace clears it
+	selectName(t, m, "cv")
+	press(t, m, "a", "ctrl+a", "left")
+	if m.PromptSelected || m.PromptInput != "cv" || m.PromptCursor != 1 {
+		t.Errorf("after left: %q, cursor %d, selected %v", m.PromptInput, m.PromptCursor, m.PromptSelected)
+	}
+	press(t, m, "ctrl+a", "ctrl+a", "backspace")
+	if m.PromptSelected || m.PromptInput != "" {
+		t.Errorf("after backspace: %q, selected %v", m.PromptInput, m.PromptSelected)
+	}
+}
+
+func TestCancelCopy(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "docs/")
+	big := filepath.Join(dir, "big")
+	if err := os.WriteFile(big, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(big, 64<<20); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, Options{Path: dir})
+	selectName(t, m, "big")
+	press(t, m, "y")
+	selectName(t, m, "docs")
+	press(t, m, "l")
+
+	// The copy runs in the background, its command waits for it to end
+	_, cmd := m.Update(keyMsg(t, "p"))
+	if got := helpBar(m); !strings.HasPrefix(got, "esc:cancel copy") {
+		t.Errorf("help bar during the copy = %q, want the cancel hint first", got)
+	}
+	press(t, m, "esc")
+	settle(t, m, cmd)
+
+	// The copy may have finished before esc, but never leaves half a file
+	copied := filepath.Join(dir, "docs", "big")
+	if strings.HasPrefix(m.StatusMessage, "Cancelled") {
+		if _, err := os.Lstat(copied); !os.IsNotExist(err) {
+			t.Errorf("the cancelled copy left docs/big: %v", err)
+		}
+	} else if info, err := os.Lstat(copied); err != nil || info.Size() != 64<<20 {
+		t.Errorf("status %q, but docs/big is %v, %v", m.StatusMessage, info, err)
+	}
+	if m.transferCancel != nil || m.Cancellable != "" || m.Task != "" {
+		t.Errorf("copy left cancel %v, cancellable %q, task %q", m.transferCancel != nil, m.Cancellable, m.Task)
+	}
+	if got := helpBar(m); strings.Contains(got, "cancel") {
+		t.Errorf("help bar after the copy = %q, still offers to cancel", got)
+	}
+}
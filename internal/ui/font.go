@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontSampleLines are rasterized to show what a font looks like, stacked so
+// the sample keeps a readable aspect ratio in a narrow pane
+var fontSampleLines = []string{"The quick", "brown fox", "0123456789"}
+
+// fontScripts maps a script name to a representative rune used to probe
+// whether the font covers it
+var fontScripts = []struct {
+	name string
+	r    rune
+}{
+	{"Latin", 'A'},
+	{"Greek", 'Ω'},
+	{"Cyrillic", 'Ж'},
+	{"Hebrew", 'א'},
+	{"Arabic", 'ب'},
+	{"Devanagari", 'क'},
+	{"Thai", 'ก'},
+	{"Hangul", '한'},
+	{"Kana", 'あ'},
+	{"CJK", '中'},
+}
+
+// isFontFileByExtension detects font files.
+func isFontFileByExtension(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ttf", ".otf", ".ttc", ".otc", ".woff", ".woff2":
+		return true
+	default:
+		return false
+	}
+}
+
+// renderFontPreview shows a font's names, glyph count and script coverage
+// with a rasterized sample, degrading to a note when the font can't be parsed.
+func renderFontPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	var sb strings.Builder
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	sb.WriteString("\n")
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	f, err := parseFont(data)
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("Could not parse font: %v", err))
+		setPreview(m, sb.String())
+		return
+	}
+
+	var buf sfnt.Buffer
+	for _, field := range []struct {
+		label string
+		id    sfnt.NameID
+	}{
+		{"Family", sfnt.NameIDFamily},
+		{"Style", sfnt.NameIDSubfamily},
+		{"Version", sfnt.NameIDVersion},
+	} {
+		if name, err := f.Name(&buf, field.id); err == nil && name != "" {
+			sb.WriteString(fmt.Sprintf("%s: %s\n", field.label, name))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("Glyphs: %d\n", f.NumGlyphs()))
+
+	var scripts []string
+	for _, script := range fontScripts {
+		if index, err := f.GlyphIndex(&buf, script.r); err == nil && index != 0 {
+			scripts = append(scripts, script.name)
+		}
+	}
+	if len(scripts) > 0 {
+		sb.WriteString(fmt.Sprintf("Scripts: %s\n", strings.Join(scripts, ", ")))
+	}
+
+	if sample := rasterizeFontSample(f); sample != nil {
+		width, height := previewContentSize(m)
+		for _, sampleWidth := range []int{width / 2, width} {
+			sb.WriteString("\n")
+			sb.WriteString(imageToASCII(sample, max(1, sampleWidth), height, false))
+		}
+	}
+
+	setPreview(m, sb.String())
+}
+
+// parseFont parses a single font or the first font of a collection
+func parseFont(data []byte) (*sfnt.Font, error) {
+	if f, err := sfnt.Parse(data); err == nil {
+		return f, nil
+	}
+	collection, err := sfnt.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	return collection.Font(0)
+}
+
+// rasterizeFontSample draws the sample lines in white on black
+func rasterizeFontSample(f *sfnt.Font) image.Image {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: 48, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	width := 0
+	for _, line := range fontSampleLines {
+		width = max(width, font.MeasureString(face, line).Ceil())
+	}
+	height := lineHeight * len(fontSampleLines)
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+	drawer := font.Drawer{Dst: img, Src: image.White, Face: face}
+	for i, line := range fontSampleLines {
+		drawer.Dot = fixed.Point26_6{Y: metrics.Ascent + fixed.I(i*lineHeight)}
+		drawer.DrawString(line)
+	}
+	return img
+}
@@ -0,0 +1,343 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// gitTimeout bounds the git status run for one hovered repository
+const gitTimeout = 5 * time.Second
+
+var (
+	gitLookOnce  sync.Once
+	gitAvailable bool
+)
+
+// hasGit reports whether a git binary is on PATH, checked once per session
+func hasGit() bool {
+	gitLookOnce.Do(func() {
+		_, err := exec.LookPath("git")
+		gitAvailable = err == nil
+	})
+	return gitAvailable
+}
+
+// isGitRepo reports whether dir is the top of a git work tree. Worktrees and
+// submodules have a .git file instead of a directory.
+func isGitRepo(dir string) bool {
+	if !hasGit() {
+		return false
+	}
+	_, err := os.Lstat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// gitInfoMsg delivers the state gathered for a repository
+type gitInfoMsg struct {
+	path string
+	info models.GitInfo
+}
+
+// fetchGitInfo returns a command inspecting the repository at dir with a
+// single git status call, the remote URL is read from the repository config.
+// Failures yield a zero GitInfo so the lookup is not retried.
+func fetchGitInfo(dir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain=v2", "--branch").Output()
+		if err != nil {
+			return gitInfoMsg{path: dir}
+		}
+		info := parseGitStatus(string(out))
+		info.Remote = gitRemoteURL(dir, info.Branch)
+		return gitInfoMsg{path: dir, info: info}
+	}
+}
+
+// gitLogLength is how many commits the directory preview lists with
+// git_log_preview
+const gitLogLength = 15
+
+// gitLogMsg delivers the recent commits touching a directory
+type gitLogMsg struct {
+	path    string
+	commits []string
+}
+
+// fetchGitLog returns a command listing the last commits touching dir, in
+// whichever repository contains it. Outside a repository, and when git
+// fails or is too slow, there are none and the lookup is not retried.
+func fetchGitLog(dir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, "git", "-C", dir, "log", "--oneline", "--no-color", "-n", strconv.Itoa(gitLogLength), "--", ".").Output()
+		if err != nil {
+			return gitLogMsg{path: dir, commits: []string{}}
+		}
+		commits := []string{}
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line != "" {
+				commits = append(commits, line)
+			}
+		}
+		return gitLogMsg{path: dir, commits: commits}
+	}
+}
+
+// gitStatusMaxAge is how long the status markers of a directory are reused
+// before the next listing of it runs git status again, so typing a filter
+// does not start one per key
+const gitStatusMaxAge = 2 * time.Second
+
+// gitStatusMsg delivers the status markers of a directory's entries
+type gitStatusMsg struct {
+	dir   string
+	marks map[string]byte
+}
+
+// gitWorkTree returns the top of the work tree holding dir, the nearest
+// directory at or above it with a .git entry, or "" outside repositories
+func gitWorkTree(dir string) string {
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// fetchGitStatus returns a command reading the status of the entries of
+// dir, in the work tree at root, with one git status call limited to dir.
+// Failures yield no markers rather than an error.
+func fetchGitStatus(dir, root string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain", "-z", "--ignored", "--", ".").Output()
+		if err != nil {
+			return gitStatusMsg{dir: dir, marks: map[string]byte{}}
+		}
+		prefix, err := filepath.Rel(root, dir)
+		if err != nil {
+			return gitStatusMsg{dir: dir, marks: map[string]byte{}}
+		}
+		return gitStatusMsg{dir: dir, marks: parseGitPorcelain(string(out), filepath.ToSlash(prefix))}
+	}
+}
+
+// gitMarkRank orders the markers, a directory shows the highest of its
+// contents
+var gitMarkRank = map[byte]int{'!': 1, '?': 2, 'A': 3, 'M': 4}
+
+// parseGitPorcelain maps "git status --porcelain -z" output, whose paths
+// are relative to the work tree, to markers of the entries directly in the
+// directory at prefix
+func parseGitPorcelain(out, prefix string) map[string]byte {
+	marks := make(map[string]byte)
+	records := strings.Split(out, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if len(record) < 4 {
+			continue
+		}
+		code, path := record[:2], record[3:]
+		// Renames and copies are followed by the path they came from
+		if code[0] == 'R' || code[0] == 'C' {
+			i++
+		}
+		if prefix != "." {
+			var ok bool
+			if path, ok = strings.CutPrefix(path, prefix+"/"); !ok {
+				continue
+			}
+		}
+		name, _, _ := strings.Cut(path, "/")
+		if name == "" {
+			continue
+		}
+		mark := gitMark(code)
+		if gitMarkRank[mark] > gitMarkRank[marks[name]] {
+			marks[name] = mark
+		}
+	}
+	return marks
+}
+
+// gitMark turns a porcelain status code into the marker shown: changes in
+// the work tree, conflicts included, are modified, changes only in the
+// index are staged
+func gitMark(code string) byte {
+	switch {
+	case code == "??":
+		return '?'
+	case code == "!!":
+		return '!'
+	case code[1] != ' ':
+		return 'M'
+	}
+	return 'A'
+}
+
+// renderGitMark renders a marker from GitMarks, " M" and the like, in the
+// color of its status, over the background of the row's style
+func renderGitMark(mark string, style lipgloss.Style, cfg config.Config) string {
+	if mark == "" {
+		return ""
+	}
+	if !cfg.Color {
+		return style.Render(mark)
+	}
+	color := cfg.GitModifiedColor
+	switch mark[1] {
+	case 'A':
+		color = cfg.GitStagedColor
+	case '?':
+		color = cfg.GitUntrackedColor
+	case '!':
+		color = cfg.GitIgnoredColor
+	}
+	return style.Foreground(lipgloss.Color(color)).Render(mark)
+}
+
+// parseGitStatus reads the branch headers and dirty state from
+// "git status --porcelain=v2 --branch" output
+func parseGitStatus(out string) models.GitInfo {
+	var info models.GitInfo
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		header, ok := strings.CutPrefix(line, "# ")
+		if !ok {
+			info.Dirty = true
+			continue
+		}
+		key, value, _ := strings.Cut(header, " ")
+		switch key {
+		case "branch.head":
+			info.Branch = value
+		case "branch.upstream":
+			info.Upstream = value
+		case "branch.ab":
+			ahead, behind, _ := strings.Cut(value, " ")
+			info.Ahead, _ = strconv.Atoi(strings.TrimPrefix(ahead, "+"))
+			info.Behind, _ = strconv.Atoi(strings.TrimPrefix(behind, "-"))
+		}
+	}
+	return info
+}
+
+// gitRemoteURL returns the URL of the remote branch tracks, falling back to
+// "origin", or "" when neither is configured
+func gitRemoteURL(dir, branch string) string {
+	content, _, err := fileutils.ReadHead(filepath.Join(gitCommonDir(dir), "config"), previewReadLimit)
+	if err != nil {
+		return ""
+	}
+	sections := fileutils.ParseINI(string(content))
+
+	remote := "origin"
+	if section, ok := fileutils.FindINISection(sections, fmt.Sprintf("branch %q", branch)); ok {
+		if name, ok := section.Get("remote"); ok {
+			remote = name
+		}
+	}
+	if section, ok := fileutils.FindINISection(sections, fmt.Sprintf("remote %q", remote)); ok {
+		remoteURL, _ := section.Get("url")
+		return redactURL(remoteURL)
+	}
+	return ""
+}
+
+// redactURL hides a password or token embedded in an https remote URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "••••")
+		// url.String would percent-encode the mask
+		return strings.Replace(u.String(), url.QueryEscape("••••"), "••••", 1)
+	}
+	return raw
+}
+
+// gitCommonDir resolves the directory holding the repository config,
+// following the "gitdir:" pointer and "commondir" file of worktrees
+func gitCommonDir(dir string) string {
+	gitDir := filepath.Join(dir, ".git")
+	if data, err := os.ReadFile(gitDir); err == nil {
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return gitDir
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		gitDir = target
+	}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		return common
+	}
+	return gitDir
+}
+
+// gitHeader formats a repository's state as preview header lines, or ""
+// when nothing is known about it
+func gitHeader(info models.GitInfo) string {
+	if info.Branch == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Git: %s", info.Branch))
+	if info.Upstream != "" {
+		switch {
+		case info.Ahead > 0 && info.Behind > 0:
+			sb.WriteString(fmt.Sprintf(" (%d ahead, %d behind %s)", info.Ahead, info.Behind, info.Upstream))
+		case info.Ahead > 0:
+			sb.WriteString(fmt.Sprintf(" (%d ahead of %s)", info.Ahead, info.Upstream))
+		case info.Behind > 0:
+			sb.WriteString(fmt.Sprintf(" (%d behind %s)", info.Behind, info.Upstream))
+		default:
+			sb.WriteString(fmt.Sprintf(" (up to date with %s)", info.Upstream))
+		}
+	}
+	if info.Dirty {
+		sb.WriteString(", dirty\n")
+	} else {
+		sb.WriteString(", clean\n")
+	}
+	if info.Remote != "" {
+		sb.WriteString(fmt.Sprintf("Remote: %s\n", info.Remote))
+	}
+	return sb.String()
+}
@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+	"github.com/xo/terminfo"
+	"golang.org/x/image/draw"
+)
+
+// imageProtocolEnv overrides the detection of image_protocol = "auto", for
+// terminals that support graphics without announcing it
+const imageProtocolEnv = "BULLSEYE_IMAGE_PROTOCOL"
+
+// kittyImageID identifies bullseye's image to the kitty graphics protocol,
+// so each preview replaces the previous one
+const kittyImageID = 4547
+
+// kittyChunk is the most base64 data one kitty graphics command carries
+const kittyChunk = 4096
+
+// kittyDelete removes the image placed by a kitty graphics preview
+var kittyDelete = fmt.Sprintf("\x1b_Ga=d,d=I,i=%d,q=2\x1b\\", kittyImageID)
+
+// kittyUsed records that kitty graphics were drawn, at package level like
+// overlay so Cleanup can remove the image on exit
+var kittyUsed bool
+
+// imageProtocol resolves image_protocol to the graphics image previews are
+// drawn with, "" for ASCII art
+func imageProtocol(cfg config.Config) string {
+	protocol := cfg.ImageProtocol
+	if protocol == "auto" {
+		switch env := os.Getenv(imageProtocolEnv); env {
+		case "kitty", "sixel", "ascii":
+			protocol = env
+		default:
+			protocol = detectImageProtocol(cfg)
+		}
+	}
+	if protocol == "ascii" {
+		return ""
+	}
+	return protocol
+}
+
+// detectImageProtocol guesses the graphics the terminal supports from its
+// environment: kitty's protocol from the terminals known to implement it,
+// sixel from the Sxl terminfo capability tmux also reads. Multiplexers do
+// not pass either through, and ueberzugpp overlays take precedence.
+func detectImageProtocol(cfg config.Config) string {
+	term := os.Getenv("TERM")
+	switch {
+	case overlaySocket(cfg.UeberzugSocket) != "", os.Getenv("TMUX") != "", strings.HasPrefix(term, "screen"), strings.HasPrefix(term, "tmux"):
+		return "ascii"
+	case term == "xterm-kitty", os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-ghostty", os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return "kitty"
+	case strings.HasPrefix(term, "foot"):
+		return "sixel"
+	}
+	if ti, err := terminfo.Load(term); err == nil && ti.ExtBoolCapsShort()["Sxl"] {
+		return "sixel"
+	}
+	return "ascii"
+}
+
+// imageProtocolLabel describes how image previews are drawn, for the debug
+// screen
+func imageProtocolLabel(m *models.Model) string {
+	if m.ImageProtocol == "" {
+		return "ASCII art"
+	}
+	width, height := cellSize(m)
+	return fmt.Sprintf("%s graphics, %dx%d pixel cells", m.ImageProtocol, width, height)
+}
+
+// cellSize returns the pixel size of a terminal cell, guessing a common one
+// when the terminal does not report it
+func cellSize(m *models.Model) (int, int) {
+	if m.CellWidth <= 0 || m.CellHeight <= 0 {
+		return 10, 20
+	}
+	return m.CellWidth, m.CellHeight
+}
+
+// updateCellSize reads the cell size from the terminal, after startup and
+// each resize
+func updateCellSize(m *models.Model) {
+	if m.ImageProtocol != "" && m.Width > 0 && m.Height > 0 {
+		m.CellWidth, m.CellHeight = terminalCellSize(m.Width, m.Height)
+	}
+}
+
+// renderImageGraphics previews img with the terminal's graphics, scaled to
+// fit the preview pane without enlarging it, over blank preview lines.
+// Images the protocol cannot encode are shown as ASCII art.
+func renderImageGraphics(m *models.Model, cfg config.Config, img image.Image, fullPath string, cols, rows int) {
+	cellWidth, cellHeight := cellSize(m)
+	scaled := scaleImage(img, cols*cellWidth, rows*cellHeight)
+	cols = (scaled.Bounds().Dx() + cellWidth - 1) / cellWidth
+	rows = (scaled.Bounds().Dy() + cellHeight - 1) / cellHeight
+
+	var sequence string
+	switch m.ImageProtocol {
+	case "kitty":
+		sequence = kittyImage(scaled, cols, rows)
+	case "sixel":
+		sequence = encodeSixel(scaled)
+	}
+	if sequence == "" {
+		width, height := previewContentSize(m)
+		setPreview(m, imageToASCII(img, width, height, m.ImagePreviewColored && cfg.Color))
+		return
+	}
+	setPreview(m, strings.Repeat("\n", rows-1))
+	m.PreviewImage = models.PreviewImage{Path: fullPath, Sequence: sequence}
+}
+
+// scaleImage fits img into width by height pixels keeping its aspect
+// ratio, leaving smaller images their size
+func scaleImage(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	scale := math.Min(1, math.Min(float64(width)/float64(bounds.Dx()), float64(height)/float64(bounds.Dy())))
+	size := image.Rect(0, 0, max(1, int(float64(bounds.Dx())*scale)), max(1, int(float64(bounds.Dy())*scale)))
+	scaled := image.NewRGBA(size)
+	draw.BiLinear.Scale(scaled, size, img, bounds, draw.Src, nil)
+	return scaled
+}
+
+// kittyImage encodes img as kitty graphics commands transmitting it as PNG
+// and placing it over cols by rows cells at the cursor, which stays put
+func kittyImage(img image.Image, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	var sb strings.Builder
+	for start := 0; start < len(data); start += kittyChunk {
+		end := min(start+kittyChunk, len(data))
+		more := 0
+		if end < len(data) {
+			more = 1
+		}
+		if start == 0 {
+			fmt.Fprintf(&sb, "\x1b_Ga=T,f=100,i=%d,p=1,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\", kittyImageID, cols, rows, more, data[start:end])
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, data[start:end])
+		}
+	}
+	return sb.String()
+}
+
+// graphicsVisible reports whether the preview pane shows the image of
+// m.PreviewImage, rather than another preview or a screen covering it
+func graphicsVisible(m *models.Model) bool {
+	return m.PreviewImage.Path != "" && m.PreviewImage.Path == m.PreviewPath && !m.PreviewPending && m.PreviewOffset == 0 && !previewCovered(m)
+}
+
+// withGraphics draws the image preview over the rendered view. The
+// renderer only rewrites the lines that changed, and a line rewritten after
+// the image was drawn erases it: the image goes at the end of the last
+// line, which is made to differ whenever another does so it is written
+// last, with the cursor saved and restored around it. Frames without the
+// image remove the kitty placement.
+func (m *AppModel) withGraphics(view string) string {
+	if m.ImageProtocol == "" {
+		return view
+	}
+	if view != m.lastFrame {
+		m.lastFrame = view
+		m.frameParity = !m.frameParity
+	}
+	reset := "\x1b[0m"
+	if m.frameParity {
+		reset = "\x1b[m"
+	}
+
+	m.graphicsShown = graphicsVisible(m.Model)
+	if !m.graphicsShown {
+		if m.ImageProtocol == "kitty" {
+			return view + reset + kittyDelete
+		}
+		return view + reset
+	}
+	if m.ImageProtocol == "kitty" {
+		kittyUsed = true
+	}
+	x, y := computeLayout(m.Model).previewOrigin()
+	return view + reset + ansi.SaveCursor + ansi.CursorPosition(x+1, y+1) + m.PreviewImage.Sequence + ansi.RestoreCursor
+}
+
+// clearGraphics repaints the screen once a sixel image is no longer shown.
+// Its pixels replaced the text of the cells it covered, and the rows the
+// renderer does not rewrite would keep showing them.
+func (m *AppModel) clearGraphics() tea.Cmd {
+	if m.ImageProtocol != "sixel" || !m.graphicsShown || graphicsVisible(m.Model) {
+		return nil
+	}
+	m.graphicsShown = false
+	return tea.ClearScreen
+}
+
+// removeGraphics deletes a kitty image left on the screen, on exit
+func removeGraphics() {
+	if kittyUsed {
+		os.Stdout.WriteString(kittyDelete)
+		kittyUsed = false
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package ui
+
+// terminalCellSize is unknown on platforms without the TIOCGWINSZ ioctl
+func terminalCellSize(cols, rows int) (int, int) {
+	return 0, 0
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package ui
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalCellSize divides the terminal's pixel size by its cols by rows
+// cells, 0 when the terminal does not report its pixel size
+func terminalCellSize(cols, rows int) (int, int) {
+	size, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || size.Xpixel == 0 || size.Ypixel == 0 {
+		return 0, 0
+	}
+	return int(size.Xpixel) / cols, int(size.Ypixel) / rows
+}
@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// grepMatchLimit caps the lines a content search collects, the walk stops
+// once it is reached
+const grepMatchLimit = 1000
+
+// grepMsg delivers the matches a content search found since the last one,
+// and its outcome once done
+type grepMsg struct {
+	search   *grepSearch
+	matches  []models.GrepMatch
+	done     bool
+	searched int
+	err      error
+}
+
+// grepSearch is a content search walking the tree in the background
+type grepSearch struct {
+	updates chan grepMsg
+	cancel  context.CancelFunc
+}
+
+// promptGrep asks for the text to search the files below the current
+// directory for
+func (m *AppModel) promptGrep() {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Content search does not look inside archives"
+		return
+	}
+	m.prompt(fmt.Sprintf("Search contents below %s: ", displayName(filepath.Base(m.CurrentDir))), func(input string) tea.Cmd {
+		if input == "" {
+			return nil
+		}
+		return m.startGrep(input, false)
+	})
+	m.setPromptInput(m.GrepQuery)
+}
+
+// promptFind asks for the text to look for in the names of the entries
+// below the current directory, starting with what the search filter held
+func (m *AppModel) promptFind(query string) {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "The deep search does not look inside archives"
+		return
+	}
+	m.prompt(fmt.Sprintf("Find below %s: ", displayName(filepath.Base(m.CurrentDir))), func(input string) tea.Cmd {
+		if input == "" {
+			return nil
+		}
+		return m.startGrep(input, true)
+	})
+	m.setPromptInput(query)
+}
+
+// startGrep walks the current directory's tree for query in the
+// background, in the contents of the files or with names in the names of
+// the entries. The results view fills as matches arrive, handed over
+// whenever the previous batch has been taken so the walk never waits on
+// rendering.
+func (m *AppModel) startGrep(query string, names bool) tea.Cmd {
+	m.cancelGrep()
+	ctx, cancel := context.WithCancel(context.Background())
+	search := &grepSearch{updates: make(chan grepMsg, 1), cancel: cancel}
+	m.grep = search
+	m.GrepQuery, m.GrepRoot = query, m.CurrentDir
+	m.GrepMatches, m.GrepSelected, m.GrepSearched = nil, 0, 0
+	m.GrepRunning, m.GrepView, m.GrepNames = true, true, names
+
+	root, maxSize := m.CurrentDir, m.config.GrepMaxBytes
+	opts := fileutils.SearchOptions{Hidden: m.ShowHidden, Ignore: m.config.SearchIgnore}
+	go func() {
+		var pending []models.GrepMatch
+		total := 0
+		found := func(match models.GrepMatch) bool {
+			pending = append(pending, match)
+			total++
+			select {
+			case search.updates <- grepMsg{search: search, matches: pending}:
+				pending = nil
+			default:
+			}
+			return total < grepMatchLimit
+		}
+		var searched int
+		var err error
+		if names {
+			searched, err = fileutils.FindTree(ctx, root, query, opts, found)
+		} else {
+			searched, err = fileutils.GrepTree(ctx, root, query, maxSize, opts, found)
+		}
+		search.updates <- grepMsg{search: search, matches: pending, done: true, searched: searched, err: err}
+	}()
+	return search.wait()
+}
+
+// wait returns a command delivering the search's next message
+func (search *grepSearch) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-search.updates
+	}
+}
+
+// cancelGrep stops the content search running, if any, keeping what it
+// found
+func (m *AppModel) cancelGrep() {
+	if m.grep != nil {
+		m.grep.cancel()
+		m.grep = nil
+	}
+	m.GrepRunning = false
+}
+
+// handleGrepMsg adds the matches of the search still current to the view
+func (m *AppModel) handleGrepMsg(msg grepMsg) tea.Cmd {
+	if msg.search != m.grep {
+		// Superseded or cancelled, drain it so its walk can finish
+		if !msg.done {
+			return msg.search.wait()
+		}
+		return nil
+	}
+	m.GrepMatches = append(m.GrepMatches, msg.matches...)
+	if !msg.done {
+		return msg.search.wait()
+	}
+	m.grep = nil
+	m.GrepRunning = false
+	m.GrepSearched = msg.searched
+	switch {
+	case msg.err != nil && !errors.Is(msg.err, context.Canceled):
+		m.StatusMessage = fmt.Sprintf("Content search: %v", msg.err)
+	case len(m.GrepMatches) >= grepMatchLimit:
+		m.StatusMessage = fmt.Sprintf("Stopped at %d matches, narrow the search", grepMatchLimit)
+	case m.GrepNames:
+		m.StatusMessage = fmt.Sprintf("%d matches among %d entries", len(m.GrepMatches), msg.searched)
+	default:
+		m.StatusMessage = fmt.Sprintf("%d matches in %d files searched", len(m.GrepMatches), msg.searched)
+	}
+	return nil
+}
+
+// handleGrepKeys moves through the content search results and goes to the
+// chosen match, reporting whether the key was consumed. esc stops a walk
+// still running, and once stopped closes the view.
+func (m *AppModel) handleGrepKeys(msg tea.KeyMsg) bool {
+	switch m.keys[msg.String()] {
+	case actionUp:
+		m.GrepSelected = max(0, m.GrepSelected-1)
+		return true
+	case actionDown:
+		m.GrepSelected = max(0, min(len(m.GrepMatches)-1, m.GrepSelected+1))
+		return true
+	case actionTop:
+		m.GrepSelected = 0
+		return true
+	case actionBottom:
+		m.GrepSelected = max(0, len(m.GrepMatches)-1)
+		return true
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return false
+	case "enter", "l":
+		if len(m.GrepMatches) == 0 {
+			return true
+		}
+		match := m.GrepMatches[m.GrepSelected]
+		m.GrepView = false
+		m.navigateTo(filepath.Dir(match.Path), filepath.Base(match.Path))
+		if match.Line > 0 {
+			m.StatusMessage = fmt.Sprintf("Matched on line %d, : goes to it", match.Line)
+		}
+	case "esc":
+		if m.GrepRunning {
+			m.cancelGrep()
+			m.StatusMessage = fmt.Sprintf("Search cancelled, %d matches so far", len(m.GrepMatches))
+			return true
+		}
+		m.GrepView = false
+	case "q":
+		m.cancelGrep()
+		m.GrepView = false
+	}
+	return true
+}
+
+// renderGrepPane lists the content search matches as path:line: text, or
+// the paths the deep search found, scrolled to keep the selected one visible
+func renderGrepPane(m *models.Model, cfg config.Config, width, height int) string {
+	var content strings.Builder
+	paneContentWidth := max(0, width-2)
+	count := fmt.Sprintf(" (%d)", len(m.GrepMatches))
+	if m.GrepRunning {
+		dots := "…"
+		if !cfg.Unicode {
+			dots = "..."
+		}
+		count = fmt.Sprintf(" (%d, searching%s)", len(m.GrepMatches), dots)
+	}
+	title := fmt.Sprintf("%q below %s", m.GrepQuery, m.GrepRoot)
+	if m.GrepNames {
+		title = fmt.Sprintf("Names with %q below %s", m.GrepQuery, m.GrepRoot)
+	}
+	content.WriteString(truncatePaneTitle(title, count, paneContentWidth) + "\n")
+	content.WriteString(paneRule(cfg, width-2) + "\n")
+
+	if len(m.GrepMatches) == 0 && !m.GrepRunning {
+		content.WriteString(" No matches")
+	}
+	rows := max(1, height-2)
+	start := max(0, m.GrepSelected-rows+1)
+	end := min(start+rows, len(m.GrepMatches))
+	for i := start; i < end; i++ {
+		match := m.GrepMatches[i]
+		name, err := filepath.Rel(m.GrepRoot, match.Path)
+		if err != nil {
+			name = match.Path
+		}
+		prefix := " "
+		if i == m.GrepSelected && !cfg.Color {
+			prefix = ">"
+		}
+		line := prefix + displayName(name)
+		if match.Line > 0 {
+			line += fmt.Sprintf(":%d: %s", match.Line, displayName(match.Text))
+		}
+		line = TruncateString(line, paneContentWidth)
+		if i == m.GrepSelected {
+			line = GetPreviewHighlightStyle(cfg).Render(line)
+		}
+		content.WriteString(line + "\n")
+	}
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(strings.TrimSuffix(content.String(), "\n"))
+}
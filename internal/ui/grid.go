@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+const (
+	gridCellWidth  = 18 // Thumbnail width in characters
+	gridCellHeight = 7  // Thumbnail height in lines, the name takes one more
+)
+
+// thumbnailMsg delivers a generated thumbnail, empty when decoding failed
+type thumbnailMsg struct {
+	key   string
+	ascii string
+}
+
+// thumbnailKey identifies a thumbnail by path and mtime so edits invalidate it
+func thumbnailKey(path string, modTime time.Time) string {
+	return fmt.Sprintf("%s|%d", path, modTime.UnixNano())
+}
+
+// thumbnailCachePath returns where a thumbnail is cached on disk, or "" when
+// no state directory is available
+func thumbnailCachePath(key string) string {
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%dx%d", key, gridCellWidth, gridCellHeight)))
+	return filepath.Join(stateDir, "thumbnails", hex.EncodeToString(sum[:])+".txt")
+}
+
+// generateThumbnail returns a command producing the thumbnail for an image,
+// served from the on-disk cache when a copy for the same mtime exists
+func generateThumbnail(path string, modTime time.Time) tea.Cmd {
+	key := thumbnailKey(path, modTime)
+	return func() tea.Msg {
+		cachePath := thumbnailCachePath(key)
+		if cachePath != "" {
+			if data, err := os.ReadFile(cachePath); err == nil {
+				return thumbnailMsg{key: key, ascii: string(data)}
+			}
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return thumbnailMsg{key: key}
+		}
+		defer file.Close()
+
+		img, _, err := image.Decode(file)
+		if err != nil {
+			return thumbnailMsg{key: key}
+		}
+		ascii := imageToASCII(img, gridCellWidth, gridCellHeight, false)
+
+		if cachePath != "" {
+			config.WriteFileAtomic(cachePath, []byte(ascii), 0644)
+		}
+		return thumbnailMsg{key: key, ascii: ascii}
+	}
+}
+
+// gridPaneSize returns the content size of the grid pane, which replaces
+// the current and preview panes
+func gridPaneSize(m *models.Model) (int, int) {
+	return max(gridCellWidth+2, m.Width-computeLayout(m).ancestorsWidth()-2), getVisibleHeight(m.Height)
+}
+
+// gridLayout returns the number of cell columns and rows that fit the pane
+func gridLayout(m *models.Model) (int, int) {
+	width, height := gridPaneSize(m)
+	cols := max(1, width/(gridCellWidth+2))
+	rows := max(1, (height-2)/(gridCellHeight+3))
+	return cols, rows
+}
+
+// handleGridKeys handles the 2D navigation keys of grid mode, reporting
+// whether the key was consumed
+func (m *AppModel) handleGridKeys(msg tea.KeyMsg) bool {
+	cols, rows := gridLayout(m.Model)
+
+	switch msg.String() {
+	case "left", "h":
+		m.Selected = max(0, m.Selected-1)
+	case "right", "l":
+		m.Selected = max(0, min(len(m.Files)-1, m.Selected+1))
+	case "up", "k":
+		if m.Selected-cols >= 0 {
+			m.Selected -= cols
+		}
+	case "down", "j":
+		if m.Selected+cols < len(m.Files) {
+			m.Selected += cols
+		}
+	case "enter":
+		if len(m.Files) == 0 || !m.Files[m.Selected].IsDir() {
+			return false
+		}
+		m.enterSelected()
+	case "backspace":
+		if parent := filepath.Dir(m.CurrentDir); parent != m.CurrentDir {
+			m.navigateTo(parent, filepath.Base(m.CurrentDir))
+		}
+	case "esc", "I":
+		m.GridMode = false
+		UpdatePreview(m.Model, m.config)
+		return true
+	default:
+		return false
+	}
+
+	// Keep the selected row inside the visible rows
+	row := m.Selected / cols
+	if row < m.GridOffset {
+		m.GridOffset = row
+	} else if row >= m.GridOffset+rows {
+		m.GridOffset = row - rows + 1
+	}
+	return true
+}
+
+// requestThumbnails starts generating thumbnails for the visible images
+// that are neither cached nor already being generated
+func (m *AppModel) requestThumbnails() tea.Cmd {
+	cols, rows := gridLayout(m.Model)
+	start := m.GridOffset * cols
+	end := min(len(m.Files), start+rows*cols)
+
+	var cmds []tea.Cmd
+	for i := start; i < end; i++ {
+		file := m.Files[i]
+		if file.IsDir() || !isImageFileByExtension(file.Entry.Name()) {
+			continue
+		}
+		if m.PreviewMaxSize > 0 && file.Size > m.PreviewMaxSize {
+			continue
+		}
+		path := filepath.Join(m.CurrentDir, file.Entry.Name())
+		key := thumbnailKey(path, file.ModTime)
+		if _, ok := m.Thumbnails[key]; ok {
+			m.Stats.Thumbnails.Record(true)
+			continue
+		}
+		if m.thumbnailsPending[key] {
+			continue
+		}
+		m.Stats.Thumbnails.Record(false)
+		m.thumbnailsPending[key] = true
+		cmds = append(cmds, generateThumbnail(path, file.ModTime))
+	}
+	return tea.Batch(cmds...)
+}
+
+// renderGridPane renders the current directory as a grid of thumbnails,
+// with icons standing in for non-images and pending thumbnails
+func renderGridPane(m *models.Model, cfg config.Config, width, height int) string {
+	var content strings.Builder
+	content.WriteString(truncatePaneTitle(displayName(filepath.Base(m.CurrentDir)), fmt.Sprintf(" (%d items) [grid]", len(m.Files)), max(0, width-2)) + "\n")
+	content.WriteString(paneRule(cfg, width-2) + "\n")
+
+	cols, rows := gridLayout(m)
+	var gridRows []string
+	for r := m.GridOffset; r < m.GridOffset+rows; r++ {
+		var cells []string
+		for c := 0; c < cols; c++ {
+			i := r*cols + c
+			if i >= len(m.Files) {
+				break
+			}
+			cells = append(cells, renderGridCell(m, cfg, m.Files[i], i == m.Selected))
+		}
+		if len(cells) == 0 {
+			break
+		}
+		gridRows = append(gridRows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+	content.WriteString(lipgloss.JoinVertical(lipgloss.Left, gridRows...) + "\n")
+
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(content.String())
+}
+
+// renderGridCell renders one thumbnail with its name underneath
+func renderGridCell(m *models.Model, cfg config.Config, file models.FileInfo, isSelected bool) string {
+	thumbnail := m.Thumbnails[thumbnailKey(filepath.Join(m.CurrentDir, file.Entry.Name()), file.ModTime)]
+	if thumbnail == "" {
+		thumbnail = strings.Repeat("\n", gridCellHeight/2) + fileIcon(file, cfg)
+	}
+	body := newStyle(cfg).
+		Width(gridCellWidth).
+		Height(gridCellHeight).
+		MaxHeight(gridCellHeight).
+		Align(lipgloss.Center).
+		Render(strings.TrimRight(thumbnail, "\n"))
+
+	name := TruncateString(displayName(file.Entry.Name()), gridCellWidth)
+	label := GetFileStyle(file, isSelected, cfg).Width(gridCellWidth).Align(lipgloss.Center).Render(name)
+
+	cellStyle := newStyle(cfg).Border(lipgloss.HiddenBorder())
+	if isSelected {
+		cellStyle = cellStyle.Border(paneBorder(cfg)).BorderForeground(lipgloss.Color(cfg.SelectedItemColor))
+	}
+	return cellStyle.Render(lipgloss.JoinVertical(lipgloss.Left, body, label))
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// dependencies lists the programs optional features run, with the setting
+// that enables each feature
+var dependencies = []struct {
+	tool, feature, setting string
+	enabled                func(cfg config.Config) bool
+}{
+	{"git", "commit logs in directory previews", "git_log_preview", func(cfg config.Config) bool { return cfg.GitLogPreview }},
+	{"ffprobe", "video metadata", "video_previews", func(cfg config.Config) bool { return cfg.VideoPreviews }},
+	{"ffmpeg", "video frames", "video_previews", func(cfg config.Config) bool { return cfg.VideoPreviews }},
+	{"ueberzugpp", "image overlays", "ueberzug_socket", func(cfg config.Config) bool { return cfg.UeberzugSocket != "" }},
+}
+
+// checkDependencies looks up the programs the enabled features need on
+// PATH, without running any, and returns those missing. Features turned off
+// in the config are not checked.
+func checkDependencies(cfg config.Config) []models.MissingTool {
+	var missing []models.MissingTool
+	check := func(tool, feature, setting string) {
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, models.MissingTool{Tool: tool, Feature: feature, Setting: setting})
+		}
+	}
+	for _, dep := range dependencies {
+		if dep.enabled(cfg) {
+			check(dep.tool, dep.feature, dep.setting)
+		}
+	}
+	if args := strings.Fields(cfg.OpenerCommand); len(args) > 0 {
+		check(args[0], "opening with the desktop's application", "opener_command")
+	}
+	for _, pattern := range sortedRules(cfg.OpenRules) {
+		check(cfg.OpenRules[pattern].Command[0], "the "+pattern+" opener", "openers")
+	}
+	return missing
+}
+
+// sortedRules returns the patterns of the [openers] rules in order
+func sortedRules(rules map[string]config.OpenRule) []string {
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// missingToolsMessage summarises the missing programs for the status bar,
+// each tool once with the features it degrades, pointing at the debug
+// screen on debugKey for details
+func missingToolsMessage(missing []models.MissingTool, debugKey string) string {
+	var tools []string
+	features := make(map[string][]string)
+	for _, tool := range missing {
+		if _, ok := features[tool.Tool]; !ok {
+			tools = append(tools, tool.Tool)
+		}
+		features[tool.Tool] = append(features[tool.Tool], tool.Feature)
+	}
+	parts := make([]string, len(tools))
+	for i, tool := range tools {
+		parts[i] = fmt.Sprintf("%s (%s)", tool, strings.Join(features[tool], ", "))
+	}
+	message := "Not on PATH: " + strings.Join(parts, "; ")
+	if debugKey != "" {
+		message += ", " + debugKey + " for details"
+	}
+	return message
+}
+
+// dependencyLines lays out the missing programs for the debug screen
+func dependencyLines(missing []models.MissingTool) []string {
+	lines := []string{" Dependencies"}
+	if len(missing) == 0 {
+		return append(lines, " Every program the enabled features need is on PATH")
+	}
+	for _, tool := range missing {
+		lines = append(lines, fmt.Sprintf(" %-12s not on PATH, needed for %s (set by %s)", tool.Tool, tool.Feature, tool.Setting))
+	}
+	return lines
+}
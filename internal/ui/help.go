@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// helpHint is one "keys:action" entry of the help bar. Hints with a higher
+// priority value are dropped first when the terminal is too narrow.
+type helpHint struct {
+	keys, action string
+	priority     int
+}
+
+// helpHints returns the hints for the active input mode and layout, plus
+// those that only apply to the current state such as an active filter
+func helpHints(m *models.Model, cfg config.Config) []helpHint {
+	keys := func(actions ...keyAction) string { return hintKeys(cfg.Keys, actions...) }
+	// closeKeys names the keys closing the view toggled by action
+	closeKeys := func(action keyAction) string {
+		if k := keys(action); k != "" {
+			return k + "/esc/q"
+		}
+		return "esc/q"
+	}
+	switch m.InputMode {
+	case models.ModeSearch:
+		return []helpHint{{"", "Type to search", 0}, {"Enter", "confirm", 0}, {"Esc", "cancel", 0}, {"Ctrl+T", "fuzzy/substring", 1}}
+	case models.ModePrompt:
+		hints := []helpHint{{"", "Type your answer", 1}, {"Enter", "submit", 0}, {"Esc", "cancel", 0}, {"Left/Right", "move cursor", 2}}
+		if m.PromptPath || len(m.PromptCandidates) > 0 {
+			hints = append(hints, helpHint{"Tab/Shift+Tab", "complete", 0})
+		}
+		return hints
+	case models.ModeConfirm:
+		return []helpHint{{"y", "confirm", 0}, {"any other key", "cancel", 0}}
+	}
+
+	if m.PaletteView {
+		return []helpHint{{"", "Type to filter", 1}, {"up/down", "move", 0}, {"enter", "run", 0}, {"esc", "close", 0}}
+	}
+	if m.TrashView {
+		return []helpHint{{keys(actionDown, actionUp), "move", 0}, {"enter/r", "restore", 0}, {"E", "empty", 1}, {closeKeys(actionTrashView), "close", 0}}
+	}
+	if m.FavoritesView {
+		hints := []helpHint{{keys(actionDown, actionUp), "move", 0}, {"enter", "go to", 0}}
+		if len(m.StaleFavorites) > 0 {
+			hints = append(hints, helpHint{"D", "prune missing", 1})
+		}
+		return append(hints, helpHint{closeKeys(actionFavorites), "close", 0})
+	}
+	if m.DebugScreen {
+		return []helpHint{{closeKeys(actionDebug), "close", 0}}
+	}
+	if m.CompareView {
+		return []helpHint{{keys(actionDown, actionUp), "move", 0}, {"enter/h", "in/out", 0}, {"c/C", "copy missing/all", 1}, {">/<", "overwrite B/A", 2}, {"H", "compare contents", 2}, {"esc", "close", 0}}
+	}
+	if m.GrepView {
+		return []helpHint{{keys(actionDown, actionUp), "move", 0}, {"enter", "go to", 0}, {"esc", "stop/close", 0}}
+	}
+	if m.BatchScreen {
+		return []helpHint{{keys(actionDown, actionUp), "scroll", 0}, {"esc/q", "close", 0}}
+	}
+	move := keys(actionDown, actionUp)
+	if m.GridMode {
+		return []helpHint{{"h/" + move + "/l", "move", 0}, {"enter", "open", 1}, {"backspace", "parent", 2}, {"I/esc", "exit grid", 0}}
+	}
+	if m.QuickLook {
+		return []helpHint{
+			{move, "scroll", 0},
+			{keys(actionScrollDown, actionScrollUp), "half page", 2},
+			{keys(actionTop, actionBottom), "top/bottom", 2},
+			{":", "line", 3},
+			{closeKeys(actionQuickLook), "back", 0},
+		}
+	}
+
+	if m.Visual {
+		return []helpHint{
+			{move, "extend", 0},
+			{keys(actionClear), "mark range", 0},
+			{keys(actionDelete, actionYank, actionCut), "act on range", 1},
+			{keys(actionVisual), "cancel", 0},
+		}
+	}
+
+	var hints []helpHint
+	if m.Cancellable != "" {
+		hints = append(hints, helpHint{keys(actionClear), "cancel " + m.Cancellable, 0})
+	} else if m.SearchQuery != "" || m.FilterPreset != "" {
+		hints = append(hints, helpHint{keys(actionClear), "clear filter", 0})
+	} else if len(m.Marked) > 0 {
+		hints = append(hints, helpHint{keys(actionClear), "clear marks", 1})
+	} else if m.ClipboardCut {
+		hints = append(hints, helpHint{keys(actionClear), "clear cut", 1})
+	}
+	if len(m.Changed) > 0 {
+		hints = append(hints, helpHint{keys(actionClearChanges), "seen", 2})
+	}
+	if m.RootFS {
+		return dropUnbound(append(hints,
+			helpHint{keys(actionQuit), "quit", 0},
+			helpHint{keys(actionParent, actionEnter), "nav", 0},
+			helpHint{move, "up/down", 1},
+			helpHint{keys(actionOpen), openLabel(m), 1},
+			helpHint{keys(actionMark), "mark", 2},
+			helpHint{keys(actionSearch), "search", 2},
+			helpHint{keys(actionGotoLine), "line", 3},
+			helpHint{keys(actionRaw), "raw", 3},
+		))
+	}
+	if m.ArchiveFS != nil {
+		return dropUnbound(append(hints,
+			helpHint{keys(actionQuit), "quit", 0},
+			helpHint{keys(actionParent, actionEnter), "nav (" + keys(actionParent) + " at the root leaves)", 0},
+			helpHint{move, "up/down", 1},
+			helpHint{keys(actionYank), "extract", 1},
+			helpHint{keys(actionSearch), "search", 2},
+			helpHint{keys(actionGotoLine), "line", 3},
+			helpHint{keys(actionRaw), "raw", 3},
+		))
+	}
+
+	// Keys that only do something for the selected entry come early
+	if len(m.Files) > 0 && m.Selected < len(m.Files) {
+		file := m.Files[m.Selected]
+		fullPath := filepath.Join(m.CurrentDir, file.Entry.Name())
+		if !file.IsDir() && m.PreviewMaxSize > 0 && file.Size > m.PreviewMaxSize && m.ForcePreviewPath != fullPath {
+			hints = append(hints, helpHint{keys(actionForcePreview), "preview anyway", 1})
+		}
+		if !file.IsDir() && isImageFileByExtension(file.Entry.Name()) && cfg.Color && m.ImageProtocol == "" {
+			action := "color"
+			if m.ImagePreviewColored {
+				action = "monochrome"
+			}
+			hints = append(hints, helpHint{keys(actionImageColor), action, 2})
+		}
+		if isEnvFile(file.Entry.Name()) {
+			action := "reveal secrets"
+			if m.RevealSecretsPath == fullPath {
+				action = "mask secrets"
+			}
+			hints = append(hints, helpHint{keys(actionRevealSecrets), action, 1})
+		}
+	}
+	return dropUnbound(append(hints,
+		helpHint{keys(actionQuit), "quit", 0},
+		helpHint{keys(actionParent, actionEnter), "nav", 0},
+		helpHint{keys(actionPrevSibling, actionNextSibling), "sibling", 4},
+		helpHint{move, "up/down", 0},
+		helpHint{keys(actionOpen), openLabel(m), 1},
+		helpHint{keys(actionOpenDefault), "open with app", 4},
+		helpHint{keys(actionPhysicalPaths), "physical paths", 4},
+		helpHint{keys(actionMark), "mark", 2},
+		helpHint{keys(actionMarkAll, actionInvertMarks), "mark all/invert", 4},
+		helpHint{keys(actionYank, actionCut, actionPaste), "copy/cut/paste", 2},
+		helpHint{keys(actionRename), "rename", 3},
+		helpHint{keys(actionNewFile, actionNewDir), "new file/dir", 3},
+		helpHint{keys(actionNewTemplate), "from template", 4},
+		helpHint{keys(actionTrash), "trash", 2},
+		helpHint{keys(actionDelete), "delete", 4},
+		helpHint{keys(actionTrashView), "show trash", 4},
+		helpHint{keys(actionChmod), "chmod", 4},
+		helpHint{keys(actionBatch), "run on marked", 4},
+		helpHint{keys(actionOpenBy), "open by", 4},
+		helpHint{keys(actionToggleHidden), "hidden", 2},
+		helpHint{keys(actionSortSize, actionSortModified, actionSortName), "sort", 3},
+		helpHint{keys(actionSearch), "search", 1},
+		helpHint{keys(actionGrep), "search contents", 3},
+		helpHint{keys(actionCompare), "compare dirs", 4},
+		helpHint{keys(actionFilterPreset), "filter preset", 4},
+		helpHint{keys(actionGotoLine), "line", 4},
+		helpHint{keys(actionRefresh), "refresh", 2},
+		helpHint{keys(actionRaw), "raw", 4},
+		helpHint{keys(actionQuickLook), "quick look", 2},
+		helpHint{keys(actionGrid), "grid", 4},
+		helpHint{keys(actionFavorite), "star", 4},
+		helpHint{keys(actionFavorites), "favorites", 3},
+		helpHint{keys(actionPalette), "all actions", 1},
+	))
+}
+
+// dropUnbound leaves out the hints of actions no key is bound to
+func dropUnbound(hints []helpHint) []helpHint {
+	bound := hints[:0]
+	for _, h := range hints {
+		if h.keys != "" {
+			bound = append(bound, h)
+		}
+	}
+	return bound
+}
+
+// openLabel names what the open key does, choosing in chooser mode
+func openLabel(m *models.Model) string {
+	if m.Chooser {
+		return "choose"
+	}
+	return "open"
+}
+
+// fitHints joins hints into a line of at most width columns, dropping the
+// least important hints first while keeping the rest in order
+func fitHints(hints []helpHint, width int) string {
+	format := func(hints []helpHint) string {
+		parts := make([]string, 0, len(hints))
+		for _, h := range hints {
+			if h.keys == "" {
+				parts = append(parts, h.action)
+			} else {
+				parts = append(parts, h.keys+":"+h.action)
+			}
+		}
+		return strings.Join(parts, " | ")
+	}
+
+	for {
+		line := format(hints)
+		if len(hints) <= 1 || len([]rune(line)) <= width {
+			return line
+		}
+		drop := 0
+		for i, h := range hints {
+			if h.priority >= hints[drop].priority {
+				drop = i
+			}
+		}
+		hints = append(hints[:drop:drop], hints[drop+1:]...)
+	}
+}
+
+// renderHelpBar renders the help bar
+func renderHelpBar(m *models.Model, cfg config.Config) string {
+	helpStyle := GetHelpStyle(cfg, m.Width)
+	return helpStyle.Render(TruncateString(fitHints(helpHints(m, cfg), m.Width-2), m.Width-2)) // -2 for style padding
+}
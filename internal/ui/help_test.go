@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArchiveHintsDropUnboundKeys(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "bundle.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("notes\n"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	m := newConfiguredModel(t, Options{Path: dir}, "[keybindings]\nraw = []\ngoto_line = []\n")
+	selectName(t, m, "bundle.zip")
+	press(t, m, "enter")
+	if m.ArchiveFS == nil {
+		t.Fatal("enter did not open the archive")
+	}
+	got := helpBar(m)
+	if !strings.Contains(got, "y:extract") {
+		t.Errorf("help bar %q is not the archive's", got)
+	}
+	for _, hint := range []string{"raw", "line"} {
+		if strings.Contains(got, "| "+hint) {
+			t.Errorf("help bar %q offers the unbound %s", got, hint)
+		}
+	}
+}
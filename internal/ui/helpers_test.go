@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// testWidth and testHeight are the terminal size test models run in
+const (
+	testWidth  = 120
+	testHeight = 30
+)
+
+// keyTypes maps the names keys are bound by, such as "ctrl+r" or "enter",
+// to the key types whose messages have those names
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for k := -100; k < 200; k++ {
+		key := tea.KeyType(k)
+		if key == tea.KeyRunes {
+			continue
+		}
+		if name := (tea.KeyMsg{Type: key}).String(); name != "" {
+			if _, ok := types[name]; !ok {
+				types[name] = key
+			}
+		}
+	}
+	return types
+}()
+
+// keyMsg returns the message of pressing the key bound as name
+func keyMsg(t testing.TB, name string) tea.KeyMsg {
+	t.Helper()
+	if name == " " {
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	}
+	if key, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: key}
+	}
+	if runes := []rune(name); len(runes) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes}
+	}
+	t.Fatalf("unknown key %q", name)
+	return tea.KeyMsg{}
+}
+
+// isolateEnv points HOME at an empty directory and clears the variables
+// that would read the user's configuration or probe the terminal
+func isolateEnv(t testing.TB) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_STATE_HOME", "XDG_DATA_HOME", "NO_COLOR", "COLORFGBG", "TMUX", "UB_SOCKET", "KITTY_WINDOW_ID", "TERM_PROGRAM", "LC_ALL", "LC_CTYPE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("LANG", "C.UTF-8")
+	t.Setenv("EDITOR", "true")
+	t.Setenv(imageProtocolEnv, "ascii")
+	return home
+}
+
+// newTestModel opens a standalone model as Options ask, with the default
+// config and a testWidth by testHeight terminal, once its listing is
+// loaded
+func newTestModel(t testing.TB, opts Options) *AppModel {
+	t.Helper()
+	return newConfiguredModel(t, opts, "")
+}
+
+// newConfiguredModel is newTestModel with configTOML as the user's
+// config.toml
+func newConfiguredModel(t testing.TB, opts Options, configTOML string) *AppModel {
+	t.Helper()
+	return newEnvModel(t, opts, configTOML, nil)
+}
+
+// newEnvModel is newConfiguredModel with the variables of env set over the
+// isolated environment
+func newEnvModel(t testing.TB, opts Options, configTOML string, env map[string]string) *AppModel {
+	t.Helper()
+	home := isolateEnv(t)
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+	if configTOML != "" {
+		configDir := filepath.Join(home, ".config", "bullseye")
+		if err := os.MkdirAll(configDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configTOML), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts.Standalone = true
+	m := NewAppModel(opts)
+	if m.Err != nil {
+		t.Fatalf("NewAppModel: %v", m.Err)
+	}
+	settle(t, m, m.Init())
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: testHeight})
+	return m
+}
+
+// send updates m with msg and whatever its commands deliver, returning
+// those messages
+func send(t testing.TB, m *AppModel, msg tea.Msg) []tea.Msg {
+	t.Helper()
+	_, cmd := m.Update(msg)
+	return settle(t, m, cmd)
+}
+
+// press sends the keys one after the other, as bound in the keymap, and
+// returns the messages their commands delivered
+func press(t testing.TB, m *AppModel, keys ...string) []tea.Msg {
+	t.Helper()
+	var msgs []tea.Msg
+	for _, key := range keys {
+		msgs = append(msgs, send(t, m, keyMsg(t, key))...)
+	}
+	return msgs
+}
+
+// typeText types text into m a rune at a time
+func typeText(t testing.TB, m *AppModel, text string) {
+	t.Helper()
+	for _, r := range text {
+		send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// settle runs cmd and feeds the messages it delivers back into m, as the
+// runtime would, until no command is left. Batches and sequences run in
+// order. The messages are returned, so tests can look for tea.QuitMsg.
+func settle(t testing.TB, m *AppModel, cmd tea.Cmd) []tea.Msg {
+	t.Helper()
+	var msgs []tea.Msg
+	pending := []tea.Cmd{cmd}
+	deadline := time.Now().Add(10 * time.Second)
+	for len(pending) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("commands did not settle")
+		}
+		cmd, pending = pending[0], pending[1:]
+		if cmd == nil {
+			continue
+		}
+		msg := cmd()
+		if msg == nil {
+			continue
+		}
+		// tea.BatchMsg and the unexported sequence message are both lists
+		// of commands
+		if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+			cmds := make([]tea.Cmd, v.Len())
+			for i := range cmds {
+				cmds[i], _ = v.Index(i).Interface().(tea.Cmd)
+			}
+			pending = append(cmds, pending...)
+			continue
+		}
+		msgs = append(msgs, msg)
+		_, next := m.Update(msg)
+		pending = append([]tea.Cmd{next}, pending...)
+	}
+	return msgs
+}
+
+// hasQuit reports whether msgs ended the program
+func hasQuit(msgs []tea.Msg) bool {
+	for _, msg := range msgs {
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTree creates the files below dir, a name ending in "/" as a
+// directory, each file holding its name
+func writeTree(t testing.TB, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listed names the entries of the current listing in order
+func listed(m *AppModel) []string {
+	list := make([]string, len(m.Files))
+	for i, file := range m.Files {
+		list[i] = file.Entry.Name()
+	}
+	return list
+}
+
+// selectName puts the cursor on the named entry of the listing
+func selectName(t testing.TB, m *AppModel, name string) {
+	t.Helper()
+	i := m.indexByName(name)
+	if i < 0 {
+		t.Fatalf("no entry %q in %v", name, listed(m))
+	}
+	m.selectIndex(i)
+	settle(t, m, m.startPreview())
+}
+
+// helpBar returns the help bar as rendered, without its styling
+func helpBar(m *AppModel) string {
+	return strings.TrimSpace(ansi.Strip(renderHelpBar(m.Model, m.config)))
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+func TestHiddenPerSurface(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, ".up-hidden/", "cwd/.here-hidden", "cwd/sub/.in-hidden", "cwd/sub/visible", ".secret/inside")
+
+	// Each surface follows the toggle or is fixed by its setting
+	settings := []string{"follow", "show", "hide", "bogus"}
+	for _, parent := range settings {
+		for _, preview := range settings {
+			configTOML := fmt.Sprintf("show_hidden_parent = %q\nshow_hidden_preview = %q\n", parent, preview)
+			m := newConfiguredModel(t, Options{Path: filepath.Join(root, "cwd")}, configTOML)
+			for _, toggled := range []bool{false, true} {
+				if toggled {
+					press(t, m, ".")
+				}
+				selectName(t, m, "sub")
+				what := fmt.Sprintf("parent %s, preview %s, toggled %v", parent, preview, toggled)
+				shown := func(setting string) bool {
+					return setting == "show" || (setting != "hide" && toggled)
+				}
+
+				if got := slices.Contains(listed(m), ".here-hidden"); got != toggled {
+					t.Errorf("%s: current pane shows hidden %v", what, got)
+				}
+				up := m.Ancestors[0].Files
+				if got := slices.ContainsFunc(up, func(f models.FileInfo) bool { return f.Entry.Name() == ".up-hidden" }); got != shown(parent) {
+					t.Errorf("%s: parent pane shows hidden %v", what, got)
+				}
+				lines := strings.Join(m.PreviewLines, "\n")
+				if got := strings.Contains(lines, ".in-hidden"); got != shown(preview) || !strings.Contains(lines, "visible") {
+					t.Errorf("%s: directory preview shows hidden %v:\n%s", what, got, lines)
+				}
+			}
+		}
+	}
+
+	// A hidden current directory stays in the parent pane that hides the rest
+	m := newConfiguredModel(t, Options{Path: filepath.Join(root, ".secret")}, "show_hidden_parent = \"hide\"\n")
+	var up []string
+	for _, f := range m.Ancestors[0].Files {
+		up = append(up, f.Entry.Name())
+	}
+	if !slices.Equal(up, []string{".secret", "cwd"}) {
+		t.Errorf("parent pane %v", up)
+	}
+	if a := m.Ancestors[0]; a.Files[a.Selected].Entry.Name() != ".secret" {
+		t.Errorf("parent pane highlights %s", a.Files[a.Selected].Entry.Name())
+	}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// historyLimit caps the directories kept for back and forward, the oldest
+// are dropped
+const historyLimit = 100
+
+// saveHistoryCursor records where the cursor is in the current directory's
+// history entry, for coming back to it
+func (m *AppModel) saveHistoryCursor() {
+	if m.HistoryPos >= len(m.History) || m.History[m.HistoryPos].Dir != m.CurrentDir {
+		return
+	}
+	m.History[m.HistoryPos].CursorState = m.cursorState()
+}
+
+// pushHistory records entering dir after the current directory. As in a
+// browser, the directories gone back from are dropped.
+func (m *AppModel) pushHistory(dir string) {
+	if len(m.History) == 0 {
+		m.History = append(m.History, models.HistoryEntry{Dir: m.CurrentDir})
+	}
+	m.saveHistoryCursor()
+	m.History = append(m.History[:m.HistoryPos+1], models.HistoryEntry{Dir: dir})
+	if len(m.History) > historyLimit {
+		m.History = append(m.History[:0], m.History[len(m.History)-historyLimit:]...)
+	}
+	m.HistoryPos = len(m.History) - 1
+}
+
+// historyJump goes delta entries back (negative) or forward in the history,
+// restoring the cursor and scroll position the directory was left with
+func (m *AppModel) historyJump(delta int) {
+	target := m.HistoryPos + delta
+	if target < 0 || target >= len(m.History) {
+		if delta < 0 {
+			m.StatusMessage = "No earlier directory in the history"
+		} else {
+			m.StatusMessage = "No later directory in the history"
+		}
+		return
+	}
+	m.saveHistoryCursor()
+	m.HistoryPos = target
+	entry := m.History[target]
+	m.jumping = true
+	m.pendingRestore = &entry.CursorState
+	m.navigateTo(entry.Dir, entry.Name)
+	m.jumping = false
+	m.StatusMessage = fmt.Sprintf("History %d/%d", target+1, len(m.History))
+}
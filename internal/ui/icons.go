@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"io/fs"
 	"path/filepath"
 	"strings"
 
+	"github.com/embeddingbits/file_viewer/internal/config"
 	"github.com/embeddingbits/file_viewer/pkg/models"
 )
 
@@ -13,7 +15,7 @@ func GetFileIcon(file models.FileInfo) string {
 	name := strings.ToLower(file.Entry.Name())
 	ext := strings.ToLower(filepath.Ext(file.Entry.Name()))
 
-	if file.Entry.IsDir() {
+	if file.IsDir() {
 		// Special directory icons
 		switch filepath.Base(name) {
 		case ".git":
@@ -41,6 +43,18 @@ func GetFileIcon(file models.FileInfo) string {
 		}
 	}
 
+	// Devices, sockets and pipes
+	switch {
+	case file.Mode&fs.ModeSocket != 0:
+		return "" // nf-fa-plug
+	case file.Mode&fs.ModeNamedPipe != 0:
+		return "󰟥" // nf-md-pipe
+	case file.Mode&fs.ModeCharDevice != 0:
+		return "" // nf-fa-microchip
+	case file.Mode&fs.ModeDevice != 0:
+		return "" // nf-fa-hdd_o
+	}
+
 	// Special file names (exact match)
 	switch name {
 	case "dockerfile":
@@ -212,4 +226,33 @@ func GetFileIcon(file models.FileInfo) string {
 	}
 }
 
+// ASCIIFileIcon returns a one-column icon for terminals without UTF-8, the
+// type indicator ls -F appends: "/" for directories, "@" for symlinks, "="
+// for sockets, "|" for pipes, "#" for devices, "*" for executables and "-"
+// for other files
+func ASCIIFileIcon(file models.FileInfo) string {
+	switch {
+	case file.IsDir():
+		return "/"
+	case file.Mode&fs.ModeSymlink != 0:
+		return "@"
+	case file.Mode&fs.ModeSocket != 0:
+		return "="
+	case file.Mode&fs.ModeNamedPipe != 0:
+		return "|"
+	case file.Mode&fs.ModeDevice != 0:
+		return "#"
+	case file.Mode&0111 != 0:
+		return "*"
+	default:
+		return "-"
+	}
+}
 
+// fileIcon returns the icon drawn for file, the ASCII one when Unicode is off
+func fileIcon(file models.FileInfo, cfg config.Config) string {
+	if !cfg.Unicode {
+		return ASCIIFileIcon(file)
+	}
+	return GetFileIcon(file)
+}
@@ -0,0 +1,113 @@
+package ui
+
+import "strings"
+
+// keyAction names a command that can be bound to keys, its value is the
+// action's name in the [keybindings] table of the config
+type keyAction string
+
+const (
+	actionUp                keyAction = "up"
+	actionDown              keyAction = "down"
+	actionTop               keyAction = "top"
+	actionBottom            keyAction = "bottom"
+	actionScrollUp          keyAction = "scroll_up" // By scroll_step
+	actionScrollDown        keyAction = "scroll_down"
+	actionPageUp            keyAction = "page_up" // By page_step
+	actionPageDown          keyAction = "page_down"
+	actionPreviewScrollUp   keyAction = "preview_scroll_up" // Preview by scroll_step
+	actionPreviewScrollDown keyAction = "preview_scroll_down"
+
+	actionQuit          keyAction = "quit"
+	actionEnter         keyAction = "enter_dir" // Into the selected directory or archive
+	actionParent        keyAction = "parent_dir"
+	actionOpen          keyAction = "open_editor"  // In the editor
+	actionOpenDefault   keyAction = "open_default" // In the desktop's application for the file
+	actionActivate      keyAction = "activate"     // Enter an archive, else open
+	actionHome          keyAction = "home"
+	actionForcePreview  keyAction = "force_preview"
+	actionGrid          keyAction = "grid"
+	actionQuickLook     keyAction = "quick_look"
+	actionGotoLine      keyAction = "goto_line"
+	actionOpenBy        keyAction = "open_by"
+	actionChmod         keyAction = "chmod"
+	actionRename        keyAction = "rename"
+	actionNewFile       keyAction = "new_file"
+	actionNewDir        keyAction = "new_dir"
+	actionTrash         keyAction = "trash"
+	actionDelete        keyAction = "delete" // Permanently, after confirming
+	actionFavorite      keyAction = "favorite"
+	actionFavorites     keyAction = "favorites"
+	actionDebug         keyAction = "debug"
+	actionGotoPath      keyAction = "goto_path"
+	actionRepeat        keyAction = "repeat"
+	actionClear         keyAction = "clear" // The search filter, marks and the cut buffer
+	actionSearch        keyAction = "search"
+	actionToggleHidden  keyAction = "toggle_hidden"
+	actionSortSize      keyAction = "sort_size"
+	actionSortModified  keyAction = "sort_modified"
+	actionSortName      keyAction = "sort_name"
+	actionRefresh       keyAction = "refresh"
+	actionRaw           keyAction = "raw"
+	actionRevealSecrets keyAction = "reveal_secrets"
+	actionYank          keyAction = "yank" // Inside archives, extract
+	actionCut           keyAction = "cut"
+	actionPaste         keyAction = "paste"
+	actionPrevSibling   keyAction = "prev_sibling"
+	actionNextSibling   keyAction = "next_sibling"
+	actionMark          keyAction = "mark"
+	actionMarkAll       keyAction = "mark_all" // The entries the listing shows, under the filter
+	actionInvertMarks   keyAction = "invert_marks"
+	actionNewTemplate   keyAction = "new_from_template"
+	actionVisual        keyAction = "visual"
+	actionFilterPreset  keyAction = "filter_preset" // Followed by the key of a [filters] preset
+	actionClearChanges  keyAction = "clear_changes" // Markers of entries changed since the last visit
+	actionHistoryBack   keyAction = "history_back"  // To the previously visited directory
+	actionHistoryNext   keyAction = "history_forward"
+	actionPhysicalPaths keyAction = "physical_paths" // Toggle opening by logical or resolved path
+	actionBatch         keyAction = "batch"          // Run a command on each marked file
+	actionGrep          keyAction = "grep"           // Search file contents below the current directory
+	actionCompare       keyAction = "compare"        // Compare the current directory with another
+	actionPalette       keyAction = "palette"        // List every action to run one by name
+	actionFuzzySearch   keyAction = "fuzzy_search"   // Switch the search between fuzzy and substring matching
+	actionTrashView     keyAction = "trash_view"     // List the trash to restore or empty it
+	actionImageColor    keyAction = "image_color"    // Draw image previews in color or monochrome
+	actionMacroRecord   keyAction = "macro_record"   // Start or stop recording a key macro, in every mode
+	actionMacroReplay   keyAction = "macro_replay"
+)
+
+// bindKeys inverts a keymap of action names into the key to action lookup
+// used when dispatching key presses
+func bindKeys(keymap map[string][]string) map[string]keyAction {
+	keys := make(map[string]keyAction)
+	for action, bound := range keymap {
+		for _, key := range bound {
+			keys[key] = keyAction(action)
+		}
+	}
+	return keys
+}
+
+// hintKeys names the keys bound to actions for the help bar, using the first
+// single-character binding of each, e.g. "j/k" for down and up
+func hintKeys(keymap map[string][]string, actions ...keyAction) string {
+	names := make([]string, 0, len(actions))
+	for _, action := range actions {
+		bound := keymap[string(action)]
+		if len(bound) == 0 {
+			continue
+		}
+		name := bound[0]
+		for _, key := range bound {
+			if len(key) == 1 {
+				name = key
+				break
+			}
+		}
+		if name == " " {
+			name = "space"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, "/")
+}
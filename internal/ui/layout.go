@@ -0,0 +1,58 @@
+package ui
+
+import "github.com/embeddingbits/file_viewer/pkg/models"
+
+// paneLayout holds the widths of the panes drawn side by side, not counting
+// the two border columns each pane adds
+type paneLayout struct {
+	ancestors []int // Parent first, then further up
+	current   int
+	preview   int
+}
+
+// computeLayout sizes the ancestor, current and preview panes for the
+// terminal width. Ancestors beyond the parent are dropped, farthest first,
+// while they would leave the preview less than a quarter of the width.
+// Quick look gives the preview the whole row.
+func computeLayout(m *models.Model) paneLayout {
+	if m.QuickLook {
+		return paneLayout{preview: max(m.Width-2, 20)}
+	}
+	l := paneLayout{current: max(m.Width/3, 20)}
+	for i := 0; i < m.Columns-2; i++ {
+		if i == 0 {
+			l.ancestors = append(l.ancestors, max(m.Width/4, 15))
+		} else {
+			l.ancestors = append(l.ancestors, max(m.Width/8, 12))
+		}
+	}
+	for len(l.ancestors) > 1 && l.remaining(m.Width) < m.Width/4 {
+		l.ancestors = l.ancestors[:len(l.ancestors)-1]
+	}
+	l.preview = max(l.remaining(m.Width), 20)
+	return l
+}
+
+// previewOrigin returns the screen cell where the preview content starts,
+// inside the pane's top-left border corner
+func (l paneLayout) previewOrigin() (int, int) {
+	x := l.ancestorsWidth() + 1
+	if l.current > 0 {
+		x += l.current + 2
+	}
+	return x, 1
+}
+
+// remaining returns the width left for the preview pane
+func (l paneLayout) remaining(total int) int {
+	return total - l.ancestorsWidth() - (l.current + 2) - 2
+}
+
+// ancestorsWidth returns the columns taken by the ancestor panes, borders included
+func (l paneLayout) ancestorsWidth() int {
+	total := 0
+	for _, w := range l.ancestors {
+		total += w + 2
+	}
+	return total
+}
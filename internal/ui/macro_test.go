@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// markedNames lists the names of the marked entries, sorted
+func markedNames(m *AppModel) []string {
+	var marked []string
+	for path := range m.Marked {
+		marked = append(marked, filepath.Base(path))
+	}
+	slices.Sort(marked)
+	return marked
+}
+
+func TestMacroReplaysNavigationAndMarks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b", "c", "d", "e", "f", "g")
+	m := newTestModel(t, Options{Path: dir})
+
+	press(t, m, "ctrl+r")
+	if !m.MacroRecording {
+		t.Fatal("ctrl+r did not start recording")
+	}
+	press(t, m, "j", " ", "j", " ")
+	press(t, m, "ctrl+r")
+	if m.MacroRecording {
+		t.Fatal("ctrl+r did not stop recording")
+	}
+	if got, want := m.StatusMessage, "Recorded 4 keys, ctrl+p to replay"; got != want {
+		t.Errorf("status = %q, want %q", got, want)
+	}
+	if got, want := markedNames(m), []string{"b", "d"}; !slices.Equal(got, want) {
+		t.Fatalf("recorded marks = %v, want %v", got, want)
+	}
+
+	// From the same start each replay ends in the recorded state
+	for range 2 {
+		press(t, m, "esc", "g")
+		if len(m.Marked) != 0 || m.Selected != 0 {
+			t.Fatalf("esc and g left marks %v and the cursor on %d", m.Marked, m.Selected)
+		}
+		press(t, m, "ctrl+p")
+		if got, want := markedNames(m), []string{"b", "d"}; !slices.Equal(got, want) {
+			t.Errorf("marks after replay = %v, want %v", got, want)
+		}
+		if got := m.selectedName(); got != "e" {
+			t.Errorf("selected after replay = %q, want e", got)
+		}
+	}
+
+	// From elsewhere it carries on from there, stopping at the end
+	press(t, m, "ctrl+p")
+	if got, want := markedNames(m), []string{"b", "d", "f", "g"}; !slices.Equal(got, want) {
+		t.Errorf("marks after replay from e = %v, want %v", got, want)
+	}
+}
+
+func TestMacroReplayIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "one/a", "one/b", "one/c", "two/a", "two/b", "two/c")
+	m := newTestModel(t, Options{Path: dir})
+
+	// Into the first directory to mark its second entry
+	press(t, m, "ctrl+r", "l", "j", " ", "ctrl+r")
+	if got := m.CurrentDir; got != filepath.Join(dir, "one") {
+		t.Fatalf("CurrentDir after recording = %q, want one", got)
+	}
+	if want := map[string]bool{filepath.Join(dir, "one", "b"): true}; !maps.Equal(m.Marked, want) {
+		t.Fatalf("marks after recording = %v, want %v", m.Marked, want)
+	}
+
+	// The same keys on the second directory, each seeing the listing the
+	// previous key loaded
+	press(t, m, "h", "j")
+	press(t, m, "ctrl+p")
+	if got := m.CurrentDir; got != filepath.Join(dir, "two") {
+		t.Errorf("CurrentDir after replay = %q, want two", got)
+	}
+	if want := map[string]bool{filepath.Join(dir, "two", "b"): true}; !maps.Equal(m.Marked, want) {
+		t.Errorf("marks after replay = %v, want %v", m.Marked, want)
+	}
+	if got := m.selectedName(); got != "c" {
+		t.Errorf("selected after replay = %q, want c", got)
+	}
+	if m.Loading || m.PreviewPending {
+		t.Errorf("replay left Loading %v, PreviewPending %v", m.Loading, m.PreviewPending)
+	}
+}
+
+func TestMacroRecordsPromptKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b")
+	m := newTestModel(t, Options{Path: dir})
+
+	press(t, m, "ctrl+r", "N")
+	typeText(t, m, "new1")
+	press(t, m, "enter", "ctrl+r")
+	if _, err := os.Stat(filepath.Join(dir, "new1")); err != nil {
+		t.Fatalf("recorded prompt did not create new1: %v", err)
+	}
+
+	// Replaying types the name into the prompt again
+	if err := os.Remove(filepath.Join(dir, "new1")); err != nil {
+		t.Fatal(err)
+	}
+	press(t, m, "ctrl+p")
+	if _, err := os.Stat(filepath.Join(dir, "new1")); err != nil {
+		t.Errorf("replay did not create new1: %v", err)
+	}
+	if got := m.selectedName(); got != "new1" {
+		t.Errorf("selected after replay = %q, want new1", got)
+	}
+}
+
+func TestMacroReplayNeedsARecording(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b", "c")
+	m := newTestModel(t, Options{Path: dir})
+
+	press(t, m, "ctrl+p")
+	if m.Selected != 0 || m.MacroRecording {
+		t.Errorf("replay without a macro moved to %d, recording %v", m.Selected, m.MacroRecording)
+	}
+
+	// The replay key is not recorded, nor replayed while recording
+	press(t, m, "ctrl+r", "j", "ctrl+p", "ctrl+r")
+	if len(m.macro) != 1 || m.Selected != 1 {
+		t.Errorf("recorded %d keys ending on %d, want 1 key ending on 1", len(m.macro), m.Selected)
+	}
+}
+
+func TestMacroKeysFollowKeybindings(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b", "c")
+	m := newConfiguredModel(t, Options{Path: dir}, "[keybindings]\nmacro_record = \"ctrl+e\"\nmacro_replay = \"ctrl+y\"\nup = [\"k\", \"up\", \"ctrl+p\"]\n")
+
+	press(t, m, "ctrl+e", "j", "ctrl+e")
+	if got, want := m.StatusMessage, "Recorded 1 keys, ctrl+y to replay"; got != want {
+		t.Errorf("status = %q, want %q", got, want)
+	}
+	press(t, m, "ctrl+y")
+	if m.Selected != 2 {
+		t.Errorf("selected after replay = %d, want 2", m.Selected)
+	}
+	// ctrl+p was freed for moving up
+	press(t, m, "ctrl+p")
+	if m.Selected != 1 {
+		t.Errorf("selected after ctrl+p = %d, want 1", m.Selected)
+	}
+}
+
+func TestRepeatRename(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "notes.txt", "report.pdf", "summary.pdf")
+	m := newTestModel(t, Options{Path: dir})
+
+	selectName(t, m, "report.pdf")
+	press(t, m, "a", "ctrl+a")
+	typeText(t, m, "report-final.pdf")
+	press(t, m, "enter")
+	if got := m.selectedName(); got != "report-final.pdf" {
+		t.Fatalf("selected after rename = %q, want report-final.pdf", got)
+	}
+
+	selectName(t, m, "summary.pdf")
+	press(t, m, ";")
+	if _, err := os.Stat(filepath.Join(dir, "summary-final.pdf")); err != nil {
+		t.Errorf("repeat did not rename summary.pdf: %v", err)
+	}
+	selectName(t, m, "notes.txt")
+	press(t, m, ";")
+	if _, err := os.Stat(filepath.Join(dir, "notes-final.txt")); err != nil {
+		t.Errorf("repeat did not keep the extension of notes.txt: %v", err)
+	}
+}
+
+func TestRepeatChmod(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b", "sub/c")
+	m := newTestModel(t, Options{Path: dir})
+
+	selectName(t, m, "a")
+	press(t, m, "=")
+	typeText(t, m, "600")
+	press(t, m, "enter")
+	selectName(t, m, "b")
+	press(t, m, ";")
+	for _, name := range []string{"a", "b"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("mode of %s = %v, want 0600", name, info.Mode().Perm())
+		}
+	}
+
+	// A recursive chmod of a directory repeats recursively
+	selectName(t, m, "sub")
+	press(t, m, "=")
+	typeText(t, m, "go-rwx")
+	press(t, m, "enter", "y")
+	info, err := os.Stat(filepath.Join(dir, "sub", "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode of sub/c = %v, want 0600", info.Mode().Perm())
+	}
+	if got := m.repeatLabel; got != "chmod -R go-rwx" {
+		t.Errorf("repeat label = %q, want chmod -R go-rwx", got)
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// isMarkdownFile detects markdown files by name
+func isMarkdownFile(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// markdownStyle picks the glamour style for markdown previews: the one
+// configured, "auto" following the terminal background, and plain styles
+// without colors or Unicode
+func markdownStyle(cfg config.Config) string {
+	switch {
+	case !cfg.Unicode:
+		return styles.AsciiStyle
+	case !cfg.Color:
+		return styles.NoTTYStyle
+	case cfg.MarkdownStyle != "auto":
+		return cfg.MarkdownStyle
+	case strings.HasPrefix(cfg.Background, "light"):
+		return styles.LightStyle
+	}
+	return styles.DarkStyle
+}
+
+// renderMarkdownPreview renders a markdown file as formatted text wrapped
+// to the preview pane. Files longer than the preview reads, or that fail
+// to render, get the plain text preview.
+func renderMarkdownPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	content, truncated, err := fileutils.ReadHead(fullPath, previewReadLimit)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+	if truncated {
+		renderBinaryPreview(m, cfg, selectedFile, fullPath, "Too long to render, shown as text")
+		return
+	}
+
+	width, _ := previewContentSize(m)
+	renderer, err := glamour.NewTermRenderer(glamour.WithStandardStyle(markdownStyle(cfg)), glamour.WithWordWrap(width))
+	var rendered string
+	if err == nil {
+		rendered, err = renderer.Render(string(content))
+	}
+	if err != nil {
+		renderBinaryPreview(m, cfg, selectedFile, fullPath, fmt.Sprintf("Markdown rendering failed: %v", err))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	sb.WriteString("\n")
+	m.PreviewContentStart = strings.Count(sb.String(), "\n")
+	sb.WriteString(strings.Trim(rendered, "\n"))
+	setPreview(m, sb.String())
+}
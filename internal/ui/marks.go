@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// toggleMark marks or unmarks the selected entry and moves on to the next,
+// so holding space marks a run of entries
+func (m *AppModel) toggleMark() {
+	if len(m.Files) == 0 {
+		return
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries cannot be marked, press y to extract"
+		return
+	}
+	path := filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name())
+	if m.Marked[path] {
+		delete(m.Marked, path)
+	} else {
+		if m.Marked == nil {
+			m.Marked = make(map[string]bool)
+		}
+		m.Marked[path] = true
+	}
+	m.selectIndex(m.Selected + 1)
+}
+
+// markVisible marks every entry the listing shows, or with invert flips the
+// mark of each, so a search or filter preset selects what it narrowed to.
+// Marks of entries the filter hides are left as they are.
+func (m *AppModel) markVisible(invert bool) {
+	if len(m.Files) == 0 {
+		return
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries cannot be marked, press y to extract"
+		return
+	}
+	if m.Marked == nil {
+		m.Marked = make(map[string]bool)
+	}
+	for _, file := range m.Files {
+		path := filepath.Join(m.CurrentDir, file.Entry.Name())
+		if invert && m.Marked[path] {
+			delete(m.Marked, path)
+		} else {
+			m.Marked[path] = true
+		}
+	}
+}
+
+// clearMarks unmarks everything
+func (m *AppModel) clearMarks() {
+	clear(m.Marked)
+}
+
+// targets returns the paths an operation acts on, the marked entries in
+// name order, or else the selected one. Marked entries hidden by the filter
+// are included.
+func (m *AppModel) targets() []string {
+	if len(m.Marked) > 0 {
+		paths := make([]string, 0, len(m.Marked))
+		for path := range m.Marked {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+	if len(m.Files) == 0 {
+		return nil
+	}
+	return []string{filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name())}
+}
+
+// keepsVisual lists the commands that only change how the listing is shown,
+// the visual range stays open across them
+var keepsVisual = map[keyAction]bool{
+	actionSortSize:     true,
+	actionSortModified: true,
+	actionSortName:     true,
+	actionToggleHidden: true,
+	actionRefresh:      true,
+	actionRaw:          true,
+	actionForcePreview: true,
+}
+
+// startVisual anchors a visual range at the selected entry
+func (m *AppModel) startVisual() {
+	if len(m.Files) == 0 {
+		return
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries cannot be marked, press y to extract"
+		return
+	}
+	m.Visual = true
+	m.VisualAnchor = m.Files[m.Selected].Entry.Name()
+}
+
+// endVisual leaves visual mode, with resolve marking the range's entries by
+// name so they stay marked however the listing is sorted or filtered later
+func (m *AppModel) endVisual(resolve bool) {
+	if resolve {
+		if lo, hi, ok := visualRange(m.Model); ok {
+			if m.Marked == nil {
+				m.Marked = make(map[string]bool)
+			}
+			for _, file := range m.Files[lo : hi+1] {
+				m.Marked[filepath.Join(m.CurrentDir, file.Entry.Name())] = true
+			}
+		}
+	}
+	m.Visual = false
+	m.VisualAnchor = ""
+}
+
+// visualRange returns the listing indices from the visual anchor to the
+// cursor in ascending order. The anchor is looked up by name, so the range
+// follows it through re-sorting, and shrinks to the cursor when the filter
+// hides it.
+func visualRange(m *models.Model) (lo, hi int, ok bool) {
+	if !m.Visual || len(m.Files) == 0 {
+		return 0, 0, false
+	}
+	anchor := m.Selected
+	for i, file := range m.Files {
+		if file.Entry.Name() == m.VisualAnchor {
+			anchor = i
+			break
+		}
+	}
+	return min(anchor, m.Selected), max(anchor, m.Selected), true
+}
+
+// isMarked reports whether the entry at index i of the listing is marked or
+// inside the visual range
+func isMarked(m *models.Model, i int) bool {
+	if lo, hi, ok := visualRange(m); ok && i >= lo && i <= hi {
+		return true
+	}
+	return m.Marked[filepath.Join(m.CurrentDir, m.Files[i].Entry.Name())]
+}
+
+// markIndicator describes the marks for the status bar, "" when none
+func markIndicator(m *models.Model) string {
+	if lo, hi, ok := visualRange(m); ok {
+		count := len(m.Marked)
+		for i := lo; i <= hi; i++ {
+			if !m.Marked[filepath.Join(m.CurrentDir, m.Files[i].Entry.Name())] {
+				count++
+			}
+		}
+		return fmt.Sprintf("VISUAL, %d marked", count)
+	}
+	if len(m.Marked) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d marked", len(m.Marked))
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// wantMarked checks the marked names and the status bar's counter
+func wantMarked(t *testing.T, m *AppModel, names ...string) {
+	t.Helper()
+	if got := markedNames(m); !slices.Equal(got, names) {
+		t.Errorf("marked %v, want %v", got, names)
+	}
+	want := ""
+	if len(names) > 0 {
+		want = fmt.Sprintf("%d marked", len(names))
+	}
+	if counter := ansi.Strip(getStatusBarContent(m.Model, m.config).Marked); counter != want {
+		t.Errorf("counter %q, want %q", counter, want)
+	}
+}
+
+func TestMarkUnderFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.log", "b.log", "c.txt", "d.txt", "old.log/", "sub/x.log")
+	m := newConfiguredModel(t, Options{Path: dir}, "search_mode = \"substring\"\n")
+
+	// Marking under the filter takes the entries it shows
+	press(t, m, "/")
+	typeText(t, m, ".log")
+	press(t, m, "enter", "A")
+	wantMarked(t, m, "a.log", "b.log", "old.log")
+
+	// Inverting flips only those, the hidden marks stay
+	press(t, m, "esc")
+	wantMarked(t, m, "a.log", "b.log", "old.log")
+	if got := listed(m); len(got) != 6 {
+		t.Fatalf("listing %v after clearing the filter", got)
+	}
+	press(t, m, "/")
+	typeText(t, m, "d")
+	press(t, m, "enter", "V")
+	wantMarked(t, m, "a.log", "b.log", "d.txt")
+
+	// After clearing the filter the marks are what the operation acts on
+	press(t, m, "esc", "D")
+	if !strings.Contains(m.ConfirmPrompt, "3 files") {
+		t.Errorf("prompt %q", m.ConfirmPrompt)
+	}
+	press(t, m, "y")
+	for _, name := range []string{"a.log", "b.log", "d.txt"} {
+		wantGone(t, filepath.Join(dir, name))
+	}
+	for _, name := range []string{"c.txt", "old.log", "sub/x.log"} {
+		wantExists(t, filepath.Join(dir, name))
+	}
+	wantMarked(t, m)
+
+	// Without a filter esc clears the marks, invert marks everything
+	press(t, m, "A")
+	wantMarked(t, m, "c.txt", "old.log", "sub")
+	press(t, m, "esc")
+	wantMarked(t, m)
+	// The cursor is on the directory old.log, listed first
+	press(t, m, " ", "V")
+	wantMarked(t, m, "c.txt", "sub")
+}
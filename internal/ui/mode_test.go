@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// normalState is what normal-mode actions change, to tell that none fired
+type normalState struct {
+	dir        string
+	sortBy     string
+	reverse    bool
+	showHidden bool
+	grid       bool
+}
+
+func stateOf(m *AppModel) normalState {
+	return normalState{m.CurrentDir, m.SortBy, m.ReverseSort, m.ShowHidden, m.GridMode}
+}
+
+// wantNoAction fails if msgs quit or the model's normal-mode state moved
+// from before
+func wantNoAction(t *testing.T, m *AppModel, before normalState, msgs []tea.Msg) {
+	t.Helper()
+	if hasQuit(msgs) {
+		t.Error("the keys quit")
+	}
+	if after := stateOf(m); after != before {
+		t.Errorf("state %+v, want %+v", after, before)
+	}
+}
+
+// modeKeys are keys bound to normal-mode actions that change the listing,
+// typed as text: quit, sorts, hidden files, grid, parent and home
+const modeKeys = "qstn.Ih~"
+
+func TestSearchModeTypesNormalKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "sub/a", "q", "b")
+	m := newTestModel(t, Options{Path: dir})
+	selectName(t, m, "sub")
+	press(t, m, "l")
+	before := stateOf(m)
+
+	msgs := press(t, m, "/")
+	for _, r := range modeKeys {
+		msgs = append(msgs, send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})...)
+	}
+	wantNoAction(t, m, before, msgs)
+	if m.InputMode != models.ModeSearch || m.SearchQuery != modeKeys {
+		t.Errorf("mode %v with query %q, want search for %q", m.InputMode, m.SearchQuery, modeKeys)
+	}
+
+	// esc returns to normal mode, where the next key is an action again
+	msgs = press(t, m, "esc")
+	wantNoAction(t, m, before, msgs)
+	if m.InputMode != models.ModeNormal || m.SearchQuery != "" {
+		t.Errorf("mode %v with query %q after esc", m.InputMode, m.SearchQuery)
+	}
+	press(t, m, "h")
+	wantDir(t, m, dir)
+}
+
+func TestSearchEnterThenTypedKeysFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "q", "qs", "b")
+	m := newTestModel(t, Options{Path: dir})
+	before := stateOf(m)
+
+	// enter keeps the filter, the key right after it is normal again
+	msgs := press(t, m, "/", "q", "s", "enter")
+	wantNoAction(t, m, before, msgs)
+	if m.InputMode != models.ModeNormal || m.SearchQuery != "qs" {
+		t.Errorf("mode %v with query %q, want normal with qs", m.InputMode, m.SearchQuery)
+	}
+	if msgs := press(t, m, "q"); !hasQuit(msgs) {
+		t.Error("q after leaving search mode did not quit")
+	}
+}
+
+func TestPromptTypesNormalKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt", "b.txt")
+	m := newTestModel(t, Options{Path: dir})
+	selectName(t, m, "a.txt")
+	before := stateOf(m)
+
+	msgs := press(t, m, "a", "ctrl+a")
+	for _, r := range modeKeys[:len(modeKeys)-1] {
+		msgs = append(msgs, send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})...)
+	}
+	msgs = append(msgs, press(t, m, "enter")...)
+	wantNoAction(t, m, before, msgs)
+	name := modeKeys[:len(modeKeys)-1]
+	if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+		t.Errorf("rename to %s: %v", name, err)
+	}
+	if m.InputMode != models.ModeNormal {
+		t.Errorf("mode %v after the prompt", m.InputMode)
+	}
+
+	// esc cancels a prompt without the keys typed into it acting
+	msgs = press(t, m, "N", "q", "s", "esc")
+	wantNoAction(t, m, before, msgs)
+	if _, err := os.Stat(filepath.Join(dir, "qs")); !os.IsNotExist(err) {
+		t.Errorf("cancelled prompt created qs: %v", err)
+	}
+	if m.InputMode != models.ModeNormal {
+		t.Errorf("mode %v after esc", m.InputMode)
+	}
+}
+
+func TestConfirmAnswersOnlyOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt", "b.txt")
+	m := newTestModel(t, Options{Path: dir})
+	selectName(t, m, "a.txt")
+	before := stateOf(m)
+
+	// Any key but y declines, q included, and the keys after it are
+	// normal-mode keys again
+	msgs := press(t, m, "D", "q")
+	wantNoAction(t, m, before, msgs)
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); err != nil {
+		t.Errorf("declined delete removed a.txt: %v", err)
+	}
+	if m.InputMode != models.ModeNormal || m.StatusMessage != "Cancelled" {
+		t.Errorf("mode %v with status %q, want normal and Cancelled", m.InputMode, m.StatusMessage)
+	}
+	press(t, m, "s")
+	if m.SortBy != "size" {
+		t.Errorf("s after the confirmation sorted by %q, want size", m.SortBy)
+	}
+
+	// y confirming does not carry over either
+	selectName(t, m, "a.txt")
+	before = stateOf(m)
+	msgs = press(t, m, "D", "y")
+	wantNoAction(t, m, before, msgs)
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("confirmed delete left a.txt: %v", err)
+	}
+	if msgs := press(t, m, "y"); hasQuit(msgs) || m.InputMode != models.ModeNormal {
+		t.Errorf("y after confirming quit %v or left mode %v", hasQuit(msgs), m.InputMode)
+	}
+}
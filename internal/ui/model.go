@@ -1,9 +1,18 @@
 package ui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/embeddingbits/file_viewer/internal/config"
@@ -14,12 +23,81 @@ import (
 // AppModel represents the main application model
 type AppModel struct {
 	*models.Model
-	config config.Config
+	config         config.Config
+	confirmChoices map[string]func() tea.Cmd   // Action per answer key of the pending confirmation
+	confirmOther   func(key string) tea.Cmd    // Handles the other keys instead of cancelling, nil if they cancel
+	promptSubmit   func(input string) tea.Cmd  // Receives the answer to the open prompt
+	promptSelect   bool                        // ctrl+a selects the whole input rather than moving to its start
+	promptComplete func(input string) []string // Completions of the input on tab, nil if the prompt has none
+	pendingSelect  string                      // Entry to select once the next listing is loaded
+	pendingRestore *models.CursorState         // Cursor and scroll to restore once the next listing is loaded
+	jumping        bool                        // Navigating through the history, which records nothing
+
+	// Macro recording and repeat-last-operation state
+	replaying    bool
+	replayQueued bool // The replay key was pressed, the macro replays once its update is done
+	macro        []tea.KeyMsg
+	repeatAction func() tea.Cmd // Re-applies the last repeatable operation
+	repeatLabel  string
+
+	thumbnailsPending map[string]bool      // Thumbnails currently being generated
+	videosPending     map[string]bool      // Video previews currently being generated
+	gitPending        map[string]bool      // Repositories currently being inspected
+	gitLogPending     map[string]bool      // Directories whose commits are being listed
+	gitStatusPending  map[string]bool      // Directories whose entries' git status is being read
+	gitStatusTimes    map[string]time.Time // When each directory's git status was last read
+	gitStatusWanted   bool                 // The current directory was listed, see takeGitStatus
+
+	archiveCloser io.Closer // Releases Model.ArchiveFS
+
+	standalone bool // Quitting ends the program rather than sending QuitMsg
+
+	// Background directory loading, see startDirLoad
+	dirLoad    tea.Cmd            // Load prepared by navigateTo, started by Update
+	loadCancel context.CancelFunc // Cancels the load in flight
+	loadSeq    int                // Identifies the latest load, older results are dropped
+	preloaded  map[string]dirRead // Listings of the load being applied
+
+	previewStarted int    // PreviewGeneration whose background preview is running
+	placeholder    string // Shown with a spinner until the pending preview is done
+	spinnerFrame   int
+
+	physicalErr error       // Why the current directory's physical path did not resolve
+	dirPath     string      // The directory last listed
+	dirInfo     fs.FileInfo // dirPath as it was listed, to find it again once renamed
+
+	batchCancel context.CancelFunc // Stops the batch command running, nil when none is
+	grep        *grepSearch        // The content search running, nil when none is
+
+	// Copies and moves running in the background share one context, so esc
+	// cancels them all
+	transferCtx    context.Context
+	transferCancel context.CancelFunc // nil when no transfer runs
+	transfers      int                // How many are running
+	transferVerb   string             // "copy" or "move", of the one started last
+
+	compareSettled map[string]bool // Files compared by content, see compareKey, to whether they are the same
+
+	// Change tracking, see trackVisit
+	visitDir   string            // Directory whose visit is tracked, "" for none
+	visitFiles []models.FileInfo // Its latest listing, saved when it is left
+	lastVisit  config.Visit      // Its snapshot as previously left
+	visited    bool              // lastVisit exists
+
+	keys map[string]keyAction // Key bindings of the movement actions
+	held keyRepeat            // Repeats of the last movement key, for key_acceleration
+
+	// Terminal graphics, see withGraphics
+	lastFrame     string // The last view rendered, without the image
+	frameParity   bool   // Flipped whenever the view changes
+	graphicsShown bool   // The last view drew the image preview
 }
 
-// NewAppModel creates a new application model
-func NewAppModel() *AppModel {
-	dir, err := os.Getwd()
+// NewAppModel creates a new application model showing opts.Path, or the
+// working directory when it is empty. A file path opens its directory with
+// the file selected, opts.Select picks the entry to select explicitly.
+func NewAppModel(opts Options) *AppModel {
+	dir, selectName, err := resolveStart(opts)
 	if err != nil {
 		return &AppModel{
 			Model: &models.Model{Err: err},
@@ -30,19 +108,104 @@ func NewAppModel() *AppModel {
 
 	m := &AppModel{
 		Model: &models.Model{
-			CurrentDir: dir,
-			BaseDir: baseDir,
-			Selected:   0,
-			SortBy:     "name",
-			ShowHidden: false,
+			CurrentDir:          dir,
+			BaseDir:             baseDir,
+			Selected:            0,
+			SortBy:              "name",
+			ShowHidden:          false,
+			HiddenPosition:      cfg.HiddenPosition,
+			PreviewMaxSize:      cfg.PreviewMaxBytes,
+			Columns:             cfg.Columns,
+			SortFollowSymlinks:  cfg.SortFollowSymlinks,
+			SortDiskSize:        cfg.SortSizeOnDisk,
+			PhysicalPaths:       cfg.PhysicalPaths,
+			ImagePreviewColored: cfg.ColorImages,
+			FuzzySearch:         cfg.SearchMode == "fuzzy",
+			LinkTargets:         make(map[string]fs.FileInfo),
+			DirCursors:          make(map[string]models.CursorState),
+			Thumbnails:          make(map[string]string),
+			VideoPreviews:       make(map[string]models.VideoPreview),
+			GitInfos:            make(map[string]models.GitInfo),
+			GitLogs:             make(map[string][]string),
+			GitMarks:            make(map[string]map[string]byte),
+			ProjectBadges:       make(map[string]string),
+			Chooser:             opts.Chooser,
 		},
-		config: cfg,
+		config:            cfg,
+		thumbnailsPending: make(map[string]bool),
+		videosPending:     make(map[string]bool),
+		gitPending:        make(map[string]bool),
+		gitLogPending:     make(map[string]bool),
+		gitStatusPending:  make(map[string]bool),
+		gitStatusTimes:    make(map[string]time.Time),
+		compareSettled:    make(map[string]bool),
+		keys:              bindKeys(cfg.Keys),
+		standalone:        opts.Standalone,
+	}
+	// Where an embedded browser is on the screen is not known, so only a
+	// standalone one draws images over its preview pane
+	if opts.Standalone {
+		m.ImageProtocol = imageProtocol(cfg)
+	}
+	if opts.FS != nil {
+		m.ArchiveFS = opts.FS
+		m.ArchivePath = fsRoot
+		m.RootFS = true
 	}
 
+	// A hidden entry asked for by name is shown rather than reported missing
+	if strings.HasPrefix(selectName, ".") {
+		m.ShowHidden = true
+	}
+	favorites, err := config.LoadFavorites()
+	m.Favorites = favorites
 	m.loadCurrentDir()
+	if !cfg.Unicode && cfg.Locale != "" {
+		m.StatusMessage = fmt.Sprintf("Not a UTF-8 locale (%s), drawing with ASCII (set unicode = true to override)", cfg.Locale)
+	}
+	m.MissingTools = checkDependencies(cfg)
+	if len(m.MissingTools) > 0 {
+		m.StatusMessage = missingToolsMessage(m.MissingTools, hintKeys(cfg.Keys, actionDebug))
+	}
+	if len(cfg.OpenerProblems) > 0 {
+		m.StatusMessage = "openers: " + strings.Join(cfg.OpenerProblems, "; ")
+	}
+	if len(cfg.KeyProblems) > 0 {
+		m.StatusMessage = "keybindings: " + strings.Join(cfg.KeyProblems, "; ")
+	}
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error loading favorites: %v", err)
+	}
+	if selectName != "" {
+		if m.selectByName(selectName) {
+			UpdatePreview(m.Model, m.config)
+		} else {
+			m.StatusMessage = fmt.Sprintf("%s not found in %s", selectName, dir)
+		}
+	}
 	return m
 }
 
+// resolveStartPath turns the path given on the command line into the
+// directory to open and the entry to select in it
+func resolveStartPath(startPath, selectName string) (string, string, error) {
+	path, err := filepath.Abs(startPath)
+	if err != nil {
+		return "", "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", err
+	}
+	if info.IsDir() {
+		return path, selectName, nil
+	}
+	if selectName == "" {
+		selectName = filepath.Base(path)
+	}
+	return filepath.Dir(path), selectName, nil
+}
+
 // Init initializes the model
 func (m *AppModel) Init() tea.Cmd {
 	return nil
@@ -50,237 +213,1046 @@ func (m *AppModel) Init() tea.Cmd {
 
 // loadCurrentDir loads the current directory contents
 func (m *AppModel) loadCurrentDir() {
-	files, err := fileutils.ReadDirWithInfo(m.CurrentDir)
+	m.cancelDirLoad()
+	start := time.Now()
+	files, err := m.readListing(m.CurrentDir)
 	if err != nil {
+		// The directory was renamed or removed underneath us, follow it or
+		// relocate to the nearest survivor
+		if errors.Is(err, fs.ErrNotExist) && m.ArchiveFS == nil {
+			// Entering a directory below the renamed one follows it as well
+			if dir, ok := fileutils.FindRenamed(m.dirPath, m.dirInfo); ok && dir != m.dirPath && fileutils.IsSameOrAncestor(m.dirPath, m.CurrentDir) {
+				m.StatusMessage = fmt.Sprintf("%s was renamed to %s", m.dirPath, filepath.Base(dir))
+				m.relocate(m.dirPath, dir)
+				m.loadCurrentDir()
+				return
+			}
+			if dir := fileutils.NearestExistingDir(m.CurrentDir); dir != m.CurrentDir {
+				m.StatusMessage = fmt.Sprintf("%s no longer exists, moved to %s", m.CurrentDir, dir)
+				m.CurrentDir = dir
+				m.Selected = 0
+				m.ListOffset = 0
+				m.PreviewOffset = 0
+				m.loadCurrentDir()
+				return
+			}
+		}
 		m.Err = err
 		return
 	}
 
-	m.Files = fileutils.FilterFiles(files, m.ShowHidden, m.SearchQuery)
-	fileutils.SortFiles(m.Files, m.SortBy, m.ReverseSort)
+	m.dirPath, m.dirInfo = m.CurrentDir, nil
+	if m.ArchiveFS == nil {
+		m.dirInfo, _ = os.Stat(m.CurrentDir)
+	}
+	m.trackVisit(files)
+	m.resolvePhysicalDir()
+	m.Lookalikes = fileutils.Lookalikes(files)
+	m.Files = filterPreset(m.Model, m.config, filterFiles(m.Model, files, m.ShowHidden, m.SearchQuery))
+	fileutils.SortFiles(m.Files, m.SortBy, m.ReverseSort, m.HiddenPosition, m.config.SortNatural)
+	// While typing, the best fuzzy matches come first
+	if m.FuzzySearch && m.InputMode == models.ModeSearch && m.SearchQuery != "" {
+		fileutils.SortByScore(m.Files, m.SearchQuery)
+	}
+	m.LargestFileSize = largestFileSize(m.Files)
 
-	// Load parent directory
-	m.ParentDir = filepath.Dir(m.CurrentDir)
-	if m.ParentDir != m.CurrentDir {
-		parentFiles, err := fileutils.ReadDirWithInfo(m.ParentDir)
-		if err == nil {
-			m.ParentFiles = fileutils.FilterFiles(parentFiles, m.ShowHidden, m.SearchQuery)
-			fileutils.SortFiles(m.ParentFiles, m.SortBy, m.ReverseSort)
+	m.loadAncestors()
+	m.Stats.DirLoad = time.Since(start)
+	m.Stats.DirLoadPath = m.CurrentDir
+	m.Stats.DirEntries = len(files)
 
-			// Find current directory in parent list
-			currentDirName := filepath.Base(m.CurrentDir)
-			for i, file := range m.ParentFiles {
-				if file.Entry.Name() == currentDirName {
-					m.ParentSelected = i
+	found := false
+	if m.pendingSelect != "" {
+		found = m.selectByName(m.pendingSelect)
+		m.pendingSelect = ""
+	}
+	if m.pendingRestore != nil {
+		m.restoreCursor(m.pendingRestore, found)
+		m.pendingRestore = nil
+	}
+	m.clampSelection()
+	m.gitStatusWanted = true
+
+	UpdatePreview(m.Model, m.config)
+}
+
+// relocate moves the current directory, and the history and remembered
+// cursors under it, from old to the path it was renamed to
+func (m *AppModel) relocate(old, renamed string) {
+	moved := func(path string) string {
+		if path == old {
+			return renamed
+		}
+		if strings.HasPrefix(path, old+string(filepath.Separator)) {
+			return renamed + path[len(old):]
+		}
+		return path
+	}
+	m.CurrentDir = moved(m.CurrentDir)
+	for i := range m.History {
+		m.History[i].Dir = moved(m.History[i].Dir)
+	}
+	for dir, state := range m.DirCursors {
+		if to := moved(dir); to != dir {
+			delete(m.DirCursors, dir)
+			m.DirCursors[to] = state
+		}
+	}
+}
+
+// loadAncestors lists the directories above the current one, one per
+// ancestor column, each with the entry leading back down selected
+func (m *AppModel) loadAncestors() {
+	m.Ancestors = m.Ancestors[:0]
+	child := m.CurrentDir
+	for len(m.Ancestors) < m.Columns-2 {
+		dir := filepath.Dir(child)
+		if dir == child {
+			break
+		}
+		listing := models.DirListing{Dir: dir}
+		if files, err := m.readListing(dir); err == nil {
+			// The filter only narrows the parent pane when configured to
+			query := ""
+			if m.config.FilterParent && len(m.Ancestors) == 0 {
+				query = m.SearchQuery
+			}
+			listing.Files = keepEntry(filterFiles(m.Model, files, hiddenShown(m.Model, m.config.ShowHiddenParent), query), files, filepath.Base(child))
+			fileutils.SortFiles(listing.Files, m.SortBy, m.ReverseSort, m.HiddenPosition, m.config.SortNatural)
+			for i, file := range listing.Files {
+				if file.Entry.Name() == filepath.Base(child) {
+					listing.Selected = i
 					break
 				}
 			}
 		}
-	} else {
-		m.ParentFiles = nil
+		m.Ancestors = append(m.Ancestors, listing)
+		child = dir
 	}
+	m.updateAncestorOffsets()
+}
 
-	// Reset selection if out of bounds
-	if m.Selected >= len(m.Files) {
-		m.Selected = max(0, len(m.Files)-1)
+// keepEntry adds the named entry of all back to filtered when the filter or
+// hidden files setting removed it, so an ancestor never hides the way down
+func keepEntry(filtered, all []models.FileInfo, name string) []models.FileInfo {
+	if slices.ContainsFunc(filtered, func(f models.FileInfo) bool { return f.Entry.Name() == name }) {
+		return filtered
 	}
-
-	UpdatePreview(m.Model)
+	for _, file := range all {
+		if file.Entry.Name() == name {
+			return append(filtered, file)
+		}
+	}
+	return filtered
 }
 
-// Update handles model updates
+// Update handles model updates, then starts the directory load or preview
+// they left pending
 func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	return model, tea.Batch(cmd, m.takeReplay(), m.takeDirLoad(), m.startPreview(), m.takeGitStatus(), m.clearGraphics())
+}
+
+// update handles a message
+func (m *AppModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		firstSize := m.Height == 0
 		m.Width = msg.Width
 		m.Height = msg.Height
+		// The initial selection was made before the list height was known
+		if firstSize {
+			m.centerSelection()
+		}
+		m.clampSelection()
+		m.updateAncestorOffsets()
+		updateCellSize(m.Model)
+		UpdatePreview(m.Model, m.config)
+		return m, m.previewCommands()
+
+	case thumbnailMsg:
+		delete(m.thumbnailsPending, msg.key)
+		m.Thumbnails[msg.key] = msg.ascii
 		return m, nil
 
-	case tea.KeyMsg:
-		if m.SearchMode {
-			return m.handleSearchMode(msg)
+	case videoPreviewMsg:
+		delete(m.videosPending, msg.key)
+		m.VideoPreviews[msg.key] = msg.preview
+		if m.PreviewPath == msg.path {
+			UpdatePreview(m.Model, m.config)
 		}
+		return m, nil
 
-		return m.handleNormalMode(msg)
+	case gitInfoMsg:
+		delete(m.gitPending, msg.path)
+		m.GitInfos[msg.path] = msg.info
+		if m.PreviewPath == msg.path {
+			UpdatePreview(m.Model, m.config)
+		}
+		return m, nil
+
+	case gitStatusMsg:
+		delete(m.gitStatusPending, msg.dir)
+		m.GitMarks[msg.dir] = msg.marks
+		return m, nil
+
+	case gitLogMsg:
+		delete(m.gitLogPending, msg.path)
+		m.GitLogs[msg.path] = msg.commits
+		if m.PreviewPath == msg.path && len(msg.commits) > 0 {
+			UpdatePreview(m.Model, m.config)
+		}
+		return m, nil
+
+	case openByMsg:
+		m.handleOpenBy(msg)
+		return m, nil
+
+	case taskMsg:
+		return m, m.handleTaskMsg(msg)
+
+	case dirLoadedMsg:
+		m.handleDirLoaded(msg)
+		return m, m.previewCommands()
+
+	case previewMsg:
+		return m, m.handlePreview(msg)
+
+	case previewSpinMsg:
+		return m, m.handlePreviewSpin(msg)
+
+	case grepMsg:
+		return m, m.handleGrepMsg(msg)
+
+	case openerDoneMsg:
+		if msg.err != nil {
+			m.StatusMessage = fmt.Sprintf("%s failed: %v", msg.name, msg.err)
+		}
+		return m, nil
+
+	case externalDoneMsg:
+		m.handleExternalDone(msg)
+		return m, m.previewCommands()
+
+	case tea.MouseMsg:
+		m.handleMouse(msg)
+		return m, m.previewCommands()
+
+	case tea.KeyMsg:
+		model, cmd := m.handleKey(msg)
+		return model, tea.Batch(cmd, m.previewCommands())
 	}
 	return m, nil
 }
 
+// requestGitInfo inspects the repository at dir unless it is known or not
+// a repository
+func (m *AppModel) requestGitInfo(dir string) tea.Cmd {
+	if _, ok := m.GitInfos[dir]; ok {
+		m.Stats.Git.Record(true)
+		return nil
+	}
+	if m.gitPending[dir] || !isGitRepo(dir) {
+		return nil
+	}
+	m.Stats.Git.Record(false)
+	m.gitPending[dir] = true
+	return fetchGitInfo(dir)
+}
+
+// takeGitStatus starts reading the git status of the current directory's
+// entries once it was listed, unless the markers are recent or already
+// being read
+func (m *AppModel) takeGitStatus() tea.Cmd {
+	if !m.gitStatusWanted {
+		return nil
+	}
+	m.gitStatusWanted = false
+	dir := m.CurrentDir
+	if !m.config.GitStatus || m.ArchiveFS != nil || !hasGit() || m.gitStatusPending[dir] || time.Since(m.gitStatusTimes[dir]) < gitStatusMaxAge {
+		return nil
+	}
+	root := gitWorkTree(dir)
+	if root == "" {
+		return nil
+	}
+	m.gitStatusPending[dir] = true
+	m.gitStatusTimes[dir] = time.Now()
+	return fetchGitStatus(dir, root)
+}
+
+// requestGitLog lists the commits touching dir with git_log_preview, once
+func (m *AppModel) requestGitLog(dir string) tea.Cmd {
+	if !m.config.GitLogPreview || m.ArchiveFS != nil || !hasGit() {
+		return nil
+	}
+	if _, ok := m.GitLogs[dir]; ok || m.gitLogPending[dir] {
+		return nil
+	}
+	m.gitLogPending[dir] = true
+	return fetchGitLog(dir)
+}
+
+// previewCommands starts the background work the visible grid or preview
+// is waiting on, and moves the image overlay along with the preview
+func (m *AppModel) previewCommands() tea.Cmd {
+	m.updateOverlay()
+	if m.GridMode {
+		return m.requestThumbnails()
+	}
+	if len(m.Files) == 0 {
+		return nil
+	}
+
+	file := m.Files[m.Selected]
+	fullPath := filepath.Join(m.CurrentDir, file.Entry.Name())
+	if file.IsDir() {
+		return tea.Batch(m.requestGitInfo(fullPath), m.requestGitLog(fullPath))
+	}
+	if m.ArchiveFS != nil || !m.config.VideoPreviews || !isVideoFileByExtension(file.Entry.Name()) || m.RawPreviewPath == fullPath {
+		return nil
+	}
+
+	width, height := previewContentSize(m.Model)
+	key := videoPreviewKey(fullPath, file.ModTime, width, height/2)
+	if _, ok := m.VideoPreviews[key]; ok {
+		m.Stats.Videos.Record(true)
+		return nil
+	}
+	if m.videosPending[key] {
+		return nil
+	}
+	m.Stats.Videos.Record(false)
+	m.videosPending[key] = true
+	return extractVideoPreview(fullPath, key, width, height/2)
+}
+
+// handleKey dispatches a key event to the handler of the active mode
+func (m *AppModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if handled, cmd := m.handleMacroKeys(msg); handled {
+		return m, cmd
+	}
+
+	switch m.InputMode {
+	case models.ModeSearch:
+		return m.handleSearchMode(msg)
+	case models.ModePrompt:
+		return m.handlePromptMode(msg)
+	case models.ModeConfirm:
+		return m.handleConfirmMode(msg)
+	default:
+		return m.handleNormalMode(msg)
+	}
+}
+
+// handleMacroKeys records key events and handles the record/replay keys
+// before any mode dispatch, so macros capture keys of every mode
+func (m *AppModel) handleMacroKeys(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if m.replaying {
+		return false, nil
+	}
+	if action := m.keys[msg.String()]; action == actionMacroRecord || action == actionMacroReplay {
+		return true, commands[action](m)
+	}
+	if m.MacroRecording {
+		m.macro = append(m.macro, msg)
+	}
+	return false, nil
+}
+
+// toggleMacroRecording starts recording a macro, discarding the last, or
+// stops the recording
+func (m *AppModel) toggleMacroRecording() {
+	m.MacroRecording = !m.MacroRecording
+	if m.MacroRecording {
+		m.macro = nil
+		m.StatusMessage = fmt.Sprintf("Recording macro, %s to stop", hintKeys(m.config.Keys, actionMacroRecord))
+	} else {
+		m.StatusMessage = fmt.Sprintf("Recorded %d keys, %s to replay", len(m.macro), hintKeys(m.config.Keys, actionMacroReplay))
+	}
+}
+
+// queueReplay replays the recorded macro once the current update is done,
+// nothing while recording
+func (m *AppModel) queueReplay() {
+	if !m.MacroRecording && !m.replaying && len(m.macro) > 0 {
+		m.replayQueued = true
+	}
+}
+
+// takeReplay replays the macro queueReplay asked for
+func (m *AppModel) takeReplay() tea.Cmd {
+	if !m.replayQueued {
+		return nil
+	}
+	m.replayQueued = false
+	return m.replayMacro()
+}
+
+// replayMacro feeds the recorded keys through Update in order, running
+// any commands they produce in sequence afterwards
+func (m *AppModel) replayMacro() tea.Cmd {
+	m.replaying = true
+	defer func() { m.replaying = false }()
+
+	var cmds []tea.Cmd
+	for _, key := range m.macro {
+		_, cmd := m.Update(key)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return tea.Sequence(cmds...)
+}
+
+// setRepeatAction registers the operation the repeat key re-applies
+func (m *AppModel) setRepeatAction(label string, action func() tea.Cmd) {
+	m.repeatLabel = label
+	m.repeatAction = action
+}
+
 // handleSearchMode handles key events when in search mode
 func (m *AppModel) handleSearchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
-		m.SearchMode = false
+		m.InputMode = models.ModeNormal
 		m.loadCurrentDir()
+		if query := m.SearchQuery; query != "" {
+			m.setRepeatAction("filter "+query, func() tea.Cmd {
+				m.SearchQuery = query
+				m.loadCurrentDir()
+				return nil
+			})
+		}
 		return m, nil
 	case "ctrl+c", "esc":
-		m.SearchMode = false
+		m.InputMode = models.ModeNormal
 		m.SearchQuery = ""
 		m.loadCurrentDir()
 		return m, nil
+	case "ctrl+t":
+		m.toggleFuzzy()
+		return m, nil
 	case "backspace":
-		if len(m.SearchQuery) > 0 {
-			m.SearchQuery = m.SearchQuery[:len(m.SearchQuery)-1]
+		if query := []rune(m.SearchQuery); len(query) > 0 {
+			m.SearchQuery = string(query[:len(query)-1])
 			m.loadCurrentDir()
 		}
 		return m, nil
 	default:
-		if len(msg.String()) == 1 {
-			m.SearchQuery += msg.String()
+		text := typedText(msg)
+		// Names cannot hold a slash, typing one searches the whole tree
+		if text == "/" {
+			query := m.SearchQuery
+			m.InputMode = models.ModeNormal
+			m.SearchQuery = ""
+			m.loadCurrentDir()
+			m.promptFind(query)
+			return m, nil
+		}
+		if text != "" {
+			m.SearchQuery += text
 			m.loadCurrentDir()
 		}
 		return m, nil
 	}
 }
 
-// handleNormalMode handles key events when in normal mode
-func (m *AppModel) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// typedText returns the text a key press inserts into a query or prompt.
+// Fast typing and pastes can deliver several runes in one message, which
+// all belong to the input rather than to any binding.
+func typedText(msg tea.KeyMsg) string {
+	switch msg.Type {
+	case tea.KeyRunes:
+		return string(msg.Runes)
+	case tea.KeySpace:
+		return " "
+	}
+	return ""
+}
+
+// prompt asks for a line of text in the status bar and passes it to submit
+// on enter
+func (m *AppModel) prompt(label string, submit func(input string) tea.Cmd) {
+	m.InputMode = models.ModePrompt
+	m.PromptLabel = label
+	m.setPromptInput("")
+	m.promptSubmit = submit
+	m.PromptPath = false
+	m.promptComplete = nil
+	m.promptSelect = false
+}
+
+// pathPrompt is a prompt for a path, completed with tab from the directory
+// typed so far
+func (m *AppModel) pathPrompt(label string, submit func(input string) tea.Cmd) {
+	m.prompt(label, submit)
+	m.PromptPath = true
+	m.promptComplete = func(input string) []string {
+		return fileutils.CompletePath(input, m.CurrentDir, m.ShowHidden, func(dir string) ([]models.FileInfo, error) {
+			return readDir(m.Model, dir)
+		})
+	}
+}
+
+// setPromptInput replaces the prompt input, with the cursor at its end
+func (m *AppModel) setPromptInput(input string) {
+	m.PromptInput = input
+	m.PromptCursor = len([]rune(input))
+	m.PromptSelected = false
+}
+
+// completePrompt completes the prompt input, or moves step candidates on
+// while cycling through several completions
+func (m *AppModel) completePrompt(step int) {
+	if m.promptComplete == nil {
+		return
+	}
+	if len(m.PromptCandidates) > 0 {
+		n := len(m.PromptCandidates)
+		m.PromptCandidate = ((m.PromptCandidate+step)%n + n) % n
+		m.setPromptInput(m.PromptCandidates[m.PromptCandidate])
+		return
+	}
+
+	candidates := m.promptComplete(m.PromptInput)
+	switch len(candidates) {
+	case 0:
+		return
+	case 1:
+		// A single match is taken as typed, so the next tab completes inside it
+		m.setPromptInput(candidates[0])
+		return
+	}
+	m.PromptCandidates = candidates
+	m.PromptCandidate = 0
+	if step < 0 {
+		m.PromptCandidate = len(candidates) - 1
+	}
+	m.setPromptInput(candidates[m.PromptCandidate])
+}
+
+// handlePromptMode handles key events while a prompt is open
+func (m *AppModel) handlePromptMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
-	case "ctrl+c", "q":
-		return m, tea.Quit
-
-	case "up", "k":
-		if m.Selected > 0 {
-			m.Selected--
-			if m.Selected < m.ListOffset {
-				m.ListOffset = m.Selected
-			}
-			UpdatePreview(m.Model)
+	case "enter":
+		submit, input := m.promptSubmit, m.PromptInput
+		m.closePrompt()
+		if submit != nil {
+			return m, submit(input)
 		}
+		return m, nil
+	case "ctrl+c", "esc":
+		m.closePrompt()
+		return m, nil
+	case "tab":
+		m.completePrompt(1)
+		return m, nil
+	case "shift+tab":
+		m.completePrompt(-1)
+		return m, nil
+	}
 
-	case "down", "j":
-		if m.Selected < len(m.Files)-1 {
-			m.Selected++
-			visibleHeight := m.getVisibleHeight()
-			if m.Selected >= m.ListOffset+visibleHeight {
-				m.ListOffset = m.Selected - visibleHeight + 1
-			}
-			UpdatePreview(m.Model)
-		}
+	runes := []rune(m.PromptInput)
+	cursor := max(0, min(m.PromptCursor, len(runes)))
+	if msg.String() == "ctrl+a" && m.promptSelect {
+		m.PromptSelected = true
+		m.PromptCursor = len(runes)
+		return m, nil
+	}
+	// A selection is replaced by what is typed, or dropped by moving
+	selected := m.PromptSelected
+	m.PromptSelected = false
+	switch msg.String() {
+	case "left", "ctrl+b":
+		m.PromptCursor = max(0, cursor-1)
+		return m, nil
+	case "right", "ctrl+f":
+		m.PromptCursor = min(len(runes), cursor+1)
+		return m, nil
+	case "home", "ctrl+a":
+		m.PromptCursor = 0
+		return m, nil
+	case "end", "ctrl+e":
+		m.PromptCursor = len(runes)
+		return m, nil
+	}
 
-	case "right", "l":
-		if len(m.Files) == 0 {
+	// Editing the input ends cycling through completions
+	m.PromptCandidates = nil
+	if selected {
+		runes, cursor = nil, 0
+		m.PromptInput, m.PromptCursor = "", 0
+		if msg.String() == "backspace" || msg.String() == "delete" {
 			return m, nil
 		}
-		selectedFile := m.Files[m.Selected]
-		fullPath := filepath.Join(m.CurrentDir, selectedFile.Entry.Name())
-		if selectedFile.Entry.IsDir() {
-			m.CurrentDir = fullPath
-			m.Selected = 0
-			m.ListOffset = 0
-			m.PreviewOffset = 0
-			m.loadCurrentDir()
+	}
+	switch msg.String() {
+	case "backspace":
+		if cursor > 0 {
+			m.PromptInput = string(runes[:cursor-1]) + string(runes[cursor:])
+			m.PromptCursor = cursor - 1
 		}
+		return m, nil
+	case "delete":
+		if cursor < len(runes) {
+			m.PromptInput = string(runes[:cursor]) + string(runes[cursor+1:])
+		}
+		return m, nil
+	}
+	typed := []rune(typedText(msg))
+	m.PromptInput = string(runes[:cursor]) + string(typed) + string(runes[cursor:])
+	m.PromptCursor = cursor + len(typed)
+	return m, nil
+}
 
-	case "left", "h":
-		parent := filepath.Dir(m.CurrentDir)
-		if parent != m.CurrentDir {
-			m.CurrentDir = parent
-			m.Selected = m.ParentSelected
-			m.ListOffset = max(0, m.Selected-m.getVisibleHeight()/2)
-			m.PreviewOffset = 0
-			m.loadCurrentDir()
+func (m *AppModel) closePrompt() {
+	m.InputMode = models.ModeNormal
+	m.PromptLabel = ""
+	m.setPromptInput("")
+	m.PromptPath = false
+	m.PromptCandidates = nil
+	m.promptSubmit = nil
+	m.promptComplete = nil
+}
+
+// gotoPreviewLine scrolls the preview to a 1-based line of the file
+func (m *AppModel) gotoPreviewLine(input string) tea.Cmd {
+	line, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || line < 1 {
+		m.StatusMessage = fmt.Sprintf("Invalid line number: %q", input)
+		return nil
+	}
+	m.PreviewLine = min(line, max(1, len(m.PreviewLines)-m.PreviewContentStart))
+	m.PreviewOffset = max(0, min(m.PreviewContentStart+m.PreviewLine-1, len(m.PreviewLines)-1))
+	return nil
+}
+
+// gotoPath navigates to a typed directory, or to the directory of a typed
+// file with the file selected
+func (m *AppModel) gotoPath(input string) tea.Cmd {
+	if strings.TrimSpace(input) == "" {
+		return nil
+	}
+	path := fileutils.ExpandPath(fileutils.UnescapePath(input), m.CurrentDir)
+	var info fs.FileInfo
+	var err error
+	if name, ok := archiveEntry(m.Model, path); ok {
+		info, err = fs.Stat(m.ArchiveFS, name)
+	} else {
+		info, err = os.Stat(path)
+	}
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
 		}
+		m.StatusMessage = fmt.Sprintf("Cannot go to %s: %v", path, err)
+		return nil
+	}
+	if info.IsDir() {
+		m.navigateTo(path, "")
+	} else {
+		m.navigateTo(filepath.Dir(path), filepath.Base(path))
+	}
+	return nil
+}
 
-	case "o", "enter": // Open file in editor
-		if len(m.Files) == 0 {
-			return m, nil
+// confirm asks a y/n question in the status bar and runs action on yes
+func (m *AppModel) confirm(prompt string, action func() tea.Cmd) {
+	m.choose(prompt, map[string]func() tea.Cmd{"y": action})
+}
+
+// choose asks a question answered with a single key, running the action of
+// that key. Keys without an action cancel.
+func (m *AppModel) choose(prompt string, choices map[string]func() tea.Cmd) {
+	m.InputMode = models.ModeConfirm
+	m.ConfirmPrompt = prompt
+	m.confirmChoices = choices
+	m.confirmOther = nil
+}
+
+// handleConfirmMode handles key events while a confirmation is pending,
+// anything but an answer cancels so a stray keypress can't trigger an action
+func (m *AppModel) handleConfirmMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	action, other := m.confirmChoices[msg.String()], m.confirmOther
+	m.InputMode = models.ModeNormal
+	m.ConfirmPrompt = ""
+	m.confirmChoices = nil
+	m.confirmOther = nil
+
+	if action != nil {
+		return m, action()
+	}
+	if other != nil {
+		return m, other(msg.String())
+	}
+	m.StatusMessage = "Cancelled"
+	return m, nil
+}
+
+// openInEditor hands the file to $EDITOR, suspending the TUI until it exits
+func (m *AppModel) openInEditor(fullPath string) tea.Cmd {
+	return m.runExternal(editorCommand(m.config, m.usePath(fullPath), m.PreviewLine))
+}
+
+// externalDoneMsg reports that a command run with runExternal has exited
+type externalDoneMsg struct {
+	name   string
+	err    error
+	dir    string
+	before fileutils.DirSnapshot // Listing of dir before the command ran, nil if unreadable
+}
+
+// runExternal suspends the TUI to run cmd on the terminal. The current
+// directory is snapshotted first so its changes can be reported afterwards.
+func (m *AppModel) runExternal(cmd *exec.Cmd) tea.Cmd {
+	// The command owns the terminal until it exits
+	removeOverlay()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	dir := m.CurrentDir
+	before, _ := fileutils.SnapshotDir(dir)
+	name := filepath.Base(cmd.Path)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return externalDoneMsg{name: name, err: err, dir: dir, before: before}
+	})
+}
+
+// handleExternalDone refreshes the listing after an external command and
+// summarises what it changed in the directory it was started from
+func (m *AppModel) handleExternalDone(msg externalDoneMsg) {
+	selected := m.selectedName()
+	m.loadCurrentDir()
+	if i := m.indexByName(selected); i >= 0 {
+		m.selectIndex(i)
+	}
+
+	if msg.err != nil {
+		m.StatusMessage = fmt.Sprintf("%s failed: %v", msg.name, msg.err)
+		return
+	}
+	if msg.before == nil {
+		return
+	}
+	after, err := fileutils.SnapshotDir(msg.dir)
+	if err != nil {
+		return
+	}
+	if changes := fileutils.DiffSnapshots(msg.before, after).String(); changes != "" {
+		m.StatusMessage = fmt.Sprintf("%s changed %s: %s", msg.name, filepath.Base(msg.dir), changes)
+	}
+}
+
+// handleNormalMode handles key events when in normal mode
+func (m *AppModel) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.StatusMessage = ""
+
+	if m.PaletteView {
+		if consumed, cmd := m.handlePaletteKeys(msg); consumed {
+			return m, cmd
 		}
-		selectedFile := m.Files[m.Selected]
-		if !selectedFile.Entry.IsDir() {
-			fullPath := filepath.Join(m.CurrentDir, selectedFile.Entry.Name())
-			editor := os.Getenv("EDITOR")
-			if editor == "" {
-				editor = "nvim"
-			}
-			cmd := exec.Command(editor, fullPath)
-			cmd.Stdin = os.Stdin
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
-				if err != nil {
-					return err
-				}
-				return nil
-			})
+	}
+	if m.TrashView {
+		if consumed, cmd := m.handleTrashKeys(msg); consumed {
+			return m, cmd
 		}
+	}
+	if m.FavoritesView && m.handleFavoritesKeys(msg) {
+		return m, nil
+	}
+	if m.DebugScreen && m.handleDebugKeys(msg) {
+		return m, nil
+	}
+	if m.BatchScreen && m.handleBatchKeys(msg) {
+		return m, nil
+	}
+	if m.GrepView && m.handleGrepKeys(msg) {
+		return m, nil
+	}
+	if m.CompareView {
+		if consumed, cmd := m.handleCompareKeys(msg); consumed {
+			return m, cmd
+		}
+	}
+	if m.GridMode && m.handleGridKeys(msg) {
+		return m, nil
+	}
+	if m.QuickLook && m.handleQuickLookKeys(msg) {
+		return m, nil
+	}
+	action, ok := m.keys[msg.String()]
+	if !ok {
+		return m, nil
+	}
+	// In visual mode movements extend the range and v drops it. Commands
+	// other than re-sorting and the like mark the range first and act on it,
+	// esc and space only do that.
+	if _, isCommand := commands[action]; m.Visual && isCommand && !keepsVisual[action] {
+		m.endVisual(action != actionVisual)
+		if action == actionVisual || action == actionClear || action == actionMark {
+			return m, nil
+		}
+	}
+	if command, ok := commands[action]; ok {
+		return m, command(m)
+	}
+	if steps := m.acceleratedSteps(msg.String(), action); steps > 1 && len(m.Files) > 0 {
+		m.selectIndex(m.Selected + steps*moveDirection(action))
+		return m, nil
+	}
+	m.moveCursor(action)
+	return m, nil
+}
 
-	case "g": // Go to top
-		m.Selected = 0
-		m.ListOffset = 0
-		UpdatePreview(m.Model)
+// moveCursor runs a movement action on the current listing or preview
+func (m *AppModel) moveCursor(action keyAction) {
+	switch action {
+	case actionPreviewScrollUp:
+		m.scrollPreview(-m.config.Scroll.Lines(m.previewRows()))
+		return
+	case actionPreviewScrollDown:
+		m.scrollPreview(m.config.Scroll.Lines(m.previewRows()))
+		return
+	}
+
+	if len(m.Files) == 0 {
+		return
+	}
+	rows := m.listRows()
+	switch action {
+	case actionUp:
+		m.selectIndex(m.Selected - 1)
+	case actionDown:
+		m.selectIndex(m.Selected + 1)
+	case actionTop:
+		m.selectIndex(0)
+	case actionBottom:
+		m.selectIndex(len(m.Files) - 1)
+	case actionScrollUp:
+		m.scrollBy(-m.config.Scroll.Lines(rows))
+	case actionScrollDown:
+		m.scrollBy(m.config.Scroll.Lines(rows))
+	case actionPageUp:
+		m.scrollBy(-m.config.Page.Lines(rows))
+	case actionPageDown:
+		m.scrollBy(m.config.Page.Lines(rows))
+	}
+}
 
-	case "G": // Go to bottom
+// scrollPreview moves the preview by delta lines, keeping its last line at
+// the bottom of the pane at most
+func (m *AppModel) scrollPreview(delta int) {
+	m.PreviewOffset = max(0, min(m.PreviewOffset+delta, len(m.PreviewLines)-m.previewRows()))
+}
+
+// previewRows returns how many preview lines fit in the preview pane
+func (m *AppModel) previewRows() int {
+	_, rows := previewContentSize(m.Model)
+	return rows
+}
+
+// handleMouse scrolls the pane under the pointer on wheel events
+func (m *AppModel) handleMouse(msg tea.MouseMsg) {
+	if msg.Action != tea.MouseActionPress || m.GridMode {
+		return
+	}
+	var delta int
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		delta = -1
+	case tea.MouseButtonWheelDown:
+		delta = 1
+	default:
+		return
+	}
+
+	layout := computeLayout(m.Model)
+	ancestorsWidth := layout.ancestorsWidth()
+	switch {
+	case m.QuickLook:
+		m.scrollPreview(delta * m.config.Scroll.Lines(m.previewRows()))
+	case msg.X < ancestorsWidth:
+	case msg.X < ancestorsWidth+layout.current+2:
 		if len(m.Files) > 0 {
-			m.Selected = len(m.Files) - 1
-			visibleHeight := m.getVisibleHeight()
-			m.ListOffset = max(0, len(m.Files)-visibleHeight)
-			UpdatePreview(m.Model)
-		}
-
-	case "~": // Go to home directory
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			m.CurrentDir = homeDir
-			m.Selected = 0
-			m.ListOffset = 0
-			m.PreviewOffset = 0
-			m.loadCurrentDir()
+			m.scrollBy(delta * m.config.Scroll.Lines(m.listRows()))
 		}
+	default:
+		m.scrollPreview(delta * m.config.Scroll.Lines(m.previewRows()))
+	}
+}
 
-	case "/": // Search mode
-		m.SearchMode = true
-		m.SearchQuery = ""
+// selectIndex moves the cursor to entry i, scrolling only as far as needed
+// to keep it visible
+func (m *AppModel) selectIndex(i int) {
+	previous := m.Selected
+	m.Selected = i
+	m.clampSelection()
+	if m.Selected != previous {
+		UpdatePreview(m.Model, m.config)
+	}
+}
 
-	case ".": // Toggle hidden files
-		m.ShowHidden = !m.ShowHidden
-		m.loadCurrentDir()
+// scrollBy moves the cursor and the view together by delta entries, so the
+// cursor keeps its row on screen until the list runs out
+func (m *AppModel) scrollBy(delta int) {
+	m.ListOffset = max(0, min(m.ListOffset+delta, len(m.Files)-m.listRows()))
+	m.selectIndex(m.Selected + delta)
+}
 
-	case "s": // Sort by size
-		if m.SortBy == "size" {
-			m.ReverseSort = !m.ReverseSort
-		} else {
-			m.SortBy = "size"
-			m.ReverseSort = false
+// navigateTo switches the listing to dir, selecting the entry named
+// selectName, or the one remembered for dir, when it is present. With
+// search_scope = "directory" an active search query does not follow into the
+// new directory. The listing loads in the background, see startDirLoad.
+func (m *AppModel) navigateTo(dir, selectName string) {
+	if _, ok := archiveEntry(m.Model, dir); m.ArchiveFS != nil && !ok {
+		if m.RootFS {
+			return
 		}
-		m.loadCurrentDir()
-
-	case "t": // Sort by time
-		if m.SortBy == "modified" {
-			m.ReverseSort = !m.ReverseSort
-		} else {
-			m.SortBy = "modified"
-			m.ReverseSort = false
+		m.rememberCursor()
+		m.closeArchive()
+	} else {
+		m.rememberCursor()
+	}
+	// The remembered entry, unless another is asked for, comes back with
+	// the scroll position of the time; when it is gone the cursor starts at
+	// the top
+	if state, ok := m.DirCursors[dir]; ok && !m.jumping && (selectName == "" || selectName == state.Name) {
+		selectName = state.Name
+		m.pendingRestore = &models.CursorState{Name: state.Name, ListOffset: state.ListOffset}
+	} else if !m.jumping {
+		m.pendingRestore = nil
+	}
+	if m.config.SearchScope == "directory" {
+		m.SearchQuery = ""
+		m.FilterPreset = ""
+	}
+	// Marks belong to the directory, yanking or cutting carries them elsewhere
+	if dir != m.CurrentDir {
+		m.clearMarks()
+		if _, inArchive := archiveEntry(m.Model, dir); !m.jumping && !inArchive {
+			m.pushHistory(dir)
 		}
-		m.loadCurrentDir()
+	}
+	m.CurrentDir = dir
+	m.Selected = 0
+	m.ListOffset = 0
+	m.PreviewOffset = 0
+	m.GridOffset = 0
+	m.pendingSelect = selectName
+	m.startDirLoad()
+}
 
-	case "n": // Sort by name
-		if m.SortBy == "name" {
-			m.ReverseSort = !m.ReverseSort
-		} else {
-			m.SortBy = "name"
-			m.ReverseSort = false
+// reloadKeepingCursor re-reads the current directory with the cursor on the
+// same entry if it still exists
+func (m *AppModel) reloadKeepingCursor() {
+	selected := m.selectedName()
+	m.loadCurrentDir()
+	if i := m.indexByName(selected); i >= 0 {
+		m.selectIndex(i)
+	}
+}
+
+// moveToSibling navigates to the next directory in the parent listing in
+// direction dir (-1 or 1), skipping files
+func (m *AppModel) moveToSibling(dir int) {
+	if len(m.Ancestors) > 0 {
+		parent := m.Ancestors[0]
+		for i := parent.Selected + dir; i >= 0 && i < len(parent.Files); i += dir {
+			if parent.Files[i].IsDir() {
+				m.navigateTo(filepath.Join(parent.Dir, parent.Files[i].Entry.Name()), "")
+				return
+			}
 		}
-		m.loadCurrentDir()
+	}
+	m.StatusMessage = "No more sibling directories"
+}
 
-	case "r": // Refresh
-		m.loadCurrentDir()
+// rememberCursor records the selected entry of the current directory so
+// re-entering it, or previewing it from the parent, lands on the same entry
+func (m *AppModel) rememberCursor() {
+	if len(m.Files) > 0 && m.Selected < len(m.Files) {
+		m.DirCursors[m.CurrentDir] = m.cursorState()
+	}
+}
 
-	case "ctrl+u": // Page up
-		visibleHeight := m.getVisibleHeight()
-		m.Selected = max(0, m.Selected-visibleHeight/2)
-		m.ListOffset = max(0, m.ListOffset-visibleHeight/2)
-		UpdatePreview(m.Model)
+// cursorState records where the cursor is in the listing
+func (m *AppModel) cursorState() models.CursorState {
+	return models.CursorState{Name: m.selectedName(), Selected: m.Selected, ListOffset: m.ListOffset}
+}
+
+// restoreCursor puts the cursor back where state recorded it after a
+// listing loaded: on the same entry when found, else at the same index,
+// with the list scrolled as it was
+func (m *AppModel) restoreCursor(state *models.CursorState, found bool) {
+	if !found {
+		m.Selected = state.Selected
+	}
+	m.ListOffset = state.ListOffset
+}
 
-	case "ctrl+d": // Page down
-		visibleHeight := m.getVisibleHeight()
-		m.Selected = min(len(m.Files)-1, m.Selected+visibleHeight/2)
-		if m.Selected >= m.ListOffset+visibleHeight {
-			m.ListOffset = m.Selected - visibleHeight + 1
+// selectByName moves the cursor to the named entry and scrolls it into the
+// middle of the view, reporting whether the entry was found
+func (m *AppModel) selectByName(name string) bool {
+	i := m.indexByName(name)
+	if i < 0 {
+		return false
+	}
+	m.Selected = i
+	m.centerSelection()
+	return true
+}
+
+// indexByName returns the index of the named entry in the listing, or -1
+func (m *AppModel) indexByName(name string) int {
+	for i, file := range m.Files {
+		if file.Entry.Name() == name {
+			return i
 		}
-		UpdatePreview(m.Model)
 	}
-	return m, nil
+	return -1
+}
+
+// selectedName returns the name of the selected entry, "" for an empty listing
+func (m *AppModel) selectedName() string {
+	if m.Selected < len(m.Files) {
+		return m.Files[m.Selected].Entry.Name()
+	}
+	return ""
+}
+
+// centerSelection scrolls the list so the cursor sits in its middle
+func (m *AppModel) centerSelection() {
+	rows := m.listRows()
+	m.ListOffset = max(0, min(m.Selected-rows/2, len(m.Files)-rows))
+}
+
+// clampSelection keeps Selected and ListOffset valid for the current listing,
+// e.g. after a search narrows it, an entry disappears or the pane grows. The
+// view never scrolls past the last full page.
+func (m *AppModel) clampSelection() {
+	m.Selected = max(0, min(m.Selected, len(m.Files)-1))
+	rows := m.listRows()
+	m.ListOffset = max(0, min(min(m.ListOffset, m.Selected), len(m.Files)-rows))
+	if m.Selected >= m.ListOffset+rows {
+		m.ListOffset = m.Selected - rows + 1
+	}
+}
+
+// updateAncestorOffsets centers each ancestor pane on the entry leading to
+// the current directory, so it stays visible in long listings
+func (m *AppModel) updateAncestorOffsets() {
+	rows := m.listRows()
+	for i := range m.Ancestors {
+		a := &m.Ancestors[i]
+		a.Offset = max(0, min(a.Selected-rows/2, len(a.Files)-rows))
+	}
+}
+
+// listRows returns how many entries fit below a pane's header and separator
+func (m *AppModel) listRows() int {
+	return max(1, m.getVisibleHeight()-2)
 }
 
 // getVisibleHeight returns the visible height for the file list
@@ -290,7 +1262,7 @@ func (m *AppModel) getVisibleHeight() int {
 
 // View renders the application view
 func (m *AppModel) View() string {
-	return RenderView(m.Model, m.config)
+	return m.withGraphics(RenderView(m.Model, m.config))
 }
 
 // Helper functions
@@ -0,0 +1,621 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// fixtureFiles is the tree the key binding tests browse: two directories,
+// a hidden file and enough files to fill more than a page of the listing
+func fixtureFiles() map[string]string {
+	files := map[string]string{
+		"docs/guide.md":  "# Guide\n",
+		"docs/notes.txt": "notes\n",
+		"src/main.go":    "package main\n",
+		".hidden":        "hidden\n",
+		"a.txt":          "a\n",
+		"b.log":          "b\n",
+		"c.md":           "c\n",
+		"big.bin":        strings.Repeat("\x00", 4096),
+		"prod.env":       "TOKEN=hunter2\n",
+	}
+	var long strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&long, "line %d\n", i)
+	}
+	files["long.txt"] = long.String()
+	for i := range 40 {
+		files[fmt.Sprintf("f%02d", i)] = "f\n"
+	}
+	return files
+}
+
+// fixtureTime is the modification time of the fixture's files, c.md is an
+// hour newer
+var fixtureTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fixtureModTime returns the modification time of the named fixture file
+func fixtureModTime(name string) time.Time {
+	if name == "c.md" {
+		return fixtureTime.Add(time.Hour)
+	}
+	return fixtureTime
+}
+
+// writeFixture creates the fixture below dir
+func writeFixture(t *testing.T, dir string) {
+	t.Helper()
+	for name, data := range fixtureFiles() {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, fixtureModTime(name), fixtureModTime(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePNG(t, filepath.Join(dir, "photo.png"), 40, 30, -1)
+}
+
+// fixtureFS is the fixture as an in-memory filesystem, without the image
+func fixtureFS() fstest.MapFS {
+	fsys := make(fstest.MapFS)
+	for name, data := range fixtureFiles() {
+		fsys[name] = &fstest.MapFile{Data: []byte(data), Mode: 0o644, ModTime: fixtureModTime(name)}
+	}
+	return fsys
+}
+
+// keyCase is the state transition pressing an action's keys makes
+type keyCase struct {
+	action string
+	// keys are pressed in order, text is typed after them and then is
+	// submitted with enter
+	keys []string
+	text string
+	// start is the entry the cursor starts on
+	start string
+	// fake also runs the case on fixtureFS, for read-only actions
+	fake   bool
+	config string
+	setup  func(t *testing.T, m *AppModel, root string)
+	check  func(t *testing.T, m *AppModel, root string, msgs []tea.Msg)
+}
+
+// wantDir fails unless the listing shows dir
+func wantDir(t *testing.T, m *AppModel, dir string) {
+	t.Helper()
+	if m.CurrentDir != dir {
+		t.Errorf("CurrentDir = %q, want %q", m.CurrentDir, dir)
+	}
+}
+
+// wantSelected fails unless the cursor is on the named entry, at index i
+// when i is not negative
+func wantSelected(t *testing.T, m *AppModel, name string, i int) {
+	t.Helper()
+	if got := m.selectedName(); got != name || i >= 0 && m.Selected != i {
+		t.Errorf("selected %q at %d, want %q at %d", got, m.Selected, name, i)
+	}
+}
+
+// wantExists fails unless path exists
+func wantExists(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Errorf("%s: %v", filepath.Base(path), err)
+	}
+	return info
+}
+
+// wantGone fails if path exists
+func wantGone(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("%s still exists: %v", filepath.Base(path), err)
+	}
+}
+
+// hasExec reports whether msgs handed the terminal to a program
+func hasExec(msgs []tea.Msg) bool {
+	for _, msg := range msgs {
+		if fmt.Sprintf("%T", msg) == "tea.execMsg" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedBy reports whether the files, directories aside, are in order
+func sortedBy(m *AppModel, less func(a, b models.FileInfo) bool) bool {
+	var files []models.FileInfo
+	for _, file := range m.Files {
+		if !file.IsDir() {
+			files = append(files, file)
+		}
+	}
+	for i := 1; i < len(files); i++ {
+		if less(files[i], files[i-1]) {
+			return false
+		}
+	}
+	return len(files) > 1
+}
+
+var keyCases = []keyCase{
+	{action: "up", keys: []string{"k"}, start: "b.log", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantSelected(t, m, "a.txt", 2)
+	}},
+	{action: "down", keys: []string{"j"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantSelected(t, m, "src", 1)
+	}},
+	{action: "top", keys: []string{"G", "g"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.Selected != 0 || m.ListOffset != 0 {
+			t.Errorf("selected %d with offset %d, want both 0", m.Selected, m.ListOffset)
+		}
+	}},
+	{action: "bottom", keys: []string{"G"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if last := len(m.Files) - 1; m.Selected != last {
+			t.Errorf("selected %d, want the last entry %d", m.Selected, last)
+		}
+		if want := len(m.Files) - m.listRows(); m.ListOffset != want {
+			t.Errorf("offset %d, want %d", m.ListOffset, want)
+		}
+	}},
+	{action: "scroll_down", keys: []string{"ctrl+d"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		step := m.config.Scroll.Lines(m.listRows())
+		if m.Selected != step || m.ListOffset != step {
+			t.Errorf("selected %d with offset %d, want both %d", m.Selected, m.ListOffset, step)
+		}
+	}},
+	{action: "scroll_up", keys: []string{"ctrl+d", "ctrl+d", "ctrl+u"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		step := m.config.Scroll.Lines(m.listRows())
+		if m.Selected != step || m.ListOffset != step {
+			t.Errorf("selected %d with offset %d, want both %d", m.Selected, m.ListOffset, step)
+		}
+	}},
+	{action: "page_down", keys: []string{"pgdown"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		step := m.config.Page.Lines(m.listRows())
+		if m.Selected != step || m.ListOffset != step {
+			t.Errorf("selected %d with offset %d, want both %d", m.Selected, m.ListOffset, step)
+		}
+	}},
+	{action: "page_up", keys: []string{"pgdown", "pgup"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.Selected != 0 || m.ListOffset != 0 {
+			t.Errorf("selected %d with offset %d, want both 0", m.Selected, m.ListOffset)
+		}
+	}},
+	{action: "preview_scroll_down", keys: []string{"J"}, start: "long.txt", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if want := m.config.Scroll.Lines(m.previewRows()); m.PreviewOffset != want {
+			t.Errorf("preview offset %d, want %d", m.PreviewOffset, want)
+		}
+	}},
+	{action: "preview_scroll_up", keys: []string{"J", "J", "K"}, start: "long.txt", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if want := m.config.Scroll.Lines(m.previewRows()); m.PreviewOffset != want {
+			t.Errorf("preview offset %d, want %d", m.PreviewOffset, want)
+		}
+	}},
+
+	{action: "quit", keys: []string{"q"}, fake: true, check: func(t *testing.T, m *AppModel, root string, msgs []tea.Msg) {
+		if !hasQuit(msgs) {
+			t.Errorf("q did not quit, messages %v", msgs)
+		}
+	}},
+	{action: "enter_dir", keys: []string{"l"}, start: "docs", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, filepath.Join(root, "docs"))
+		if got, want := listed(m), []string{"guide.md", "notes.txt"}; !slices.Equal(got, want) {
+			t.Errorf("listing %v, want %v", got, want)
+		}
+	}},
+	{action: "parent_dir", keys: []string{"l", "h"}, start: "src", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, root)
+		wantSelected(t, m, "src", 1)
+	}},
+	{action: "open_editor", keys: []string{"o"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, msgs []tea.Msg) {
+		if !hasExec(msgs) {
+			t.Errorf("o did not run the editor, messages %v", msgs)
+		}
+	}},
+	{action: "open_default", keys: []string{"O"}, start: "a.txt", config: "opener_command = \"true\"\n", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if got, want := m.StatusMessage, "Opening a.txt with true"; got != want {
+			t.Errorf("status %q, want %q", got, want)
+		}
+	}},
+	{action: "activate", keys: []string{"enter"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, msgs []tea.Msg) {
+		if !hasExec(msgs) {
+			t.Errorf("enter did not open the file, messages %v", msgs)
+		}
+	}},
+	{action: "home", keys: []string{"~"}, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, os.Getenv("HOME"))
+	}},
+	{action: "force_preview", keys: []string{"P"}, start: "long.txt", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if want := filepath.Join(root, "long.txt"); m.ForcePreviewPath != want {
+			t.Errorf("ForcePreviewPath = %q, want %q", m.ForcePreviewPath, want)
+		}
+	}},
+	{action: "grid", keys: []string{"I"}, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.GridMode {
+			t.Error("I did not open the grid")
+		}
+	}},
+	{action: "quick_look", keys: []string{"i"}, start: "long.txt", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.QuickLook {
+			t.Error("i did not open quick look")
+		}
+	}},
+	{action: "goto_line", keys: []string{":"}, text: "50", start: "long.txt", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.PreviewLine != 50 || m.PreviewOffset != m.PreviewContentStart+49 {
+			t.Errorf("preview line %d at offset %d, want line 50 at %d", m.PreviewLine, m.PreviewOffset, m.PreviewContentStart+49)
+		}
+	}},
+	{action: "open_by", keys: []string{"L"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if want := filepath.Join(root, "a.txt"); m.OpenByPath != want {
+			t.Errorf("OpenByPath = %q, want %q", m.OpenByPath, want)
+		}
+		if m.OpenByReport == "" || m.OpenByReport == "Scanning processes..." {
+			t.Errorf("scan did not finish, report %q", m.OpenByReport)
+		}
+	}},
+	{action: "chmod", keys: []string{"="}, text: "600", start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if info := wantExists(t, filepath.Join(root, "a.txt")); info != nil && info.Mode().Perm() != 0o600 {
+			t.Errorf("mode %v, want 0600", info.Mode().Perm())
+		}
+	}},
+	{action: "rename", keys: []string{"a", "ctrl+a"}, text: "z.txt", start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantGone(t, filepath.Join(root, "a.txt"))
+		wantExists(t, filepath.Join(root, "z.txt"))
+		wantSelected(t, m, "z.txt", -1)
+	}},
+	{action: "new_file", keys: []string{"N"}, text: "made.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if info := wantExists(t, filepath.Join(root, "made.txt")); info != nil && !info.Mode().IsRegular() {
+			t.Errorf("made.txt is %v, want a file", info.Mode())
+		}
+		wantSelected(t, m, "made.txt", -1)
+	}},
+	{action: "new_dir", keys: []string{"M"}, text: "made", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if info := wantExists(t, filepath.Join(root, "made")); info != nil && !info.IsDir() {
+			t.Errorf("made is %v, want a directory", info.Mode())
+		}
+		wantSelected(t, m, "made", -1)
+	}},
+	{action: "trash", keys: []string{"d"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantGone(t, filepath.Join(root, "a.txt"))
+		wantExists(t, filepath.Join(os.Getenv("HOME"), ".local", "share", "Trash", "files", "a.txt"))
+	}},
+	{action: "delete", keys: []string{"D", "y"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantGone(t, filepath.Join(root, "a.txt"))
+		if m.InputMode != models.ModeNormal {
+			t.Errorf("input mode %v after confirming", m.InputMode)
+		}
+	}},
+	{action: "favorite", keys: []string{"*"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if path := filepath.Join(root, "a.txt"); !m.Favorites[path] {
+			t.Errorf("favorites %v, want %s", m.Favorites, path)
+		}
+	}},
+	{action: "favorites", keys: []string{"*", "F"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.FavoritesView || !slices.Equal(m.FavoriteList, []string{filepath.Join(root, "a.txt")}) {
+			t.Errorf("favorites view %v listing %v", m.FavoritesView, m.FavoriteList)
+		}
+	}},
+	{action: "debug", keys: []string{"ctrl+g"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.DebugScreen {
+			t.Error("ctrl+g did not open the debug screen")
+		}
+	}},
+	{action: "goto_path", keys: []string{"C"}, text: "src/main.go", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, filepath.Join(root, "src"))
+		wantSelected(t, m, "main.go", 0)
+	}},
+	{action: "repeat", keys: []string{"=", "6", "0", "0", "enter", "j", ";"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if info := wantExists(t, filepath.Join(root, "b.log")); info != nil && info.Mode().Perm() != 0o600 {
+			t.Errorf("mode of b.log %v, want 0600", info.Mode().Perm())
+		}
+	}},
+	{action: "clear", keys: []string{"/", "l", "o", "g", "enter", "esc"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.SearchQuery != "" || m.indexByName("a.txt") < 0 {
+			t.Errorf("query %q lists %v, want the whole listing", m.SearchQuery, listed(m))
+		}
+	}},
+	{action: "search", keys: []string{"/"}, text: ".log", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if got, want := listed(m), []string{"b.log"}; m.SearchQuery != ".log" || !slices.Equal(got, want) {
+			t.Errorf("query %q lists %v, want %v", m.SearchQuery, got, want)
+		}
+	}},
+	{action: "toggle_hidden", keys: []string{"."}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.ShowHidden || m.indexByName(".hidden") < 0 {
+			t.Errorf("ShowHidden %v, listing %v", m.ShowHidden, listed(m))
+		}
+	}},
+	{action: "sort_size", keys: []string{"s"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.SortBy != "size" || !sortedBy(m, func(a, b models.FileInfo) bool { return a.Size < b.Size }) {
+			t.Errorf("sorted by %q, listing %v", m.SortBy, listed(m))
+		}
+	}},
+	{action: "sort_modified", keys: []string{"t"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.SortBy != "modified" || !sortedBy(m, func(a, b models.FileInfo) bool { return a.ModTime.Before(b.ModTime) }) {
+			t.Errorf("sorted by %q, listing %v", m.SortBy, listed(m))
+		}
+	}},
+	{action: "sort_name", keys: []string{"s", "n"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.SortBy != "name" || !sortedBy(m, func(a, b models.FileInfo) bool { return a.Entry.Name() < b.Entry.Name() }) {
+			t.Errorf("sorted by %q, listing %v", m.SortBy, listed(m))
+		}
+	}},
+	{action: "refresh", keys: []string{"r"}, setup: func(t *testing.T, m *AppModel, root string) {
+		writeTree(t, root, "new.txt")
+	}, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.indexByName("new.txt") < 0 {
+			t.Errorf("listing %v lacks new.txt", listed(m))
+		}
+	}},
+	{action: "raw", keys: []string{"R"}, start: "c.md", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if want := filepath.Join(root, "c.md"); m.RawPreviewPath != want {
+			t.Errorf("RawPreviewPath = %q, want %q", m.RawPreviewPath, want)
+		}
+	}},
+	{action: "reveal_secrets", keys: []string{"U"}, start: "prod.env", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !strings.Contains(strings.Join(m.PreviewLines, "\n"), "hunter2") {
+			t.Errorf("preview does not reveal the token:\n%s", strings.Join(m.PreviewLines, "\n"))
+		}
+	}},
+	{action: "yank", keys: []string{"y"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if want := []string{filepath.Join(root, "a.txt")}; !slices.Equal(m.Clipboard, want) || m.ClipboardCut {
+			t.Errorf("clipboard %v cut %v, want %v copied", m.Clipboard, m.ClipboardCut, want)
+		}
+	}},
+	{action: "cut", keys: []string{"x"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if want := []string{filepath.Join(root, "a.txt")}; !slices.Equal(m.Clipboard, want) || !m.ClipboardCut {
+			t.Errorf("clipboard %v cut %v, want %v cut", m.Clipboard, m.ClipboardCut, want)
+		}
+	}},
+	{action: "paste", keys: []string{"y", "g", "l", "p"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantExists(t, filepath.Join(root, "a.txt"))
+		wantExists(t, filepath.Join(root, "docs", "a.txt"))
+	}},
+	{action: "prev_sibling", keys: []string{"l", "]", "["}, start: "docs", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, filepath.Join(root, "docs"))
+	}},
+	{action: "next_sibling", keys: []string{"l", "]"}, start: "docs", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, filepath.Join(root, "src"))
+	}},
+	{action: "mark", keys: []string{" "}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if got := markedNames(m); !slices.Equal(got, []string{"a.txt"}) {
+			t.Errorf("marked %v, want a.txt", got)
+		}
+		wantSelected(t, m, "b.log", -1)
+	}},
+	{action: "mark_all", keys: []string{"A"}, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if len(m.Marked) != len(m.Files) {
+			t.Errorf("marked %d of %d entries", len(m.Marked), len(m.Files))
+		}
+	}},
+	{action: "invert_marks", keys: []string{" ", "V"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.Marked[filepath.Join(root, "a.txt")] || len(m.Marked) != len(m.Files)-1 {
+			t.Errorf("marked %d of %d entries, a.txt %v", len(m.Marked), len(m.Files), m.Marked[filepath.Join(root, "a.txt")])
+		}
+	}},
+	{action: "new_from_template", keys: []string{"T", "enter", "enter"}, setup: func(t *testing.T, m *AppModel, root string) {
+		dir, err := templatesDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTree(t, dir, "memo.txt")
+	}, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantExists(t, filepath.Join(root, "memo.txt"))
+		wantSelected(t, m, "memo.txt", -1)
+	}},
+	{action: "visual", keys: []string{"v", "j", "j", " "}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if got, want := markedNames(m), []string{"a.txt", "b.log", "big.bin"}; !slices.Equal(got, want) || m.Visual {
+			t.Errorf("marked %v in visual mode %v, want %v", got, m.Visual, want)
+		}
+	}},
+	{action: "filter_preset", keys: []string{"f", "l"}, fake: true, config: "[filters]\nlogs = \"*.log\"\n", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if got, want := listed(m), []string{"docs", "src", "b.log"}; !slices.Equal(got, want) {
+			t.Errorf("listing %v, want %v", got, want)
+		}
+	}},
+	{action: "clear_changes", keys: []string{"u"}, start: "docs", config: "track_changes = true\n", setup: func(t *testing.T, m *AppModel, root string) {
+		press(t, m, "l")
+		writeTree(t, root, "new.txt")
+		press(t, m, "h")
+		if !m.Changed["new.txt"] {
+			t.Fatalf("changes %v do not include new.txt", m.Changed)
+		}
+	}, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if len(m.Changed) != 0 {
+			t.Errorf("changes %v left after clearing", m.Changed)
+		}
+	}},
+	{action: "history_back", keys: []string{"l", "ctrl+o"}, start: "docs", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, root)
+		wantSelected(t, m, "docs", 0)
+	}},
+	{action: "history_forward", keys: []string{"l", "ctrl+o", "tab"}, start: "docs", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantDir(t, m, filepath.Join(root, "docs"))
+	}},
+	{action: "physical_paths", keys: []string{"W"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.PhysicalPaths {
+			t.Error("W did not switch to physical paths")
+		}
+	}},
+	{action: "batch", keys: []string{"!"}, text: "touch %f.done", start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		wantExists(t, filepath.Join(root, "a.txt.done"))
+		if !m.BatchScreen {
+			t.Error("the batch results are not shown")
+		}
+	}},
+	{action: "grep", keys: []string{"ctrl+f"}, text: "Guide", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.GrepView || len(m.GrepMatches) != 1 {
+			t.Errorf("grep view %v with %d results, want 1", m.GrepView, len(m.GrepMatches))
+		}
+	}},
+	{action: "compare", keys: []string{"|"}, text: "../root-other", setup: func(t *testing.T, m *AppModel, root string) {
+		writeTree(t, root+"-other", "a.txt")
+	}, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.CompareView || m.CompareB != root+"-other" {
+			t.Errorf("compare view %v against %q", m.CompareView, m.CompareB)
+		}
+	}},
+	{action: "palette", keys: []string{"ctrl+k"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.PaletteView {
+			t.Error("ctrl+k did not open the palette")
+		}
+	}},
+	{action: "fuzzy_search", keys: []string{"/", "ctrl+t"}, text: "log", fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		// Fuzzy by default, substring search no longer matches long.txt
+		if got, want := listed(m), []string{"b.log"}; m.FuzzySearch || !slices.Equal(got, want) {
+			t.Errorf("fuzzy %v lists %v, want %v", m.FuzzySearch, got, want)
+		}
+	}},
+	{action: "trash_view", keys: []string{"d", "X"}, start: "a.txt", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.TrashView || len(m.TrashEntries) != 1 {
+			t.Errorf("trash view %v with %d items, want 1", m.TrashView, len(m.TrashEntries))
+		}
+	}},
+	{action: "image_color", keys: []string{"c"}, start: "photo.png", check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.ImagePreviewColored == m.config.ColorImages {
+			t.Errorf("colored image previews %v, want the default toggled", m.ImagePreviewColored)
+		}
+	}},
+	{action: "macro_record", keys: []string{"ctrl+r", "j", "j"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if !m.MacroRecording || len(m.macro) != 2 {
+			t.Errorf("recording %v with %d keys, want 2", m.MacroRecording, len(m.macro))
+		}
+	}},
+	{action: "macro_replay", keys: []string{"ctrl+r", "j", "ctrl+r", "ctrl+p"}, fake: true, check: func(t *testing.T, m *AppModel, root string, _ []tea.Msg) {
+		if m.Selected != 2 {
+			t.Errorf("selected %d after replay, want 2", m.Selected)
+		}
+	}},
+}
+
+func TestKeyCasesCoverEveryAction(t *testing.T) {
+	cases := make(map[string]keyCase)
+	for _, c := range keyCases {
+		if _, dup := cases[c.action]; dup {
+			t.Errorf("action %s has two cases", c.action)
+		}
+		cases[c.action] = c
+	}
+	for action, bound := range config.DefaultKeybindings {
+		c, ok := cases[action]
+		if !ok {
+			t.Errorf("action %s has no case", action)
+			continue
+		}
+		if len(bound) > 0 && !slices.ContainsFunc(bound, func(key string) bool { return slices.Contains(c.keys, key) }) {
+			t.Errorf("case of %s presses %v, none of its keys %v", action, c.keys, bound)
+		}
+	}
+	for action := range cases {
+		if _, ok := config.DefaultKeybindings[action]; !ok {
+			t.Errorf("case for unknown action %s", action)
+		}
+	}
+}
+
+func TestKeyBindings(t *testing.T) {
+	for _, c := range keyCases {
+		t.Run(c.action, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "root")
+			writeFixture(t, root)
+			runKeyCase(t, c, Options{Path: root}, root)
+		})
+		if c.fake {
+			t.Run(c.action+"/fake", func(t *testing.T) {
+				runKeyCase(t, c, Options{FS: fixtureFS()}, fsRoot)
+			})
+		}
+	}
+}
+
+// runKeyCase opens opts, presses the case's keys and checks the result,
+// with root the path the listing starts at
+func runKeyCase(t *testing.T, c keyCase, opts Options, root string) {
+	m := newConfiguredModel(t, opts, c.config)
+	wantDir(t, m, root)
+	if c.start != "" {
+		selectName(t, m, c.start)
+	}
+	if c.setup != nil {
+		c.setup(t, m, root)
+	}
+	msgs := press(t, m, c.keys...)
+	if c.text != "" {
+		typeText(t, m, c.text)
+		msgs = append(msgs, press(t, m, "enter")...)
+	}
+	c.check(t, m, root, msgs)
+}
+
+func TestRebindViewKeys(t *testing.T) {
+	tests := []struct {
+		action, old, key string
+		start            string
+		shown            func(m *AppModel) bool
+	}{
+		{"quick_look", "i", "Z", "long.txt", func(m *AppModel) bool { return m.QuickLook }},
+		{"favorites", "F", "Z", "", func(m *AppModel) bool { return m.FavoritesView }},
+		{"debug", "ctrl+g", "ctrl+t", "", func(m *AppModel) bool { return m.DebugScreen }},
+		{"trash_view", "X", "Z", "", func(m *AppModel) bool { return m.TrashView }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "root")
+			writeFixture(t, root)
+			m := newConfiguredModel(t, Options{Path: root}, fmt.Sprintf("[keybindings]\n%s = %q\n", tt.action, tt.key))
+			if tt.start != "" {
+				selectName(t, m, tt.start)
+			}
+
+			// The view closes on the key that opened it, not the default
+			press(t, m, tt.key)
+			if !tt.shown(m) {
+				t.Fatalf("%s did not open %s", tt.key, tt.action)
+			}
+			if want := tt.key + "/esc/q"; !strings.Contains(helpBar(m), want) {
+				t.Errorf("help bar %q does not offer %s", helpBar(m), want)
+			}
+			press(t, m, tt.old)
+			if !tt.shown(m) {
+				t.Errorf("the unbound %s closed %s", tt.old, tt.action)
+			}
+			press(t, m, tt.key)
+			if tt.shown(m) {
+				t.Errorf("%s did not close %s", tt.key, tt.action)
+			}
+		})
+	}
+}
+
+func TestHomeWithoutHOME(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a", "b")
+	m := newTestModel(t, Options{Path: dir})
+	selectName(t, m, "b")
+	t.Setenv("HOME", "")
+	os.Unsetenv("HOME")
+
+	press(t, m, "~")
+	wantDir(t, m, dir)
+	wantSelected(t, m, "b", 1)
+	if want := "Cannot determine home directory: $HOME is not defined"; m.StatusMessage != want {
+		t.Errorf("status %q, want %q", m.StatusMessage, want)
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// openByTimeout bounds the /proc scan, busy systems have many descriptors
+const openByTimeout = 2 * time.Second
+
+// openByMsg delivers the processes found holding a file open
+type openByMsg struct {
+	path   string
+	report string
+}
+
+// scanOpenBy starts looking for the processes that have the selected entry
+// open, the report replacing its preview until the selection moves
+func (m *AppModel) scanOpenBy() tea.Cmd {
+	if len(m.Files) == 0 {
+		return nil
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are not open by any process"
+		return nil
+	}
+	path := filepath.Join(m.CurrentDir, m.Files[m.Selected].Entry.Name())
+	m.OpenByPath = path
+	m.OpenByReport = "Scanning processes..."
+	UpdatePreview(m.Model, m.config)
+	return func() tea.Msg {
+		result, err := fileutils.OpenBy(path, openByTimeout)
+		if err != nil {
+			return openByMsg{path: path, report: fmt.Sprintf("Error: %v", err)}
+		}
+		return openByMsg{path: path, report: formatOpenBy(result)}
+	}
+}
+
+// handleOpenBy shows a finished scan if its entry is still selected
+func (m *AppModel) handleOpenBy(msg openByMsg) {
+	if m.OpenByPath != msg.path {
+		return
+	}
+	m.OpenByReport = msg.report
+	UpdatePreview(m.Model, m.config)
+}
+
+// formatOpenBy lays out a scan as a table of pid, command, fd and mode
+func formatOpenBy(result fileutils.OpenByResult) string {
+	var sb strings.Builder
+	if len(result.Handles) == 0 {
+		sb.WriteString("Not open by any process\n")
+	} else {
+		pids := make(map[int]bool)
+		for _, h := range result.Handles {
+			pids[h.PID] = true
+		}
+		noun := "processes"
+		if len(pids) == 1 {
+			noun = "process"
+		}
+		sb.WriteString(fmt.Sprintf("Open by %d %s:\n\n", len(pids), noun))
+		sb.WriteString(fmt.Sprintf("%7s  %-16s %5s  %s\n", "PID", "COMMAND", "FD", "MODE"))
+		for _, h := range result.Handles {
+			sb.WriteString(fmt.Sprintf("%7d  %-16s %5s  %s\n", h.PID, displayName(h.Command), h.FD, h.Mode))
+		}
+	}
+	if result.Unreadable > 0 {
+		sb.WriteString(fmt.Sprintf("\n%d processes could not be inspected, try as root to see all\n", result.Unreadable))
+	}
+	if result.TimedOut {
+		sb.WriteString(fmt.Sprintf("\nScan stopped after %s, the list may be incomplete\n", openByTimeout))
+	}
+	return sb.String()
+}
+
+// renderOpenByPreview shows the open-by report under the entry's name
+func renderOpenByPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo) {
+	header := fmt.Sprintf("%s %s\n\n", fileIcon(selectedFile, cfg), displayName(selectedFile.Entry.Name()))
+	m.PreviewContentStart = 2
+	setPreview(m, header+m.OpenByReport)
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// paletteEntry is one action the command palette lists, with the keys
+// bound to it, "" for actions only the palette runs
+type paletteEntry struct {
+	action keyAction
+	keys   string
+}
+
+// paletteEntries lists every action of the keymap except the palette's own,
+// fuzzy filtered by the palette query with the best matches first, else by
+// name
+func paletteEntries(m *models.Model, cfg config.Config) []paletteEntry {
+	type scored struct {
+		entry paletteEntry
+		score int
+	}
+	var found []scored
+	for name := range config.DefaultKeybindings {
+		if keyAction(name) == actionPalette {
+			continue
+		}
+		score, _, ok := fileutils.FuzzyMatch(name, m.PaletteQuery)
+		if !ok {
+			continue
+		}
+		keys := make([]string, 0, len(cfg.Keys[name]))
+		for _, key := range cfg.Keys[name] {
+			if key == " " {
+				key = "space"
+			}
+			keys = append(keys, key)
+		}
+		found = append(found, scored{paletteEntry{keyAction(name), strings.Join(keys, ", ")}, score})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].score != found[j].score {
+			return found[i].score > found[j].score
+		}
+		return found[i].entry.action < found[j].entry.action
+	})
+	entries := make([]paletteEntry, len(found))
+	for i, f := range found {
+		entries[i] = f.entry
+	}
+	return entries
+}
+
+// openPalette shows the command palette with an empty query
+func (m *AppModel) openPalette() {
+	m.PaletteQuery = ""
+	m.PaletteSelected = 0
+	m.PaletteView = true
+}
+
+// runAction runs an action as pressing its key in the listing would
+func (m *AppModel) runAction(action keyAction) tea.Cmd {
+	if command, ok := commands[action]; ok {
+		return command(m)
+	}
+	m.moveCursor(action)
+	return nil
+}
+
+// handlePaletteKeys edits the palette query, moves through the actions it
+// matches and runs the chosen one, reporting whether the key was consumed.
+// Typed keys go to the query, so only the arrow keys move.
+func (m *AppModel) handlePaletteKeys(msg tea.KeyMsg) (bool, tea.Cmd) {
+	entries := paletteEntries(m.Model, m.config)
+	switch msg.String() {
+	case "ctrl+c":
+		return false, nil
+	case "up":
+		m.PaletteSelected = max(0, m.PaletteSelected-1)
+	case "down":
+		m.PaletteSelected = max(0, min(len(entries)-1, m.PaletteSelected+1))
+	case "enter":
+		if len(entries) == 0 {
+			return true, nil
+		}
+		m.PaletteView = false
+		return true, m.runAction(entries[m.PaletteSelected].action)
+	case "esc":
+		m.PaletteView = false
+	case "backspace":
+		if query := []rune(m.PaletteQuery); len(query) > 0 {
+			m.PaletteQuery = string(query[:len(query)-1])
+			m.PaletteSelected = 0
+		}
+	default:
+		if text := typedText(msg); text != "" {
+			m.PaletteQuery += text
+			m.PaletteSelected = 0
+		}
+	}
+	return true, nil
+}
+
+// renderPalettePane lists the actions matching the palette query with their
+// keys right-aligned, below the query being typed
+func renderPalettePane(m *models.Model, cfg config.Config, width, height int) string {
+	var content strings.Builder
+	paneContentWidth := max(0, width-2)
+	entries := paletteEntries(m, cfg)
+	content.WriteString(truncatePaneTitle("Run: "+m.PaletteQuery, fmt.Sprintf(" (%d)", len(entries)), paneContentWidth) + "\n")
+	content.WriteString(paneRule(cfg, width-2) + "\n")
+
+	if len(entries) == 0 {
+		content.WriteString(" No matching actions")
+	}
+	rows := max(1, height-2)
+	start := max(0, m.PaletteSelected-rows+1)
+	end := min(start+rows, len(entries))
+	for i := start; i < end; i++ {
+		entry := entries[i]
+		prefix := " "
+		if i == m.PaletteSelected && !cfg.Color {
+			prefix = ">"
+		}
+		name := prefix + string(entry.action)
+		keys := entry.keys
+		if keys == "" {
+			keys = "(no key)"
+		}
+		line := TruncateString(name, paneContentWidth)
+		if gap := paneContentWidth - len([]rune(name)) - len([]rune(keys)) - 1; gap >= 1 {
+			line = name + strings.Repeat(" ", gap) + keys
+		}
+		if i == m.PaletteSelected {
+			line = GetPreviewHighlightStyle(cfg).Render(line)
+		}
+		content.WriteString(line + "\n")
+	}
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(strings.TrimSuffix(content.String(), "\n"))
+}
@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfTextPages is how many pages of text a PDF preview extracts
+const pdfTextPages = 2
+
+// isPDFFile detects PDF documents by name
+func isPDFFile(fileName string) bool {
+	return strings.ToLower(filepath.Ext(fileName)) == ".pdf"
+}
+
+// pdfDocument is what a PDF preview shows of a document
+type pdfDocument struct {
+	title, author string
+	pages         int
+	text          []string // Text of the first pages, in order
+}
+
+// readPDF reads the metadata of a PDF and, with withText, the text of its
+// first pages. A text extraction error is returned along with the metadata.
+// The parser panics on some malformed files, which is reported as an error.
+func readPDF(fullPath string, withText bool) (doc pdfDocument, textErr error, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return doc, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return doc, nil, err
+	}
+	r, err := pdf.NewReader(f, info.Size())
+	if err != nil {
+		return doc, nil, err
+	}
+
+	meta := r.Trailer().Key("Info")
+	doc.title = strings.TrimSpace(meta.Key("Title").Text())
+	doc.author = strings.TrimSpace(meta.Key("Author").Text())
+	doc.pages = r.NumPage()
+	if !withText {
+		return doc, nil, nil
+	}
+
+	for i := 1; i <= min(doc.pages, pdfTextPages); i++ {
+		text, err := r.Page(i).GetPlainText(nil)
+		if err != nil {
+			return doc, err, nil
+		}
+		if len(text) > previewReadLimit {
+			text = text[:previewReadLimit]
+		}
+		doc.text = append(doc.text, strings.TrimSpace(text))
+	}
+	return doc, nil, nil
+}
+
+// renderPDFPreview shows the title, author and page count of a PDF above
+// the text of its first pages. Encrypted documents, and those whose text
+// cannot be extracted, get the metadata and file info alone. Text is not
+// extracted from files above preview_max_size unless P forces it.
+func renderPDFPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	tooLarge := m.PreviewMaxSize > 0 && selectedFile.Size > m.PreviewMaxSize && m.ForcePreviewPath != fullPath
+	doc, textErr, err := readPDF(fullPath, !tooLarge)
+
+	var sb strings.Builder
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	sb.WriteString("\n")
+	switch {
+	case errors.Is(err, pdf.ErrInvalidPassword):
+		sb.WriteString("Encrypted, a password is needed to read it")
+		setPreview(m, sb.String())
+		return
+	case err != nil:
+		sb.WriteString(fmt.Sprintf("Could not read PDF: %v", err))
+		setPreview(m, sb.String())
+		return
+	}
+
+	if doc.title != "" {
+		sb.WriteString(fmt.Sprintf("Title: %s\n", doc.title))
+	}
+	if doc.author != "" {
+		sb.WriteString(fmt.Sprintf("Author: %s\n", doc.author))
+	}
+	sb.WriteString(fmt.Sprintf("Pages: %d\n", doc.pages))
+
+	switch {
+	case tooLarge:
+		sb.WriteString(fmt.Sprintf("\nLarger than the %s preview limit, press P to extract the text", fileutils.FormatSize(m.PreviewMaxSize)))
+	case textErr != nil:
+		sb.WriteString(fmt.Sprintf("\nCould not extract the text: %v", textErr))
+	case strings.Join(doc.text, "") == "":
+		sb.WriteString("\nNo text on the first pages")
+	default:
+		m.PreviewContentStart = strings.Count(sb.String(), "\n")
+		for i, text := range doc.text {
+			sb.WriteString(fmt.Sprintf("\nPage %d of %d\n", i+1, doc.pages))
+			if text != "" {
+				sb.WriteString(text + "\n")
+			}
+		}
+	}
+	setPreview(m, strings.TrimRight(sb.String(), "\n"))
+}
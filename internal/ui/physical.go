@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// resolvePhysicalDir records the current directory with its symlinks
+// resolved, for the real path lines and physical_paths. Archives have none.
+func (m *AppModel) resolvePhysicalDir() {
+	m.PhysicalDir, m.physicalErr = "", nil
+	if m.ArchiveFS != nil {
+		return
+	}
+	resolved, err := filepath.EvalSymlinks(m.CurrentDir)
+	if err != nil {
+		m.physicalErr = err
+		return
+	}
+	if resolved != m.CurrentDir {
+		m.PhysicalDir = resolved
+	}
+}
+
+// usePath returns the path opening or handing out the entry at fullPath
+// uses: the logical one, or with physical_paths the one its directory
+// resolves to. A directory that does not resolve keeps the logical path and
+// says so.
+func (m *AppModel) usePath(fullPath string) string {
+	if !m.PhysicalPaths {
+		return fullPath
+	}
+	if m.physicalErr != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot resolve the physical path (%v), using %s", m.physicalErr, fullPath)
+		return fullPath
+	}
+	if m.PhysicalDir == "" || filepath.Dir(fullPath) != m.CurrentDir {
+		return fullPath
+	}
+	return filepath.Join(m.PhysicalDir, filepath.Base(fullPath))
+}
+
+// togglePhysicalPaths switches opening files between logical and physical
+// paths
+func (m *AppModel) togglePhysicalPaths() {
+	m.PhysicalPaths = !m.PhysicalPaths
+	switch {
+	case !m.PhysicalPaths:
+		m.StatusMessage = "Using logical paths, as navigated"
+	case m.PhysicalDir != "":
+		m.StatusMessage = "Using physical paths, here " + m.PhysicalDir
+	default:
+		m.StatusMessage = "Using physical paths, symlinks resolved"
+	}
+}
+
+// ExitDir returns the directory to hand to the shell on exit, resolved with
+// physical_paths. Browsing an Options.FS it is the slash-separated path of
+// the current directory within it.
+func (m *AppModel) ExitDir() string {
+	if m.RootFS {
+		if name, _ := archiveEntry(m.Model, m.CurrentDir); name != "." {
+			return name
+		}
+		return ""
+	}
+	if m.ArchiveFS != nil {
+		return filepath.Dir(m.ArchivePath)
+	}
+	if m.PhysicalPaths && m.PhysicalDir != "" {
+		return m.PhysicalDir
+	}
+	return m.CurrentDir
+}
+
+// realPathLine is the preview header line naming where an entry of the
+// current directory really lives, "" when no symlinked directory leads to it
+func realPathLine(m *models.Model, name string) string {
+	if m.PhysicalDir == "" {
+		return ""
+	}
+	return fmt.Sprintf("Real path: %s\n", filepath.Join(m.PhysicalDir, name))
+}
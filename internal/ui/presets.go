@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// presetKeys assigns each [filters] preset the key that selects it: the
+// first letter of its name not taken by a preset sorted before it, so
+// "images" and "info" become i and n. A name whose letters are all taken
+// gets no key.
+func presetKeys(filters map[string]string) map[string]string {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keys := make(map[string]string, len(names))
+	for _, name := range names {
+		for _, r := range strings.ToLower(name) {
+			if key := string(r); r != ' ' && keys[key] == "" {
+				keys[key] = name
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// presetList describes the presets as "d:docs i:images"
+func presetList(keys map[string]string) string {
+	parts := make([]string, 0, len(keys))
+	for key, name := range keys {
+		parts = append(parts, key+":"+name)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// choosePreset asks for the key of the filter preset to apply. A key
+// without a preset lists the presets rather than silently cancelling.
+func (m *AppModel) choosePreset() {
+	keys := presetKeys(m.config.Filters)
+	if len(keys) == 0 {
+		m.StatusMessage = "No filter presets, define them in the [filters] table of the config"
+		return
+	}
+	choices := make(map[string]func() tea.Cmd, len(keys))
+	for key, name := range keys {
+		choices[key] = func() tea.Cmd { return m.applyPreset(name) }
+	}
+	m.choose("Filter preset: "+presetList(keys), choices)
+	m.confirmOther = func(key string) tea.Cmd {
+		if key == "esc" || key == "ctrl+c" {
+			m.StatusMessage = "Cancelled"
+		} else {
+			m.StatusMessage = fmt.Sprintf("No filter preset on %s, presets: %s", key, presetList(keys))
+		}
+		return nil
+	}
+}
+
+// applyPreset narrows the listing to the named preset's pattern, on top of
+// the search filter
+func (m *AppModel) applyPreset(name string) tea.Cmd {
+	m.FilterPreset = name
+	m.reloadKeepingCursor()
+	m.setRepeatAction("filter preset "+name, func() tea.Cmd {
+		m.FilterPreset = name
+		m.reloadKeepingCursor()
+		return nil
+	})
+	return nil
+}
+
+// filterPreset narrows files to the active filter preset. A preset whose
+// pattern does not parse leaves them as they are and says so.
+func filterPreset(m *models.Model, cfg config.Config, files []models.FileInfo) []models.FileInfo {
+	if m.FilterPreset == "" {
+		return files
+	}
+	filtered, err := fileutils.FilterGlob(files, cfg.Filters[m.FilterPreset])
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Filter preset %s: %v", m.FilterPreset, err)
+	}
+	return filtered
+}
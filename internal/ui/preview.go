@@ -1,24 +1,33 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	// Import decoders for desired image formats
-	_ "image/jpeg"
-	_ "image/png"
 	_ "golang.org/x/image/bmp"
 	_ "golang.org/x/image/tiff"
 	_ "golang.org/x/image/webp"
+	_ "image/jpeg"
+	_ "image/png"
 
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/embeddingbits/file_viewer/internal/config"
 	"github.com/embeddingbits/file_viewer/internal/fileutils"
 	"github.com/embeddingbits/file_viewer/pkg/models"
 	"github.com/qeesung/image2ascii/convert"
 )
 
+// previewReadLimit caps how much of a file is read for a text or hex preview
+const previewReadLimit = 256 * 1024
+
 // isImageFileByExtension helper detects a wide range of common image formats.
 func isImageFileByExtension(fileName string) bool {
 	ext := strings.ToLower(filepath.Ext(fileName))
@@ -31,125 +40,440 @@ func isImageFileByExtension(fileName string) bool {
 }
 
 // UpdatePreview is the main entry point to update the preview pane content.
-func UpdatePreview(m *models.Model) {
+// Previews that read the filesystem are generated in the background, see
+// startPreview, with a placeholder shown until they arrive.
+func UpdatePreview(m *models.Model, cfg config.Config) {
+	m.PreviewGeneration++
+	m.PreviewPending = false
+	m.PreviewHighlight = -1
 	if len(m.Files) == 0 {
-		m.Preview = "No Items"
+		setPreview(m, "No Items")
+		m.Stats.Preview = 0
+		m.Stats.PreviewPath = m.PreviewPath
 		return
 	}
 
 	selectedFile := m.Files[m.Selected]
 	fullPath := filepath.Join(m.CurrentDir, selectedFile.Entry.Name())
+	selectPreviewTarget(m, fullPath)
+	if previewsInBackground(m, selectedFile, fullPath) {
+		m.PreviewPending = true
+		setPreview(m, previewingText(cfg))
+		return
+	}
+	generatePreview(m, cfg, selectedFile, fullPath)
+}
 
-	if selectedFile.Entry.IsDir() {
-		updateDirectoryPreview(m, selectedFile, fullPath)
-	} else {
-		updateFilePreview(m, selectedFile, fullPath)
+// generatePreview renders the preview of the selected entry, timed for the
+// debug screen
+func generatePreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	start := time.Now()
+	renderSelectedPreview(m, cfg, selectedFile, fullPath)
+	m.Stats.Preview = time.Since(start)
+	m.Stats.PreviewPath = m.PreviewPath
+}
+
+// previewsInBackground reports whether the preview of file reads from the
+// filesystem, which may take long for a huge directory or a slow disk.
+// Archive entries are in memory, devices and the open-by report are
+// described from what is known already.
+func previewsInBackground(m *models.Model, file models.FileInfo, fullPath string) bool {
+	_, inArchive := archiveEntry(m, fullPath)
+	return !inArchive && m.OpenByPath != fullPath && !fileutils.IsSpecialFile(file.Mode)
+}
+
+// previewingText is the placeholder shown while a preview is generated
+func previewingText(cfg config.Config) string {
+	if !cfg.Unicode {
+		return "Previewing..."
+	}
+	return "Previewing…"
+}
+
+// setPreview stores a generated preview, split into lines once here rather
+// than on every frame
+func setPreview(m *models.Model, preview string) {
+	m.PreviewLines = strings.Split(preview, "\n")
+	m.PreviewImage = models.PreviewImage{}
+}
+
+// selectPreviewTarget makes fullPath the previewed entry. Per-file preview
+// state only sticks while the same file stays selected.
+func selectPreviewTarget(m *models.Model, fullPath string) {
+	if m.PreviewPath != fullPath {
+		m.PreviewPath = fullPath
+		m.PreviewOffset = 0
+		m.PreviewLine = 0
+		m.RawPreviewPath = ""
+		m.ForcePreviewPath = ""
+		m.RevealSecretsPath = ""
+		m.OpenByPath = ""
+	}
+	m.PreviewContentStart = 0
+}
+
+// renderSelectedPreview renders the preview of the selected entry
+func renderSelectedPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	if m.OpenByPath == fullPath {
+		renderOpenByPreview(m, cfg, selectedFile)
+		return
+	}
+
+	name, inArchive := archiveEntry(m, fullPath)
+	switch {
+	case selectedFile.LinkBroken:
+		renderBrokenLinkPreview(m, cfg, selectedFile, fullPath)
+	case selectedFile.IsDir():
+		updateDirectoryPreview(m, cfg, selectedFile, fullPath)
+	case inArchive:
+		renderArchiveEntryPreview(m, cfg, selectedFile, name)
+	default:
+		updateFilePreview(m, cfg, selectedFile, fullPath)
 	}
 }
 
 // updateDirectoryPreview shows the contents of a selected directory.
-func updateDirectoryPreview(m *models.Model, selectedFile models.FileInfo, fullPath string) {
-	// ... (This function is unchanged)
-	subFiles, err := fileutils.ReadDirWithInfo(fullPath)
+func updateDirectoryPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	subFiles, err := readDir(m, fullPath)
 	if err != nil {
-		m.Preview = fmt.Sprintf("Error: %v", err)
+		setPreview(m, fmt.Sprintf("Error: %v", err))
 		return
 	}
-	filtered := fileutils.FilterFiles(subFiles, m.ShowHidden, m.SearchQuery)
-	fileutils.SortFiles(filtered, m.SortBy, m.ReverseSort)
+	filtered := filterPreset(m, cfg, filterFiles(m, subFiles, hiddenShown(m, cfg.ShowHiddenPreview), m.SearchQuery))
+	fileutils.SortFiles(filtered, m.SortBy, m.ReverseSort, m.HiddenPosition, cfg.SortNatural)
 
 	var sb strings.Builder
+	if line := realPathLine(m, selectedFile.Entry.Name()); line != "" {
+		sb.WriteString(line + "\n")
+	}
+	if header := gitHeader(m.GitInfos[fullPath]); header != "" {
+		sb.WriteString(header + "\n")
+	}
+	if commits := m.GitLogs[fullPath]; len(commits) > 0 {
+		sb.WriteString("Recent commits:\n")
+		for _, commit := range commits {
+			sb.WriteString("  " + commit + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	headerLines := strings.Count(sb.String(), "\n")
 	for i, f := range filtered {
 		if i >= 100 {
 			sb.WriteString("... and more files")
 			break
 		}
-		icon := GetFileIcon(f)
-		sb.WriteString(fmt.Sprintf("%s %s\n", icon, f.Entry.Name()))
+		icon := fileIcon(f, cfg)
+		sb.WriteString(fmt.Sprintf("%s %s%s\n", icon, displayName(f.Entry.Name()), linkSuffix(f)))
+	}
+	setPreview(m, sb.String())
+
+	// Highlight the entry l would land on: the remembered one, else the first
+	if len(filtered) > 0 {
+		m.PreviewHighlight = headerLines
+		for i, f := range filtered[:min(len(filtered), 100)] {
+			if f.Entry.Name() == m.DirCursors[fullPath].Name {
+				m.PreviewHighlight = headerLines + i
+				break
+			}
+		}
+		rows := max(1, m.Height-6)
+		if m.PreviewHighlight >= m.PreviewOffset+rows {
+			m.PreviewOffset = m.PreviewHighlight - rows/2
+		}
 	}
-	m.Preview = sb.String()
 }
 
+// filePreviewer renders a specialised preview for the files it matches.
+// Previewers without a raw renderer fall back to the text/hex view when the
+// raw preview mode is toggled on.
+type filePreviewer struct {
+	match  func(fileName string) bool
+	sniff  func(head []byte) bool // Optional content check for files the name did not match
+	render func(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string)
+	raw    func(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string)
+
+	anySize bool // Never reads the whole file, so the preview size limit does not apply
+}
+
+// filePreviewers are tried in order, the first match renders the preview
+var filePreviewers = []filePreviewer{
+	{match: isImageFileByExtension, render: renderImagePreview},
+	{match: isSVGFile, render: renderSVGPreview},
+	{match: isZipFile, render: renderArchiveListPreview, anySize: true},
+	{match: fileutils.IsArchive, render: renderArchiveListPreview},
+	{match: isVideoFileByExtension, render: renderVideoPreview, anySize: true},
+	{match: isFontFileByExtension, render: renderFontPreview},
+	{match: isINISummaryFile, render: renderINISummaryPreview},
+	{match: isEnvFile, render: renderEnvPreview, raw: renderEnvPreview},
+	{match: isDiffFileByExtension, sniff: looksLikeDiff, render: renderDiffPreview},
+	{match: isMarkdownFile, render: renderMarkdownPreview},
+	{match: isPDFFile, render: renderPDFPreview, anySize: true},
+}
+
+// sniffLength is how much of a file is read to detect its type by content.
+const sniffLength = 512
+
 // updateFilePreview handles rendering for image, text, and binary files.
-func updateFilePreview(m *models.Model, selectedFile models.FileInfo, fullPath string) {
+func updateFilePreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
 	fileName := selectedFile.Entry.Name()
+	raw := m.RawPreviewPath == fullPath
+
+	// Reading a device, socket or FIFO can block forever, describe it instead
+	if fileutils.IsSpecialFile(selectedFile.Mode) {
+		renderSpecialFilePreview(m, cfg, selectedFile)
+		return
+	}
+	if selectedFile.Virtual {
+		renderVirtualFilePreview(m, cfg, selectedFile, fullPath)
+		return
+	}
 
-	// --- ASPECT-RATIO-PRESERVING IMAGE RENDERING LOGIC ---
-	if isImageFileByExtension(fileName) {
-		file, err := os.Open(fullPath)
-		if err != nil {
-			m.Preview = fmt.Sprintf("Error opening image: %v", err)
-			return
+	tooLarge := m.PreviewMaxSize > 0 && selectedFile.Size > m.PreviewMaxSize && m.ForcePreviewPath != fullPath
+
+	var head []byte
+	headRead := false
+	for _, p := range filePreviewers {
+		if !p.match(fileName) {
+			if p.sniff == nil {
+				continue
+			}
+			if !headRead {
+				head, _, _ = fileutils.ReadHead(fullPath, sniffLength)
+				headRead = true
+			}
+			if !p.sniff(head) {
+				continue
+			}
 		}
-		defer file.Close()
+		switch {
+		case tooLarge && (raw || !p.anySize):
+			renderLargeFilePreview(m, cfg, selectedFile)
+		case !raw:
+			p.render(m, cfg, selectedFile, fullPath)
+		case p.raw != nil:
+			p.raw(m, cfg, selectedFile, fullPath)
+		default:
+			renderBinaryPreview(m, cfg, selectedFile, fullPath, "")
+		}
+		return
+	}
+
+	// Fallback for files without a specialised previewer.
+	if tooLarge {
+		renderLargeFilePreview(m, cfg, selectedFile)
+		return
+	}
+	renderBinaryPreview(m, cfg, selectedFile, fullPath, "")
+}
+
+// renderImagePreview renders an image as ASCII art preserving its aspect ratio.
+func renderImagePreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error opening image: %v", err))
+		return
+	}
+	defer file.Close()
 
-		img, _, err := image.Decode(file)
-		if err != nil {
-			renderBinaryPreview(m, selectedFile, fullPath)
-			return
+	img, _, err := image.Decode(file)
+	if err != nil {
+		renderBinaryPreview(m, cfg, selectedFile, fullPath, imageDecodeNote(file, err))
+		return
+	}
+
+	showImage(m, cfg, img, fullPath)
+}
+
+// showImage previews the decoded image of fullPath, with the terminal's
+// graphics when it has them and as ASCII art otherwise
+func showImage(m *models.Model, cfg config.Config, img image.Image, fullPath string) {
+	contentWidth, contentHeight := previewContentSize(m)
+	if m.ImageProtocol != "" {
+		renderImageGraphics(m, cfg, img, fullPath, contentWidth, contentHeight)
+		return
+	}
+	setPreview(m, imageToASCII(img, contentWidth, contentHeight, m.ImagePreviewColored && cfg.Color))
+}
+
+// imageToASCII renders img as ASCII art fitted into a box of the given
+// character dimensions while preserving its aspect ratio.
+func imageToASCII(img image.Image, boxWidth, boxHeight int, colored bool) string {
+	// 1. Get original image dimensions.
+	imageWidth := img.Bounds().Dx()
+	imageHeight := img.Bounds().Dy()
+	if imageWidth == 0 || imageHeight == 0 {
+		return ""
+	}
+
+	// 2. Define the aspect ratio of a terminal character (they are taller than wide).
+	//    The value 0.55 is a good approximation.
+	charRatio := 0.55
+
+	// 3. Calculate the visual aspect ratio of the image and the box.
+	//    We adjust the image's ratio to account for the non-square character cells.
+	imageAspect := (float64(imageWidth) / float64(imageHeight)) / charRatio
+	boxAspect := float64(boxWidth) / float64(boxHeight)
+
+	var finalWidth, finalHeight int
+
+	// 4. Compare ratios to decide whether to fit to width or height.
+	if imageAspect > boxAspect {
+		// The image is "wider" than the box, so we're limited by the box's width.
+		finalWidth = boxWidth
+		finalHeight = int(float64(finalWidth) / imageAspect)
+	} else {
+		// The image is "taller" than the box, so we're limited by the box's height.
+		finalHeight = boxHeight
+		finalWidth = int(float64(finalHeight) * imageAspect)
+	}
+
+	// 5. Set converter options with the calculated dimensions.
+	converter := convert.NewImageConverter()
+	options := convert.DefaultOptions
+	options.Colored = colored
+	options.FixedWidth = max(1, finalWidth)   // Ensure width is at least 1
+	options.FixedHeight = max(1, finalHeight) // Ensure height is at least 1
+
+	ascii := converter.Image2ASCIIString(img, &options)
+	if colored {
+		// End every line's colors, so they do not run into the pane's
+		// background padding or the next pane. The converter's resets are
+		// spelled the usual way for withBackground to find them.
+		lines := strings.Split(ascii, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = strings.ReplaceAll(line, "\x1b[0;00m", "\x1b[0m") + "\x1b[0m"
+			}
 		}
+		ascii = strings.Join(lines, "\n")
+	}
+	return ascii
+}
 
-		// --- NEW: SOPHISTICATED SIZING LOGIC ---
-
-		// 1. Calculate available content space within the pane's borders.
-		parentWidth := max(m.Width/4, 15)
-		currentWidth := max(m.Width/3, 20)
-		paneWidth := max(m.Width-parentWidth-currentWidth-4, 20)
-		paneHeight := max(1, m.Height-4)
-		contentWidth := max(1, paneWidth-2)
-		contentHeight := max(1, paneHeight-2)
-
-		// 2. Get original image dimensions.
-		imageWidth := img.Bounds().Dx()
-		imageHeight := img.Bounds().Dy()
-
-		// 3. Define the aspect ratio of a terminal character (they are taller than wide).
-		//    The value 0.55 is a good approximation.
-		charRatio := 0.55
-
-		// 4. Calculate the visual aspect ratio of the image and the pane.
-		//    We adjust the image's ratio to account for the non-square character cells.
-		imageAspect := (float64(imageWidth) / float64(imageHeight)) / charRatio
-		paneAspect := float64(contentWidth) / float64(contentHeight)
-
-		var finalWidth, finalHeight int
-
-		// 5. Compare ratios to decide whether to fit to width or height.
-		if imageAspect > paneAspect {
-			// The image is "wider" than the pane, so we're limited by the pane's width.
-			finalWidth = contentWidth
-			finalHeight = int(float64(finalWidth) / imageAspect)
-		} else {
-			// The image is "taller" than the pane, so we're limited by the pane's height.
-			finalHeight = contentHeight
-			finalWidth = int(float64(finalHeight) * imageAspect)
+// imageDecodeNote explains why an image could not be decoded, including the
+// header dimensions when only the pixel data is broken (e.g. a truncated file).
+func imageDecodeNote(file *os.File, decodeErr error) string {
+	if _, err := file.Seek(0, io.SeekStart); err == nil {
+		if cfg, format, err := image.DecodeConfig(file); err == nil {
+			return fmt.Sprintf("Image decode failed (%s, %dx%d): %v", format, cfg.Width, cfg.Height, decodeErr)
 		}
+	}
+	return fmt.Sprintf("Image decode failed: %v", decodeErr)
+}
+
+// renderSpecialFilePreview describes a device, socket or named pipe without
+// opening it.
+func renderSpecialFilePreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo) {
+	var sb strings.Builder
+	icon := fileIcon(selectedFile, cfg)
+	sb.WriteString(fmt.Sprintf("%s %s\n", icon, displayName(selectedFile.Entry.Name())))
+	if info, err := selectedFile.Entry.Info(); err == nil {
+		sb.WriteString(fmt.Sprintf("Type: %s\n", fileutils.DescribeSpecialFile(info)))
+		sb.WriteString(fmt.Sprintf("Mode: %s\n", fileutils.FormatPermissions(info.Mode())))
+	}
+	sb.WriteString(fmt.Sprintf("Modified: %s\n", selectedFile.ModTime.Format("2006-01-02 15:04:05")))
+	setPreview(m, sb.String())
+}
+
+// renderBrokenLinkPreview describes a symlink whose target is missing or
+// loops, rather than failing to read it
+func renderBrokenLinkPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	var sb strings.Builder
+	icon := fileIcon(selectedFile, cfg)
+	sb.WriteString(fmt.Sprintf("%s %s%s\n", icon, displayName(selectedFile.Entry.Name()), linkSuffix(selectedFile)))
+	reason := "the target does not exist"
+	if _, err := os.Stat(fullPath); errors.Is(err, syscall.ELOOP) {
+		reason = "the link loops back on itself"
+	}
+	sb.WriteString("Broken link: " + reason + "\n")
+	sb.WriteString(fmt.Sprintf("Modified: %s\n", selectedFile.ModTime.Format("2006-01-02 15:04:05")))
+	setPreview(m, sb.String())
+}
 
-		// 6. Set converter options with our perfectly calculated dimensions.
-		converter := convert.NewImageConverter()
-		options := convert.DefaultOptions
-		options.Colored = false // Still rendering as monochrome per last request
-		options.FixedWidth = max(1, finalWidth)   // Ensure width is at least 1
-		options.FixedHeight = max(1, finalHeight) // Ensure height is at least 1
+// formatFileSize returns the size for preview headers, along with the size
+// on disk when the two differ significantly
+func formatFileSize(file models.FileInfo) string {
+	if !fileutils.DiskSizeDiffers(file.Size, file.DiskSize) {
+		return fileutils.FormatSize(file.Size)
+	}
+	return fmt.Sprintf("%s apparent, %s on disk", fileutils.FormatSize(file.Size), fileutils.FormatSize(file.DiskSize))
+}
+
+// fileHeader returns the name, size and modification time lines that
+// start file previews.
+func fileHeader(m *models.Model, cfg config.Config, selectedFile models.FileInfo) string {
+	var sb strings.Builder
+	icon := fileIcon(selectedFile, cfg)
+	sb.WriteString(fmt.Sprintf("%s %s%s\n", icon, displayName(selectedFile.Entry.Name()), linkSuffix(selectedFile)))
+	sb.WriteString(realPathLine(m, selectedFile.Entry.Name()))
+	sb.WriteString(fmt.Sprintf("Size: %s\n", formatFileSize(selectedFile)))
+	sb.WriteString(fmt.Sprintf("Modified: %s\n", selectedFile.ModTime.Format("2006-01-02 15:04:05")))
+	return sb.String()
+}
+
+// linkSuffix shows where a symlink points after its name, "" for other entries
+func linkSuffix(file models.FileInfo) string {
+	if file.LinkTarget == "" {
+		return ""
+	}
+	return " -> " + displayName(file.LinkTarget)
+}
+
+// renderLargeFilePreview shows only the file header for files above the
+// preview size threshold, so selecting a huge file never triggers a read.
+func renderLargeFilePreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo) {
+	var sb strings.Builder
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	sb.WriteString(fmt.Sprintf("\nLarger than the %s preview limit, press P to preview anyway", fileutils.FormatSize(m.PreviewMaxSize)))
+	setPreview(m, sb.String())
+}
 
-		asciiStr := converter.Image2ASCIIString(img, &options)
-		m.Preview = asciiStr
+// renderBinaryPreview shows file info and a hex dump, with an optional note
+// (such as a decode error) placed above the content.
+func renderBinaryPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string, note string) {
+	content, truncated, err := fileutils.ReadHead(fullPath, previewReadLimit)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
 		return
 	}
 
-	// Fallback for non-image files.
-	renderBinaryPreview(m, selectedFile, fullPath)
+	// Follow symlinks for the mode, the listing's mode is the link's own
+	mode := selectedFile.Mode
+	if fileInfo, err := os.Stat(fullPath); err == nil {
+		mode = fileInfo.Mode()
+	}
+	renderContentPreview(m, cfg, selectedFile, content, truncated, mode, note)
 }
 
-// renderBinaryPreview shows file info and a hex dump.
-func renderBinaryPreview(m *models.Model, selectedFile models.FileInfo, fullPath string) {
-	// ... (This function is unchanged)
-	content, err := os.ReadFile(fullPath)
+// virtualReadTimeout bounds reads of virtual files, some of which block
+// until the kernel has something to report
+const virtualReadTimeout = 500 * time.Millisecond
+
+// renderVirtualFilePreview shows a file of /proc, /sys and the like. Their
+// reported size is usually zero, so the content is read regardless, up to
+// the usual limit and in bounded time.
+func renderVirtualFilePreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	info, err := os.Stat(fullPath)
 	if err != nil {
-		m.Preview = fmt.Sprintf("Error reading file: %v", err)
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+	if fileutils.IsSpecialFile(info.Mode()) {
+		selectedFile.Mode = info.Mode()
+		renderSpecialFilePreview(m, cfg, selectedFile)
 		return
 	}
+	content, truncated, err := fileutils.ReadHeadWithin(fullPath, previewReadLimit, virtualReadTimeout)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+	renderContentPreview(m, cfg, selectedFile, content, truncated, info.Mode(), "")
+}
 
+// renderContentPreview lays out the file info, note and text or hex dump of
+// content, the first bytes of the file.
+func renderContentPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, content []byte, truncated bool, mode fs.FileMode, note string) {
 	fileName := selectedFile.Entry.Name()
 	isText := fileutils.IsTextFileByExtension(fileName)
 	if !isText {
@@ -157,24 +481,33 @@ func renderBinaryPreview(m *models.Model, selectedFile models.FileInfo, fullPath
 	}
 
 	var sb strings.Builder
-	icon := GetFileIcon(selectedFile)
-	sb.WriteString(fmt.Sprintf("%s %s\n", icon, selectedFile.Entry.Name()))
-	sb.WriteString(fmt.Sprintf("Size: %s\n", fileutils.FormatSize(selectedFile.Size)))
-	sb.WriteString(fmt.Sprintf("Modified: %s\n", selectedFile.ModTime.Format("2006-01-02 15:04:05")))
-	if fileInfo, err := os.Stat(fullPath); err == nil {
-		sb.WriteString(fmt.Sprintf("Mode: %s\n", fileInfo.Mode().String()))
+	icon := fileIcon(selectedFile, cfg)
+	sb.WriteString(fmt.Sprintf("%s %s%s\n", icon, displayName(selectedFile.Entry.Name()), linkSuffix(selectedFile)))
+	sb.WriteString(realPathLine(m, selectedFile.Entry.Name()))
+	if !selectedFile.Virtual {
+		sb.WriteString(fmt.Sprintf("Size: %s\n", formatFileSize(selectedFile)))
+		sb.WriteString(fmt.Sprintf("Modified: %s\n", selectedFile.ModTime.Format("2006-01-02 15:04:05")))
+	}
+	sb.WriteString(fmt.Sprintf("Mode: %s\n", fileutils.FormatPermissions(mode)))
+	if note != "" {
+		sb.WriteString(note + "\n")
 	}
 	sb.WriteString("\n")
+	m.PreviewContentStart = strings.Count(sb.String(), "\n")
 
 	if isText && len(content) > 0 {
 		contentStr := string(content)
 		if len(contentStr) > 50000 {
 			lines := strings.Split(contentStr, "\n")
 			if len(lines) > 500 {
-				contentStr = strings.Join(lines[:500], "\n") + "\n\n... (file truncated for preview)"
+				contentStr = strings.Join(lines[:500], "\n")
+				truncated = true
 			}
 		}
 		sb.WriteString(contentStr)
+		if truncated {
+			sb.WriteString("\n\n... (file truncated for preview)")
+		}
 	} else if len(content) == 0 {
 		sb.WriteString("(empty file)")
 	} else {
@@ -200,9 +533,9 @@ func renderBinaryPreview(m *models.Model, selectedFile models.FileInfo, fullPath
 			}
 			sb.WriteString("|\n")
 		}
-		if len(content) > 256 {
-			sb.WriteString(fmt.Sprintf("\n... (%d more bytes)", len(content)-256))
+		if selectedFile.Size > 256 && !selectedFile.Virtual {
+			sb.WriteString(fmt.Sprintf("\n... (%d more bytes)", selectedFile.Size-256))
 		}
 	}
-	m.Preview = sb.String()
+	setPreview(m, sb.String())
 }
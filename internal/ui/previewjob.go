@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"io/fs"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// previewSpinInterval is how often the spinner of a preview placeholder
+// turns
+const previewSpinInterval = 120 * time.Millisecond
+
+// previewMsg delivers a preview generated by startPreview, on the copy of
+// the model it was rendered into. A partial one holds the placeholder
+// shown until the preview is done, which next delivers.
+type previewMsg struct {
+	preview *models.Model
+	partial bool
+	next    tea.Cmd
+}
+
+// previewSpinMsg turns the spinner of the placeholder shown for the
+// preview of that generation
+type previewSpinMsg struct {
+	generation int
+}
+
+// startPreview generates the pending preview in the background. The
+// renderers write into a copy of the model holding just the selected entry
+// and private copies of the caches they consult, so the UI goroutine keeps
+// changing its own state meanwhile. Macro replays expect each key to see the
+// previous one's preview, so they render at once.
+func (m *AppModel) startPreview() tea.Cmd {
+	if !m.PreviewPending || m.previewStarted == m.PreviewGeneration || m.Selected >= len(m.Files) {
+		return nil
+	}
+	selectedFile := m.Files[m.Selected]
+	fullPath := m.PreviewPath
+	if m.replaying {
+		m.PreviewPending = false
+		generatePreview(m.Model, m.config, selectedFile, fullPath)
+		return nil
+	}
+	m.previewStarted = m.PreviewGeneration
+
+	snapshot := *m.Model
+	snapshot.Files = []models.FileInfo{selectedFile}
+	snapshot.Selected = 0
+	snapshot.Ancestors = nil
+	snapshot.Stats = models.PerfStats{}
+	snapshot.LinkTargets = make(map[string]fs.FileInfo)
+	snapshot.DirCursors = map[string]models.CursorState{fullPath: m.DirCursors[fullPath]}
+	snapshot.GitInfos = make(map[string]models.GitInfo)
+	if info, ok := m.GitInfos[fullPath]; ok {
+		snapshot.GitInfos[fullPath] = info
+	}
+	snapshot.GitLogs = map[string][]string{fullPath: m.GitLogs[fullPath]}
+	snapshot.VideoPreviews = maps.Clone(m.VideoPreviews)
+	snapshot.Marked, snapshot.Favorites, snapshot.StaleFavorites = nil, nil, nil
+	snapshot.Thumbnails, snapshot.ProjectBadges = nil, nil
+	cfg := m.config
+	// Room for the partial result and the final one, so the job ends even
+	// once nobody waits for it
+	updates := make(chan previewMsg, 2)
+	go func() {
+		if placeholder, ok := imagePlaceholder(&snapshot, cfg, selectedFile, fullPath); ok {
+			updates <- previewMsg{preview: &models.Model{PreviewGeneration: snapshot.PreviewGeneration, PreviewLines: []string{placeholder}}, partial: true}
+		}
+		generatePreview(&snapshot, cfg, selectedFile, fullPath)
+		updates <- previewMsg{preview: &snapshot}
+	}()
+	return waitPreview(updates)
+}
+
+// waitPreview returns a command delivering the next result of a preview job
+func waitPreview(updates chan previewMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg := <-updates
+		if msg.partial {
+			msg.next = waitPreview(updates)
+		}
+		return msg
+	}
+}
+
+// imagePlaceholder describes the image the preview of file decodes, from
+// its header, to show with a spinner while the whole image is decoded.
+// It reports false for files previewed otherwise or whose header is not an
+// image's, the decoding error then comes with the preview.
+func imagePlaceholder(m *models.Model, cfg config.Config, file models.FileInfo, fullPath string) (string, bool) {
+	tooLarge := m.PreviewMaxSize > 0 && file.Size > m.PreviewMaxSize && m.ForcePreviewPath != fullPath
+	if file.IsDir() || file.LinkBroken || file.Virtual || tooLarge || m.RawPreviewPath == fullPath || !isImageFileByExtension(file.Entry.Name()) {
+		return "", false
+	}
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	header, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return "", false
+	}
+	ellipsis := "…"
+	if !cfg.Unicode {
+		ellipsis = "..."
+	}
+	return fmt.Sprintf("Decoding %dx%d %s image%s", header.Width, header.Height, strings.ToUpper(format), ellipsis), true
+}
+
+// spinnerFrames returns the frames of the placeholder spinner
+func spinnerFrames(cfg config.Config) []string {
+	if !cfg.Unicode {
+		return []string{"|", "/", "-", "\\"}
+	}
+	return []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+}
+
+// showPlaceholder shows the placeholder of a pending preview, with the
+// current frame of its spinner
+func (m *AppModel) showPlaceholder() {
+	frames := spinnerFrames(m.config)
+	setPreview(m.Model, frames[m.spinnerFrame%len(frames)]+" "+m.placeholder)
+}
+
+// spinPreview turns the placeholder spinner after previewSpinInterval
+func (m *AppModel) spinPreview() tea.Cmd {
+	generation := m.PreviewGeneration
+	return tea.Tick(previewSpinInterval, func(time.Time) tea.Msg {
+		return previewSpinMsg{generation}
+	})
+}
+
+// handlePreviewSpin turns the spinner while its preview is pending
+func (m *AppModel) handlePreviewSpin(msg previewSpinMsg) tea.Cmd {
+	if msg.generation != m.PreviewGeneration || !m.PreviewPending {
+		return nil
+	}
+	m.spinnerFrame++
+	m.showPlaceholder()
+	return m.spinPreview()
+}
+
+// handlePreview shows a background preview unless the selection or its
+// state changed since it was started. A partial result replaces the
+// placeholder until the preview follows.
+func (m *AppModel) handlePreview(msg previewMsg) tea.Cmd {
+	p := msg.preview
+	if p.PreviewGeneration != m.PreviewGeneration || !m.PreviewPending {
+		return nil
+	}
+	if msg.partial {
+		m.placeholder = p.PreviewLines[0]
+		m.spinnerFrame = 0
+		m.showPlaceholder()
+		return tea.Batch(msg.next, m.spinPreview())
+	}
+	m.PreviewPending = false
+	m.PreviewLines = p.PreviewLines
+	m.PreviewContentStart = p.PreviewContentStart
+	m.PreviewHighlight = p.PreviewHighlight
+	m.PreviewOffset = p.PreviewOffset
+	m.PreviewImage = p.PreviewImage
+	for path, target := range p.LinkTargets {
+		m.LinkTargets[path] = target
+	}
+	m.Stats.Preview = p.Stats.Preview
+	m.Stats.PreviewPath = p.Stats.PreviewPath
+	m.Stats.EntriesStated += p.Stats.EntriesStated
+	m.Stats.LinkTargets.Hits += p.Stats.LinkTargets.Hits
+	m.Stats.LinkTargets.Misses += p.Stats.LinkTargets.Misses
+	return nil
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePNG writes a width by height PNG to path, cut to keep bytes when
+// keep is positive
+func writePNG(t *testing.T, path string, width, height, keep int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if keep > 0 {
+		data = data[:keep]
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImagePreviewShowsPlaceholderWithSpinner(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt")
+	writePNG(t, filepath.Join(dir, "photo.png"), 40, 30, 0)
+	m := newTestModel(t, Options{Path: dir})
+
+	m.selectIndex(m.indexByName("photo.png"))
+	if !m.PreviewPending || m.PreviewLines[0] != "Previewing…" {
+		t.Fatalf("preview while pending = %q", m.PreviewLines)
+	}
+	msg, ok := m.startPreview()().(previewMsg)
+	if !ok || !msg.partial {
+		t.Fatalf("the job's first message = %#v, want a partial preview", msg)
+	}
+	_, cmd := m.Update(msg)
+	if got, want := m.PreviewLines[0], "⠋ Decoding 40x30 PNG image…"; got != want {
+		t.Errorf("placeholder = %q, want %q", got, want)
+	}
+	m.Update(previewSpinMsg{m.PreviewGeneration})
+	if got, want := m.PreviewLines[0], "⠙ Decoding 40x30 PNG image…"; got != want {
+		t.Errorf("placeholder after a turn = %q, want %q", got, want)
+	}
+
+	settle(t, m, cmd)
+	if m.PreviewPending || strings.Contains(strings.Join(m.PreviewLines, "\n"), "Decoding") {
+		t.Errorf("preview once done = %q, pending %v", m.PreviewLines, m.PreviewPending)
+	}
+	// The spinner stops with the placeholder
+	frame := m.spinnerFrame
+	if cmd := m.handlePreviewSpin(previewSpinMsg{m.PreviewGeneration}); cmd != nil || m.spinnerFrame != frame {
+		t.Error("the spinner kept turning once the preview was done")
+	}
+}
+
+func TestPlaceholderOfSupersededPreviewIsDropped(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.txt")
+	writePNG(t, filepath.Join(dir, "photo.png"), 40, 30, 0)
+	m := newTestModel(t, Options{Path: dir})
+
+	m.selectIndex(m.indexByName("photo.png"))
+	msg := m.startPreview()().(previewMsg)
+	m.selectIndex(m.indexByName("a.txt"))
+	if cmd := m.handlePreview(msg); cmd != nil {
+		t.Error("a superseded partial preview waited for the rest")
+	}
+	if strings.Contains(m.PreviewLines[0], "Decoding") {
+		t.Errorf("superseded placeholder shown: %q", m.PreviewLines[0])
+	}
+}
+
+func TestImageWithoutAHeaderHasNoPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fake.png"), []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, Options{Path: dir})
+
+	UpdatePreview(m.Model, m.config)
+	if msg := m.startPreview()().(previewMsg); msg.partial {
+		t.Errorf("placeholder for a file that is not an image: %q", msg.preview.PreviewLines)
+	}
+}
+
+func TestImageDecodeFailureIsShown(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "cut.png"), 40, 30, 60)
+	m := newTestModel(t, Options{Path: dir})
+
+	preview := strings.Join(m.PreviewLines, "\n")
+	if !strings.Contains(preview, "Image decode failed (png, 40x30): ") {
+		t.Errorf("preview of a truncated PNG lacks the decode error:\n%s", preview)
+	}
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// defaultProjectMarkers identify common project roots, in priority order
+var defaultProjectMarkers = []config.ProjectMarker{
+	{File: "go.mod", Badge: "go"},
+	{File: "Cargo.toml", Badge: "rust"},
+	{File: "package.json", Badge: "node"},
+	{File: "pyproject.toml", Badge: "python"},
+}
+
+// projectBadge returns the badge of the first marker file found in dir.
+// Detection costs one stat per marker and is cached per directory, so only
+// directories that are actually drawn are ever checked.
+func projectBadge(m *models.Model, cfg config.Config, dir string) string {
+	if badge, ok := m.ProjectBadges[dir]; ok {
+		return badge
+	}
+
+	badge := ""
+	for _, markers := range [][]config.ProjectMarker{cfg.ProjectMarkers, defaultProjectMarkers} {
+		for _, marker := range markers {
+			if marker.File == "" {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, marker.File)); err == nil {
+				badge = marker.Badge
+				break
+			}
+		}
+		if badge != "" {
+			break
+		}
+	}
+	m.ProjectBadges[dir] = badge
+	return badge
+}
@@ -0,0 +1,60 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// toggleQuickLook maximizes the preview of the selected entry to the whole
+// window, or returns to the column layout. The listing is left untouched
+// meanwhile, so leaving lands on the same entry and scroll position.
+func (m *AppModel) toggleQuickLook() {
+	if !m.QuickLook && len(m.Files) == 0 {
+		return
+	}
+	m.QuickLook = !m.QuickLook
+	// Images and wrapped previews depend on the pane size
+	UpdatePreview(m.Model, m.config)
+	m.scrollPreview(0)
+}
+
+// handleQuickLookKeys scrolls the maximized preview with the movement keys,
+// reporting whether the key was consumed. Apart from quitting and jumping to
+// a line, keys that would change the listing behind it are swallowed.
+func (m *AppModel) handleQuickLookKeys(msg tea.KeyMsg) bool {
+	rows := m.previewRows()
+	switch m.keys[msg.String()] {
+	case actionUp:
+		m.scrollPreview(-1)
+		return true
+	case actionDown:
+		m.scrollPreview(1)
+		return true
+	case actionTop:
+		m.PreviewOffset = 0
+		return true
+	case actionBottom:
+		m.scrollPreview(len(m.PreviewLines))
+		return true
+	case actionScrollUp, actionPreviewScrollUp:
+		m.scrollPreview(-m.config.Scroll.Lines(rows))
+		return true
+	case actionScrollDown, actionPreviewScrollDown:
+		m.scrollPreview(m.config.Scroll.Lines(rows))
+		return true
+	case actionPageUp:
+		m.scrollPreview(-m.config.Page.Lines(rows))
+		return true
+	case actionPageDown:
+		m.scrollPreview(m.config.Page.Lines(rows))
+		return true
+	case actionQuickLook:
+		m.toggleQuickLook()
+		return true
+	}
+
+	switch msg.String() {
+	case "ctrl+c", ":":
+		return false
+	case "esc", "q":
+		m.toggleQuickLook()
+	}
+	return true
+}
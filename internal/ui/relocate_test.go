@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// rename renames old to new below dir, as another program would
+func rename(t *testing.T, dir, old, new string) {
+	t.Helper()
+	if err := os.Rename(filepath.Join(dir, old), filepath.Join(dir, new)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenamedCurrentDir(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "project/a.txt", "project/b.txt", "project/sub/c.txt", "other/")
+	m := newTestModel(t, Options{Path: filepath.Join(root, "project")})
+	selectName(t, m, "b.txt")
+
+	// Refreshing follows the directory to its new name, keeping the cursor
+	rename(t, root, "project", "renamed")
+	press(t, m, "r")
+	wantDir(t, m, filepath.Join(root, "renamed"))
+	wantSelected(t, m, "b.txt", -1)
+	if want := filepath.Join(root, "project") + " was renamed to renamed"; m.StatusMessage != want {
+		t.Errorf("status %q, want %q", m.StatusMessage, want)
+	}
+
+	// So does entering a directory below it
+	rename(t, root, "renamed", "again")
+	selectName(t, m, "sub")
+	press(t, m, "l")
+	wantDir(t, m, filepath.Join(root, "again", "sub"))
+	press(t, m, "h")
+	wantDir(t, m, filepath.Join(root, "again"))
+	wantSelected(t, m, "sub", -1)
+
+	// A directory moved elsewhere cannot be followed, the nearest existing
+	// ancestor is shown instead
+	press(t, m, "l")
+	if err := os.Rename(filepath.Join(root, "again"), filepath.Join(root, "other", "again")); err != nil {
+		t.Fatal(err)
+	}
+	press(t, m, "r")
+	wantDir(t, m, root)
+	if want := filepath.Join(root, "again", "sub") + " no longer exists, moved to " + root; m.StatusMessage != want {
+		t.Errorf("status %q, want %q", m.StatusMessage, want)
+	}
+
+	// as it is when the directory is removed
+	m = newTestModel(t, Options{Path: filepath.Join(root, "other", "again", "sub")})
+	if err := os.RemoveAll(filepath.Join(root, "other", "again")); err != nil {
+		t.Fatal(err)
+	}
+	press(t, m, "j")
+	press(t, m, "r")
+	wantDir(t, m, filepath.Join(root, "other"))
+}
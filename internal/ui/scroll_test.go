@@ -0,0 +1,282 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// numberedModel opens a directory of n files, f000 on, with configTOML
+func numberedModel(t *testing.T, n int, configTOML string) *AppModel {
+	t.Helper()
+	dir := t.TempDir()
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("f%03d", i)
+	}
+	writeTree(t, dir, names...)
+	return newConfiguredModel(t, Options{Path: dir}, configTOML)
+}
+
+// wantCursor checks the cursor and list offset, and that the cursor is on
+// screen within a listing that fills the pane as far as it can
+func wantCursor(t *testing.T, m *AppModel, selected, offset int) {
+	t.Helper()
+	if m.Selected != selected || m.ListOffset != offset {
+		t.Errorf("cursor %d at offset %d, want %d at %d", m.Selected, m.ListOffset, selected, offset)
+	}
+	rows := m.listRows()
+	if len(m.Files) > 0 && (m.ListOffset > m.Selected || m.Selected >= m.ListOffset+rows) {
+		t.Errorf("cursor %d is off the screen of %d rows at offset %d", m.Selected, rows, m.ListOffset)
+	}
+}
+
+func TestScrollEmptyList(t *testing.T) {
+	m := newTestModel(t, Options{Path: t.TempDir()})
+	for _, key := range []string{"j", "k", "G", "g", "ctrl+d", "ctrl+u", "pgdown", "pgup", "J", "K"} {
+		press(t, m, key)
+		wantCursor(t, m, 0, 0)
+	}
+	send(t, m, tea.MouseMsg{X: testWidth / 2, Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown})
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: 5})
+	wantCursor(t, m, 0, 0)
+}
+
+func TestScrollShorterThanPage(t *testing.T) {
+	// 24 rows fit at the test height
+	m := numberedModel(t, 5, "")
+	press(t, m, "ctrl+d")
+	wantCursor(t, m, 4, 0)
+	press(t, m, "ctrl+u")
+	wantCursor(t, m, 0, 0)
+	press(t, m, "pgdown")
+	wantCursor(t, m, 4, 0)
+	press(t, m, "pgup")
+	wantCursor(t, m, 0, 0)
+
+	// One entry short of a page never scrolls
+	m = numberedModel(t, 23, "")
+	press(t, m, "G")
+	wantCursor(t, m, 22, 0)
+	press(t, m, "pgdown", "ctrl+d")
+	wantCursor(t, m, 22, 0)
+}
+
+func TestScrollSteps(t *testing.T) {
+	tests := []struct {
+		config string
+		height int
+		keys   []string
+		// Cursor and offset after each key
+		want [][2]int
+	}{
+		// The defaults, half a page of 24 rows and a full one
+		{"", testHeight, []string{"ctrl+d", "ctrl+d", "pgdown", "ctrl+u", "pgup"}, [][2]int{{12, 12}, {24, 24}, {48, 48}, {36, 36}, {12, 12}}},
+		// Odd heights round half a page down
+		{"", 31, []string{"ctrl+d", "ctrl+d", "ctrl+u"}, [][2]int{{12, 12}, {24, 24}, {12, 12}}},
+		{"", 9, []string{"ctrl+d", "pgdown", "ctrl+u"}, [][2]int{{1, 1}, {4, 4}, {3, 3}}},
+		// A pane of one row still moves
+		{"", 7, []string{"ctrl+d", "pgdown"}, [][2]int{{1, 1}, {2, 2}}},
+		{"scroll_step = 3\npage_step = \"half\"\n", testHeight, []string{"ctrl+d", "ctrl+d", "pgdown", "ctrl+u"}, [][2]int{{3, 3}, {6, 6}, {18, 18}, {15, 15}}},
+		{"scroll_step = \"full\"\npage_step = 10\n", testHeight, []string{"ctrl+d", "pgdown", "pgup"}, [][2]int{{24, 24}, {34, 34}, {24, 24}}},
+		// Invalid steps keep the defaults
+		{"scroll_step = 0\npage_step = \"most\"\n", testHeight, []string{"ctrl+d", "pgdown"}, [][2]int{{12, 12}, {36, 36}}},
+	}
+	for _, tt := range tests {
+		m := numberedModel(t, 100, tt.config)
+		send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: tt.height})
+		for i, key := range tt.keys {
+			press(t, m, key)
+			if got := [2]int{m.Selected, m.ListOffset}; got != tt.want[i] {
+				t.Errorf("%q at height %d, after %s: cursor %d at offset %d, want %v", tt.config, tt.height, strings.Join(tt.keys[:i+1], " "), m.Selected, m.ListOffset, tt.want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestScrollToEnd(t *testing.T) {
+	m := numberedModel(t, 100, "")
+	press(t, m, "G")
+	wantCursor(t, m, 99, 76)
+
+	// Paging down at the end leaves the last page in place
+	press(t, m, "pgdown", "ctrl+d")
+	wantCursor(t, m, 99, 76)
+	press(t, m, "ctrl+u")
+	wantCursor(t, m, 87, 64)
+	press(t, m, "g")
+	wantCursor(t, m, 0, 0)
+
+	// Scrolling near the end stops the view at the last page, the cursor
+	// runs on to the last entry
+	press(t, m, "pgdown", "pgdown", "pgdown")
+	wantCursor(t, m, 72, 72)
+	press(t, m, "pgdown")
+	wantCursor(t, m, 96, 76)
+	press(t, m, "pgdown")
+	wantCursor(t, m, 99, 76)
+}
+
+func TestScrollResize(t *testing.T) {
+	m := numberedModel(t, 100, "")
+	press(t, m, "G")
+	for range 5 {
+		press(t, m, "k")
+	}
+	wantCursor(t, m, 94, 76)
+
+	// Shrinking below the cursor's row brings the view down to the cursor
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: 10})
+	wantCursor(t, m, 94, 91)
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: 7})
+	wantCursor(t, m, 94, 94)
+
+	// Growing fills the pane back up to the last entry
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: testHeight})
+	wantCursor(t, m, 94, 76)
+	press(t, m, "ctrl+u")
+	wantCursor(t, m, 82, 64)
+
+	// Shrinking with the cursor above the new bottom keeps both
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: 26})
+	wantCursor(t, m, 82, 64)
+}
+
+func TestScrollMouseWheel(t *testing.T) {
+	m := numberedModel(t, 100, "scroll_step = 3\n")
+	layout := computeLayout(m.Model)
+	wheel := func(button tea.MouseButton) {
+		send(t, m, tea.MouseMsg{X: layout.ancestorsWidth() + 1, Y: 5, Action: tea.MouseActionPress, Button: button})
+	}
+	wheel(tea.MouseButtonWheelDown)
+	wheel(tea.MouseButtonWheelDown)
+	wantCursor(t, m, 6, 6)
+	wheel(tea.MouseButtonWheelUp)
+	wantCursor(t, m, 3, 3)
+	for range 5 {
+		wheel(tea.MouseButtonWheelUp)
+	}
+	wantCursor(t, m, 0, 0)
+}
+
+func TestScrollPreview(t *testing.T) {
+	dir := t.TempDir()
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	writeTree(t, dir, "short.txt")
+	if err := os.WriteFile(filepath.Join(dir, "long.txt"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newConfiguredModel(t, Options{Path: dir}, "scroll_step = 10\n")
+	selectName(t, m, "long.txt")
+
+	start := m.PreviewOffset
+	press(t, m, "J")
+	if m.PreviewOffset != start+10 {
+		t.Errorf("preview offset %d after J, want %d", m.PreviewOffset, start+10)
+	}
+	press(t, m, "K", "K")
+	if m.PreviewOffset != 0 {
+		t.Errorf("preview offset %d scrolling up past the top, want 0", m.PreviewOffset)
+	}
+
+	// The last line stops at the bottom of the pane
+	last := len(m.PreviewLines) - m.previewRows()
+	for range 20 {
+		press(t, m, "J")
+	}
+	if m.PreviewOffset != last {
+		t.Errorf("preview offset %d scrolling past the end, want %d", m.PreviewOffset, last)
+	}
+
+	// A preview shorter than the pane does not scroll
+	selectName(t, m, "short.txt")
+	press(t, m, "J")
+	if m.PreviewOffset != 0 {
+		t.Errorf("short preview offset %d, want 0", m.PreviewOffset)
+	}
+}
+
+// wantAncestor checks an ancestor pane's highlighted entry and offset, and
+// that the entry is among the rows the pane renders
+func wantAncestor(t *testing.T, m *AppModel, level int, name string, offset int) {
+	t.Helper()
+	a := m.Ancestors[level]
+	if got := a.Files[a.Selected].Entry.Name(); got != name || a.Offset != offset {
+		t.Errorf("ancestor %d highlights %s at offset %d, want %s at %d", level, got, a.Offset, name, offset)
+	}
+	pane := renderAncestorPane(a, m.config, 30, m.getVisibleHeight())
+	if !strings.Contains(pane, " "+name) {
+		t.Errorf("ancestor %d pane lacks %s:\n%s", level, name, pane)
+	}
+}
+
+func TestScrollParentPane(t *testing.T) {
+	dir := t.TempDir()
+	var names []string
+	for i := range 60 {
+		names = append(names, fmt.Sprintf("d%03d/", i))
+	}
+	for i := range 40 {
+		names = append(names, fmt.Sprintf("d030/e%03d/", i))
+	}
+	writeTree(t, dir, names...)
+
+	// 24 rows fit, the entry is centered where it can be
+	m := newConfiguredModel(t, Options{Path: filepath.Join(dir, "d030")}, "columns = 3\n")
+	wantAncestor(t, m, 0, "d030", 18)
+	press(t, m, "h", "j", "l")
+	wantAncestor(t, m, 0, "d031", 19)
+	press(t, m, "h", "g", "l")
+	wantAncestor(t, m, 0, "d000", 0)
+	press(t, m, "h", "G", "l")
+	wantAncestor(t, m, 0, "d059", 36)
+
+	// Resizing keeps it in view
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: 10})
+	wantAncestor(t, m, 0, "d059", 56)
+	press(t, m, "h", "k", "k", "l")
+	wantAncestor(t, m, 0, "d057", 55)
+	send(t, m, tea.WindowSizeMsg{Width: testWidth, Height: 40})
+	wantAncestor(t, m, 0, "d057", 26)
+
+	// Further ancestors scroll on their own
+	m = newConfiguredModel(t, Options{Path: filepath.Join(dir, "d030", "e035")}, "columns = 4\n")
+	wantAncestor(t, m, 0, "e035", 16)
+	wantAncestor(t, m, 1, "d030", 18)
+}
+
+// BenchmarkRenderPreviewPane renders a frame of a 1500-line preview at
+// each scroll position in turn
+func BenchmarkRenderPreviewPane(b *testing.B) {
+	dir := b.TempDir()
+	lines := make([]string, 1500)
+	for i := range lines {
+		// Short enough lines that the preview is not truncated
+		lines[i] = fmt.Sprintf("\tx%d := a*%d + b", i, i)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "long.go"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	m := newTestModel(b, Options{Path: dir})
+	selectName(b, m, "long.go")
+	if len(m.PreviewLines) < len(lines) {
+		b.Fatalf("preview has %d lines, want at least %d", len(m.PreviewLines), len(lines))
+	}
+	width, height := computeLayout(m.Model).preview, getVisibleHeight(m.Height)
+	last := len(m.PreviewLines) - m.previewRows()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.PreviewOffset = i % last
+		renderPreviewPane(m.Model, m.config, width, height)
+	}
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// filterFiles applies showHidden and the search query to files, fuzzy or
+// as a substring per the search mode
+func filterFiles(m *models.Model, files []models.FileInfo, showHidden bool, query string) []models.FileInfo {
+	if m.FuzzySearch {
+		return fileutils.FilterFilesFuzzy(files, showHidden, query)
+	}
+	return fileutils.FilterFiles(files, showHidden, query)
+}
+
+// hiddenShown resolves a show_hidden_parent or show_hidden_preview setting
+// against the main hidden files toggle
+func hiddenShown(m *models.Model, setting string) bool {
+	switch setting {
+	case "show":
+		return true
+	case "hide":
+		return false
+	}
+	return m.ShowHidden
+}
+
+// toggleFuzzy switches the search between fuzzy and substring matching
+func (m *AppModel) toggleFuzzy() {
+	m.FuzzySearch = !m.FuzzySearch
+	m.StatusMessage = "Substring search"
+	if m.FuzzySearch {
+		m.StatusMessage = "Fuzzy search"
+	}
+	m.loadCurrentDir()
+}
+
+// matchPositions returns the rune indexes of name the search query
+// matched, for highlighting
+func matchPositions(m *models.Model, name string) []int {
+	if m.SearchQuery == "" {
+		return nil
+	}
+	if m.FuzzySearch {
+		_, positions, _ := fileutils.FuzzyMatch(name, m.SearchQuery)
+		return positions
+	}
+	return fileutils.MatchPositions(name, m.SearchQuery)
+}
+
+// highlightName renders name, already made displayable and truncated to
+// within the original, in style with the characters at positions
+// underlined. An ellipsis truncation left is never highlighted.
+func highlightName(name string, truncated bool, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(name)
+	}
+	runes := []rune(name)
+	limit := len(runes)
+	if truncated {
+		limit = max(0, limit-len("..."))
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, i := range positions {
+		matched[i] = true
+	}
+	highlight := style.Underline(true).Bold(true)
+	hit := func(i int) bool { return i < limit && matched[i] }
+	var sb strings.Builder
+	for start := 0; start < len(runes); {
+		// Runs of matched and unmatched characters are rendered together
+		end := start + 1
+		for end < len(runes) && hit(end) == hit(start) {
+			end++
+		}
+		if hit(start) {
+			sb.WriteString(highlight.Render(string(runes[start:end])))
+		} else {
+			sb.WriteString(style.Render(string(runes[start:end])))
+		}
+		start = end
+	}
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+func TestSearchNonASCII(t *testing.T) {
+	dir := t.TempDir()
+	// résumé is decomposed, as macOS writes names
+	writeTree(t, dir, "caf\u00e9.txt", "re\u0301sume\u0301.pdf", "Straße.md", "日本語のメモ.txt", "plain.txt")
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"\u00e9", []string{"caf\u00e9.txt", "re\u0301sume\u0301.pdf"}},
+		{"e\u0301", []string{"caf\u00e9.txt", "re\u0301sume\u0301.pdf"}},
+		{"STRASSE", []string{"Straße.md"}},
+		{"日本語", []string{"日本語のメモ.txt"}},
+	}
+	for _, mode := range []string{"fuzzy", "substring"} {
+		for _, tt := range tests {
+			m := newConfiguredModel(t, Options{Path: dir}, "search_mode = \""+mode+"\"\n")
+			press(t, m, "/")
+			// Input methods commit several runes in one message
+			send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tt.query)})
+			if m.SearchQuery != tt.query {
+				t.Errorf("%s: query %q, want %q", mode, m.SearchQuery, tt.query)
+			}
+			got := listed(m)
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s search %q lists %q, want %q", mode, tt.query, got, tt.want)
+			}
+			for _, name := range got {
+				if len(matchPositions(m.Model, name)) == 0 {
+					t.Errorf("%s search %q highlights nothing of %q", mode, tt.query, name)
+				}
+			}
+		}
+	}
+}
+
+func TestSearchScope(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "nodes/note.md", "nodes/other.txt", "nodes/deeper/", "notes.txt", "src/main.go")
+	tests := []struct {
+		scope string
+		query string // Left after entering nodes
+		// Listings after entering nodes and coming back
+		inNodes, back []string
+	}{
+		{"session", "no", []string{"note.md"}, []string{"nodes", "notes.txt"}},
+		{"directory", "", []string{"deeper", "note.md", "other.txt"}, []string{"nodes", "notes.txt", "src"}},
+	}
+	for _, tt := range tests {
+		m := newConfiguredModel(t, Options{Path: dir}, "search_mode = \"substring\"\nsearch_scope = \""+tt.scope+"\"\n")
+		press(t, m, "/")
+		typeText(t, m, "no")
+		press(t, m, "enter")
+		if got := listed(m); !slices.Equal(got, []string{"nodes", "notes.txt"}) {
+			t.Fatalf("%s: filtered listing %q", tt.scope, got)
+		}
+
+		selectName(t, m, "nodes")
+		press(t, m, "l")
+		got := listed(m)
+		slices.Sort(got)
+		if !slices.Equal(got, tt.inNodes) {
+			t.Errorf("%s: listing of nodes %q, want %q", tt.scope, got, tt.inNodes)
+		}
+		// A kept filter is shown, so the listing does not just look empty
+		indicator := strings.Contains(m.View(), "[filter: no, esc clears]")
+		if m.SearchQuery != tt.query || indicator != (tt.query != "") {
+			t.Errorf("%s: query %q in nodes, indicator shown %v", tt.scope, m.SearchQuery, indicator)
+		}
+
+		press(t, m, "h")
+		got = listed(m)
+		slices.Sort(got)
+		if !slices.Equal(got, tt.back) || m.selectedName() != "nodes" {
+			t.Errorf("%s: listing back %q on %q, want %q on nodes", tt.scope, got, m.selectedName(), tt.back)
+		}
+	}
+}
+
+func TestEscClearsSearch(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "nodes/note.md", "nodes/other.txt", "notes.txt", "src/main.go")
+	for _, scope := range []string{"session", "directory"} {
+		m := newConfiguredModel(t, Options{Path: dir}, "search_mode = \"substring\"\nsearch_scope = \""+scope+"\"\n")
+
+		// While typing the query
+		press(t, m, "/")
+		typeText(t, m, "no")
+		press(t, m, "esc")
+		if m.SearchQuery != "" || len(m.Files) != 3 || m.InputMode != models.ModeNormal {
+			t.Errorf("%s: esc while typing left query %q, %d entries", scope, m.SearchQuery, len(m.Files))
+		}
+
+		// Once confirmed, and after navigating with it
+		press(t, m, "/")
+		typeText(t, m, "no")
+		press(t, m, "enter")
+		selectName(t, m, "nodes")
+		press(t, m, "l", "/")
+		typeText(t, m, "other")
+		press(t, m, "enter", "esc")
+		if m.SearchQuery != "" || len(m.Files) != 2 || strings.Contains(m.View(), "[filter:") {
+			t.Errorf("%s: esc left query %q, %d entries", scope, m.SearchQuery, len(m.Files))
+		}
+		press(t, m, "h")
+		if len(m.Files) != 3 {
+			t.Errorf("%s: the cleared query still filters the parent: %q", scope, listed(m))
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"strings"
+)
+
+// encodeSixel encodes img as a sixel image in the web-safe colors.
+// Mostly transparent pixels are left unpainted, showing the pane beneath.
+func encodeSixel(img *image.RGBA) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	indexes := ditherWebSafe(img)
+
+	var sb strings.Builder
+	// P2 = 1 keeps the pixels no color is drawn to
+	fmt.Fprintf(&sb, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	var used [256]bool
+	for _, index := range indexes {
+		used[index] = true
+	}
+	for index, c := range palette.WebSafe {
+		if used[index] {
+			r, g, b, _ := c.RGBA()
+			fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", index, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+		}
+	}
+
+	// Each band of six rows is drawn once per color in it, returning to
+	// the band's start with $ in between and moving on with -. bits holds
+	// the sixels of the band's colors, a row of width per color.
+	bits := make([]byte, len(palette.WebSafe)*width)
+	for top := 0; top < height; top += 6 {
+		var inBand [256]bool
+		for y := top; y < min(top+6, height); y++ {
+			for x := 0; x < width; x++ {
+				// Mostly transparent pixels are left unpainted
+				if img.Pix[img.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)+3] < 0x80 {
+					continue
+				}
+				index := indexes[y*width+x]
+				inBand[index] = true
+				bits[int(index)*width+x] |= 1 << (y - top)
+			}
+		}
+		first := true
+		for index := range inBand {
+			if !inBand[index] {
+				continue
+			}
+			if !first {
+				sb.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&sb, "#%d", index)
+			row := bits[index*width : (index+1)*width]
+			var run int
+			var last byte
+			for x, b := range row {
+				if sixel := '?' + b; sixel == last {
+					run++
+				} else {
+					writeSixelRun(&sb, last, run)
+					last, run = sixel, 1
+				}
+				row[x] = 0
+			}
+			// An empty run at the end of the row draws nothing
+			if last != '?' {
+				writeSixelRun(&sb, last, run)
+			}
+		}
+		sb.WriteByte('-')
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+// ditherWebSafe maps the pixels of img to the indexes of palette.WebSafe,
+// row by row, with Floyd-Steinberg error diffusion. The palette is a 6x6x6
+// color cube, so each pixel's color is computed rather than searched for.
+func ditherWebSafe(img *image.RGBA) []uint8 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	indexes := make([]uint8, width*height)
+	// Errors carried to the current and next row, three channels per
+	// pixel with a pixel of margin on both sides
+	current := make([]int, (width+2)*3)
+	next := make([]int, (width+2)*3)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			offset := img.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)
+			index := 0
+			for channel := 0; channel < 3; channel++ {
+				e := (x+1)*3 + channel
+				value := max(0, min(255, int(img.Pix[offset+channel])+current[e]/16))
+				level := (value*5 + 127) / 255
+				index = index*6 + level
+				diff := value - level*51
+				current[e+3] += diff * 7
+				next[e-3] += diff * 3
+				next[e] += diff * 5
+				next[e+3] += diff
+			}
+			indexes[y*width+x] = uint8(index)
+		}
+		current, next = next, current
+		clear(next)
+	}
+	return indexes
+}
+
+// writeSixelRun writes run repeats of a sixel, compressed when long enough
+// to be shorter
+func writeSixelRun(sb *strings.Builder, sixel byte, run int) {
+	switch {
+	case run == 0:
+	case run > 3:
+		fmt.Fprintf(sb, "!%d%c", run, sixel)
+	default:
+		sb.WriteString(strings.Repeat(string(sixel), run))
+	}
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// sizeBarLevels are the bar glyphs from the smallest to the largest file
+var sizeBarLevels = []rune("▁▂▃▄▅▆▇█")
+
+// asciiSizeBarLevels replace sizeBarLevels when Unicode is off
+var asciiSizeBarLevels = []rune(".:-=+*#@")
+
+// sizeGradient colors size text from the smallest (green) to the largest
+// (red) file, one 256-color code per bar level
+var sizeGradient = []string{"34", "70", "106", "142", "178", "214", "208", "196"}
+
+// sizeColumnWidth fits sizes up to "1023.9 KB"
+const sizeColumnWidth = 9
+
+// largestFileSize returns the size that scales the size indicator, the
+// largest file of the listing. Directories are left out.
+func largestFileSize(files []models.FileInfo) int64 {
+	var largest int64
+	for _, file := range files {
+		if !file.IsDir() && file.SortSize > largest {
+			largest = file.SortSize
+		}
+	}
+	return largest
+}
+
+// sizeLevel places size on a logarithmic scale from 0 to levels-1 relative
+// to largest, so a directory with one huge file still tells the rest apart
+func sizeLevel(size, largest int64, levels int) int {
+	if size <= 0 || largest <= 0 {
+		return 0
+	}
+	level := math.Round(math.Log1p(float64(size)) / math.Log1p(float64(largest)) * float64(levels-1))
+	return max(0, min(int(level), levels-1))
+}
+
+// sizeIndicatorWidth returns the columns a row reserves for the indicator
+func sizeIndicatorWidth(mode string) int {
+	switch mode {
+	case "bar":
+		return 1
+	case "color":
+		return sizeColumnWidth
+	}
+	return 0
+}
+
+// renderSizeIndicator renders the bar or colored size of a file row, blank
+// for directories so the column stays aligned
+func renderSizeIndicator(file models.FileInfo, largest int64, mode string, rowStyle lipgloss.Style, cfg config.Config) string {
+	width := sizeIndicatorWidth(mode)
+	if file.IsDir() {
+		return rowStyle.Render(fmt.Sprintf("%*s", width, ""))
+	}
+	level := sizeLevel(file.SortSize, largest, len(sizeBarLevels))
+	if mode == "bar" {
+		if !cfg.Unicode {
+			return rowStyle.Render(string(asciiSizeBarLevels[level]))
+		}
+		return rowStyle.Render(string(sizeBarLevels[level]))
+	}
+	return rowStyle.Foreground(lipgloss.Color(sizeGradient[level])).
+		Render(fmt.Sprintf("%*s", width, fileutils.FormatSize(file.SortSize)))
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortKeepsCursor(t *testing.T) {
+	// Sizes and times each order the names differently, so every sort
+	// moves the entry under the cursor
+	dir := t.TempDir()
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 60 {
+		path := filepath.Join(dir, fmt.Sprintf("f%02d", i))
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", (i*7)%60+1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := t0.Add(time.Duration((i*13)%60) * time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newTestModel(t, Options{Path: dir})
+
+	moved := 0
+	tests := []struct {
+		key, sortBy string
+		reverse     bool
+	}{
+		{"s", "size", false},
+		{"s", "size", true},
+		{"t", "modified", false},
+		{"t", "modified", true},
+		{"n", "name", false},
+		{"n", "name", true},
+		{"n", "name", false},
+	}
+	for i, tt := range tests {
+		name := fmt.Sprintf("f%02d", (i*17+5)%60)
+		selectName(t, m, name)
+		before := m.Selected
+		press(t, m, tt.key)
+		if m.SortBy != tt.sortBy || m.ReverseSort != tt.reverse {
+			t.Fatalf("%s: sorted by %s reversed %v, want %s %v", tt.key, m.SortBy, m.ReverseSort, tt.sortBy, tt.reverse)
+		}
+		what := fmt.Sprintf("%s reversed %v", tt.sortBy, tt.reverse)
+		if got := m.selectedName(); got != name {
+			t.Errorf("%s: cursor on %s, want %s", what, got, name)
+		}
+		// Revealed where the entry landed
+		wantCursor(t, m, m.Selected, m.ListOffset)
+		if m.Selected != before {
+			moved++
+		}
+	}
+	if moved < len(tests)-1 {
+		t.Errorf("the entry moved in %d of %d sorts, the fixture does not reorder", moved, len(tests))
+	}
+
+	// With a filter, the entry is found among the entries it shows
+	press(t, m, "/")
+	typeText(t, m, "f1")
+	press(t, m, "enter")
+	selectName(t, m, "f17")
+	press(t, m, "s")
+	if got := m.selectedName(); got != "f17" {
+		t.Errorf("filtered: cursor on %s, want f17", got)
+	}
+}
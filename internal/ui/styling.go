@@ -2,20 +2,107 @@ package ui
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
 	"github.com/embeddingbits/file_viewer/pkg/models"
+	"github.com/muesli/termenv"
 )
 
+// plainRenderer renders for a terminal without colors: styles made from it
+// keep their layout (width, padding, borders) but emit no escape sequences
+var plainRenderer = func() *lipgloss.Renderer {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.Ascii)
+	return r
+}()
+
+// asciiBorder draws pane borders from plain ASCII when colors or Unicode
+// are off
+var asciiBorder = lipgloss.Border{
+	Top:         "-",
+	Bottom:      "-",
+	Left:        "|",
+	Right:       "|",
+	TopLeft:     "+",
+	TopRight:    "+",
+	BottomLeft:  "+",
+	BottomRight: "+",
+}
+
+// newStyle starts every style the UI draws with, one that renders unstyled
+// when colors are disabled
+func newStyle(cfg config.Config) lipgloss.Style {
+	if !cfg.Color {
+		return plainRenderer.NewStyle()
+	}
+	return lipgloss.NewStyle()
+}
+
+// paneBorder returns the border drawn around panes
+func paneBorder(cfg config.Config) lipgloss.Border {
+	if !cfg.Color || !cfg.Unicode {
+		return asciiBorder
+	}
+	return lipgloss.RoundedBorder()
+}
+
+// paneRule returns the line separating a pane's title from its content
+func paneRule(cfg config.Config, width int) string {
+	if !cfg.Color || !cfg.Unicode {
+		return strings.Repeat("-", max(0, width))
+	}
+	return strings.Repeat("─", max(0, width))
+}
+
+// rowIcon returns the icon leading a listing row. Without colors the
+// selected and marked rows cannot be highlighted, so their icon gives way to
+// a ">" cursor and a "+" mark padded to the same width. A one-column ASCII
+// icon only has room for the cursor.
+func rowIcon(file models.FileInfo, isSelected, isMarked bool, cfg config.Config) string {
+	icon := fileIcon(file, cfg)
+	if cfg.Color || !isSelected && !isMarked {
+		return icon
+	}
+	marker := ""
+	if isSelected {
+		marker = ">"
+	}
+	if isMarked {
+		marker += "+"
+	}
+	marker = ansi.Truncate(marker, max(1, ansi.StringWidth(icon)), "")
+	return marker + strings.Repeat(" ", max(0, ansi.StringWidth(icon)-ansi.StringWidth(marker)))
+}
+
 // GetFileStyle returns the appropriate style for a file or directory
 func GetFileStyle(file models.FileInfo, isSelected bool, cfg config.Config) lipgloss.Style {
 	var color string
 
-	if file.IsHidden {
+	if file.Mode&fs.ModeSetuid != 0 {
+		color = cfg.SetuidColor
+	} else if file.Mode&fs.ModeSetgid != 0 {
+		color = cfg.SetgidColor
+	} else if file.Entry.IsDir() && file.Mode&fs.ModeSticky != 0 {
+		color = cfg.StickyColor
+	} else if file.Mode&fs.ModeSymlink != 0 {
+		color = cfg.SymlinkColor
+		if file.LinkBroken {
+			color = cfg.BrokenLinkColor
+		}
+	} else if file.IsHidden {
 		color = cfg.HiddenFileColor
 	} else if file.Entry.IsDir() {
 		color = cfg.DirColor
+	} else if fileutils.IsSpecialFile(file.Mode) {
+		color = cfg.SpecialFileColor
 	} else {
 		// Check if executable
 		if info, err := file.Entry.Info(); err == nil && info.Mode()&0111 != 0 {
@@ -25,7 +112,7 @@ func GetFileStyle(file models.FileInfo, isSelected bool, cfg config.Config) lipg
 		}
 	}
 
-	style := lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+	style := newStyle(cfg).Foreground(lipgloss.Color(color))
 
 	if isSelected {
 		// Use foreground color with configured hover background instead of highlighting
@@ -37,47 +124,142 @@ func GetFileStyle(file models.FileInfo, isSelected bool, cfg config.Config) lipg
 
 // GetBorderStyle returns the border style for panes
 func GetBorderStyle(cfg config.Config) lipgloss.Style {
-	return lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color(cfg.BorderColor))
+	return newStyle(cfg).Border(paneBorder(cfg)).BorderForeground(lipgloss.Color(cfg.BorderColor))
 }
 
 // GetPreviewBorderStyle returns the border style for the preview pane
 func GetPreviewBorderStyle(cfg config.Config) lipgloss.Style {
-	return lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+	return newStyle(cfg).
+		Border(paneBorder(cfg)).
 		BorderForeground(lipgloss.Color(cfg.PreviewBorderColor))
 }
 
+// GetPreviewBgSequence returns the escape sequence that switches to the
+// preview background, empty when the terminal renders no colors
+func GetPreviewBgSequence(cfg config.Config) string {
+	seq, _, _ := strings.Cut(newStyle(cfg).Background(lipgloss.Color(cfg.PreviewBgColor)).Render(" "), " ")
+	return seq
+}
+
+// GetPreviewHighlightStyle returns the style for the highlighted entry in a
+// directory preview
+func GetPreviewHighlightStyle(cfg config.Config) lipgloss.Style {
+	return newStyle(cfg).
+		Foreground(lipgloss.Color(cfg.SelectedItemColor)).
+		Background(lipgloss.Color(cfg.HoverBgColor))
+}
+
 // GetStatusStyle returns the style for the status bar
 func GetStatusStyle(cfg config.Config, width int) lipgloss.Style {
-	return lipgloss.NewStyle().
+	return newStyle(cfg).
 		Width(width).
 		Background(lipgloss.Color(cfg.StatusBarBgColor)).
 		Foreground(lipgloss.Color(cfg.StatusBarFgColor)).
 		Padding(0, 1)
 }
 
+// GetNoMatchStyle returns the style for the search "no matches" hint
+func GetNoMatchStyle(cfg config.Config) lipgloss.Style {
+	return newStyle(cfg).
+		Background(lipgloss.Color(cfg.StatusBarBgColor)).
+		Foreground(lipgloss.Color("203")).
+		Bold(true)
+}
+
+// GetFilterStyle returns the style for the active filter indicator
+func GetFilterStyle(cfg config.Config) lipgloss.Style {
+	return newStyle(cfg).
+		Background(lipgloss.Color(cfg.StatusBarBgColor)).
+		Foreground(lipgloss.Color(cfg.SelectedItemColor)).
+		Bold(true)
+}
+
+// renderPermissions colors the setuid, setgid and sticky characters of an
+// ls-style permission string as their entries are, so they stand out in
+// the status bar
+func renderPermissions(perms string, cfg config.Config) string {
+	if len(perms) != 10 || !strings.ContainsAny(perms[1:], "sStT") {
+		return perms
+	}
+	colors := map[int]string{3: cfg.SetuidColor, 6: cfg.SetgidColor, 9: cfg.StickyColor}
+	var sb strings.Builder
+	for i := range len(perms) {
+		color, special := colors[i]
+		if !special || !strings.ContainsRune("sStT", rune(perms[i])) {
+			sb.WriteByte(perms[i])
+			continue
+		}
+		sb.WriteString(newStyle(cfg).
+			Background(lipgloss.Color(cfg.StatusBarBgColor)).
+			Foreground(lipgloss.Color(color)).
+			Bold(true).
+			Render(perms[i : i+1]))
+	}
+	return sb.String()
+}
+
 // GetHelpStyle returns the style for the help bar
-func GetHelpStyle(width int) lipgloss.Style {
-	return lipgloss.NewStyle().
+func GetHelpStyle(cfg config.Config, width int) lipgloss.Style {
+	return newStyle(cfg).
 		Width(width).
 		Background(lipgloss.Color("236")).
 		Foreground(lipgloss.Color("248")).
 		Padding(0, 1)
 }
 
-// TruncateString truncates a string to fit within the specified width
+// TruncateString cuts s to at most width display columns, ending it in
+// "..." when there is room. Wide characters and escape sequences are
+// measured as the terminal shows them.
 func TruncateString(s string, width int) string {
-	if len(s) <= width {
+	if ansi.StringWidth(s) <= width {
 		return s
 	}
-	return s[:width-3] + "..."
+	if width > 3 {
+		return ansi.Truncate(s, width, "...")
+	}
+	return ansi.Truncate(s, max(0, width), "")
+}
+
+// displayName makes a file name safe to print on one line: control
+// characters such as newlines or escapes, and bytes that are not UTF-8, are
+// shown as "?", like ls does
+func displayName(name string) string {
+	if utf8.ValidString(name) && !strings.ContainsFunc(name, unicode.IsControl) {
+		return name
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return '?'
+		}
+		return r
+	}, strings.ToValidUTF8(name, "?"))
+}
+
+// TruncateStringLeft is TruncateString cutting from the start, for text
+// whose end matters such as typed input
+func TruncateStringLeft(s string, width int) string {
+	w := ansi.StringWidth(s)
+	if w <= width {
+		return s
+	}
+	width = max(0, width)
+	prefix := "..."
+	if width <= 3 {
+		prefix = ""
+	}
+	// A wide character straddling the cut is kept, cut once more then
+	for cut := w - width + len(prefix); ; cut++ {
+		if out := ansi.TruncateLeft(s, cut, prefix); ansi.StringWidth(out) <= width {
+			return out
+		}
+	}
 }
 
 // FormatFileName formats a file name with size information
 func FormatFileName(file models.FileInfo, maxWidth int, showSize bool) string {
 	name := file.Entry.Name()
 
-	if showSize && !file.Entry.IsDir() {
+	if showSize && !file.IsDir() {
 		// Import the fileutils package to use FormatSize
 		// For now, we'll use a simple approach
 		sizeStr := " (unknown)"
@@ -100,7 +282,7 @@ func FormatFileName(file models.FileInfo, maxWidth int, showSize bool) string {
 	if len(name) > maxWidth {
 		// Calculate how much space the size info takes
 		sizeInfoLen := 0
-		if showSize && !file.Entry.IsDir() {
+		if showSize && !file.IsDir() {
 			sizeInfoLen = len(" (xxx.x KB)")
 		}
 
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestSpecialBitStyles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"setuid":        {Mode: fs.ModeSetuid | 0o755},
+		"setuid-noexec": {Mode: fs.ModeSetuid | 0o644},
+		"setgid":        {Mode: fs.ModeSetgid | 0o755},
+		"both":          {Mode: fs.ModeSetuid | fs.ModeSetgid | 0o755},
+		"sticky-file":   {Mode: fs.ModeSticky | 0o644},
+		"tmp":           {Mode: fs.ModeDir | fs.ModeSticky | 0o777},
+		"shared":        {Mode: fs.ModeDir | fs.ModeSetgid | 0o775},
+		"plain":         {Mode: 0o644},
+		"dir":           {Mode: fs.ModeDir | 0o755},
+	}
+	m := newConfiguredModel(t, Options{FS: fsys}, "setuid_color = \"#ff0000\"\nsetgid_color = \"#ffff00\"\nsticky_color = \"#0000ff\"\n")
+	cfg := m.config
+	if cfg.SetuidColor != "#ff0000" || cfg.StickyColor != "#0000ff" {
+		t.Errorf("configured colors not loaded: %q, %q", cfg.SetuidColor, cfg.StickyColor)
+	}
+
+	// setuid over setgid over sticky, which only directories show
+	want := map[string]string{
+		"setuid":        cfg.SetuidColor,
+		"setuid-noexec": cfg.SetuidColor,
+		"setgid":        cfg.SetgidColor,
+		"both":          cfg.SetuidColor,
+		"sticky-file":   cfg.DefaultFgColor,
+		"tmp":           cfg.StickyColor,
+		"shared":        cfg.SetgidColor,
+		"plain":         cfg.DefaultFgColor,
+		"dir":           cfg.DirColor,
+	}
+	for _, file := range m.Files {
+		name := file.Entry.Name()
+		for _, selected := range []bool{false, true} {
+			if got := GetFileStyle(file, selected, cfg).GetForeground(); got != lipgloss.Color(want[name]) {
+				t.Errorf("%s, selected %v: color %v, want %v", name, selected, got, want[name])
+			}
+		}
+	}
+}
+
+func TestSpecialBitPermissions(t *testing.T) {
+	forceColors(t)
+	fsys := fstest.MapFS{
+		"setuid": {Mode: fs.ModeSetuid | 0o755},
+		"setgid": {Mode: fs.ModeSetgid | 0o745},
+		"tmp":    {Mode: fs.ModeDir | fs.ModeSticky | 0o777},
+		"plain":  {Mode: 0o644},
+	}
+	m := newTestModel(t, Options{FS: fsys})
+	tests := []struct {
+		name, perms string
+		special     int // Index of the special character, -1 for none
+	}{
+		{"setuid", "-rwsr-xr-x", 3},
+		{"setgid", "-rwxr-Sr-x", 6},
+		{"tmp", "drwxrwxrwt", 9},
+		{"plain", "-rw-r--r--", -1},
+	}
+	for _, tt := range tests {
+		selectName(t, m, tt.name)
+		view := m.View()
+		if !strings.Contains(ansi.Strip(view), tt.perms) {
+			t.Errorf("%s: status bar lacks %q", tt.name, tt.perms)
+		}
+
+		// The special character is styled on its own, the rest is plain
+		rendered := renderPermissions(tt.perms, m.config)
+		if ansi.Strip(rendered) != tt.perms {
+			t.Errorf("%s: rendered permissions read %q", tt.name, ansi.Strip(rendered))
+		}
+		if tt.special < 0 {
+			if rendered != tt.perms {
+				t.Errorf("%s: plain permissions styled: %q", tt.name, rendered)
+			}
+			continue
+		}
+		if !strings.HasPrefix(rendered, tt.perms[:tt.special]+"\x1b[") || !strings.Contains(view, rendered) {
+			t.Errorf("%s: %q not styled at %d in the status bar", tt.name, rendered, tt.special)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// isSVGFile detects SVG images by name
+func isSVGFile(fileName string) bool {
+	return strings.ToLower(filepath.Ext(fileName)) == ".svg"
+}
+
+// renderSVGPreview rasterizes an SVG image to the preview pane's size in
+// pixels and shows it like other images. SVGs that do not parse or have no
+// size get the text preview of their XML.
+func renderSVGPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	cols, rows := previewContentSize(m)
+	cellWidth, cellHeight := cellSize(m)
+	img, err := rasterizeSVG(content, cols*cellWidth, rows*cellHeight)
+	if err != nil {
+		renderBinaryPreview(m, cfg, selectedFile, fullPath, fmt.Sprintf("SVG rendering failed: %v", err))
+		return
+	}
+	showImage(m, cfg, img, fullPath)
+}
+
+// rasterizeSVG draws an SVG document into an image fitting width by height
+// pixels, keeping the aspect ratio of its view box. The parser panics on
+// some malformed documents, which is reported as an error.
+func rasterizeSVG(content []byte, width, height int) (img image.Image, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed SVG: %v", r)
+		}
+	}()
+
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(content), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, err
+	}
+	box := icon.ViewBox
+	if box.W <= 0 || box.H <= 0 {
+		return nil, fmt.Errorf("no width and height")
+	}
+	scale := math.Min(float64(width)/box.W, float64(height)/box.H)
+	size := image.Rect(0, 0, max(1, int(box.W*scale)), max(1, int(box.H*scale)))
+
+	rgba := image.NewRGBA(size)
+	icon.SetTarget(0, 0, float64(size.Dx()), float64(size.Dy()))
+	scanner := rasterx.NewScannerGV(size.Dx(), size.Dy(), rgba, size)
+	icon.Draw(rasterx.NewDasher(size.Dx(), size.Dy(), scanner), 1)
+	return rgba, nil
+}
@@ -0,0 +1,75 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// taskMsg reports the progress of a background task, or its outcome once
+// done
+type taskMsg struct {
+	task       *backgroundTask
+	progress   string // Shown in the status bar while running
+	done       bool
+	result     string // Status message once done
+	dir        string // Directory the task changed, reloaded when it is shown
+	selectName string // Entry of dir to put the cursor on, "" keeps it
+}
+
+// backgroundTask is a file operation running outside the update loop
+type backgroundTask struct {
+	updates chan taskMsg
+	then    func() // Run in the update loop once the task is done, may be nil
+}
+
+// taskReport hands a running task's progress to the status bar
+type taskReport func(progress string)
+
+// startTask runs work in the background. Progress reports are dropped while
+// the previous one is still unread, so work never waits on rendering. work
+// returns the final status message and the entry to select in dir.
+func (m *AppModel) startTask(progress, dir string, work func(report taskReport) (string, string)) tea.Cmd {
+	return m.startTaskThen(progress, dir, work, nil)
+}
+
+// startTaskThen is startTask calling then in the update loop once work is
+// done, for tasks with more to show than a status message
+func (m *AppModel) startTaskThen(progress, dir string, work func(report taskReport) (string, string), then func()) tea.Cmd {
+	task := &backgroundTask{updates: make(chan taskMsg, 1), then: then}
+	m.Task = progress
+	go func() {
+		result, selectName := work(func(progress string) {
+			select {
+			case task.updates <- taskMsg{task: task, progress: progress}:
+			default:
+			}
+		})
+		task.updates <- taskMsg{task: task, done: true, result: result, dir: dir, selectName: selectName}
+	}()
+	return task.wait()
+}
+
+// wait returns a command delivering the task's next message
+func (task *backgroundTask) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-task.updates
+	}
+}
+
+// handleTaskMsg shows a task's progress, and once done reloads the listing
+// it changed and reports the outcome
+func (m *AppModel) handleTaskMsg(msg taskMsg) tea.Cmd {
+	if !msg.done {
+		m.Task = msg.progress
+		return msg.task.wait()
+	}
+	m.Task = ""
+	if msg.dir == m.CurrentDir && m.ArchiveFS == nil {
+		m.reloadKeepingCursor()
+		if i := m.indexByName(msg.selectName); msg.selectName != "" && i >= 0 {
+			m.selectIndex(i)
+		}
+	}
+	m.StatusMessage = msg.result
+	if msg.task.then != nil {
+		msg.task.then()
+	}
+	return nil
+}
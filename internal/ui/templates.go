@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+)
+
+// templatesDir returns the directory holding the templates of T
+func templatesDir() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// listTemplates returns the names of the template files, symlinks followed
+func listTemplates(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if info, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil && info.Mode().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// promptTemplate asks which template to create a file from, listing them
+// all with tab cycling through the list, and then for the new file's name
+func (m *AppModel) promptTemplate() {
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only"
+		return
+	}
+	dir, err := templatesDir()
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot locate templates: %v", err)
+		return
+	}
+	names, err := listTemplates(dir)
+	if len(names) == 0 {
+		m.StatusMessage = "No templates in " + displayName(dir)
+		if err != nil && !os.IsNotExist(err) {
+			m.StatusMessage = fmt.Sprintf("Cannot read templates: %v", err)
+		}
+		return
+	}
+
+	m.prompt("Template: ", func(input string) tea.Cmd {
+		if !containsString(names, input) {
+			m.StatusMessage = fmt.Sprintf("No template %q", input)
+			return nil
+		}
+		template := filepath.Join(dir, input)
+		m.prompt("New file from "+displayName(input)+": ", func(name string) tea.Cmd {
+			m.createFromTemplate(template, name)
+			return nil
+		})
+		m.setPromptInput(input)
+		return nil
+	})
+	m.promptComplete = func(input string) []string {
+		var matches []string
+		for _, name := range names {
+			if strings.HasPrefix(name, input) {
+				matches = append(matches, name)
+			}
+		}
+		return matches
+	}
+	m.completePrompt(1)
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// createFromTemplate creates name below the current directory with the
+// content of template. Text templates get {{name}} (the file name without
+// its extension) and {{date}} substituted, binary ones are copied verbatim.
+func (m *AppModel) createFromTemplate(template, name string) {
+	if name == "" {
+		return
+	}
+	if err := validateNewPath(name); err != nil {
+		m.StatusMessage = fmt.Sprintf("Invalid name %q: %v", name, err)
+		return
+	}
+	fullPath := filepath.Join(m.CurrentDir, name)
+	if _, err := os.Lstat(fullPath); err == nil {
+		m.StatusMessage = existsMessage(fullPath, "already exists")
+		return
+	}
+	info, err := os.Stat(template)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot read template: %v", err)
+		return
+	}
+	content, err := os.ReadFile(template)
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot read template: %v", err)
+		return
+	}
+	if fileutils.IsLikelyTextFile(content) {
+		base := filepath.Base(name)
+		content, err = fileutils.ExpandTemplate(content, map[string]string{
+			"name": strings.TrimSuffix(base, filepath.Ext(base)),
+			"date": time.Now().Format("2006-01-02"),
+		})
+		if err != nil {
+			m.StatusMessage = fmt.Sprintf("Template %s: %v", displayName(filepath.Base(template)), err)
+			return
+		}
+	}
+
+	if err = os.MkdirAll(filepath.Dir(fullPath), 0o755); err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm()); err == nil {
+			_, err = f.Write(content)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+	m.loadCurrentDir()
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error creating %s: %v", displayName(name), err)
+		return
+	}
+	top, _, _ := strings.Cut(name, "/")
+	if i := m.indexByName(top); i >= 0 {
+		m.selectIndex(i)
+	}
+	m.StatusMessage = fmt.Sprintf("Created %s from %s", displayName(name), displayName(filepath.Base(template)))
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// trashSelected moves the marked entries, or the selected one, to the
+// trash in the background, as a move across filesystems may copy. Several
+// entries are trashed after a confirmation telling how much they hold, as
+// is the current directory or one of its ancestors.
+func (m *AppModel) trashSelected() tea.Cmd {
+	if len(m.Files) == 0 && len(m.Marked) == 0 {
+		return nil
+	}
+	if m.ArchiveFS != nil {
+		m.StatusMessage = "Archive entries are read-only"
+		return nil
+	}
+	paths, files := existingTargets(m.targets())
+	if len(paths) == 0 {
+		m.clearMarks()
+		m.StatusMessage = "Nothing to trash, the marked entries are gone"
+		return nil
+	}
+	warning := currentDirWarning(m.CurrentDir, paths)
+	if len(paths) == 1 && warning == "" {
+		m.clearMarks()
+		return m.trashPaths(paths)
+	}
+	m.confirm(warning+selectionPrompt("Trash", m.CurrentDir, files), func() tea.Cmd {
+		m.clearMarks()
+		return m.trashPaths(paths)
+	})
+	return nil
+}
+
+// trashPaths moves paths to the trash in the background
+func (m *AppModel) trashPaths(paths []string) tea.Cmd {
+	restore := hintKeys(m.config.Keys, actionTrashView)
+	return m.startTask("Moving to the trash", m.CurrentDir, func(report taskReport) (string, string) {
+		var failures []error
+		for i, path := range paths {
+			report(fmt.Sprintf("Moving to the trash: %d of %d", i+1, len(paths)))
+			if err := fileutils.TrashPath(path); err != nil {
+				failures = append(failures, err)
+			}
+		}
+		switch {
+		case len(failures) > 0 && len(paths) == 1:
+			return fmt.Sprintf("Error trashing: %v", failures[0]), ""
+		case len(failures) > 0:
+			return fmt.Sprintf("Trashed %d of %d entries, error: %v", len(paths)-len(failures), len(paths), failures[0]), ""
+		case len(paths) == 1:
+			return fmt.Sprintf("Trashed %s, %s restores", displayName(filepath.Base(paths[0])), restore), ""
+		}
+		return fmt.Sprintf("Trashed %d entries, %s restores", len(paths), restore), ""
+	})
+}
+
+// openTrash shows the trash view
+func (m *AppModel) openTrash() {
+	m.loadTrash()
+	m.TrashSelected = 0
+	m.TrashView = true
+}
+
+// loadTrash lists the trash cans, the most recently trashed entries first
+func (m *AppModel) loadTrash() {
+	entries, err := fileutils.ListTrash()
+	if err != nil {
+		m.StatusMessage = fmt.Sprintf("Error reading the trash: %v", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Deleted.After(entries[j].Deleted) })
+	m.TrashEntries = entries
+	m.TrashSelected = max(0, min(m.TrashSelected, len(entries)-1))
+}
+
+// handleTrashKeys moves through the trash view, restores the selected entry
+// and empties the trash, reporting whether the key was consumed
+func (m *AppModel) handleTrashKeys(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch m.keys[msg.String()] {
+	case actionTrashView:
+		m.TrashView = false
+		return true, nil
+	case actionUp:
+		m.TrashSelected = max(0, m.TrashSelected-1)
+		return true, nil
+	case actionDown:
+		m.TrashSelected = max(0, min(len(m.TrashEntries)-1, m.TrashSelected+1))
+		return true, nil
+	case actionTop:
+		m.TrashSelected = 0
+		return true, nil
+	case actionBottom:
+		m.TrashSelected = max(0, len(m.TrashEntries)-1)
+		return true, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return false, nil
+	case "enter", "r":
+		if len(m.TrashEntries) == 0 {
+			return true, nil
+		}
+		return true, m.restoreTrashed(m.TrashEntries[m.TrashSelected])
+	case "E":
+		m.confirmEmptyTrash()
+	case "esc", "q":
+		m.TrashView = false
+	}
+	return true, nil
+}
+
+// restoreTrashed moves entry back to where it was trashed from, then
+// refreshes the trash view
+func (m *AppModel) restoreTrashed(entry models.TrashEntry) tea.Cmd {
+	name := displayName(filepath.Base(entry.Path))
+	return m.startTaskThen("Restoring "+name, filepath.Dir(entry.Path), func(taskReport) (string, string) {
+		if err := fileutils.RestoreTrash(entry); err != nil {
+			return fmt.Sprintf("Error restoring: %v", err), ""
+		}
+		return fmt.Sprintf("Restored %s to %s", name, displayName(filepath.Dir(entry.Path))), filepath.Base(entry.Path)
+	}, m.refreshTrash)
+}
+
+// confirmEmptyTrash asks before deleting everything the trash view lists
+func (m *AppModel) confirmEmptyTrash() {
+	entries := m.TrashEntries
+	if len(entries) == 0 {
+		m.StatusMessage = "The trash is empty"
+		return
+	}
+	m.confirm(fmt.Sprintf("Permanently delete the %d entries in the trash? (y/n)", len(entries)), func() tea.Cmd {
+		return m.startTaskThen("Emptying the trash", "", func(report taskReport) (string, string) {
+			var failures []error
+			for i, entry := range entries {
+				report(fmt.Sprintf("Emptying the trash: %d of %d", i+1, len(entries)))
+				if err := fileutils.PurgeTrash(entry); err != nil {
+					failures = append(failures, err)
+				}
+			}
+			if len(failures) > 0 {
+				return fmt.Sprintf("Deleted %d of %d trashed entries, error: %v", len(entries)-len(failures), len(entries), failures[0]), ""
+			}
+			return fmt.Sprintf("Emptied the trash, %d entries deleted", len(entries)), ""
+		}, m.refreshTrash)
+	})
+}
+
+// refreshTrash reloads the trash view after a task changed the trash
+func (m *AppModel) refreshTrash() {
+	if m.TrashView {
+		m.loadTrash()
+	}
+}
+
+// renderTrashPane lists the trashed entries with where they came from and
+// when they were trashed, scrolled to keep the selected one visible
+func renderTrashPane(m *models.Model, cfg config.Config, width, height int) string {
+	var content strings.Builder
+	paneContentWidth := max(0, width-2)
+	content.WriteString(truncatePaneTitle("Trash", fmt.Sprintf(" (%d)", len(m.TrashEntries)), paneContentWidth) + "\n")
+	content.WriteString(paneRule(cfg, width-2) + "\n")
+
+	if len(m.TrashEntries) == 0 {
+		content.WriteString(" The trash is empty")
+	}
+	rows := max(1, height-2)
+	start := max(0, m.TrashSelected-rows+1)
+	end := min(start+rows, len(m.TrashEntries))
+	for i := start; i < end; i++ {
+		entry := m.TrashEntries[i]
+		prefix := " "
+		if i == m.TrashSelected && !cfg.Color {
+			prefix = ">"
+		}
+		when := "unknown date"
+		if !entry.Deleted.IsZero() {
+			when = entry.Deleted.Format("2006-01-02 15:04")
+		}
+		path := displayName(entry.Path)
+		if entry.Info.IsDir() {
+			path += "/"
+		}
+		line := TruncateString(fmt.Sprintf("%s%s  %s", prefix, when, path), paneContentWidth)
+		if i == m.TrashSelected {
+			line = GetPreviewHighlightStyle(cfg).Render(line)
+		}
+		content.WriteString(line + "\n")
+	}
+	borderStyle := GetBorderStyle(cfg)
+	return borderStyle.Width(width).Height(height).Render(strings.TrimSuffix(content.String(), "\n"))
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// overlayIdentifier names bullseye's placement to the ueberzugpp daemon
+const overlayIdentifier = "bullseye-preview"
+
+// overlay is the placement last sent to the ueberzugpp socket, kept at
+// package level like frameDir so Cleanup can remove it on exit
+var overlay struct {
+	socket string
+	placed string // Placement key, "" when nothing is shown
+}
+
+// overlayCommand is a ueberzug JSON command, sent one per line
+type overlayCommand struct {
+	Action     string `json:"action"`
+	Identifier string `json:"identifier"`
+	X          int    `json:"x,omitempty"`
+	Y          int    `json:"y,omitempty"`
+	MaxWidth   int    `json:"max_width,omitempty"`
+	MaxHeight  int    `json:"max_height,omitempty"`
+	Path       string `json:"path,omitempty"`
+}
+
+// overlaySocket returns the configured ueberzugpp socket, falling back to
+// $UB_SOCKET as exported by the usual launcher scripts
+func overlaySocket(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	return os.Getenv("UB_SOCKET")
+}
+
+// updateOverlay places the selected image over the preview pane through
+// ueberzugpp, or removes the placement when no image is previewed. Nothing
+// is sent while the placement is unchanged.
+func (m *AppModel) updateOverlay() {
+	socket := overlaySocket(m.config.UeberzugSocket)
+	if socket == "" {
+		return
+	}
+	path := overlayImagePath(m.Model)
+	if path == "" {
+		removeOverlay()
+		return
+	}
+
+	x, y := computeLayout(m.Model).previewOrigin()
+	width, height := previewContentSize(m.Model)
+	key := fmt.Sprintf("%s|%d,%d|%dx%d", path, x, y, width, height)
+	if overlay.placed == key && overlay.socket == socket {
+		return
+	}
+	overlay.socket = socket
+	if sendOverlayCommand(socket, overlayCommand{
+		Action:     "add",
+		Identifier: overlayIdentifier,
+		X:          x,
+		Y:          y,
+		MaxWidth:   width,
+		MaxHeight:  height,
+		Path:       path,
+	}) == nil {
+		overlay.placed = key
+	}
+}
+
+// overlayImagePath returns the image previewed in the preview pane, or ""
+// when the preview shows anything else
+func overlayImagePath(m *models.Model) string {
+	if previewCovered(m) || len(m.Files) == 0 || m.ArchiveFS != nil {
+		return ""
+	}
+	file := m.Files[m.Selected]
+	fullPath := filepath.Join(m.CurrentDir, file.Entry.Name())
+	if file.IsDir() || !isImageFileByExtension(file.Entry.Name()) || m.RawPreviewPath == fullPath || m.OpenByPath == fullPath {
+		return ""
+	}
+	if m.PreviewMaxSize > 0 && file.Size > m.PreviewMaxSize && m.ForcePreviewPath != fullPath {
+		return ""
+	}
+	return fullPath
+}
+
+// previewCovered reports whether a screen replacing the listing and its
+// preview is shown
+func previewCovered(m *models.Model) bool {
+	return m.GridMode || m.PaletteView || m.TrashView || m.DebugScreen || m.BatchScreen || m.GrepView || m.CompareView || m.FavoritesView
+}
+
+// removeOverlay clears a placement shown by updateOverlay
+func removeOverlay() {
+	if overlay.placed == "" {
+		return
+	}
+	sendOverlayCommand(overlay.socket, overlayCommand{Action: "remove", Identifier: overlayIdentifier})
+	overlay.placed = ""
+}
+
+// sendOverlayCommand writes one command to the daemon's socket. A missing
+// or unresponsive daemon only costs the short timeout, the ASCII preview
+// stays visible underneath either way.
+func sendOverlayCommand(socket string, cmd overlayCommand) error {
+	conn, err := net.DialTimeout("unix", socket, 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+
+	line, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// iniSummaryFields lists, per file extension, the section/key pairs shown
+// above the raw text of desktop entries and systemd units
+var iniSummaryFields = map[string][][2]string{
+	".desktop": {
+		{"Desktop Entry", "Name"},
+		{"Desktop Entry", "Comment"},
+		{"Desktop Entry", "Exec"},
+		{"Desktop Entry", "Categories"},
+	},
+	".service": {
+		{"Unit", "Description"},
+		{"Service", "ExecStart"},
+		{"Install", "WantedBy"},
+	},
+	".timer": {
+		{"Unit", "Description"},
+		{"Timer", "OnCalendar"},
+		{"Timer", "OnBootSec"},
+		{"Timer", "Unit"},
+		{"Install", "WantedBy"},
+	},
+	".socket": {
+		{"Unit", "Description"},
+		{"Socket", "ListenStream"},
+		{"Install", "WantedBy"},
+	},
+	".target": {
+		{"Unit", "Description"},
+		{"Install", "WantedBy"},
+	},
+	".path": {
+		{"Unit", "Description"},
+		{"Path", "PathChanged"},
+		{"Install", "WantedBy"},
+	},
+	".mount": {
+		{"Unit", "Description"},
+		{"Mount", "What"},
+		{"Mount", "Where"},
+		{"Install", "WantedBy"},
+	},
+}
+
+// isINISummaryFile detects desktop entries and systemd unit files.
+func isINISummaryFile(fileName string) bool {
+	_, ok := iniSummaryFields[strings.ToLower(filepath.Ext(fileName))]
+	return ok
+}
+
+// renderINISummaryPreview shows the key fields of a desktop entry or unit
+// file in a structured header above its raw text.
+func renderINISummaryPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	content, _, err := fileutils.ReadHead(fullPath, previewReadLimit)
+	if err != nil {
+		setPreview(m, fmt.Sprintf("Error reading file: %v", err))
+		return
+	}
+
+	sections := fileutils.ParseINI(string(content))
+	var summary []string
+	for _, field := range iniSummaryFields[strings.ToLower(filepath.Ext(selectedFile.Entry.Name()))] {
+		section, ok := fileutils.FindINISection(sections, field[0])
+		if !ok {
+			continue
+		}
+		for _, key := range section.Keys {
+			if key.Key == field[1] {
+				summary = append(summary, fmt.Sprintf("%s: %s", key.Key, key.Value))
+			}
+		}
+	}
+
+	note := ""
+	if len(summary) > 0 {
+		note = "\n" + strings.Join(summary, "\n")
+	}
+	renderBinaryPreview(m, cfg, selectedFile, fullPath, note)
+}
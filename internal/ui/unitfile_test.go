@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestINISummaryPreview(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.desktop":    "[Desktop Entry]\nName=Editor\nComment=Edit text\nExec=editor %F\nCategories=Utility;TextEditor;\nIcon=editor\n",
+		"backup.service": "# Nightly\n[Unit]\nDescription=Backup job\n\n[Service]\nExecStart=/usr/bin/backup \\\n  --all\n\n[Install]\nWantedBy=multi-user.target\nWantedBy=backup.target\n",
+		"backup.timer":   "[Unit]\nDescription=Nightly backup\n[Timer]\nOnCalendar=daily\nUnit=backup.service\n",
+		"OLD.DESKTOP":    "[Desktop Entry]\nName=Old\n",
+		"empty.service":  "[Service]\nType=oneshot\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newTestModel(t, Options{Path: dir})
+
+	// The fields in the order listed, repeated keys each, above the raw text
+	tests := []struct {
+		name    string
+		summary []string
+	}{
+		{"app.desktop", []string{"Name: Editor", "Comment: Edit text", "Exec: editor %F", "Categories: Utility;TextEditor;"}},
+		{"backup.service", []string{"Description: Backup job", "ExecStart: /usr/bin/backup --all", "WantedBy: multi-user.target", "WantedBy: backup.target"}},
+		{"backup.timer", []string{"Description: Nightly backup", "OnCalendar: daily", "Unit: backup.service"}},
+		{"OLD.DESKTOP", []string{"Name: Old"}},
+		{"empty.service", nil},
+	}
+	for _, tt := range tests {
+		selectName(t, m, tt.name)
+		lines := m.PreviewLines
+		start := slices.Index(lines, "")
+		if start < 0 || m.PreviewContentStart > len(lines) {
+			t.Fatalf("%s: preview has no header:\n%s", tt.name, strings.Join(lines, "\n"))
+		}
+		var summary []string
+		if end := m.PreviewContentStart - 1; end > start+1 {
+			summary = lines[start+1 : end]
+		}
+		if !slices.Equal(summary, tt.summary) {
+			t.Errorf("%s: summary %q, want %q", tt.name, summary, tt.summary)
+		}
+		if raw := strings.Join(lines[m.PreviewContentStart:], "\n"); raw != files[tt.name] {
+			t.Errorf("%s: raw text %q, want %q", tt.name, raw, files[tt.name])
+		}
+	}
+}
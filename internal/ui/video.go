@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/pkg/models"
+)
+
+// videoPreviewTimeout bounds the ffprobe and ffmpeg work for one preview
+const videoPreviewTimeout = 15 * time.Second
+
+var (
+	frameDirOnce sync.Once
+	frameDir     string // Temporary directory for extracted frames
+)
+
+// isVideoFileByExtension detects common video container formats.
+func isVideoFileByExtension(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".mp4", ".m4v", ".mkv", ".webm", ".mov", ".avi", ".flv", ".wmv", ".mpg", ".mpeg", ".ts":
+		return true
+	default:
+		return false
+	}
+}
+
+// videoPreviewMsg delivers the metadata and frame generated for a video
+type videoPreviewMsg struct {
+	key     string
+	path    string
+	preview models.VideoPreview
+}
+
+// videoPreviewKey identifies a video preview by path, mtime and frame size
+func videoPreviewKey(path string, modTime time.Time, width, height int) string {
+	return fmt.Sprintf("%s|%d|%dx%d", path, modTime.UnixNano(), width, height)
+}
+
+// previewContentSize returns the usable character size of the preview pane
+func previewContentSize(m *models.Model) (int, int) {
+	return max(1, computeLayout(m).preview-2), max(1, getVisibleHeight(m.Height)-2)
+}
+
+// renderVideoPreview shows the extracted frame above the file header and
+// ffprobe metadata, or a placeholder while they are being generated. With
+// video_previews off only the header is shown.
+func renderVideoPreview(m *models.Model, cfg config.Config, selectedFile models.FileInfo, fullPath string) {
+	if !cfg.VideoPreviews {
+		setPreview(m, fileHeader(m, cfg, selectedFile))
+		return
+	}
+	width, height := previewContentSize(m)
+	preview, ok := m.VideoPreviews[videoPreviewKey(fullPath, selectedFile.ModTime, width, height/2)]
+
+	var sb strings.Builder
+	if ok && preview.Frame != "" {
+		sb.WriteString(preview.Frame)
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fileHeader(m, cfg, selectedFile))
+	if !ok {
+		sb.WriteString("\nLoading video preview...")
+	} else if preview.Metadata != "" {
+		sb.WriteString("\n" + preview.Metadata)
+	}
+	setPreview(m, sb.String())
+}
+
+// extractVideoPreview returns a command running ffprobe for metadata and
+// ffmpeg for a frame ~10% into the video. Missing tools are not an error,
+// the corresponding part of the preview is simply left empty.
+func extractVideoPreview(path, key string, width, height int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), videoPreviewTimeout)
+		defer cancel()
+
+		metadata, duration := probeVideo(ctx, path)
+		return videoPreviewMsg{
+			key:  key,
+			path: path,
+			preview: models.VideoPreview{
+				Metadata: metadata,
+				Frame:    extractVideoFrame(ctx, path, duration/10, width, height),
+			},
+		}
+	}
+}
+
+// probeVideo summarises the container and streams reported by ffprobe
+func probeVideo(ctx context.Context, path string) (string, float64) {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return "", 0
+	}
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-of", "json",
+		"-show_entries", "format=duration,bit_rate:stream=codec_type,codec_name,width,height,r_frame_rate",
+		path).Output()
+	if err != nil {
+		return "", 0
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+			FrameRate string `json:"r_frame_rate"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return "", 0
+	}
+
+	var sb strings.Builder
+	duration, _ := strconv.ParseFloat(probe.Format.Duration, 64)
+	if duration > 0 {
+		sb.WriteString(fmt.Sprintf("Duration: %s\n", time.Duration(duration*float64(time.Second)).Round(time.Second).String()))
+	}
+	if bitRate, err := strconv.ParseInt(probe.Format.BitRate, 10, 64); err == nil {
+		sb.WriteString(fmt.Sprintf("Bit rate: %d kb/s\n", bitRate/1000))
+	}
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			sb.WriteString(fmt.Sprintf("Video: %s %dx%d", stream.CodecName, stream.Width, stream.Height))
+			if fps := parseFrameRate(stream.FrameRate); fps > 0 {
+				sb.WriteString(fmt.Sprintf(" @ %.2f fps", fps))
+			}
+			sb.WriteString("\n")
+		case "audio":
+			sb.WriteString(fmt.Sprintf("Audio: %s\n", stream.CodecName))
+		case "subtitle":
+			sb.WriteString(fmt.Sprintf("Subtitle: %s\n", stream.CodecName))
+		}
+	}
+	return sb.String(), duration
+}
+
+// parseFrameRate converts ffprobe's "30000/1001" style rates to fps
+func parseFrameRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		return 0
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// extractVideoFrame grabs one frame at offset seconds into a temporary PNG
+// and renders it through the image preview pipeline
+func extractVideoFrame(ctx context.Context, path string, offset float64, width, height int) string {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return ""
+	}
+	frameDirOnce.Do(func() {
+		frameDir, _ = os.MkdirTemp("", "bullseye-frames-")
+	})
+	if frameDir == "" {
+		return ""
+	}
+
+	tmp, err := os.CreateTemp(frameDir, "frame-*.png")
+	if err != nil {
+		return ""
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	err = exec.CommandContext(ctx, "ffmpeg", "-v", "error", "-y",
+		"-ss", strconv.FormatFloat(offset, 'f', 2, 64), "-i", path,
+		"-frames:v", "1", tmp.Name()).Run()
+	if err != nil {
+		return ""
+	}
+
+	file, err := os.Open(tmp.Name())
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return ""
+	}
+	return imageToASCII(img, width, height, false)
+}
+
+// Cleanup removes temporary files and the image overlay created while
+// previewing, call on exit
+func Cleanup() {
+	removeOverlay()
+	removeGraphics()
+	if frameDir != "" {
+		os.RemoveAll(frameDir)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/embeddingbits/file_viewer/internal/config"
+	"github.com/embeddingbits/file_viewer/internal/fileutils"
 	"github.com/embeddingbits/file_viewer/pkg/models"
 )
 
@@ -17,6 +19,15 @@ type StatusBarContent struct {
 	SortInfo     string
 	FileCount    string
 	Permissions  string // To hold file mode like "-rwxr-xr-x"
+	PreviewMode  string // "raw" when the rendered preview is bypassed, "revealed" for unmasked secrets, "open by" for the process list
+	Filter       string // Active search query outside of search mode
+	Recording    bool   // A keyboard macro is being recorded
+	Task         string // Progress of a background operation
+	Cut          string // "1 file cut" while the cut buffer holds an entry
+	Marked       string // "3 marked" while entries are marked
+	Archive      string // "inside name.zip" while browsing an archive
+	PreviewPos   string // Visible preview lines like "L 120-168/843 20%", when it scrolls
+	Message      string // Transient status message, replaces the directory info
 }
 
 // RenderView renders the complete application view
@@ -29,18 +40,50 @@ func RenderView(m *models.Model, cfg config.Config) string {
 		return "Initializing..."
 	}
 
-	// Calculate pane widths
-	parentWidth := max(m.Width/4, 15)
-	currentWidth := max(m.Width/3, 20)
-	previewWidth := max(m.Width-parentWidth-currentWidth-4, 20)
-
+	layout := computeLayout(m)
 	visibleHeight := getVisibleHeight(m.Height)
 
-	// Panes
-	parentPane := renderParentPane(m, cfg, parentWidth, visibleHeight)
-	currentPane := renderCurrentPane(m, cfg, currentWidth, visibleHeight)
-	previewPane := renderPreviewPane(m, cfg, previewWidth, visibleHeight)
-	panes := lipgloss.JoinHorizontal(lipgloss.Top, parentPane, currentPane, previewPane)
+	// Panes, the farthest ancestor on the left. Ancestors above the root
+	// are drawn empty so the layout does not shift near it.
+	var row []string
+	for i := len(layout.ancestors) - 1; i >= 0; i-- {
+		var listing models.DirListing
+		if i < len(m.Ancestors) {
+			listing = m.Ancestors[i]
+		}
+		row = append(row, renderAncestorPane(listing, cfg, layout.ancestors[i], visibleHeight))
+	}
+	if m.PaletteView {
+		row = []string{renderPalettePane(m, cfg, max(m.Width-2, 20), visibleHeight)}
+	} else if m.TrashView {
+		row = []string{renderTrashPane(m, cfg, max(m.Width-2, 20), visibleHeight)}
+	} else if m.FavoritesView {
+		row = []string{renderFavoritesPane(m, cfg, max(m.Width-2, 20), visibleHeight)}
+	} else if m.DebugScreen {
+		row = []string{renderDebugPane(m, cfg, max(m.Width-2, 20), visibleHeight)}
+	} else if m.CompareView {
+		row = []string{renderComparePane(m, cfg, max(m.Width-2, 20), visibleHeight)}
+	} else if m.GrepView {
+		row = []string{renderGrepPane(m, cfg, max(m.Width-2, 20), visibleHeight)}
+	} else if m.BatchScreen {
+		row = []string{renderBatchPane(m, cfg, max(m.Width-2, 20), visibleHeight)}
+	} else if m.QuickLook {
+		row = []string{renderPreviewPane(m, cfg, layout.preview, visibleHeight)}
+	} else if m.GridMode {
+		gridWidth, gridHeight := gridPaneSize(m)
+		row = append(row, renderGridPane(m, cfg, gridWidth, gridHeight))
+	} else {
+		row = append(row,
+			renderCurrentPane(m, cfg, layout.current, visibleHeight),
+			renderPreviewPane(m, cfg, layout.preview, visibleHeight))
+	}
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, row...)
+	if len(m.PromptCandidates) > 1 {
+		// The completions replace the bottom row of the panes, right above the prompt
+		if i := strings.LastIndex(panes, "\n"); i >= 0 {
+			panes = panes[:i+1] + renderPromptCandidates(m, cfg)
+		}
+	}
 
 	// --- MODIFIED: Status Bar Rendering Layout ---
 	statusBarContent := getStatusBarContent(m, cfg)
@@ -48,13 +91,42 @@ func RenderView(m *models.Model, cfg config.Config) string {
 
 	var status string
 	if statusBarContent.IsSearchMode {
-		status = statusStyle.Render(statusBarContent.SearchQuery)
+		// Keep the end of long input in view, that is where typing happens
+		status = statusStyle.Render(TruncateStringLeft(statusBarContent.SearchQuery, m.Width-2))
 	} else {
 		// Left side of the status bar contains Directory and Sort info.
 		leftStatus := strings.Join([]string{statusBarContent.Directory, statusBarContent.SortInfo}, "")
-		
+		if statusBarContent.Filter != "" {
+			leftStatus += " " + GetFilterStyle(cfg).Render(statusBarContent.Filter)
+		}
+		if statusBarContent.Message != "" {
+			// Messages may quote command output, which can span lines
+			leftStatus = strings.Join(strings.Fields(statusBarContent.Message), " ")
+		}
+
 		// Right side now contains Permissions and File Count.
 		var rightItems []string
+		if statusBarContent.Task != "" {
+			rightItems = append(rightItems, statusBarContent.Task)
+		}
+		if statusBarContent.Marked != "" {
+			rightItems = append(rightItems, statusBarContent.Marked)
+		}
+		if statusBarContent.Cut != "" {
+			rightItems = append(rightItems, statusBarContent.Cut)
+		}
+		if statusBarContent.Recording {
+			rightItems = append(rightItems, "recording")
+		}
+		if statusBarContent.Archive != "" {
+			rightItems = append(rightItems, statusBarContent.Archive)
+		}
+		if statusBarContent.PreviewPos != "" {
+			rightItems = append(rightItems, statusBarContent.PreviewPos)
+		}
+		if statusBarContent.PreviewMode != "" {
+			rightItems = append(rightItems, statusBarContent.PreviewMode)
+		}
 		if statusBarContent.Permissions != "" {
 			rightItems = append(rightItems, statusBarContent.Permissions)
 		}
@@ -62,14 +134,23 @@ func RenderView(m *models.Model, cfg config.Config) string {
 			rightItems = append(rightItems, statusBarContent.FileCount)
 		}
 		rightStatus := strings.Join(rightItems, " | ")
-		
+
+		// A long name or message is cut to leave room for the right side,
+		// which gives way entirely on very narrow terminals
+		leftWidth := m.Width - 2 - lipgloss.Width(rightStatus) - 1
+		if leftWidth < 10 {
+			rightStatus = ""
+			leftWidth = m.Width - 2
+		}
+		leftStatus = TruncateString(leftStatus, max(0, leftWidth))
+
 		// Create the flexible gap in between
 		gapWidth := m.Width - lipgloss.Width(leftStatus) - lipgloss.Width(rightStatus) - 2 // -2 for style padding
 		if gapWidth < 0 {
 			gapWidth = 0
 		}
 		gap := strings.Repeat(" ", gapWidth)
-		
+
 		finalStatusText := lipgloss.JoinHorizontal(lipgloss.Top, leftStatus, gap, rightStatus)
 		status = statusStyle.Render(finalStatusText)
 	}
@@ -81,119 +162,273 @@ func RenderView(m *models.Model, cfg config.Config) string {
 	return lipgloss.JoinVertical(lipgloss.Left, panes, status, help)
 }
 
+// promptWithCursor returns the prompt input with the character under the
+// cursor reversed, or marked with | when colors are off. At the end of the
+// input no cursor is drawn, as before editing was possible. A selected input
+// is reversed as a whole, or bracketed.
+func promptWithCursor(m *models.Model, cfg config.Config) string {
+	if m.PromptSelected && m.PromptInput != "" {
+		if !cfg.Color {
+			return "[" + m.PromptInput + "]"
+		}
+		return "\x1b[7m" + m.PromptInput + "\x1b[27m"
+	}
+	runes := []rune(m.PromptInput)
+	if m.PromptCursor < 0 || m.PromptCursor >= len(runes) {
+		return m.PromptInput
+	}
+	before, at, after := string(runes[:m.PromptCursor]), string(runes[m.PromptCursor]), string(runes[m.PromptCursor+1:])
+	if !cfg.Color {
+		return before + "|" + at + after
+	}
+	// Only reverse video is toggled, the status bar colors carry on
+	return before + "\x1b[7m" + at + "\x1b[27m" + after
+}
+
+// renderPromptCandidates lists the names of the prompt's completions on one
+// line, the one in the input highlighted
+func renderPromptCandidates(m *models.Model, cfg config.Config) string {
+	names := make([]string, len(m.PromptCandidates))
+	for i, candidate := range m.PromptCandidates {
+		name := filepath.Base(fileutils.UnescapePath(candidate))
+		if strings.HasSuffix(candidate, "/") {
+			name += "/"
+		}
+		if i == m.PromptCandidate {
+			if !cfg.Color {
+				name = "[" + name + "]"
+			}
+			name = GetPreviewHighlightStyle(cfg).Render(name)
+		}
+		names[i] = name
+	}
+	// Start later in the list when the highlighted name would be cut off
+	width := max(0, m.Width-2)
+	start := 0
+	for start < m.PromptCandidate && ansi.StringWidth(strings.Join(names[start:m.PromptCandidate+1], "  ")) > width {
+		start++
+	}
+	line := TruncateString(strings.Join(names[start:], "  "), width)
+	return GetHelpStyle(cfg, m.Width).Render(line)
+}
 
-// renderParentPane renders the parent directory pane
-func renderParentPane(m *models.Model, cfg config.Config, width, height int) string {
+// renderAncestorPane renders the pane of an ancestor directory
+func renderAncestorPane(listing models.DirListing, cfg config.Config, width, height int) string {
 	var content strings.Builder
-	if m.ParentFiles != nil && len(m.ParentFiles) > 0 {
-		content.WriteString(fmt.Sprintf(" %s\n", filepath.Base(m.ParentDir)))
-		content.WriteString(strings.Repeat("─", width-2) + "\n")
+	if len(listing.Files) > 0 {
 		paneContentWidth := max(0, width-2)
+		content.WriteString(TruncateString(" "+displayName(filepath.Base(listing.Dir)), paneContentWidth) + "\n")
+		content.WriteString(paneRule(cfg, width-2) + "\n")
 
-		for i, file := range m.ParentFiles {
-			if i >= height-2 {
-				break
-			}
-			icon := GetFileIcon(file)
-			name := file.Entry.Name()
-			maxNameWidth := paneContentWidth - len(icon) - 1
-			if len(name) > maxNameWidth {
-				if maxNameWidth > 3 {
-					name = name[:maxNameWidth-3] + "..."
-				} else {
-					name = name[:max(0, maxNameWidth)]
-				}
-			}
-			style := GetFileStyle(file, i == m.ParentSelected, cfg)
+		start := min(listing.Offset, len(listing.Files))
+		end := min(start+height-2, len(listing.Files))
+		for i := start; i < end; i++ {
+			file := listing.Files[i]
+			icon := rowIcon(file, i == listing.Selected, false, cfg)
+			name := displayName(file.Entry.Name())
+			maxNameWidth := paneContentWidth - ansi.StringWidth(icon) - 1
+			name = TruncateString(name, maxNameWidth)
+			style := GetFileStyle(file, i == listing.Selected, cfg)
 			line := fmt.Sprintf("%s %s", icon, name)
 			content.WriteString(style.Render(line) + "\n")
 		}
 	}
 	borderStyle := GetBorderStyle(cfg)
-	return borderStyle.Width(width).Height(height).Render(content.String())
+	// Without the final newline a full listing is exactly height rows tall
+	return borderStyle.Width(width).Height(height).Render(strings.TrimSuffix(content.String(), "\n"))
+}
+
+// truncatePaneTitle fits " name suffix" into width, shortening the name so
+// the suffix with the item count stays visible
+func truncatePaneTitle(name, suffix string, width int) string {
+	return TruncateString(" "+TruncateString(name, width-1-ansi.StringWidth(suffix))+suffix, width)
 }
 
 // renderCurrentPane renders the current directory pane
 func renderCurrentPane(m *models.Model, cfg config.Config, width, height int) string {
 	var content strings.Builder
-	content.WriteString(fmt.Sprintf(" %s (%d items)\n", filepath.Base(m.CurrentDir), len(m.Files)))
-	content.WriteString(strings.Repeat("─", width-2) + "\n")
+	paneContentWidth := max(0, width-2)
+	count := fmt.Sprintf(" (%d items)", len(m.Files))
+	if m.Loading {
+		count = ""
+	}
+	content.WriteString(truncatePaneTitle(displayName(filepath.Base(m.CurrentDir)), count, paneContentWidth) + "\n")
+	content.WriteString(paneRule(cfg, width-2) + "\n")
 
-	if len(m.Files) == 0 {
+	if m.Loading {
+		content.WriteString(" " + loadingText(cfg))
+	} else if len(m.Files) == 0 {
 		content.WriteString(" No Items")
 	} else {
 		start := m.ListOffset
 		end := min(start+height-2, len(m.Files))
-		paneContentWidth := max(0, width-2)
+		// Entries of /proc and the like report no useful size
+		indicator := cfg.SizeIndicator
+		if m.Files[0].Virtual {
+			indicator = "none"
+		}
 
 		for i := start; i < end; i++ {
 			file := m.Files[i]
-			icon := GetFileIcon(file)
+			marked := isMarked(m, i)
+			icon := rowIcon(file, i == m.Selected, marked, cfg)
 			name := file.Entry.Name()
-			maxNameWidth := paneContentWidth - len(icon) - 1
-			if len(name) > maxNameWidth {
-				if maxNameWidth > 3 {
-					name = name[:maxNameWidth-3] + "..."
-				} else {
-					name = name[:max(0, maxNameWidth)]
+			badge := ""
+			if cfg.ProjectBadges && file.IsDir() {
+				if b := projectBadge(m, cfg, filepath.Join(m.CurrentDir, name)); b != "" {
+					badge = " [" + b + "]"
 				}
 			}
+			if m.Favorites[filepath.Join(m.CurrentDir, name)] {
+				badge += " " + favoriteMark(cfg)
+			}
+			if m.Changed[name] {
+				badge += " " + changedMark(cfg)
+			}
+			if m.Lookalikes[name] {
+				badge += " " + lookalikeMark(cfg)
+			}
+			gitMark := ""
+			if mark := m.GitMarks[m.CurrentDir][name]; mark != 0 {
+				gitMark = " " + string(mark)
+			}
+			// The size indicator is right-aligned after a separating space
+			indicatorWidth := sizeIndicatorWidth(indicator)
+			if indicatorWidth > 0 {
+				indicatorWidth++
+			}
+			maxNameWidth := paneContentWidth - ansi.StringWidth(icon) - 1 - len(gitMark) - ansi.StringWidth(badge) - indicatorWidth
+			full := displayName(name) + linkSuffix(file)
+			shown := TruncateString(full, maxNameWidth)
 			style := GetFileStyle(file, i == m.Selected, cfg)
-			line := fmt.Sprintf("%s %s", icon, name)
-			content.WriteString(style.Render(line) + "\n")
+			if marked {
+				style = style.Foreground(lipgloss.Color(cfg.MarkedColor)).Bold(true)
+			}
+			line := fmt.Sprintf("%s %s%s%s", icon, shown, gitMark, badge)
+			// The characters the search matched are underlined
+			rendered := style.Render(icon+" ") + highlightName(shown, shown != full, matchPositions(m, name), style) + renderGitMark(gitMark, style, cfg)
+			if indicatorWidth > 0 {
+				badge += strings.Repeat(" ", max(0, paneContentWidth-indicatorWidth-ansi.StringWidth(line)+1))
+				content.WriteString(rendered + style.Render(badge) + renderSizeIndicator(file, m.LargestFileSize, indicator, style, cfg) + "\n")
+				continue
+			}
+			content.WriteString(rendered + style.Render(badge) + "\n")
 		}
 	}
 	borderStyle := GetBorderStyle(cfg)
-	return borderStyle.Width(width).Height(height).Render(content.String())
+	return borderStyle.Width(width).Height(height).Render(strings.TrimSuffix(content.String(), "\n"))
 }
 
 // renderPreviewPane renders the preview pane
 func renderPreviewPane(m *models.Model, cfg config.Config, width, height int) string {
-	var content strings.Builder
-	if m.Preview != "" {
-		lines := strings.Split(m.Preview, "\n")
+	bg := GetPreviewBgSequence(cfg)
+	rows := make([]string, height)
+	if len(m.PreviewLines) > 0 {
+		lines := m.PreviewLines
 		start := m.PreviewOffset
 		end := min(start+height-2, len(lines))
 		paneContentWidth := max(0, width-2)
-		
+
 		for i := start; i < end; i++ {
 			line := lines[i]
-			if len(line) > paneContentWidth {
-				if paneContentWidth > 3 {
-					line = line[:paneContentWidth-3] + "..."
-				} else {
-					line = line[:paneContentWidth]
-				}
+			// Measure and cut by display width so colorized previews are
+			// not split inside an escape sequence.
+			line = TruncateString(line, paneContentWidth)
+			if i == m.PreviewHighlight {
+				line = GetPreviewHighlightStyle(cfg).Render(line)
 			}
-			content.WriteString(line + "\n")
+			rows[i-start] = line
 		}
 	}
+	// Every row is padded to the full width, so the background has a
+	// straight edge instead of ending with each line
+	for i, row := range rows {
+		rows[i] = withBackground(row, bg, width)
+	}
 	previewBorderStyle := GetPreviewBorderStyle(cfg)
-	return previewBorderStyle.Width(width).Height(height).Render(content.String())
+	return previewBorderStyle.Width(width).Height(height).Render(strings.Join(rows, "\n"))
 }
 
+// withBackground pads line to width on the background set by bg and turns
+// the background back on after every reset inside colorized content
+func withBackground(line, bg string, width int) string {
+	if bg == "" {
+		return line
+	}
+	padding := strings.Repeat(" ", max(0, width-ansi.StringWidth(line)))
+	line = strings.ReplaceAll(line, "\x1b[0m", "\x1b[0m"+bg)
+	line = strings.ReplaceAll(line, "\x1b[m", "\x1b[m"+bg)
+	return bg + line + padding + "\x1b[0m"
+}
 
 func getStatusBarContent(m *models.Model, cfg config.Config) StatusBarContent {
-	if m.SearchMode {
+	if m.InputMode == models.ModeSearch {
+		label := "Search"
+		if m.FuzzySearch {
+			label = "Fuzzy search"
+		}
+		query := fmt.Sprintf("%s: %s", label, m.SearchQuery)
+		switch {
+		case m.SearchQuery == "":
+		case len(m.Files) == 0:
+			hint := GetNoMatchStyle(cfg).Render("no matches, enter keeps the empty list, esc cancels")
+			query = fmt.Sprintf("%s (%s)", query, hint)
+		case len(m.Files) == 1:
+			query = fmt.Sprintf("%s (1 match)", query)
+		default:
+			query = fmt.Sprintf("%s (%d matches)", query, len(m.Files))
+		}
+		return StatusBarContent{
+			IsSearchMode: true,
+			SearchQuery:  query,
+		}
+	}
+	switch m.InputMode {
+	case models.ModeConfirm:
 		return StatusBarContent{
 			IsSearchMode: true,
-			SearchQuery:  fmt.Sprintf("Search: %s", m.SearchQuery),
+			SearchQuery:  m.ConfirmPrompt,
 		}
+	case models.ModePrompt:
+		return StatusBarContent{
+			IsSearchMode: true,
+			SearchQuery:  m.PromptLabel + promptWithCursor(m, cfg),
+		}
+	}
+
+	var dir, fileCount, permissions, previewMode string
+	var filter, archive string
+	switch {
+	case m.SearchQuery != "" && m.FilterPreset != "":
+		filter = fmt.Sprintf("[filter: %s in %s, esc clears]", m.SearchQuery, m.FilterPreset)
+	case m.SearchQuery != "":
+		filter = fmt.Sprintf("[filter: %s, esc clears]", m.SearchQuery)
+	case m.FilterPreset != "":
+		filter = fmt.Sprintf("[filter: %s, esc clears]", m.FilterPreset)
 	}
-	
-	var dir, fileCount, permissions string
-	
+	if m.ArchiveFS != nil && !m.RootFS {
+		archive = "inside " + filepath.Base(m.ArchivePath)
+	}
+
 	if len(m.Files) > 0 && m.Selected < len(m.Files) {
 		selectedFile := m.Files[m.Selected]
-		dir = fmt.Sprintf("Dir: %s", selectedFile.Entry.Name())
+		dir = fmt.Sprintf("Dir: %s", displayName(selectedFile.Entry.Name()))
 		fileCount = fmt.Sprintf("%d/%d", m.Selected+1, len(m.Files))
-		
+
 		if info, err := selectedFile.Entry.Info(); err == nil {
-			permissions = info.Mode().String()
+			permissions = renderPermissions(fileutils.FormatPermissions(info.Mode()), cfg)
+		}
+		switch filepath.Join(m.CurrentDir, selectedFile.Entry.Name()) {
+		case m.RawPreviewPath:
+			previewMode = "raw"
+		case m.RevealSecretsPath:
+			previewMode = "revealed"
+		case m.OpenByPath:
+			previewMode = "open by"
 		}
 
 	} else {
-		dir = fmt.Sprintf("Dir: %s", filepath.Base(m.CurrentDir))
+		dir = fmt.Sprintf("Dir: %s", displayName(filepath.Base(m.CurrentDir)))
 	}
 
 	return StatusBarContent{
@@ -201,17 +436,28 @@ func getStatusBarContent(m *models.Model, cfg config.Config) StatusBarContent {
 		Directory:    dir,
 		FileCount:    fileCount,
 		Permissions:  permissions,
+		PreviewMode:  previewMode,
+		Filter:       filter,
+		Recording:    m.MacroRecording,
+		Task:         m.Task,
+		Cut:          cutIndicator(m),
+		Marked:       markIndicator(m),
+		Archive:      archive,
+		PreviewPos:   previewPosition(m),
+		Message:      m.StatusMessage,
 	}
 }
 
-// renderHelpBar renders the help bar
-func renderHelpBar(m *models.Model, cfg config.Config) string {
-	helpText := "q:quit | h/l:nav | j/k:up/down | o:open | .:hidden | s:size | t:time | n:name | /:search | r:refresh"
-	if m.SearchMode {
-		helpText = "Type to search | Enter:confirm | Esc:cancel"
+// previewPosition describes which preview lines are visible and how far
+// through the preview they reach, or "" when it fits the pane
+func previewPosition(m *models.Model) string {
+	_, rows := previewContentSize(m)
+	if m.GridMode || len(m.PreviewLines) <= rows {
+		return ""
 	}
-	helpStyle := GetHelpStyle(m.Width)
-	return helpStyle.Render(helpText)
+	first := min(m.PreviewOffset+1, len(m.PreviewLines))
+	last := min(m.PreviewOffset+rows, len(m.PreviewLines))
+	return fmt.Sprintf("L %d-%d/%d %d%%", first, last, len(m.PreviewLines), last*100/len(m.PreviewLines))
 }
 
 // Helper functions
@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// nameFragments are the pieces pathological names are made of: wide and
+// zero-width characters, combining marks, emoji sequences, bidi overrides,
+// control characters and escape sequences
+var nameFragments = []string{
+	"a", "W", " ", "-", ".", "日", "本", "語", "\uff21", "\u3000", "e\u0301", "\u0301\u0301",
+	"\U0001f600", "\U0001f469\u200d\U0001f469\u200d\U0001f467", "\U0001f1ef\U0001f1f5", "\u2764\ufe0f",
+	"\u200b", "\u202e", "\u00a0", "\ufdfa", "\ufffd",
+	"\t", "\r", "\n", "\x07", "\x1b[31m", "\x1b]8;;x\x07", "\x7f",
+}
+
+// rawFragments are not UTF-8, only real filesystems take them in names
+var rawFragments = []string{"\xff", "\xe6\x97", "\xc3"}
+
+// pathologicalName returns a name of up to maxFragments of fragments
+func pathologicalName(rng *rand.Rand, fragments []string, maxFragments int) string {
+	var sb strings.Builder
+	for n := 1 + rng.Intn(maxFragments); sb.Len() == 0 || n > 0; n-- {
+		sb.WriteString(fragments[rng.Intn(len(fragments))])
+	}
+	name := strings.ReplaceAll(sb.String(), "/", "")
+	if name == "." || name == ".." {
+		return name + "x"
+	}
+	return name
+}
+
+// wantFits checks that no line of the view is wider than the terminal and
+// that it has no more lines than the terminal
+func wantFits(t *testing.T, m *AppModel, what string) {
+	t.Helper()
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) > m.Height {
+		t.Errorf("%s: %d lines in a terminal of %d", what, len(lines), m.Height)
+	}
+	for i, line := range lines {
+		if width := ansi.StringWidth(line); width > m.Width {
+			t.Errorf("%s: line %d is %d wide in a terminal of %d: %q", what, i, width, m.Width, ansi.Strip(line))
+			return
+		}
+	}
+}
+
+func TestViewWidthWithPathologicalNames(t *testing.T) {
+	// At least as wide as the panes' minimum widths together
+	sizes := [][2]int{{64, 12}, {81, 24}, {97, 15}, {121, 30}, {200, 45}}
+	for seed := int64(1); seed <= 8; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		fsys := make(fstest.MapFS)
+		deep := ""
+		for range 3 {
+			deep = path.Join(deep, pathologicalName(rng, nameFragments, 300))
+		}
+		for range 6 {
+			name := pathologicalName(rng, nameFragments, 4000)
+			fsys[name] = &fstest.MapFile{Data: []byte(pathologicalName(rng, nameFragments, 200)), Mode: 0o644}
+			fsys[path.Join(deep, name)] = &fstest.MapFile{Data: []byte("x"), Mode: 0o644}
+		}
+
+		m := newTestModel(t, Options{FS: fsys})
+		for _, size := range sizes {
+			send(t, m, tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+			for i := range m.Files {
+				m.selectIndex(i)
+				wantFits(t, m, fmt.Sprintf("seed %d, %dx%d, entry %d", seed, size[0], size[1], i))
+			}
+			m.StatusMessage = "Cannot open " + pathologicalName(rng, nameFragments, 4000)
+			wantFits(t, m, fmt.Sprintf("seed %d, %dx%d, status", seed, size[0], size[1]))
+			m.StatusMessage = ""
+		}
+
+		// Deep in the tree, the path in the header is long as well
+		m = newTestModel(t, Options{FS: fsys, Path: deep})
+		for _, size := range sizes {
+			send(t, m, tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+			wantFits(t, m, fmt.Sprintf("seed %d, %dx%d, deep", seed, size[0], size[1]))
+			press(t, m, "?")
+			wantFits(t, m, fmt.Sprintf("seed %d, %dx%d, help", seed, size[0], size[1]))
+			press(t, m, "esc")
+		}
+
+		// Names of a real directory, at most 255 bytes but not all UTF-8
+		dir := t.TempDir()
+		for range 6 {
+			name := pathologicalName(rng, slices.Concat(nameFragments, rawFragments), 200)
+			if len(name) > 255 {
+				name = strings.TrimSuffix(name[:255], ".")
+			}
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil && !os.IsExist(err) {
+				t.Fatal(err)
+			}
+		}
+		m = newTestModel(t, Options{Path: dir})
+		for _, size := range sizes {
+			send(t, m, tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+			for i := range m.Files {
+				m.selectIndex(i)
+				wantFits(t, m, fmt.Sprintf("seed %d, %dx%d, real entry %d", seed, size[0], size[1], i))
+			}
+		}
+	}
+}
+
+func FuzzTruncateString(f *testing.F) {
+	for _, fragment := range slices.Concat(nameFragments, rawFragments) {
+		f.Add(strings.Repeat(fragment, 50), 7)
+	}
+	// Cut from the left, bytes that are not UTF-8 once measured wider
+	f.Add("0\xe600日\xe60\xa50", 7)
+	// A wide character straddling the cut from the left
+	f.Add("0000日?0?", 7)
+	f.Fuzz(func(t *testing.T, s string, width int) {
+		width %= 300
+		s = displayName(s)
+		for _, truncate := range []func(string, int) string{TruncateString, TruncateStringLeft} {
+			if got := truncate(s, width); ansi.StringWidth(got) > max(0, width) {
+				t.Errorf("%q cut to %d is %d wide: %q", s, width, ansi.StringWidth(got), got)
+			}
+		}
+	})
+}
@@ -0,0 +1,106 @@
+// Package browser embeds bullseye's file browser in other Bubble Tea
+// programs, for example as a file picker: the listing with its sorting,
+// filtering and previews, driven by the keybindings and settings of the
+// user's bullseye config.
+//
+// A Browser is a tea.Model. Pass it the messages of the embedding program,
+// including tea.WindowSizeMsg or a size set with SetSize, and render its
+// View. It sends ChosenMsg when entries are chosen in chooser mode and
+// QuitMsg when the quit key is pressed.
+package browser
+
+import (
+	"io/fs"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/embeddingbits/file_viewer/internal/ui"
+)
+
+// Options configure a Browser
+type Options struct {
+	// Dir is the directory shown first, the working directory when empty.
+	// A file opens its directory with the file selected.
+	Dir string
+
+	// Select names the entry to select in Dir
+	Select string
+
+	// Chooser makes opening an entry (enter or o) choose it: the marked
+	// entries, else the selected file, are sent as a ChosenMsg. A selected
+	// directory is entered, directories are chosen by marking them.
+	Chooser bool
+
+	// Standalone is for a Browser that is the whole program: the quit key
+	// ends it instead of sending QuitMsg, and images are previewed with the
+	// terminal's graphics where supported
+	Standalone bool
+
+	// FS is browsed read-only instead of the real filesystem when set. Dir
+	// and the paths of ChosenMsg are then slash-separated paths within it.
+	FS fs.FS
+}
+
+// ChosenMsg carries the paths chosen in chooser mode
+type ChosenMsg = ui.ChosenMsg
+
+// QuitMsg is sent when the quit key is pressed in a Browser that is not
+// standalone, for the embedding program to close it
+type QuitMsg = ui.QuitMsg
+
+// Browser is the file browser component
+type Browser struct {
+	app *ui.AppModel
+}
+
+// New creates a Browser as configured by opts. An unreadable Dir is
+// reported by Err and shown in place of the listing.
+func New(opts Options) *Browser {
+	return &Browser{app: ui.NewAppModel(ui.Options{
+		Path:       opts.Dir,
+		Select:     opts.Select,
+		Chooser:    opts.Chooser,
+		Standalone: opts.Standalone,
+		FS:         opts.FS,
+	})}
+}
+
+// Init implements tea.Model
+func (b *Browser) Init() tea.Cmd {
+	return b.app.Init()
+}
+
+// Update implements tea.Model, always returning b
+func (b *Browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	_, cmd := b.app.Update(msg)
+	return b, cmd
+}
+
+// View implements tea.Model
+func (b *Browser) View() string {
+	return b.app.View()
+}
+
+// SetSize lays the browser out in width by height cells, for programs that
+// give it less than the whole window
+func (b *Browser) SetSize(width, height int) tea.Cmd {
+	_, cmd := b.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	return cmd
+}
+
+// Dir returns the directory being browsed, with symlinks resolved when the
+// user set physical_paths, or its path within Options.FS
+func (b *Browser) Dir() string {
+	return b.app.ExitDir()
+}
+
+// Err returns why the browser could not start, nil when it did
+func (b *Browser) Err() error {
+	return b.app.Err
+}
+
+// Close saves the state the browser persists and removes the image overlay
+// and temporary files of previews. Call it once the program has ended.
+func (b *Browser) Close() {
+	b.app.Close()
+	ui.Cleanup()
+}
@@ -9,30 +9,276 @@ import (
 type FileInfo struct {
 	Entry    fs.DirEntry
 	Size     int64
+	DiskSize int64 // Allocated bytes, differs from Size for sparse or compressed files
 	ModTime  time.Time
+	Mode     fs.FileMode // Mode of the entry itself (symlinks are not followed)
 	IsHidden bool
+	Virtual  bool // On a virtual filesystem such as /proc, not stat'ed and without a meaningful size
+
+	// For symlinks, the target as written in the link, whether it is
+	// missing and whether it is a directory
+	LinkTarget string
+	LinkBroken bool
+	LinkDir    bool
+
+	// Size and modification time sorted by, those of the link target for
+	// symlinks when sort_follow_symlinks is set. The size is the allocated
+	// one with sort_size_on_disk.
+	SortSize    int64
+	SortModTime time.Time
+}
+
+// IsDir reports whether the entry is a directory or a symlink to one
+func (f FileInfo) IsDir() bool {
+	return f.Entry.IsDir() || f.LinkDir
+}
+
+// VideoPreview holds the asynchronously generated parts of a video preview
+type VideoPreview struct {
+	Frame    string // ASCII rendering of a frame, empty if ffmpeg is unavailable
+	Metadata string // Summary of ffprobe output, empty if ffprobe is unavailable
+}
+
+// PreviewImage is an image preview drawn with terminal graphics rather than
+// as ASCII art
+type PreviewImage struct {
+	Path     string // Previewed image, "" when the preview has none
+	Sequence string // Escape sequence drawing the image at the cursor
+}
+
+// GitInfo summarises the state of a git repository shown in its directory
+// preview. A zero Branch means the directory could not be inspected.
+type GitInfo struct {
+	Branch   string // Current branch, or "(detached)"
+	Upstream string // Configured upstream such as "origin/main", empty if none
+	Ahead    int
+	Behind   int
+	Remote   string // URL of the upstream's remote, else of "origin"
+	Dirty    bool   // Uncommitted or untracked changes exist
+}
+
+// CursorState is where the cursor was in a directory's listing
+type CursorState struct {
+	Name       string // Entry under the cursor, found again by name so sorting and filtering do not matter
+	Selected   int    // Cursor index
+	ListOffset int
+}
+
+// HistoryEntry is a directory in the back/forward history, with where the
+// cursor was when it was left
+type HistoryEntry struct {
+	Dir string
+	CursorState
+}
+
+// BatchResult is how the batch command ran on one file
+type BatchResult struct {
+	Name   string // Path relative to the directory the batch ran in
+	Status string // "ok", "exit 2", "skipped" and the like
+	Failed bool
+	Output string // Last line the command printed
+}
+
+// GrepMatch is a line of a file containing the text searched for
+type GrepMatch struct {
+	Path string
+	Line int    // 1-based, 0 for a match of the name
+	Text string // The line, trimmed
+}
+
+// CompareKind classifies an entry of a directory comparison
+type CompareKind int
+
+const (
+	CompareSame    CompareKind = iota // Alike on both sides
+	CompareOnlyA                      // Only in the first directory
+	CompareOnlyB                      // Only in the second directory
+	CompareDiffers                    // Of another type or size on each side, or of other content
+	CompareUnsure                     // The same size but modified at other times, the content decides
+)
+
+// CompareEntry is a name of a directory comparison, with its file on each
+// side, nil where it is missing
+type CompareEntry struct {
+	Name string
+	Kind CompareKind
+	A, B fs.FileInfo
+}
+
+// TrashEntry is an entry of a trash can, with where it was trashed from
+type TrashEntry struct {
+	Name    string      // Name in the can's files directory
+	Can     string      // Trash can holding it
+	Path    string      // Original path it is restored to
+	Deleted time.Time   // When it was trashed, zero when unknown
+	Info    fs.FileInfo // Of the trashed entry itself
+}
+
+// InputMode selects the single handler that receives key presses, so a key
+// typed into a prompt can never reach a normal-mode binding
+type InputMode int
+
+const (
+	ModeNormal  InputMode = iota // Navigation and commands
+	ModeSearch                   // Typing the filter query
+	ModePrompt                   // Typing the answer to PromptLabel
+	ModeConfirm                  // Answering the y/n ConfirmPrompt
+)
+
+// CacheStats counts the lookups of a cache for the debug screen
+type CacheStats struct {
+	Hits, Misses int
+}
+
+// Record counts one lookup
+func (c *CacheStats) Record(hit bool) {
+	if hit {
+		c.Hits++
+	} else {
+		c.Misses++
+	}
+}
+
+// PerfStats are the timings and counters shown on the debug screen
+type PerfStats struct {
+	DirLoad       time.Duration // Last listing of the current directory and its ancestors
+	DirLoadPath   string
+	DirEntries    int
+	Preview       time.Duration // Last preview generation
+	PreviewPath   string
+	EntriesStated int // Directory entries and symlink targets stat'ed since startup
+
+	Thumbnails, Videos, Git, LinkTargets CacheStats
+}
+
+// MissingTool is a program an enabled feature runs that is not on PATH
+type MissingTool struct {
+	Tool    string
+	Feature string // What does not work without it, such as "video frames"
+	Setting string // Config setting turning the feature off, which silences the warning
+}
+
+// DirListing is an ancestor directory shown in a column left of the current one
+type DirListing struct {
+	Dir      string
+	Files    []FileInfo
+	Selected int // Entry leading towards the current directory
+	Offset   int // First entry rendered, keeps Selected in view
 }
 
 // Model represents the main application model
 type Model struct {
-	CurrentDir     string
-	BaseDir        string
-	ParentDir      string
-	Files          []FileInfo
-	ParentFiles    []FileInfo
-	Selected       int
-	ParentSelected int
-	ListOffset     int
-	Preview        string
-	PreviewOffset  int
-	Width          int
-	Height         int
-	Err            error
-	Config         interface{} // Will be properly typed when imported
-	ShowHidden     bool
-	SortBy         string // "name", "size", "modified"
-	ReverseSort    bool
-	SearchMode     bool
-	SearchQuery    string
-	ImagePreviewColored bool
+	CurrentDir          string
+	BaseDir             string
+	Files               []FileInfo
+	Loading             bool         // Files is being read in the background
+	Ancestors           []DirListing // Parent first, then further up as configured by columns
+	Selected            int
+	DirCursors          map[string]CursorState // Cursor per visited directory, restored on entering it again
+	ListOffset          int
+	PreviewLines        []string // Generated preview, split into lines
+	PreviewOffset       int
+	PreviewHighlight    int    // Preview line to highlight (the child entered by l), -1 for none
+	PreviewPath         string // File or directory the preview was generated for
+	PreviewContentStart int    // Preview line where the file content starts, after the header
+	PreviewLine         int    // 1-based file line jumped to in the preview, 0 if none
+	PreviewGeneration   int    // Counts preview updates, results of superseded background previews are dropped
+	PreviewPending      bool   // The preview is being generated in the background
+	RawPreviewPath      string // File whose preview is shown raw instead of rendered
+	ForcePreviewPath    string // File previewed despite exceeding PreviewMaxSize
+	RevealSecretsPath   string // Env file whose secret values are shown unmasked
+	OpenByPath          string // Entry whose preview lists the processes holding it open
+	OpenByReport        string // That list, or the scan still running
+	PreviewMaxSize      int64  // Files above this size only get a header preview
+	Width               int
+	Height              int
+	Err                 error
+	StatusMessage       string          // Transient message shown in the status bar
+	Task                string          // Progress of a background operation, e.g. "chmod: 1200 entries"
+	Cancellable         string          // Running operation esc cancels, e.g. "copy", "" when none can be
+	Clipboard           []string        // Paths yanked with y or cut with x, pasted with p
+	ClipboardCut        bool            // Clipboard was cut, pasting moves it
+	ClipboardIsDir      bool            // The single clipboard entry is a directory
+	Marked              map[string]bool // Paths in the current directory marked with space, operations act on them
+	Changed             map[string]bool // Entry names new or modified since the last visit, with track_changes
+	Lookalikes          map[string]bool // Entry names equal to another's but for case or Unicode normalization
+	Visual              bool            // Visual mode, the entries from VisualAnchor to Selected count as marked
+	VisualAnchor        string          // Entry name v was pressed on, followed through re-sorting
+	Config              interface{}     // Will be properly typed when imported
+	ShowHidden          bool
+	SortBy              string // "name", "size", "modified"
+	ReverseSort         bool
+	HiddenPosition      string                 // "mixed", "first", "last"
+	Columns             int                    // Panes including current and preview, at least 2
+	SortFollowSymlinks  bool                   // Symlinks sort by their target's size and mtime
+	SortDiskSize        bool                   // Sort and scale sizes by allocated rather than apparent size
+	LargestFileSize     int64                  // Largest file in Files, scales the size indicator
+	LinkTargets         map[string]fs.FileInfo // Symlink path to its target's info, nil if broken
+	InputMode           InputMode
+	SearchQuery         string
+	FilterPreset        string   // Name of the [filters] preset narrowing the listing, "" for none
+	FuzzySearch         bool     // The search query matches names fuzzily rather than as a substring
+	PromptLabel         string   // Question shown in ModePrompt, e.g. "Go to line: "
+	PromptInput         string   // Text typed so far in ModePrompt
+	PromptCursor        int      // Rune offset of the cursor in PromptInput
+	PromptSelected      bool     // All of PromptInput is selected, typing replaces it
+	PromptPath          bool     // The prompt takes a path, completed with tab
+	PromptCandidates    []string // Completions cycled through with tab, listed when there are several
+	PromptCandidate     int      // Index of the completion in PromptInput
+	ConfirmPrompt       string
+	MacroRecording      bool
+	GridMode            bool            // Current directory shown as a thumbnail grid
+	QuickLook           bool            // The preview fills the window
+	DebugScreen         bool            // Performance stats fill the window
+	BatchScreen         bool            // The results of the last batch command fill the window
+	BatchCommand        string          // Command template of the last batch
+	BatchResults        []BatchResult   // Per-file outcome of the last batch, in path order
+	BatchOffset         int             // First result line shown
+	GrepView            bool            // Content search results fill the window
+	GrepNames           bool            // The search is of the names of the entries, a deep search
+	GrepQuery           string          // Text the content search looks for
+	GrepRoot            string          // Directory the content search walks
+	GrepMatches         []GrepMatch     // Lines found so far, in walk order
+	GrepSelected        int             // Index of the selected match
+	GrepRunning         bool            // The walk is still going, matches keep arriving
+	GrepSearched        int             // Files searched by the finished walk
+	CompareView         bool            // The comparison of two directories fills the window
+	CompareA            string          // Directory compared, the current one when the comparison started
+	CompareB            string          // Directory it is compared with
+	CompareEntries      []CompareEntry  // Names of both, sorted
+	CompareSelected     int             // Index of the selected entry
+	CompareDepth        int             // Directories entered below the compared ones
+	PaletteView         bool            // The command palette fills the window
+	PaletteQuery        string          // Typed filter of the palette's actions
+	PaletteSelected     int             // Index of the selected action among those matching
+	TrashView           bool            // The trash fills the window
+	TrashEntries        []TrashEntry    // Trashed entries, the most recently trashed first
+	TrashSelected       int             // Index of the selected entry
+	History             []HistoryEntry  // Directories visited, oldest first, for back and forward
+	HistoryPos          int             // Index of the current directory in History
+	Favorites           map[string]bool // Starred paths, persisted in the state directory
+	FavoritesView       bool            // The favorites list fills the window
+	FavoriteList        []string        // Favorites in the order the view lists them
+	FavoriteSelected    int
+	StaleFavorites      map[string]bool // Listed favorites that no longer exist
+	Stats               PerfStats
+	MissingTools        []MissingTool              // Programs of enabled features not found at startup
+	GridOffset          int                        // First visible grid row
+	Thumbnails          map[string]string          // Generated thumbnails keyed by path and mtime
+	VideoPreviews       map[string]VideoPreview    // Generated video previews keyed by path, mtime and size
+	GitInfos            map[string]GitInfo         // Repository state keyed by repository directory
+	GitLogs             map[string][]string        // Recent commits touching a directory, empty outside repositories
+	GitMarks            map[string]map[string]byte // Directory to the git status of its entries by name: 'M' modified, 'A' staged, '?' untracked, '!' ignored
+	ProjectBadges       map[string]string          // Detected project badge per directory, "" if none
+	ArchiveFS           fs.FS                      // Archive being browsed, nil on the real filesystem
+	ArchivePath         string                     // Real path of ArchiveFS, paths below it are inside the archive
+	RootFS              bool                       // ArchiveFS is the filesystem browsed rather than an archive, and is never left
+	Chooser             bool                       // Opening an entry chooses it for the embedding program
+	PhysicalDir         string                     // CurrentDir with symlinks resolved, "" when the same
+	PhysicalPaths       bool                       // Files are opened by their physical path rather than the logical one
+	ImagePreviewColored bool                       // Image previews are drawn in the image's colors
+	ImageProtocol       string                     // "kitty" or "sixel" draws image previews with terminal graphics, "" as ASCII art
+	CellWidth           int                        // Pixel width of a terminal cell, 0 when the terminal does not report it
+	CellHeight          int                        // Pixel height of a terminal cell, likewise
+	PreviewImage        PreviewImage               // Terminal graphics of the preview, drawn over its blank lines
 }